@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"os"
 	"os/signal"
@@ -13,9 +14,14 @@ import (
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/claude"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/correlator"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/github"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/gitlab"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/helm"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/mcp"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/policy"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/redact"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
 )
@@ -24,18 +30,31 @@ func main() {
 	// Parse command line flags
 	configPath := flag.String("config", "config.yaml", "path to config file")
 	logLevel := flag.String("log-level", "info", "logging level (debug, info, warn, error)")
+	transport := flag.String("transport", "", "MCP transport (http, stdio); overrides server.transport in the config file")
 	flag.Parse()
 
+	// Load configuration before logging anything: in stdio mode stdout carries
+	// the protocol stream, so logs must be routed to stderr first
+	cfg, loadErr := config.Load(*configPath)
+	if loadErr == nil && *transport != "" {
+		cfg.Server.Transport = *transport
+	}
+	if loadErr == nil && cfg.Server.Transport == "" {
+		cfg.Server.Transport = config.TransportHTTP
+	}
+	if *transport == config.TransportStdio || (loadErr == nil && cfg.Server.Transport == config.TransportStdio) {
+		_ = os.Setenv("LOG_OUTPUT", "stderr")
+	}
+
 	// Initialize logger
 	_ = os.Setenv("LOG_LEVEL", *logLevel)
 	logger := logging.NewLogger()
 	logger.Info("Starting Kubernetes Claude MCP server")
 
-	// Load configuration
+	// Check configuration
 	logger.Info("Loading configuration", "path", *configPath)
-	cfg, err := config.Load(*configPath)
-	if err != nil {
-		logger.Fatal("Failed to load configuration", "error", err)
+	if loadErr != nil {
+		logger.Fatal("Failed to load configuration", "error", loadErr)
 	}
 
 	// Validate configuration
@@ -54,20 +73,26 @@ func main() {
 		logger.Fatal("Failed to load credentials", "error", loadErr)
 	}
 
-	// Initialize Kubernetes client
-	logger.Info("Initializing Kubernetes client")
-	k8sClient, err := k8s.NewClient(cfg.Kubernetes, logger.Named("k8s"))
+	// Initialize Kubernetes clients, one per configured cluster
+	logger.Info("Initializing Kubernetes clients")
+	clusters, err := k8s.NewClusterRegistry(cfg.Kubernetes, logger.Named("k8s"))
 	if err != nil {
-		logger.Fatal("Failed to create Kubernetes client", "error", err)
+		logger.Fatal("Failed to create Kubernetes clients", "error", err)
 	}
 
-	// Check Kubernetes connectivity
-	if err := k8sClient.CheckConnectivity(ctx); err != nil {
-		logger.Warn("Kubernetes connectivity check failed", "error", err)
-	} else {
-		logger.Info("Kubernetes connectivity confirmed")
+	// Check Kubernetes connectivity (don't fail if a cluster is unavailable)
+	for _, name := range clusters.Names() {
+		k8sClient, _ := clusters.Get(name)
+		if err := k8sClient.CheckConnectivity(ctx); err != nil {
+			logger.Warn("Kubernetes connectivity check failed", "cluster", name, "error", err)
+		} else {
+			logger.Info("Kubernetes connectivity confirmed", "cluster", name)
+		}
 	}
 
+	// Start informer caches; reads fall back to the API server until they sync
+	clusters.StartCaches(ctx)
+
 	// Initialize ArgoCD client
 	logger.Info("Initializing ArgoCD client")
 	argoClient := argocd.NewClient(&cfg.ArgoCD, credProvider, logger.Named("argocd"))
@@ -79,6 +104,9 @@ func main() {
 		logger.Info("ArgoCD connectivity confirmed")
 	}
 
+	// Build the resource-to-application index; lookups query ArgoCD until it refreshes
+	argoClient.StartIndex(ctx)
+
 	// Initialize GitLab client
 	logger.Info("Initializing GitLab client")
 	gitlabClient := gitlab.NewClient(&cfg.GitLab, credProvider, logger.Named("gitlab"))
@@ -90,53 +118,83 @@ func main() {
 		logger.Info("GitLab connectivity confirmed")
 	}
 
-	// Initialize Claude client
-	logger.Info("Initializing Claude client")
-	claudeConfig := claude.ClaudeConfig{
-		APIKey:      cfg.Claude.APIKey,
-		BaseURL:     cfg.Claude.BaseURL,
-		ModelID:     cfg.Claude.ModelID,
-		MaxTokens:   cfg.Claude.MaxTokens,
-		Temperature: cfg.Claude.Temperature,
+	// Choose the SCM provider for each repository by host. GitLab serves project IDs
+	// without a host and repositories on unknown hosts.
+	gitlabHost, err := scm.HostFromURL(cfg.GitLab.URL)
+	if err != nil {
+		logger.Warn("Could not determine GitLab host, assuming gitlab.com", "error", err)
+		gitlabHost = "gitlab.com"
+	}
+	repos := scm.NewRegistry(gitlabHost, gitlabClient, logger.Named("scm"))
+
+	// Initialize GitHub client when configured
+	if cfg.GitHub.Enabled() {
+		logger.Info("Initializing GitHub client")
+		githubClient := github.NewClient(&cfg.GitHub, credProvider, logger.Named("github"))
+		githubHost, err := scm.HostFromURL(cfg.GitHub.APIURL())
+		if err != nil {
+			logger.Fatal("Invalid GitHub URL", "error", err)
+		}
+		repos.Register(githubHost, githubClient)
+
+		// Check GitHub connectivity (don't fail if unavailable)
+		if err := githubClient.CheckConnectivity(ctx); err != nil {
+			logger.Warn("GitHub connectivity check failed", "error", err)
+		} else {
+			logger.Info("GitHub connectivity confirmed")
+		}
 	}
-	claudeClient := claude.NewClient(claudeConfig, logger.Named("claude"))
+
+	// Mask Secrets and sensitive values before they reach Claude or API callers
+	redactor, err := redact.NewRedactor(cfg.Redaction)
+	if err != nil {
+		logger.Fatal("Failed to create redactor", "error", err)
+	}
+	if !redactor.Enabled() {
+		logger.Warn("Redaction is disabled; Secret data may be sent to Claude")
+	}
+
+	// Build the policy engine that checks the manifests merge requests render
+	policyEngine, err := policy.NewEngine(cfg.Policy)
+	if err != nil {
+		logger.Fatal("Failed to create policy engine", "error", err)
+	}
+
+	// Initialize Helm correlator, which renders charts and manifests for merge request diffs
+	helmCorrelator := correlator.NewHelmCorrelator(repos, logger.Named("helm")).
+		WithRenderOptions(helm.RenderOptions{
+			KubeVersion:       cfg.Helm.KubeVersion,
+			APIVersions:       cfg.Helm.APIVersions,
+			BuildDependencies: cfg.Helm.BuildDependencies,
+		}).
+		WithRedactor(redactor).
+		WithPolicy(policyEngine)
 
 	// Initialize GitOps correlator
 	logger.Info("Initializing GitOps correlator")
 	gitOpsCorrelator := correlator.NewGitOpsCorrelator(
-		k8sClient,
+		clusters,
 		argoClient,
-		gitlabClient,
+		repos,
 		logger.Named("correlator"),
-	)
+	).WithHelmCorrelator(helmCorrelator)
 
 	// Initialize troubleshoot correlator
 	troubleshootCorrelator := correlator.NewTroubleshootCorrelator(
 		gitOpsCorrelator,
-		k8sClient,
+		clusters,
 		logger.Named("troubleshoot"),
 	)
 
-	// Initialize MCP protocol handler
-	logger.Info("Initializing MCP protocol handler")
-	mcpHandler := mcp.NewProtocolHandler(
-		claudeClient,
+	// Initialize MCP server (JSON-RPC tools, resources and prompts)
+	mcpServer := mcp.NewServer(
 		gitOpsCorrelator,
-		k8sClient,
-		logger.Named("mcp"),
-	)
-
-	// Initialize API server
-	logger.Info("Initializing API server")
-	server := api.NewServer(
-		cfg.Server,
-		k8sClient,
-		argoClient,
-		gitlabClient,
-		mcpHandler,
 		troubleshootCorrelator,
-		logger.Named("api"),
-	)
+		clusters,
+		argoClient,
+		repos,
+		logger.Named("mcp-server"),
+	).WithRedactor(redactor)
 
 	// Handle graceful shutdown
 	go func() {
@@ -156,6 +214,70 @@ func main() {
 		<-shutdownCtx.Done()
 	}()
 
+	// In stdio mode the process is an MCP subprocess of the client: serve the
+	// protocol on stdin/stdout and leave the reasoning to the calling agent
+	if cfg.Server.Transport == config.TransportStdio {
+		logger.Info("Starting MCP server", "transport", config.TransportStdio)
+		if err := mcpServer.ServeStdio(ctx, os.Stdin, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+			logger.Fatal("MCP stdio server error", "error", err)
+		}
+		logger.Info("Server shutdown complete")
+		return
+	}
+
+	// Initialize Claude client
+	logger.Info("Initializing Claude client")
+	claudeConfig := claude.ClaudeConfig{
+		APIKey:      cfg.Claude.APIKey,
+		BaseURL:     cfg.Claude.BaseURL,
+		ModelID:     cfg.Claude.ModelID,
+		MaxTokens:   cfg.Claude.MaxTokens,
+		Temperature: cfg.Claude.Temperature,
+	}
+	claudeClient := claude.NewClient(claudeConfig, logger.Named("claude"))
+
+	// Initialize MCP protocol handler
+	logger.Info("Initializing MCP protocol handler")
+	mcpHandler := mcp.NewProtocolHandler(
+		claudeClient,
+		gitOpsCorrelator,
+		clusters,
+		logger.Named("mcp"),
+	).WithRedactor(redactor)
+
+	// Let Claude fetch more cluster data on demand through the read-only MCP tools
+	if cfg.Claude.Agent.Enabled {
+		logger.Info("Enabling Claude tool-use agent",
+			"maxIterations", cfg.Claude.Agent.MaxIterations,
+			"maxTotalTokens", cfg.Claude.Agent.MaxTotalTokens)
+		mcpHandler.WithAgent(mcp.NewAgent(
+			claudeClient,
+			mcpServer,
+			cfg.Claude.Agent.MaxIterations,
+			cfg.Claude.Agent.MaxTotalTokens,
+			logger.Named("agent"),
+		))
+	}
+
+	// Initialize API server
+	logger.Info("Initializing API server")
+	server := api.NewServer(
+		cfg.Server,
+		clusters,
+		argoClient,
+		gitlabClient,
+		mcpHandler,
+		mcpServer,
+		troubleshootCorrelator,
+		logger.Named("api"),
+	).WithRedactor(redactor)
+
+	// Review merge requests as GitLab reports them opened or pushed to
+	if cfg.GitLab.WebhookSecret != "" {
+		logger.Info("Enabling GitLab merge request webhook")
+		server.WithGitLabWebhook(cfg.GitLab.WebhookSecret)
+	}
+
 	// Start server
 	logger.Info("Starting MCP server", "address", cfg.Server.Address)
 	if err := server.Start(ctx); err != nil {
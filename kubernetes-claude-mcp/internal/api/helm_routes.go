@@ -0,0 +1,206 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/helm"
+
+	"github.com/gorilla/mux"
+)
+
+// Release metadata is authorized as its own kind. Values and manifests come from the
+// release Secrets and may hold credentials, so reading them requires access to Secrets.
+const (
+	helmReleaseKind = "helmreleases"
+	helmSecretKind  = "secrets"
+)
+
+// setupHelmRoutes configures the API routes for Helm releases read from the cluster
+func (s *Server) setupHelmRoutes() {
+	// Add to the secure API subrouter
+	apiSecure := s.router.PathPrefix("/api/v1").Subrouter()
+	apiSecure.Use(s.authMiddleware)
+
+	// Helm release endpoints; each accepts a ?cluster= query parameter
+	apiSecure.HandleFunc("/helm/releases", s.handleListHelmReleases).Methods("GET")
+	apiSecure.HandleFunc("/helm/releases/{name}", s.handleGetHelmRelease).Methods("GET")
+	apiSecure.HandleFunc("/helm/releases/{name}/history", s.handleHelmReleaseHistory).Methods("GET")
+	apiSecure.HandleFunc("/helm/releases/{name}/diff", s.handleDiffHelmRelease).Methods("GET")
+}
+
+// handleListHelmReleases handles requests to list the Helm releases in a namespace
+func (s *Server) handleListHelmReleases(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+
+	// Listing across all namespaces is allowed for a namespace-limited role;
+	// the results are filtered to its namespaces below
+	identity := auth.IdentityFromContext(r.Context())
+	if namespace == "" && !s.authorizeAction(w, r, auth.ActionRead, helmReleaseKind) {
+		return
+	}
+	if namespace != "" && !s.authorize(w, r, auth.ActionRead, namespace, helmReleaseKind) {
+		return
+	}
+
+	releases, ok := s.helmReleases(w, r)
+	if !ok {
+		return
+	}
+
+	summaries, err := releases.List(r.Context(), namespace)
+	if err != nil {
+		s.respondWithServerError(w, "Failed to list Helm releases", err)
+		return
+	}
+
+	if namespace == "" && !identity.AllNamespaces() {
+		allowed := summaries[:0]
+		for _, summary := range summaries {
+			if identity.NamespaceAllowed(summary.Namespace) {
+				allowed = append(allowed, summary)
+			}
+		}
+		summaries = allowed
+	}
+
+	s.respondWithJSON(w, http.StatusOK, map[string]interface{}{"releases": summaries})
+}
+
+// handleGetHelmRelease handles requests for the computed values and manifest of a
+// release revision, the latest unless ?revision= is given
+func (s *Server) handleGetHelmRelease(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	namespace := r.URL.Query().Get("namespace")
+
+	revision, ok := s.revisionParam(w, r, "revision")
+	if !ok {
+		return
+	}
+	if !s.authorizeHelmRelease(w, r, namespace, helmSecretKind) {
+		return
+	}
+
+	releases, ok := s.helmReleases(w, r)
+	if !ok {
+		return
+	}
+
+	detail, err := releases.Get(r.Context(), namespace, name, revision)
+	if err != nil {
+		s.respondWithHelmError(w, "Failed to get Helm release", err)
+		return
+	}
+
+	s.respondWithRedactedJSON(w, http.StatusOK, detail)
+}
+
+// handleHelmReleaseHistory handles requests for the revision history of a release
+func (s *Server) handleHelmReleaseHistory(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	namespace := r.URL.Query().Get("namespace")
+
+	if !s.authorizeHelmRelease(w, r, namespace, helmReleaseKind) {
+		return
+	}
+
+	releases, ok := s.helmReleases(w, r)
+	if !ok {
+		return
+	}
+
+	summary, err := releases.History(r.Context(), namespace, name)
+	if err != nil {
+		s.respondWithHelmError(w, "Failed to get Helm release history", err)
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, summary)
+}
+
+// handleDiffHelmRelease handles requests to diff the values and objects of two
+// revisions of a release. ?from= defaults to the revision before ?to=, which defaults
+// to the latest.
+func (s *Server) handleDiffHelmRelease(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	namespace := r.URL.Query().Get("namespace")
+
+	from, ok := s.revisionParam(w, r, "from")
+	if !ok {
+		return
+	}
+	to, ok := s.revisionParam(w, r, "to")
+	if !ok {
+		return
+	}
+	if !s.authorizeHelmRelease(w, r, namespace, helmSecretKind) {
+		return
+	}
+
+	releases, ok := s.helmReleases(w, r)
+	if !ok {
+		return
+	}
+
+	mask := func(obj map[string]interface{}) map[string]interface{} {
+		redacted, _ := s.redactor.RedactObject(obj)
+		return redacted
+	}
+	diff, err := releases.Diff(r.Context(), namespace, name, from, to, mask)
+	if err != nil {
+		s.respondWithHelmError(w, "Failed to diff Helm release", err)
+		return
+	}
+
+	s.respondWithRedactedJSON(w, http.StatusOK, diff)
+}
+
+// authorizeHelmRelease requires a namespace, since releases are namespaced, and checks
+// the caller's role for reading the kind in it
+func (s *Server) authorizeHelmRelease(w http.ResponseWriter, r *http.Request, namespace, kind string) bool {
+	if namespace == "" {
+		s.respondWithError(w, http.StatusBadRequest, "Namespace is required", nil)
+		return false
+	}
+	return s.authorize(w, r, auth.ActionRead, namespace, kind)
+}
+
+// helmReleases returns a release reader for the cluster named by ?cluster=
+func (s *Server) helmReleases(w http.ResponseWriter, r *http.Request) (*helm.Releases, bool) {
+	k8sClient, ok := s.clusterClient(w, r, r.URL.Query().Get("cluster"))
+	if !ok {
+		return nil, false
+	}
+	return helm.NewReleases(k8sClient.GetClientset(), s.logger.Named("helm")), true
+}
+
+// revisionParam parses an optional revision query parameter, which is 0 when absent
+func (s *Server) revisionParam(w http.ResponseWriter, r *http.Request, param string) (int, bool) {
+	value := r.URL.Query().Get(param)
+	if value == "" {
+		return 0, true
+	}
+	revision, err := strconv.Atoi(value)
+	if err != nil || revision < 1 {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid "+param+" revision", err)
+		return 0, false
+	}
+	return revision, true
+}
+
+// respondWithHelmError sends 404 Not Found for a missing release or revision, 400 Bad
+// Request for a diff of a first revision with nothing before it, and otherwise falls
+// back to respondWithServerError
+func (s *Server) respondWithHelmError(w http.ResponseWriter, message string, err error) {
+	if errors.Is(err, helm.ErrReleaseNotFound) {
+		s.respondWithError(w, http.StatusNotFound, message, err)
+		return
+	}
+	if errors.Is(err, helm.ErrNoEarlierRevision) {
+		s.respondWithError(w, http.StatusBadRequest, message, err)
+		return
+	}
+	s.respondWithServerError(w, message, err)
+}
@@ -3,6 +3,8 @@ package api
 import (
 	"net/http"
 
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
+
 	"github.com/gorilla/mux"
 )
 
@@ -26,10 +28,19 @@ func (s *Server) handleNamespaceTopology(w http.ResponseWriter, r *http.Request)
 
 	s.logger.Info("Handling namespace topology request", "namespace", namespace)
 
+	if !s.authorize(w, r, auth.ActionRead, namespace, "") {
+		return
+	}
+
+	k8sClient, ok := s.clusterClient(w, r, r.URL.Query().Get("cluster"))
+	if !ok {
+		return
+	}
+
 	// Get topology from the resource mapper
-	topology, err := s.resourceMapper.GetNamespaceTopology(r.Context(), namespace)
+	topology, err := k8sClient.ResourceMapper.GetNamespaceTopology(r.Context(), namespace)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to get namespace topology", err)
+		s.respondWithServerError(w, "Failed to get namespace topology", err)
 		return
 	}
 
@@ -43,10 +54,19 @@ func (s *Server) handleNamespaceGraph(w http.ResponseWriter, r *http.Request) {
 
 	s.logger.Info("Handling namespace graph request", "namespace", namespace)
 
+	if !s.authorize(w, r, auth.ActionRead, namespace, "") {
+		return
+	}
+
+	k8sClient, ok := s.clusterClient(w, r, r.URL.Query().Get("cluster"))
+	if !ok {
+		return
+	}
+
 	// Get resource graph from the resource mapper
-	graph, err := s.resourceMapper.GetResourceGraph(r.Context(), namespace)
+	graph, err := k8sClient.ResourceMapper.GetResourceGraph(r.Context(), namespace)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to get namespace graph", err)
+		s.respondWithServerError(w, "Failed to get namespace graph", err)
 		return
 	}
 
@@ -60,14 +80,32 @@ func (s *Server) handleNamespaceResources(w http.ResponseWriter, r *http.Request
 
 	s.logger.Info("Handling namespace resources request", "namespace", namespace)
 
+	if !s.authorize(w, r, auth.ActionRead, namespace, "") {
+		return
+	}
+
+	k8sClient, ok := s.clusterClient(w, r, r.URL.Query().Get("cluster"))
+	if !ok {
+		return
+	}
+
 	// Get all resources in the namespace
-	resources, err := s.k8sClient.GetAllNamespaceResources(r.Context(), namespace)
+	resources, err := k8sClient.GetAllNamespaceResources(r.Context(), namespace)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to get namespace resources", err)
+		s.respondWithServerError(w, "Failed to get namespace resources", err)
 		return
 	}
 
-	s.respondWithJSON(w, http.StatusOK, resources)
+	// Drop the kinds the caller's role does not cover, such as Secrets
+	identity := auth.IdentityFromContext(r.Context())
+	for kind := range resources.Resources {
+		if !identity.KindAllowed(kind) {
+			delete(resources.Resources, kind)
+			delete(resources.Stats, kind)
+		}
+	}
+
+	s.respondWithRedactedJSON(w, http.StatusOK, resources)
 }
 
 // handleNamespaceAnalysis handles requests for namespace analysis
@@ -77,10 +115,19 @@ func (s *Server) handleNamespaceAnalysis(w http.ResponseWriter, r *http.Request)
 
 	s.logger.Info("Handling namespace analysis request", "namespace", namespace)
 
+	if !s.authorize(w, r, auth.ActionQuery, namespace, "") {
+		return
+	}
+
+	cluster := r.URL.Query().Get("cluster")
+	if _, ok := s.clusterClient(w, r, cluster); !ok {
+		return
+	}
+
 	// Get namespace analysis from the MCP protocol handler
-	analysis, err := s.mcpHandler.AnalyzeNamespace(r.Context(), namespace)
+	analysis, err := s.mcpHandler.AnalyzeNamespace(r.Context(), cluster, namespace)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to analyze namespace", err)
+		s.respondWithServerError(w, "Failed to analyze namespace", err)
 		return
 	}
 	s.respondWithJSON(w, http.StatusOK, analysis)
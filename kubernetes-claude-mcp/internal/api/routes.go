@@ -4,10 +4,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
-
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/argocd"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/claude"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/correlator"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/mcp"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
 	"github.com/gorilla/mux"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
 // setupRoutes configures the API routes
@@ -15,6 +24,12 @@ func (s *Server) setupRoutes() {
 	// Apply CORS middleware to all routes
 	s.router.Use(s.corsMiddleware)
 
+	// Model Context Protocol endpoint (streamable HTTP transport)
+	if s.mcpServer != nil {
+		s.router.Handle("/mcp", s.authMiddleware(s.requireFullReadAccess(mcp.NewHTTPHandler(s.mcpServer)))).
+			Methods("GET", "POST", "DELETE")
+	}
+
 	// API version prefix
 	apiV1 := s.router.PathPrefix("/api/v1").Subrouter()
 
@@ -32,16 +47,22 @@ func (s *Server) setupRoutes() {
 	apiSecure.HandleFunc("/mcp/resource", s.handleResourceQuery).Methods("POST")
 	apiSecure.HandleFunc("/mcp/commit", s.handleCommitQuery).Methods("POST")
 	apiSecure.HandleFunc("/mcp/troubleshoot", s.handleTroubleshoot).Methods("POST")
+	apiSecure.HandleFunc("/mcp/timeline", s.handleTimelineQuery).Methods("POST")
 
-	// Kubernetes resource endpoints
+	// Kubernetes resource endpoints; each accepts a ?cluster= query parameter
+	apiSecure.HandleFunc("/clusters", s.handleListClusters).Methods("GET")
 	apiSecure.HandleFunc("/namespaces", s.handleListNamespaces).Methods("GET")
 	apiSecure.HandleFunc("/resources/{resource}", s.handleListResources).Methods("GET")
 	apiSecure.HandleFunc("/resources/{resource}/{name}", s.handleGetResource).Methods("GET")
 	apiSecure.HandleFunc("/events", s.handleGetEvents).Methods("GET")
+	apiSecure.HandleFunc("/timeline", s.handleGetTimeline).Methods("GET")
+	apiSecure.HandleFunc("/changes", s.handleGetChanges).Methods("GET")
 
 	// ArgoCD endpoints
 	apiSecure.HandleFunc("/argocd/applications", s.handleListArgoApplications).Methods("GET")
 	apiSecure.HandleFunc("/argocd/applications/{name}", s.handleGetArgoApplication).Methods("GET")
+	apiSecure.HandleFunc("/argocd/applicationsets", s.handleListArgoApplicationSets).Methods("GET")
+	apiSecure.HandleFunc("/argocd/applicationsets/{name}", s.handleGetArgoApplicationSet).Methods("GET")
 
 	// GitLab endpoints
 	apiSecure.HandleFunc("/gitlab/projects", s.handleListGitLabProjects).Methods("GET")
@@ -49,6 +70,7 @@ func (s *Server) setupRoutes() {
 
 	// Merge Request endpoints
 	apiSecure.HandleFunc("/mcp/mergeRequest", s.handleMergeRequestQuery).Methods("POST")
+	apiSecure.HandleFunc("/mcp/mergeRequest/policy", s.handleMergeRequestPolicy).Methods("POST")
 }
 
 // handleMergeRequestQuery handles MCP requests for analyzing merge requests
@@ -73,21 +95,192 @@ func (s *Server) handleMergeRequestQuery(w http.ResponseWriter, r *http.Request)
 		"projectId", request.ProjectID,
 		"mergeRequestIID", request.MergeRequestIID)
 
+	// Merge request analysis can touch resources in any namespace
+	if !s.authorize(w, r, auth.ActionQuery, "", "") {
+		return
+	}
+
 	// Process the request
 	response, err := s.mcpHandler.ProcessRequest(r.Context(), &request)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to process request", err)
+		s.respondWithServerError(w, "Failed to process request", err)
 		return
 	}
 
 	s.respondWithJSON(w, http.StatusOK, response)
 }
 
+// handleMergeRequestPolicy checks a merge request's rendered manifests against the
+// policy rules and returns the verdict. Claude is not involved, so the result is
+// deterministic and can gate a pipeline.
+func (s *Server) handleMergeRequestPolicy(w http.ResponseWriter, r *http.Request) {
+	var request models.MCPRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	if request.ProjectID == "" || request.MergeRequestIID <= 0 {
+		s.respondWithError(w, http.StatusBadRequest, "Project ID and merge request IID are required", nil)
+		return
+	}
+
+	s.logger.Info("Received merge request policy check",
+		"projectId", request.ProjectID,
+		"mergeRequestIID", request.MergeRequestIID)
+
+	if !s.authorize(w, r, auth.ActionQuery, "", "") {
+		return
+	}
+
+	result, err := s.mcpHandler.CheckMergeRequestPolicy(r.Context(), request.ProjectID, request.MergeRequestIID)
+	if err != nil {
+		s.respondWithServerError(w, "Failed to check merge request policy", err)
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, result)
+}
+
+// handleGetTimeline returns the merged timeline of a resource, an ArgoCD application
+// or a namespace, without asking Claude
+func (s *Server) handleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := models.MCPRequest{
+		Cluster:     query.Get("cluster"),
+		Namespace:   query.Get("namespace"),
+		Resource:    query.Get("resource"),
+		Name:        query.Get("name"),
+		Application: query.Get("application"),
+		Since:       query.Get("since"),
+		Until:       query.Get("until"),
+	}
+	if !s.validateTimelineRequest(w, r, &request, auth.ActionRead) {
+		return
+	}
+
+	timeline, err := s.mcpHandler.BuildTimeline(r.Context(), &request)
+	if err != nil {
+		s.respondWithServerError(w, "Failed to build timeline", err)
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, timeline)
+}
+
+// handleTimelineQuery builds the timeline of a resource, an ArgoCD application or a
+// namespace and asks Claude for the change most likely to have triggered an incident
+func (s *Server) handleTimelineQuery(w http.ResponseWriter, r *http.Request) {
+	var request models.MCPRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid request format", err)
+		return
+	}
+
+	// Force action to be queryTimeline
+	request.Action = "queryTimeline"
+
+	if !s.validateTimelineRequest(w, r, &request, auth.ActionQuery) {
+		return
+	}
+
+	s.respondWithAnalysis(w, r, "Failed to process request", func(onDelta claude.StreamHandler) (interface{}, error) {
+		return s.mcpHandler.ProcessRequestStream(r.Context(), &request, onDelta)
+	})
+}
+
+// validateTimelineRequest checks a timeline request names what it covers over a valid
+// window, and that the caller may see it: the namespace of a resource or namespace
+// timeline, or the namespace an ArgoCD application deploys to
+func (s *Server) validateTimelineRequest(w http.ResponseWriter, r *http.Request, request *models.MCPRequest, action string) bool {
+	if request.Application == "" && request.Namespace == "" {
+		s.respondWithError(w, http.StatusBadRequest, "A namespace or an ArgoCD application is required", nil)
+		return false
+	}
+	if (request.Resource == "") != (request.Name == "") {
+		s.respondWithError(w, http.StatusBadRequest, "Resource and name must be given together", nil)
+		return false
+	}
+	if _, _, err := correlator.TimelineWindow(request.Since, request.Until, time.Now()); err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid timeline window", err)
+		return false
+	}
+
+	s.logger.Info("Received timeline request",
+		"cluster", request.Cluster,
+		"namespace", request.Namespace,
+		"resource", request.Resource,
+		"name", request.Name,
+		"application", request.Application)
+
+	namespace, kind := request.Namespace, request.Resource
+	if request.Application != "" {
+		if !s.authorizeAction(w, r, action, "application") {
+			return false
+		}
+		app, err := s.argoClient.GetApplication(r.Context(), request.Application)
+		if err != nil {
+			s.respondWithServerError(w, "Failed to get ArgoCD application", err)
+			return false
+		}
+		namespace, kind = app.Spec.Destination.Namespace, "application"
+	}
+	if !s.authorize(w, r, action, namespace, kind) {
+		return false
+	}
+
+	_, ok := s.clusterClient(w, r, request.Cluster)
+	return ok
+}
+
+// handleGetChanges reports what changed in a namespace, or across the cluster when no
+// namespace is given, ordered by how likely each change is to have caused the
+// namespace's warnings
+func (s *Server) handleGetChanges(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cluster := query.Get("cluster")
+	namespace := query.Get("namespace")
+
+	since, until, err := correlator.TimelineWindow(query.Get("since"), query.Get("until"), time.Now())
+	if err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid change window", err)
+		return
+	}
+
+	s.logger.Info("Received change report request",
+		"cluster", cluster,
+		"namespace", namespace,
+		"since", since,
+		"until", until)
+
+	// Without a namespace the report covers the whole cluster
+	if !s.authorize(w, r, auth.ActionRead, namespace, "") {
+		return
+	}
+	if _, ok := s.clusterClient(w, r, cluster); !ok {
+		return
+	}
+
+	// The report names resources of every kind, so leave out the kinds the caller's
+	// role does not cover, such as Secrets
+	identity := auth.IdentityFromContext(r.Context())
+	report, err := s.mcpHandler.DetectChanges(r.Context(), cluster, namespace, since, until, identity.KindAllowed)
+	if err != nil {
+		s.respondWithServerError(w, "Failed to detect changes", err)
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, report)
+}
+
 // handleHealth handles health check requests
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	type healthResponse struct {
 		Status   string            `json:"status"`
 		Services map[string]string `json:"services"`
+		Clusters map[string]string `json:"clusters,omitempty"`
 	}
 
 	// Check each service
@@ -100,12 +293,19 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 
-	// Check Kubernetes connectivity
-	if err := s.k8sClient.CheckConnectivity(ctx); err != nil {
-		services["kubernetes"] = "unavailable"
-		s.logger.Warn("Kubernetes health check failed", "error", err)
-	} else {
-		services["kubernetes"] = "available"
+	// Check Kubernetes connectivity; the default cluster decides the overall status
+	clusters := make(map[string]string)
+	for _, name := range s.clusters.Names() {
+		client, _ := s.clusters.Get(name)
+		if err := client.CheckConnectivity(ctx); err != nil {
+			clusters[name] = "unavailable"
+			s.logger.Warn("Kubernetes health check failed", "cluster", name, "error", err)
+		} else {
+			clusters[name] = "available"
+		}
+	}
+	if status, ok := clusters[s.clusters.DefaultName()]; ok {
+		services["kubernetes"] = status
 	}
 
 	// Check ArgoCD connectivity
@@ -138,6 +338,9 @@ func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 		Status:   status,
 		Services: services,
 	}
+	if len(clusters) > 1 {
+		response.Clusters = clusters
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -167,9 +370,11 @@ func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
 // This endpoint checks if the application is ready to serve traffic
 func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
 	type readinessResponse struct {
-		Status string          `json:"status"`
-		Ready  bool            `json:"ready"`
-		Checks map[string]bool `json:"checks"`
+		Status string                     `json:"status"`
+		Ready  bool                       `json:"ready"`
+		Checks map[string]bool            `json:"checks"`
+		Caches map[string]k8s.CacheStatus `json:"caches,omitempty"`
+		Index  *argocd.IndexStatus        `json:"argocdIndex,omitempty"`
 	}
 
 	ctx := r.Context()
@@ -177,31 +382,55 @@ func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
 		"kubernetes": false,
 	}
 
-	// Check Kubernetes connectivity - this is critical for readiness
-	if err := s.k8sClient.CheckConnectivity(ctx); err != nil {
+	// Check Kubernetes connectivity to the default cluster - this is critical for readiness
+	k8sClient, err := s.clusters.Get("")
+	if err == nil {
+		err = k8sClient.CheckConnectivity(ctx)
+	}
+	if err != nil {
 		s.logger.Debug("Kubernetes readiness check failed", "error", err)
-		response := readinessResponse{
-			Status: "not ready",
-			Ready:  false,
-			Checks: checks,
+	} else {
+		checks["kubernetes"] = true
+	}
+
+	// When informer caches are enabled, wait for their initial sync so the first
+	// requests are not all served by fallback LISTs
+	caches := s.clusters.CacheStatus()
+	if len(caches) > 0 {
+		checks["cache"] = true
+		for name, status := range caches {
+			if !status.Synced {
+				s.logger.Debug("Informer cache not synced", "cluster", name, "error", status.Error)
+				checks["cache"] = false
+			}
 		}
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusServiceUnavailable)
-		_ = json.NewEncoder(w).Encode(response)
-		return
 	}
 
-	checks["kubernetes"] = true
+	ready := true
+	for _, ok := range checks {
+		ready = ready && ok
+	}
 
-	// If Kubernetes is available, we're ready
 	response := readinessResponse{
 		Status: "ready",
-		Ready:  true,
+		Ready:  ready,
 		Checks: checks,
+		Caches: caches,
+	}
+
+	// Report the size and staleness of the ArgoCD resource index. It does not gate
+	// readiness: until it refreshes, lookups fall back to querying ArgoCD.
+	if index := s.argoClient.IndexStatus(); index.Enabled {
+		response.Index = &index
+	}
+	statusCode := http.StatusOK
+	if !ready {
+		response.Status = "not ready"
+		statusCode = http.StatusServiceUnavailable
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(statusCode)
 	_ = json.NewEncoder(w).Encode(response)
 }
 
@@ -214,16 +443,43 @@ func (s *Server) handleMCPRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.logger.Info("Received MCP request", "action", request.Action)
+	s.logger.Info("Received MCP request", "action", request.Action, "cluster", request.Cluster)
 
-	// Process the request
-	response, err := s.mcpHandler.ProcessRequest(r.Context(), &request)
-	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to process request", err)
+	if !s.authorizeMCPRequest(w, r, &request) {
 		return
 	}
 
-	s.respondWithJSON(w, http.StatusOK, response)
+	// Process the request
+	s.respondWithAnalysis(w, r, "Failed to process request", func(onDelta claude.StreamHandler) (interface{}, error) {
+		return s.mcpHandler.ProcessRequestStream(r.Context(), &request, onDelta)
+	})
+}
+
+// authorizeMCPRequest checks a request to the generic MCP endpoint as the dedicated
+// endpoint for its action would, responding with an error when it is not allowed
+func (s *Server) authorizeMCPRequest(w http.ResponseWriter, r *http.Request, request *models.MCPRequest) bool {
+	switch {
+	case request.Action == "queryTimeline":
+		// A timeline may cover an ArgoCD application, whose namespace the request does
+		// not name, so it is checked as on the timeline endpoints
+		return s.validateTimelineRequest(w, r, request, auth.ActionQuery)
+	case request.Action == "queryMergeRequest" || request.Action == "queryCommit":
+		// Merge request and commit analysis can touch resources in any namespace
+		if !s.authorize(w, r, auth.ActionQuery, "", "") {
+			return false
+		}
+	case request.Action == "queryResource" && strings.EqualFold(request.Resource, "namespace"):
+		// A namespace query covers the whole namespace named by the request
+		if !s.authorize(w, r, auth.ActionQuery, request.Name, "") {
+			return false
+		}
+	default:
+		if !s.authorize(w, r, auth.ActionQuery, request.Namespace, request.Resource) {
+			return false
+		}
+	}
+	_, ok := s.clusterClient(w, r, request.Cluster)
+	return ok
 }
 
 // handleResourceQuery handles MCP requests for querying resources
@@ -245,30 +501,45 @@ func (s *Server) handleResourceQuery(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.logger.Info("Received resource query",
+		"cluster", request.Cluster,
 		"resource", request.Resource,
 		"name", request.Name,
 		"namespace", request.Namespace)
 
+	// A namespace query covers the whole namespace named by the request
+	isNamespace := strings.EqualFold(request.Resource, "namespace")
+	if isNamespace && !s.authorize(w, r, auth.ActionQuery, request.Name, "") {
+		return
+	}
+	if !isNamespace && !s.authorize(w, r, auth.ActionQuery, request.Namespace, request.Resource) {
+		return
+	}
+
+	k8sClient, ok := s.clusterClient(w, r, request.Cluster)
+	if !ok {
+		return
+	}
+
 	// Special handling for namespace resources to provide comprehensive data
-	if strings.EqualFold(request.Resource, "namespace") {
+	if isNamespace {
 		// Get namespace topology
-		topology, err := s.k8sClient.GetNamespaceTopology(r.Context(), request.Name)
+		topology, err := k8sClient.GetNamespaceTopology(r.Context(), request.Name)
 		if err != nil {
-			s.respondWithError(w, http.StatusInternalServerError, "Failed to get namespace topology", err)
+			s.respondWithServerError(w, "Failed to get namespace topology", err)
 			return
 		}
 
 		// Get all resources in the namespace
-		resources, err := s.k8sClient.GetAllNamespaceResources(r.Context(), request.Name)
+		resources, err := k8sClient.GetAllNamespaceResources(r.Context(), request.Name)
 		if err != nil {
-			s.respondWithError(w, http.StatusInternalServerError, "Failed to get namespace resources", err)
+			s.respondWithServerError(w, "Failed to get namespace resources", err)
 			return
 		}
 
 		// Get namespace analysis
-		analysis, err := s.mcpHandler.AnalyzeNamespace(r.Context(), request.Name)
+		analysis, err := s.mcpHandler.AnalyzeNamespace(r.Context(), request.Cluster, request.Name)
 		if err != nil {
-			s.respondWithError(w, http.StatusInternalServerError, "Failed to analyze namespace", err)
+			s.respondWithServerError(w, "Failed to analyze namespace", err)
 			return
 		}
 
@@ -309,7 +580,7 @@ func (s *Server) handleResourceQuery(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Get events for the namespace
-		events, err := s.k8sClient.GetNamespaceEvents(r.Context(), request.Name)
+		events, err := k8sClient.GetNamespaceEvents(r.Context(), request.Name)
 		if err == nil && len(events) > 0 {
 			enhancedRequest.Context += "\n## Recent Events\n"
 			for i, event := range events {
@@ -322,29 +593,25 @@ func (s *Server) handleResourceQuery(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Process the enhanced request
-		response, err := s.mcpHandler.ProcessRequest(r.Context(), &enhancedRequest)
-		if err != nil {
-			s.respondWithError(w, http.StatusInternalServerError, "Failed to process request", err)
-			return
-		}
-
-		// Add analysis insights to the response
-		if analysis != nil {
-			response.NamespaceAnalysis = analysis
-		}
+		s.respondWithAnalysis(w, r, "Failed to process request", func(onDelta claude.StreamHandler) (interface{}, error) {
+			response, err := s.mcpHandler.ProcessRequestStream(r.Context(), &enhancedRequest, onDelta)
+			if err != nil {
+				return nil, err
+			}
 
-		s.respondWithJSON(w, http.StatusOK, response)
+			// Add analysis insights to the response
+			if analysis != nil {
+				response.NamespaceAnalysis = analysis
+			}
+			return response, nil
+		})
 		return
 	}
 
 	// Process regular resource query
-	response, err := s.mcpHandler.ProcessRequest(r.Context(), &request)
-	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to process request", err)
-		return
-	}
-
-	s.respondWithJSON(w, http.StatusOK, response)
+	s.respondWithAnalysis(w, r, "Failed to process request", func(onDelta claude.StreamHandler) (interface{}, error) {
+		return s.mcpHandler.ProcessRequestStream(r.Context(), &request, onDelta)
+	})
 }
 
 // handleCommitQuery handles MCP requests for analyzing commits
@@ -369,19 +636,22 @@ func (s *Server) handleCommitQuery(w http.ResponseWriter, r *http.Request) {
 		"projectId", request.ProjectID,
 		"commitSha", request.CommitSHA)
 
-	// Process the request
-	response, err := s.mcpHandler.ProcessRequest(r.Context(), &request)
-	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to process request", err)
+	// Commit analysis can touch resources in any namespace
+	if !s.authorize(w, r, auth.ActionQuery, "", "") {
 		return
 	}
 
-	s.respondWithJSON(w, http.StatusOK, response)
+	// Process the request; the response lists the affected applications, environments
+	// and resources alongside Claude's rollout risk analysis
+	s.respondWithAnalysis(w, r, "Failed to process request", func(onDelta claude.StreamHandler) (interface{}, error) {
+		return s.mcpHandler.ProcessRequestStream(r.Context(), &request, onDelta)
+	})
 }
 
 // handleTroubleshoot handles troubleshooting requests
 func (s *Server) handleTroubleshoot(w http.ResponseWriter, r *http.Request) {
 	var request struct {
+		Cluster   string `json:"cluster,omitempty"`
 		Resource  string `json:"resource"`
 		Name      string `json:"name"`
 		Namespace string `json:"namespace"`
@@ -400,47 +670,62 @@ func (s *Server) handleTroubleshoot(w http.ResponseWriter, r *http.Request) {
 	}
 
 	s.logger.Info("Received troubleshoot request",
+		"cluster", request.Cluster,
 		"resource", request.Resource,
 		"name", request.Name,
 		"namespace", request.Namespace)
 
+	// Troubleshooting is a read unless Claude is asked to analyze the result
+	action := auth.ActionRead
+	if request.Query != "" {
+		action = auth.ActionQuery
+	}
+	if !s.authorize(w, r, action, request.Namespace, request.Resource) {
+		return
+	}
+
+	if _, ok := s.clusterClient(w, r, request.Cluster); !ok {
+		return
+	}
+
 	// Process the troubleshooting request
 	result, err := s.troubleshootCorrelator.TroubleshootResource(
 		r.Context(),
+		request.Cluster,
 		request.Namespace,
 		request.Resource,
 		request.Name,
 	)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to troubleshoot resource", err)
+		s.respondWithServerError(w, "Failed to troubleshoot resource", err)
 		return
 	}
 
 	// If there's a query, use Claude to analyze the results
 	if request.Query != "" {
 		mcpRequest := &models.MCPRequest{
+			Cluster:   request.Cluster,
 			Resource:  request.Resource,
 			Name:      request.Name,
 			Namespace: request.Namespace,
 			Query:     request.Query,
 		}
 
-		response, err := s.mcpHandler.ProcessTroubleshootRequest(r.Context(), mcpRequest, result)
-		if err != nil {
-			s.respondWithError(w, http.StatusInternalServerError, "Failed to process troubleshoot analysis", err)
-			return
-		}
-
-		// Add the troubleshoot result to the response
-		responseWithResult := struct {
-			*models.MCPResponse
-			TroubleshootResult *models.TroubleshootResult `json:"troubleshootResult"`
-		}{
-			MCPResponse:        response,
-			TroubleshootResult: result,
-		}
+		s.respondWithAnalysis(w, r, "Failed to process troubleshoot analysis", func(onDelta claude.StreamHandler) (interface{}, error) {
+			response, err := s.mcpHandler.ProcessTroubleshootRequestStream(r.Context(), mcpRequest, result, onDelta)
+			if err != nil {
+				return nil, err
+			}
 
-		s.respondWithJSON(w, http.StatusOK, responseWithResult)
+			// Add the troubleshoot result to the response
+			return struct {
+				*models.MCPResponse
+				TroubleshootResult *models.TroubleshootResult `json:"troubleshootResult"`
+			}{
+				MCPResponse:        response,
+				TroubleshootResult: result,
+			}, nil
+		})
 		return
 	}
 
@@ -448,14 +733,45 @@ func (s *Server) handleTroubleshoot(w http.ResponseWriter, r *http.Request) {
 	s.respondWithJSON(w, http.StatusOK, result)
 }
 
+// handleListClusters handles requests to list the configured clusters
+func (s *Server) handleListClusters(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAction(w, r, auth.ActionRead, "") {
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		"clusters": s.clusters.Clusters(),
+		"default":  s.clusters.DefaultName(),
+	})
+}
+
 // handleListNamespaces handles requests to list namespaces
 func (s *Server) handleListNamespaces(w http.ResponseWriter, r *http.Request) {
-	namespaces, err := s.k8sClient.GetNamespaces(r.Context())
+	if !s.authorizeAction(w, r, auth.ActionRead, "") {
+		return
+	}
+
+	k8sClient, ok := s.clusterClient(w, r, r.URL.Query().Get("cluster"))
+	if !ok {
+		return
+	}
+
+	namespaces, err := k8sClient.GetNamespaces(r.Context())
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to list namespaces", err)
+		s.respondWithServerError(w, "Failed to list namespaces", err)
 		return
 	}
 
+	// Only show the namespaces the caller's role covers
+	identity := auth.IdentityFromContext(r.Context())
+	allowed := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		if identity.NamespaceAllowed(namespace) {
+			allowed = append(allowed, namespace)
+		}
+	}
+	namespaces = allowed
+
 	s.respondWithJSON(w, http.StatusOK, map[string][]string{"namespaces": namespaces})
 }
 
@@ -465,13 +781,38 @@ func (s *Server) handleListResources(w http.ResponseWriter, r *http.Request) {
 	resourceType := vars["resource"]
 	namespace := r.URL.Query().Get("namespace")
 
-	resources, err := s.k8sClient.ListResources(r.Context(), resourceType, namespace)
+	// Listing across all namespaces is allowed for a namespace-limited role;
+	// the results are filtered to its namespaces below
+	identity := auth.IdentityFromContext(r.Context())
+	if namespace == "" && !s.authorizeAction(w, r, auth.ActionRead, resourceType) {
+		return
+	}
+	if namespace != "" && !s.authorize(w, r, auth.ActionRead, namespace, resourceType) {
+		return
+	}
+
+	k8sClient, ok := s.clusterClient(w, r, r.URL.Query().Get("cluster"))
+	if !ok {
+		return
+	}
+
+	resources, err := k8sClient.ListResources(r.Context(), resourceType, namespace)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to list resources", err)
+		s.respondWithServerError(w, "Failed to list resources", err)
 		return
 	}
 
-	s.respondWithJSON(w, http.StatusOK, map[string]interface{}{"resources": resources})
+	if namespace == "" && !identity.AllNamespaces() {
+		allowed := resources[:0]
+		for _, resource := range resources {
+			if identity.NamespaceAllowed(resource.GetNamespace()) {
+				allowed = append(allowed, resource)
+			}
+		}
+		resources = allowed
+	}
+
+	s.respondWithRedactedJSON(w, http.StatusOK, map[string]interface{}{"resources": resources})
 }
 
 // handleGetResource handles requests to get a specific resource
@@ -481,13 +822,22 @@ func (s *Server) handleGetResource(w http.ResponseWriter, r *http.Request) {
 	name := vars["name"]
 	namespace := r.URL.Query().Get("namespace")
 
-	resource, err := s.k8sClient.GetResource(r.Context(), resourceType, namespace, name)
+	if !s.authorize(w, r, auth.ActionRead, namespace, resourceType) {
+		return
+	}
+
+	k8sClient, ok := s.clusterClient(w, r, r.URL.Query().Get("cluster"))
+	if !ok {
+		return
+	}
+
+	resource, err := k8sClient.GetResource(r.Context(), resourceType, namespace, name)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to get resource", err)
+		s.respondWithServerError(w, "Failed to get resource", err)
 		return
 	}
 
-	s.respondWithJSON(w, http.StatusOK, resource)
+	s.respondWithRedactedJSON(w, http.StatusOK, resource)
 }
 
 // handleGetEvents handles requests to get events
@@ -496,9 +846,18 @@ func (s *Server) handleGetEvents(w http.ResponseWriter, r *http.Request) {
 	resourceType := r.URL.Query().Get("resource")
 	name := r.URL.Query().Get("name")
 
-	events, err := s.k8sClient.GetResourceEvents(r.Context(), namespace, resourceType, name)
+	if !s.authorize(w, r, auth.ActionRead, namespace, resourceType) {
+		return
+	}
+
+	k8sClient, ok := s.clusterClient(w, r, r.URL.Query().Get("cluster"))
+	if !ok {
+		return
+	}
+
+	events, err := k8sClient.GetResourceEvents(r.Context(), namespace, resourceType, name)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to get events", err)
+		s.respondWithServerError(w, "Failed to get events", err)
 		return
 	}
 
@@ -507,12 +866,28 @@ func (s *Server) handleGetEvents(w http.ResponseWriter, r *http.Request) {
 
 // handleListArgoApplications handles requests to list ArgoCD applications
 func (s *Server) handleListArgoApplications(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAction(w, r, auth.ActionRead, "application") {
+		return
+	}
+
 	applications, err := s.argoClient.ListApplications(r.Context())
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to list ArgoCD applications", err)
+		s.respondWithServerError(w, "Failed to list ArgoCD applications", err)
 		return
 	}
 
+	// Only show applications that deploy into the caller's namespaces
+	identity := auth.IdentityFromContext(r.Context())
+	if !identity.AllNamespaces() {
+		allowed := applications[:0]
+		for _, app := range applications {
+			if identity.NamespaceAllowed(app.Spec.Destination.Namespace) {
+				allowed = append(allowed, app)
+			}
+		}
+		applications = allowed
+	}
+
 	s.respondWithJSON(w, http.StatusOK, map[string]interface{}{"applications": applications})
 }
 
@@ -521,21 +896,87 @@ func (s *Server) handleGetArgoApplication(w http.ResponseWriter, r *http.Request
 	vars := mux.Vars(r)
 	name := vars["name"]
 
+	// Check the action and kind before asking ArgoCD, so callers who may not read
+	// applications can neither send it requests nor learn which exist; the namespace is
+	// only known once fetched
+	if !s.authorizeAction(w, r, auth.ActionRead, "application") {
+		return
+	}
+
 	application, err := s.argoClient.GetApplication(r.Context(), name)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to get ArgoCD application", err)
+		s.respondWithServerError(w, "Failed to get ArgoCD application", err)
+		return
+	}
+
+	if !s.authorize(w, r, auth.ActionRead, application.Spec.Destination.Namespace, "application") {
 		return
 	}
 
 	s.respondWithJSON(w, http.StatusOK, application)
 }
 
+// handleListArgoApplicationSets handles requests to list ArgoCD ApplicationSets
+func (s *Server) handleListArgoApplicationSets(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAction(w, r, auth.ActionRead, "applicationset") {
+		return
+	}
+
+	appSets, err := s.argoClient.ListApplicationSets(r.Context())
+	if err != nil {
+		s.respondWithServerError(w, "Failed to list ArgoCD ApplicationSets", err)
+		return
+	}
+
+	// Only show ApplicationSets whose template deploys into the caller's namespaces; a
+	// templated namespace is not matched
+	identity := auth.IdentityFromContext(r.Context())
+	if !identity.AllNamespaces() {
+		allowed := appSets[:0]
+		for _, appSet := range appSets {
+			if identity.NamespaceAllowed(appSet.Spec.Template.Spec.Destination.Namespace) {
+				allowed = append(allowed, appSet)
+			}
+		}
+		appSets = allowed
+	}
+
+	s.respondWithJSON(w, http.StatusOK, map[string]interface{}{"applicationSets": appSets})
+}
+
+// handleGetArgoApplicationSet handles requests to get a specific ArgoCD ApplicationSet
+func (s *Server) handleGetArgoApplicationSet(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := vars["name"]
+
+	// As with applications, the namespace is checked once the ApplicationSet is fetched
+	if !s.authorizeAction(w, r, auth.ActionRead, "applicationset") {
+		return
+	}
+
+	appSet, err := s.argoClient.GetApplicationSet(r.Context(), name)
+	if err != nil {
+		s.respondWithServerError(w, "Failed to get ArgoCD ApplicationSet", err)
+		return
+	}
+
+	if !s.authorize(w, r, auth.ActionRead, appSet.Spec.Template.Spec.Destination.Namespace, "applicationset") {
+		return
+	}
+
+	s.respondWithJSON(w, http.StatusOK, appSet)
+}
+
 // handleListGitLabProjects handles requests to list GitLab projects
 func (s *Server) handleListGitLabProjects(w http.ResponseWriter, r *http.Request) {
+	if !s.authorizeAction(w, r, auth.ActionRead, "") {
+		return
+	}
+
 	// This would typically include pagination parameters
 	projects, err := s.gitlabClient.ListProjects(r.Context())
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to list GitLab projects", err)
+		s.respondWithServerError(w, "Failed to list GitLab projects", err)
 		return
 	}
 
@@ -547,9 +988,13 @@ func (s *Server) handleListGitLabPipelines(w http.ResponseWriter, r *http.Reques
 	vars := mux.Vars(r)
 	projectId := vars["projectId"]
 
+	if !s.authorizeAction(w, r, auth.ActionRead, "") {
+		return
+	}
+
 	pipelines, err := s.gitlabClient.ListPipelines(r.Context(), projectId)
 	if err != nil {
-		s.respondWithError(w, http.StatusInternalServerError, "Failed to list GitLab pipelines", err)
+		s.respondWithServerError(w, "Failed to list GitLab pipelines", err)
 		return
 	}
 
@@ -558,6 +1003,55 @@ func (s *Server) handleListGitLabPipelines(w http.ResponseWriter, r *http.Reques
 
 // Helper methods
 
+// clusterClient returns the Kubernetes client for a cluster name, impersonating the caller
+// when impersonation is enabled, and responds with 400 Bad Request when the cluster is not
+// registered. An empty name selects the default cluster.
+func (s *Server) clusterClient(w http.ResponseWriter, r *http.Request, cluster string) (*k8s.Client, bool) {
+	if _, err := s.clusters.Get(cluster); err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid cluster", err)
+		return nil, false
+	}
+	k8sClient, err := s.clusters.ForContext(r.Context(), cluster)
+	if err != nil {
+		s.respondWithServerError(w, "Failed to create Kubernetes client", err)
+		return nil, false
+	}
+	return k8sClient, true
+}
+
+// authorize checks the caller's role for an action on a kind in a namespace,
+// responding with 403 Forbidden when it is not allowed
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, action, namespace, kind string) bool {
+	identity := auth.IdentityFromContext(r.Context())
+	if err := identity.Authorize(action, namespace, kind); err != nil {
+		s.respondWithError(w, http.StatusForbidden, "Forbidden", err)
+		return false
+	}
+	return true
+}
+
+// authorizeAction checks the caller's role for an action on a kind without a namespace
+// check, responding with 403 Forbidden when it is not allowed. Callers filter their
+// results to the role's namespaces.
+func (s *Server) authorizeAction(w http.ResponseWriter, r *http.Request, action, kind string) bool {
+	identity := auth.IdentityFromContext(r.Context())
+	if err := identity.AuthorizeAction(action, kind); err != nil {
+		s.respondWithError(w, http.StatusForbidden, "Forbidden", err)
+		return false
+	}
+	return true
+}
+
+// respondWithServerError sends a 500 Internal Server Error response, or 403 Forbidden
+// when Kubernetes RBAC denied the request to the caller's impersonated identity
+func (s *Server) respondWithServerError(w http.ResponseWriter, message string, err error) {
+	if apierrors.IsForbidden(err) {
+		s.respondWithError(w, http.StatusForbidden, message+": forbidden by Kubernetes RBAC", err)
+		return
+	}
+	s.respondWithError(w, http.StatusInternalServerError, message, err)
+}
+
 // respondWithError sends an error response to the client
 func (s *Server) respondWithError(w http.ResponseWriter, code int, message string, err error) {
 	errorResponse := map[string]string{
@@ -584,3 +1078,25 @@ func (s *Server) respondWithJSON(w http.ResponseWriter, code int, payload interf
 	w.WriteHeader(code)
 	_ = json.NewEncoder(w).Encode(payload)
 }
+
+// maxRedactionHeaderEntries caps the audit carried in the X-Redacted-Paths header
+const maxRedactionHeaderEntries = 50
+
+// respondWithRedactedJSON sends Kubernetes objects with Secret data and sensitive
+// values masked. The audit is returned in the X-Redactions (count) and
+// X-Redacted-Paths (path=reason list) headers so the body keeps its usual shape.
+func (s *Server) respondWithRedactedJSON(w http.ResponseWriter, code int, payload interface{}) {
+	redacted, redactions := s.redactor.RedactValue("", payload)
+	if len(redactions) > 0 {
+		paths := make([]string, 0, len(redactions))
+		for i, redaction := range redactions {
+			if i == maxRedactionHeaderEntries {
+				break
+			}
+			paths = append(paths, redaction.Path+"="+redaction.Reason)
+		}
+		w.Header().Set("X-Redactions", strconv.Itoa(len(redactions)))
+		w.Header().Set("X-Redacted-Paths", strings.Join(paths, ", "))
+	}
+	s.respondWithJSON(w, code, redacted)
+}
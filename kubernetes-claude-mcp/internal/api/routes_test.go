@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+func TestMCPRequestNamespaceScope(t *testing.T) {
+	s := &Server{logger: logging.NewLogger()}
+	identity := &auth.Identity{
+		Name: "team-a",
+		Role: &auth.Role{Name: "team-a", Namespaces: []string{"team-a"}, Kinds: []string{"*"}, Actions: []string{"*"}},
+	}
+
+	// Each action is checked as its dedicated endpoint checks it, whatever namespace
+	// the request names
+	requests := map[string]string{
+		"merge request": `{"action":"queryMergeRequest","namespace":"team-a","projectId":"1","mergeRequestIid":2}`,
+		"commit":        `{"action":"queryCommit","namespace":"team-a","projectId":"1","commitSha":"abc123"}`,
+		"namespace":     `{"action":"queryResource","namespace":"team-a","resource":"namespace","name":"team-b"}`,
+	}
+	for name, body := range requests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/mcp", strings.NewReader(body))
+			req = req.WithContext(auth.WithIdentity(req.Context(), identity))
+			rec := httptest.NewRecorder()
+
+			s.handleMCPRequest(rec, req)
+			if rec.Code != http.StatusForbidden {
+				t.Errorf("Expected 403 for a namespace-scoped key, got %d: %s", rec.Code, rec.Body.String())
+			}
+		})
+	}
+}
@@ -2,15 +2,18 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/argocd"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/correlator"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/gitlab"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/mcp"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/redact"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
 	"github.com/gorilla/mux"
@@ -20,12 +23,15 @@ import (
 type Server struct {
 	router                 *mux.Router
 	server                 *http.Server
-	k8sClient              *k8s.Client
+	clusters               *k8s.ClusterRegistry
 	argoClient             *argocd.Client
 	gitlabClient           *gitlab.Client
 	mcpHandler             *mcp.ProtocolHandler
+	mcpServer              *mcp.Server
 	troubleshootCorrelator *correlator.TroubleshootCorrelator
-	resourceMapper         *k8s.ResourceMapper
+	authenticator          *auth.Authenticator
+	redactor               *redact.Redactor
+	gitlabWebhook          *gitlabWebhook
 	config                 config.ServerConfig
 	logger                 *logging.Logger
 }
@@ -33,10 +39,11 @@ type Server struct {
 // NewServer creates a new API server
 func NewServer(
 	cfg config.ServerConfig,
-	k8sClient *k8s.Client,
+	clusters *k8s.ClusterRegistry,
 	argoClient *argocd.Client,
 	gitlabClient *gitlab.Client,
 	mcpHandler *mcp.ProtocolHandler,
+	mcpServer *mcp.Server,
 	troubleshootCorrelator *correlator.TroubleshootCorrelator,
 	logger *logging.Logger,
 ) *Server {
@@ -46,25 +53,33 @@ func NewServer(
 
 	server := &Server{
 		router:                 mux.NewRouter(),
-		k8sClient:              k8sClient,
+		clusters:               clusters,
 		argoClient:             argoClient,
 		gitlabClient:           gitlabClient,
 		mcpHandler:             mcpHandler,
+		mcpServer:              mcpServer,
 		troubleshootCorrelator: troubleshootCorrelator,
+		authenticator:          auth.NewAuthenticator(cfg.Auth, logger.Named("auth")),
+		redactor:               redact.Default(),
 		config:                 cfg,
 		logger:                 logger,
 	}
 
-	// Initialize resource mapper
-	server.resourceMapper = server.k8sClient.ResourceMapper
-
 	// Set up routes
 	server.setupRoutes()
 	server.setupNamespaceRoutes()
+	server.setupHelmRoutes()
+	server.setupWebhookRoutes()
 
 	return server
 }
 
+// WithRedactor replaces the default redactor applied to Kubernetes objects in responses
+func (s *Server) WithRedactor(redactor *redact.Redactor) *Server {
+	s.redactor = redactor
+	return s
+}
+
 // Start starts the HTTP server
 func (s *Server) Start(ctx context.Context) error {
 	s.server = &http.Server{
@@ -74,6 +89,11 @@ func (s *Server) Start(ctx context.Context) error {
 		WriteTimeout: time.Duration(s.config.WriteTimeout) * time.Second,
 	}
 
+	// Review merge requests queued by the GitLab webhook in the background
+	if s.gitlabWebhook != nil {
+		go s.gitlabWebhook.run(ctx)
+	}
+
 	// Channel for server errors
 	errCh := make(chan error, 1)
 
@@ -129,11 +149,18 @@ func (s *Server) loggingMiddleware(next http.Handler) http.Handler {
 // authMiddleware checks for valid authentication
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get API key from header
-		apiKey := r.Header.Get("X-API-Key")
+		var identity *auth.Identity
 
-		// Check for bearer token if API key is not provided
-		if apiKey == "" {
+		// Get API key from header
+		if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+			var ok bool
+			identity, ok = s.authenticator.Authenticate(apiKey)
+			if !ok {
+				s.respondWithError(w, http.StatusUnauthorized, "Invalid API key", nil)
+				return
+			}
+		} else {
+			// Check for bearer token if API key is not provided
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				s.respondWithError(w, http.StatusUnauthorized, "Authentication required", nil)
@@ -147,16 +174,36 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 				return
 			}
 
-			apiKey = parts[1]
+			// Validate the token as an OIDC JWT or an API key
+			var err error
+			identity, err = s.authenticator.AuthenticateToken(r.Context(), parts[1])
+			if err != nil {
+				s.respondWithError(w, http.StatusUnauthorized, "Invalid bearer token", err)
+				return
+			}
 		}
 
-		// Validate the API key against the configured key
-		if apiKey != s.config.Auth.APIKey {
-			s.respondWithError(w, http.StatusUnauthorized, "Invalid API key", nil)
-			return
+		// Call the next handler with the caller's identity for the handlers' role checks,
+		// and as the Kubernetes user to impersonate when impersonation is enabled
+		ctx := auth.WithIdentity(r.Context(), identity)
+		if identity.KubernetesUser != "" {
+			ctx = k8s.WithImpersonation(ctx, identity.KubernetesUser, identity.KubernetesGroups)
 		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
 
-		// Call the next handler
+// requireFullReadAccess only admits callers whose role may read and query every kind in
+// every namespace. The MCP tools take arbitrary namespaces and kinds, so a limited role
+// could otherwise read around its restrictions.
+func (s *Server) requireFullReadAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity := auth.IdentityFromContext(r.Context())
+		if !identity.FullReadAccess() {
+			s.respondWithError(w, http.StatusForbidden, "Forbidden",
+				fmt.Errorf("the MCP endpoint requires a role with read and query access to every namespace and kind"))
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -173,16 +220,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// Initialize the resourceMapper in NewServer
-//
-//nolint:unused // Reserved for future resource mapper initialization
-func (s *Server) initResourceMapper() {
-	if s.k8sClient != nil {
-		s.resourceMapper = k8s.NewResourceMapper(s.k8sClient)
-		s.logger.Info("Resource mapper initialized")
-	} else {
-		s.logger.Warn("Cannot initialize resource mapper - K8s client is nil")
-	}
+// Unwrap exposes the underlying writer so http.ResponseController can flush streams
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
 }
 
 func (s *Server) corsMiddleware(next http.Handler) http.Handler {
@@ -190,7 +230,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 		// Set CORS headers
 		w.Header().Set("Access-Control-Allow-Origin", "*") // Allow all origins in development
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Mcp-Session-Id")
+		w.Header().Set("Access-Control-Expose-Headers", "Mcp-Session-Id")
 
 		// If this is a preflight request, respond with 200 OK
 		if r.Method == "OPTIONS" {
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/claude"
+)
+
+// analysisFunc runs a Claude-backed analysis, streaming text to onDelta when it is set
+type analysisFunc func(onDelta claude.StreamHandler) (interface{}, error)
+
+// wantsEventStream reports whether the client asked for a text/event-stream response
+func wantsEventStream(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream") ||
+		r.URL.Query().Get("stream") == "true"
+}
+
+// respondWithAnalysis runs an analysis and writes its result as JSON or, when the client
+// asked for a stream, as SSE "delta" events followed by a "done" event with the result
+func (s *Server) respondWithAnalysis(w http.ResponseWriter, r *http.Request, errorMessage string, analyze analysisFunc) {
+	if !wantsEventStream(r) {
+		result, err := analyze(nil)
+		if err != nil {
+			s.respondWithServerError(w, errorMessage, err)
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, result)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	// Streams outlive the server write timeout
+	_ = controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	writeEvent := func(event string, payload interface{}) error {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s event: %w", event, err)
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+			return fmt.Errorf("failed to write %s event: %w", event, err)
+		}
+		return controller.Flush()
+	}
+
+	result, err := analyze(func(text string) error {
+		return writeEvent("delta", map[string]string{"text": text})
+	})
+	if err != nil {
+		s.logger.Error(errorMessage, "error", err)
+		_ = writeEvent("error", map[string]string{"error": errorMessage, "details": err.Error()})
+		return
+	}
+
+	if err := writeEvent("done", result); err != nil {
+		s.logger.Warn("Failed to send final stream event", "error", err)
+	}
+}
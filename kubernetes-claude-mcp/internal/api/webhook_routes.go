@@ -0,0 +1,607 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/gitlab"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/policy"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+const (
+	// gitlabMergeRequestEvent is the X-Gitlab-Event header of merge request webhooks
+	gitlabMergeRequestEvent = "Merge Request Hook"
+
+	// maxWebhookBodySize caps webhook payloads, which include the MR description and
+	// the attributes that changed
+	maxWebhookBodySize = 5 << 20
+
+	// webhookQueueSize is how many reviews can wait for the worker before webhooks
+	// are rejected and left for GitLab to redeliver
+	webhookQueueSize = 32
+
+	// reviewTimeout bounds one merge request analysis and its comment
+	reviewTimeout = 10 * time.Minute
+
+	// reviewCommentMarker identifies the review comment so it is updated rather than
+	// posted again on each push
+	reviewCommentMarker = "<!-- kubernetes-claude-mcp:merge-request-review -->"
+
+	// maxInlineFindings caps the diff discussions one review starts
+	maxInlineFindings = 20
+
+	// maxListedViolations caps the policy violations listed in the review comment
+	maxListedViolations = 20
+
+	// policyStatusName names the commit status that reports the policy verdict
+	policyStatusName = "kubernetes-claude-mcp/policy"
+
+	// mergeRequestStateTTL is how long a merge request's head and review comment are
+	// remembered after its last webhook, so merge requests that are abandoned rather
+	// than closed are eventually forgotten
+	mergeRequestStateTTL = 7 * 24 * time.Hour
+)
+
+// mergeRequestReviewQuery is asked about each merge request a webhook reviews
+const mergeRequestReviewQuery = "Review this merge request for its impact on the cluster. Summarize which " +
+	"applications, environments and resources it changes, flag anything that could break a rollout or " +
+	"fail to sync, and rate the overall risk as low, medium or high. Keep the review short enough to " +
+	"read as a merge request comment."
+
+// mergeRequestCommenter reads and writes merge request comments and discussions and
+// sets commit statuses; *gitlab.Client implements it
+type mergeRequestCommenter interface {
+	GetMergeRequest(ctx context.Context, projectID string, mergeRequestIID int) (*models.GitLabMergeRequest, error)
+	GetMergeRequestComments(ctx context.Context, projectID string, mergeRequestIID int) ([]models.GitLabMergeRequestComment, error)
+	CreateMergeRequestComment(ctx context.Context, projectID string, mergeRequestIID int, body string) (*models.GitLabMergeRequestComment, error)
+	UpdateMergeRequestComment(ctx context.Context, projectID string, mergeRequestIID, noteID int, body string) (*models.GitLabMergeRequestComment, error)
+	ListMergeRequestDiscussions(ctx context.Context, projectID string, mergeRequestIID int) ([]models.GitLabDiscussion, error)
+	CreateMergeRequestDiscussion(
+		ctx context.Context,
+		projectID string,
+		mergeRequestIID int,
+		body string,
+		position *models.GitLabDiffPosition,
+	) (*models.GitLabDiscussion, error)
+	SetCommitStatus(ctx context.Context, projectID, sha string, status models.GitLabCommitStatus) (*models.GitLabCommitStatus, error)
+}
+
+// mergeRequestAnalyzer analyzes a merge request with Claude
+type mergeRequestAnalyzer func(ctx context.Context, projectID string, mergeRequestIID int) (*models.MCPResponse, error)
+
+// mergeRequestPolicyCheck checks a merge request's rendered manifests against the policy
+type mergeRequestPolicyCheck func(ctx context.Context, projectID string, mergeRequestIID int) (*models.PolicyResult, error)
+
+// mergeRequestReview is a merge request head queued for review
+type mergeRequestReview struct {
+	projectID string
+	iid       int
+	headSHA   string
+}
+
+func (r mergeRequestReview) key() string {
+	return r.projectID + "!" + strconv.Itoa(r.iid)
+}
+
+// trackedMergeRequest is what the webhook remembers about a merge request: the newest
+// head queued for review, the ID of its review comment and when a webhook last named it
+type trackedMergeRequest struct {
+	head     string
+	noteID   int
+	lastSeen time.Time
+}
+
+// gitlabMergeRequestHook is the part of a GitLab merge request webhook payload the
+// receiver reads
+type gitlabMergeRequestHook struct {
+	ObjectKind string `json:"object_kind"`
+	Project    struct {
+		ID int `json:"id"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID        int    `json:"iid"`
+		Action     string `json:"action"`
+		LastCommit struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+	} `json:"object_attributes"`
+}
+
+// gitlabWebhook reviews merge requests when GitLab reports they were opened or
+// updated. Reviews run one at a time on a background worker, at most once per MR
+// head commit. Each MR gets a single summary comment that later reviews update, a
+// diff discussion on each risky line that is not already under discussion, and a
+// commit status with the policy verdict on the reviewed head.
+type gitlabWebhook struct {
+	secret   string
+	analyze  mergeRequestAnalyzer
+	check    mergeRequestPolicyCheck
+	comments mergeRequestCommenter
+	queue    chan mergeRequestReview
+	mu       sync.Mutex
+	tracked  map[string]*trackedMergeRequest
+	logger   *logging.Logger
+}
+
+func newGitLabWebhook(
+	secret string,
+	analyze mergeRequestAnalyzer,
+	check mergeRequestPolicyCheck,
+	comments mergeRequestCommenter,
+	logger *logging.Logger,
+) *gitlabWebhook {
+	return &gitlabWebhook{
+		secret:   secret,
+		analyze:  analyze,
+		check:    check,
+		comments: comments,
+		queue:    make(chan mergeRequestReview, webhookQueueSize),
+		tracked:  make(map[string]*trackedMergeRequest),
+		logger:   logger,
+	}
+}
+
+// WithGitLabWebhook enables the GitLab webhook endpoint, which reviews merge requests
+// as they are opened or pushed to, comments the analysis on them and reports the
+// policy verdict as a commit status. Requests must carry the secret in X-Gitlab-Token.
+func (s *Server) WithGitLabWebhook(secret string) *Server {
+	analyze := func(ctx context.Context, projectID string, mergeRequestIID int) (*models.MCPResponse, error) {
+		return s.mcpHandler.ProcessRequest(ctx, &models.MCPRequest{
+			Action:          "queryMergeRequest",
+			ProjectID:       projectID,
+			MergeRequestIID: mergeRequestIID,
+			Query:           mergeRequestReviewQuery,
+		})
+	}
+	s.gitlabWebhook = newGitLabWebhook(secret, analyze, s.mcpHandler.CheckMergeRequestPolicy, s.gitlabClient, s.logger.Named("webhook"))
+	return s
+}
+
+// setupWebhookRoutes registers the webhook receivers. They authenticate with their
+// own shared secrets rather than API credentials.
+func (s *Server) setupWebhookRoutes() {
+	s.router.HandleFunc("/webhooks/gitlab", s.handleGitLabWebhook).Methods("POST")
+}
+
+// handleGitLabWebhook queues a review for merge request open and update events and
+// responds before the review runs, so GitLab's webhook timeout is not hit
+func (s *Server) handleGitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook := s.gitlabWebhook
+	if webhook == nil {
+		s.respondWithError(w, http.StatusNotFound, "GitLab webhook is not configured", nil)
+		return
+	}
+
+	token := r.Header.Get("X-Gitlab-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(webhook.secret)) != 1 {
+		s.respondWithError(w, http.StatusUnauthorized, "Invalid webhook token", nil)
+		return
+	}
+
+	if event := r.Header.Get("X-Gitlab-Event"); event != gitlabMergeRequestEvent {
+		s.respondWithJSON(w, http.StatusOK, map[string]string{"status": "ignored"})
+		return
+	}
+
+	var hook gitlabMergeRequestHook
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBodySize)).Decode(&hook); err != nil {
+		s.respondWithError(w, http.StatusBadRequest, "Invalid webhook payload", err)
+		return
+	}
+	if hook.ObjectKind != "merge_request" || hook.Project.ID == 0 || hook.ObjectAttributes.IID == 0 {
+		s.respondWithError(w, http.StatusBadRequest, "Webhook payload is not a merge request event", nil)
+		return
+	}
+
+	review := mergeRequestReview{
+		projectID: strconv.Itoa(hook.Project.ID),
+		iid:       hook.ObjectAttributes.IID,
+		headSHA:   hook.ObjectAttributes.LastCommit.ID,
+	}
+
+	status := http.StatusOK
+	result := "ignored"
+	switch hook.ObjectAttributes.Action {
+	case "open", "reopen", "update":
+		status, result = webhook.enqueue(review)
+	case "close", "merge":
+		webhook.forget(review)
+	}
+
+	s.logger.Info("Received GitLab merge request webhook",
+		"projectId", review.projectID,
+		"mergeRequestIID", review.iid,
+		"action", hook.ObjectAttributes.Action,
+		"headSHA", review.headSHA,
+		"result", result)
+	s.respondWithJSON(w, status, map[string]string{"status": result})
+}
+
+// enqueue queues a review unless the head commit was already queued or reviewed. It
+// returns the response status and a description of what happened.
+func (g *gitlabWebhook) enqueue(review mergeRequestReview) (int, string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := time.Now()
+	g.expire(now)
+
+	tracked := g.tracked[review.key()]
+	if tracked != nil {
+		tracked.lastSeen = now
+	}
+	if review.headSHA == "" || (tracked != nil && tracked.head == review.headSHA) {
+		return http.StatusOK, "duplicate"
+	}
+
+	select {
+	case g.queue <- review:
+		if tracked == nil {
+			tracked = &trackedMergeRequest{lastSeen: now}
+			g.tracked[review.key()] = tracked
+		}
+		tracked.head = review.headSHA
+		return http.StatusAccepted, "queued"
+	default:
+		return http.StatusServiceUnavailable, "queue full"
+	}
+}
+
+// expire forgets merge requests no webhook has named for mergeRequestStateTTL; the
+// caller holds the lock. A later push reviews such a merge request again and finds
+// its review comment through the API.
+func (g *gitlabWebhook) expire(now time.Time) {
+	for key, tracked := range g.tracked {
+		if now.Sub(tracked.lastSeen) > mergeRequestStateTTL {
+			delete(g.tracked, key)
+		}
+	}
+}
+
+// forget drops the state kept for a merge request once it is closed or merged
+func (g *gitlabWebhook) forget(review mergeRequestReview) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.tracked, review.key())
+}
+
+// isLatest reports whether a review is still for the newest head of its merge request
+func (g *gitlabWebhook) isLatest(review mergeRequestReview) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	tracked := g.tracked[review.key()]
+	return tracked != nil && tracked.head == review.headSHA
+}
+
+// run reviews queued merge requests until ctx is done
+func (g *gitlabWebhook) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case review := <-g.queue:
+			g.review(ctx, review)
+		}
+	}
+}
+
+// review analyzes a merge request and posts or updates its review comment. Reviews
+// overtaken by a newer push are dropped; a failed review clears its head so GitLab
+// redelivering the event retries it.
+func (g *gitlabWebhook) review(ctx context.Context, review mergeRequestReview) {
+	if !g.isLatest(review) {
+		g.logger.Debug("Skipping superseded merge request review",
+			"projectId", review.projectID,
+			"mergeRequestIID", review.iid,
+			"headSHA", review.headSHA)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reviewTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := g.postReview(ctx, review)
+	if err != nil {
+		g.mu.Lock()
+		if tracked := g.tracked[review.key()]; tracked != nil && tracked.head == review.headSHA {
+			tracked.head = ""
+		}
+		g.mu.Unlock()
+
+		g.logger.Error("Failed to review merge request",
+			"projectId", review.projectID,
+			"mergeRequestIID", review.iid,
+			"headSHA", review.headSHA,
+			"error", err)
+		return
+	}
+
+	g.logger.Info("Reviewed merge request",
+		"projectId", review.projectID,
+		"mergeRequestIID", review.iid,
+		"headSHA", review.headSHA,
+		"duration", time.Since(start))
+}
+
+func (g *gitlabWebhook) postReview(ctx context.Context, review mergeRequestReview) error {
+	// The policy check does not need Claude, so the verdict is reported even when the
+	// analysis fails
+	result := g.postPolicyStatus(ctx, review)
+
+	response, err := g.analyze(ctx, review.projectID, review.iid)
+	if err != nil {
+		return fmt.Errorf("failed to analyze merge request: %w", err)
+	}
+	if !g.isLatest(review) {
+		return nil
+	}
+
+	// Findings are pinned first so the summary can count the ones that were. They are
+	// secondary to the summary, so failing to pin them does not fail the review.
+	pinned := 0
+	if len(response.ManifestFindings) > 0 {
+		pinned, err = g.postFindings(ctx, review, response.ManifestFindings)
+		if err != nil {
+			g.logger.Warn("Failed to post inline findings",
+				"projectId", review.projectID,
+				"mergeRequestIID", review.iid,
+				"error", err)
+		}
+	}
+
+	return g.postSummary(ctx, review, formatReviewComment(review, response, result, pinned))
+}
+
+// postPolicyStatus checks the merge request against the policy and reports the verdict
+// as a commit status on the reviewed head. GitLab statuses have no warning state, so a
+// warn verdict passes with the warnings counted in the description. The result is nil
+// when the check could not run or was overtaken by a newer push; either way the status
+// is canceled rather than failed, since the head was not evaluated.
+func (g *gitlabWebhook) postPolicyStatus(ctx context.Context, review mergeRequestReview) *models.PolicyResult {
+	g.setStatus(ctx, review, "running", "Checking rendered manifests against policy")
+
+	result, err := g.check(ctx, review.projectID, review.iid)
+	switch {
+	case !g.isLatest(review):
+		g.setStatus(ctx, review, "canceled", "Superseded by a newer commit")
+		return nil
+	case err != nil:
+		g.logger.Warn("Failed to check merge request policy",
+			"projectId", review.projectID,
+			"mergeRequestIID", review.iid,
+			"error", err)
+		g.setStatus(ctx, review, "canceled", "Policy check could not run")
+		return nil
+	}
+
+	state := "success"
+	if result.Verdict == models.PolicyFail {
+		state = "failed"
+	}
+	g.setStatus(ctx, review, state, policy.Summary(result))
+	return result
+}
+
+// setStatus sets the policy commit status on the reviewed head. Failures are logged
+// rather than failing the review.
+func (g *gitlabWebhook) setStatus(ctx context.Context, review mergeRequestReview, state, description string) {
+	status := models.GitLabCommitStatus{Status: state, Name: policyStatusName, Description: description}
+	if _, err := g.comments.SetCommitStatus(ctx, review.projectID, review.headSHA, status); err != nil {
+		g.logger.Warn("Failed to set commit status",
+			"projectId", review.projectID,
+			"sha", review.headSHA,
+			"state", state,
+			"error", err)
+	}
+}
+
+// postSummary updates the merge request's review comment, or posts it the first time
+func (g *gitlabWebhook) postSummary(ctx context.Context, review mergeRequestReview, body string) error {
+	noteID, err := g.findReviewComment(ctx, review)
+	if err != nil {
+		return err
+	}
+	if noteID != 0 {
+		_, err = g.comments.UpdateMergeRequestComment(ctx, review.projectID, review.iid, noteID, body)
+		if err == nil {
+			return nil
+		}
+		if !gitlab.IsNotFound(err) {
+			return fmt.Errorf("failed to update review comment: %w", err)
+		}
+		// The comment was deleted since it was posted; post a new one
+	}
+
+	comment, err := g.comments.CreateMergeRequestComment(ctx, review.projectID, review.iid, body)
+	if err != nil {
+		return fmt.Errorf("failed to create review comment: %w", err)
+	}
+
+	g.mu.Lock()
+	if tracked := g.tracked[review.key()]; tracked != nil {
+		tracked.noteID = comment.ID
+	}
+	g.mu.Unlock()
+	return nil
+}
+
+// postFindings starts a diff discussion on the line of each of the first
+// maxInlineFindings findings, skipping findings already under discussion from an
+// earlier review, and returns how many of them are under discussion. Positions need
+// the diff refs of the reviewed head, so nothing is posted once the merge request has
+// moved on.
+func (g *gitlabWebhook) postFindings(ctx context.Context, review mergeRequestReview, findings []models.ManifestFinding) (int, error) {
+	mr, err := g.comments.GetMergeRequest(ctx, review.projectID, review.iid)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get merge request: %w", err)
+	}
+	if mr.DiffRefs.HeadSHA != review.headSHA {
+		return 0, nil
+	}
+
+	discussions, err := g.comments.ListMergeRequestDiscussions(ctx, review.projectID, review.iid)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list merge request discussions: %w", err)
+	}
+	posted := make(map[string]bool)
+	for _, discussion := range discussions {
+		for _, note := range discussion.Notes {
+			if marker := findingMarkerIn(note.Body); marker != "" {
+				posted[marker] = true
+			}
+		}
+	}
+
+	if len(findings) > maxInlineFindings {
+		findings = findings[:maxInlineFindings]
+	}
+	pinned := 0
+	for _, finding := range findings {
+		marker := findingMarker(finding)
+		if posted[marker] {
+			pinned++
+			continue
+		}
+
+		oldPath := finding.OldPath
+		if oldPath == "" {
+			oldPath = finding.NewPath
+		}
+		position := &models.GitLabDiffPosition{
+			PositionType: "text",
+			BaseSHA:      mr.DiffRefs.BaseSHA,
+			StartSHA:     mr.DiffRefs.StartSHA,
+			HeadSHA:      mr.DiffRefs.HeadSHA,
+			OldPath:      oldPath,
+			NewPath:      finding.NewPath,
+			OldLine:      finding.OldLine,
+			NewLine:      finding.NewLine,
+		}
+		body := fmt.Sprintf("**%s** (%s): %s\n\n%s", finding.Severity, finding.Rule, finding.Message, marker)
+		if _, err := g.comments.CreateMergeRequestDiscussion(ctx, review.projectID, review.iid, body, position); err != nil {
+			g.logger.Warn("Failed to create diff discussion",
+				"projectId", review.projectID,
+				"mergeRequestIID", review.iid,
+				"path", finding.NewPath,
+				"rule", finding.Rule,
+				"error", err)
+			continue
+		}
+		posted[marker] = true
+		pinned++
+	}
+	return pinned, nil
+}
+
+// findingMarkerPrefix starts the hidden marker that identifies a finding's discussion
+const findingMarkerPrefix = "<!-- kubernetes-claude-mcp:finding "
+
+// findingMarker identifies a finding by rule, file and line content, so it is not
+// raised again when later pushes move the line
+func findingMarker(finding models.ManifestFinding) string {
+	sum := sha256.Sum256([]byte(finding.Text))
+	return findingMarkerPrefix + finding.Rule + " " + finding.NewPath + " " + hex.EncodeToString(sum[:6]) + " -->"
+}
+
+// findingMarkerIn returns the finding marker in a comment body, if any
+func findingMarkerIn(body string) string {
+	start := strings.Index(body, findingMarkerPrefix)
+	if start < 0 {
+		return ""
+	}
+	end := strings.Index(body[start:], "-->")
+	if end < 0 {
+		return ""
+	}
+	return body[start : start+end+len("-->")]
+}
+
+// findReviewComment returns the ID of the merge request's review comment, or 0 when
+// there is none yet
+func (g *gitlabWebhook) findReviewComment(ctx context.Context, review mergeRequestReview) (int, error) {
+	g.mu.Lock()
+	noteID := 0
+	if tracked := g.tracked[review.key()]; tracked != nil {
+		noteID = tracked.noteID
+	}
+	g.mu.Unlock()
+	if noteID != 0 {
+		return noteID, nil
+	}
+
+	comments, err := g.comments.GetMergeRequestComments(ctx, review.projectID, review.iid)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get merge request comments: %w", err)
+	}
+	for _, comment := range comments {
+		if !comment.System && strings.Contains(comment.Body, reviewCommentMarker) {
+			return comment.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+// formatReviewComment renders the analysis and policy verdict of a merge request as
+// its review comment, counting the findings pinned as diff discussions
+func formatReviewComment(review mergeRequestReview, response *models.MCPResponse, result *models.PolicyResult, pinned int) string {
+	var b strings.Builder
+	b.WriteString(reviewCommentMarker + "\n")
+	b.WriteString("### Kubernetes impact review\n\n")
+
+	sha := review.headSHA
+	if len(sha) > 8 {
+		sha = sha[:8]
+	}
+	fmt.Fprintf(&b, "Reviewed at commit `%s`.\n\n", sha)
+
+	if result != nil {
+		fmt.Fprintf(&b, "Policy verdict: **%s** (%s)\n", result.Verdict, policy.Summary(result))
+		for i, v := range result.Violations {
+			if i == maxListedViolations {
+				fmt.Fprintf(&b, "- and %d more\n", len(result.Violations)-i)
+				break
+			}
+			name := v.Kind + " " + v.Name
+			if v.Namespace != "" {
+				name = v.Kind + " " + v.Namespace + "/" + v.Name
+			}
+			fmt.Fprintf(&b, "- **%s** `%s` %s in `%s`: %s\n", v.Severity, v.Rule, name, v.Source, v.Message)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(response.ApplicationSetChanges) > 0 {
+		b.WriteString("ApplicationSet changes:\n")
+		for _, change := range response.ApplicationSetChanges {
+			fmt.Fprintf(&b, "- %s: application %s for `%s` (%s generator)\n",
+				change.ApplicationSet, change.Change, change.Path, change.Generator)
+		}
+		b.WriteString("\n")
+	}
+
+	switch n := len(response.ManifestFindings); {
+	case n == 0:
+	case pinned == n:
+		fmt.Fprintf(&b, "%d risky changed lines are flagged in diff discussions.\n\n", n)
+	case pinned == 0:
+		fmt.Fprintf(&b, "%d risky changed lines were found but could not be flagged in diff discussions.\n\n", n)
+	default:
+		fmt.Fprintf(&b, "%d risky changed lines were found; %d are flagged in diff discussions.\n\n", n, pinned)
+	}
+
+	b.WriteString(strings.TrimSpace(response.Analysis))
+	b.WriteString("\n\n---\n_This comment is updated when new commits are pushed._\n")
+	return b.String()
+}
@@ -0,0 +1,286 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// fakeCommenter records the comments, discussions and commit statuses a review posts
+// and updates
+type fakeCommenter struct {
+	mu          sync.Mutex
+	headSHA     string
+	comments    []models.GitLabMergeRequestComment
+	discussions []models.GitLabDiscussion
+	positions   []models.GitLabDiffPosition
+	statuses    []string
+	created     int
+	updated     int
+}
+
+func (f *fakeCommenter) SetCommitStatus(
+	_ context.Context,
+	_, sha string,
+	status models.GitLabCommitStatus,
+) (*models.GitLabCommitStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses = append(f.statuses, sha+" "+status.Status)
+	status.SHA = sha
+	return &status, nil
+}
+
+func (f *fakeCommenter) GetMergeRequest(context.Context, string, int) (*models.GitLabMergeRequest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	mr := &models.GitLabMergeRequest{}
+	mr.DiffRefs.BaseSHA = "base"
+	mr.DiffRefs.StartSHA = "start"
+	mr.DiffRefs.HeadSHA = f.headSHA
+	return mr, nil
+}
+
+func (f *fakeCommenter) ListMergeRequestDiscussions(context.Context, string, int) ([]models.GitLabDiscussion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]models.GitLabDiscussion(nil), f.discussions...), nil
+}
+
+func (f *fakeCommenter) CreateMergeRequestDiscussion(
+	_ context.Context,
+	_ string,
+	_ int,
+	body string,
+	position *models.GitLabDiffPosition,
+) (*models.GitLabDiscussion, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	discussion := models.GitLabDiscussion{Notes: []models.GitLabMergeRequestComment{{Body: body, Position: position}}}
+	f.discussions = append(f.discussions, discussion)
+	f.positions = append(f.positions, *position)
+	return &discussion, nil
+}
+
+func (f *fakeCommenter) GetMergeRequestComments(context.Context, string, int) ([]models.GitLabMergeRequestComment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]models.GitLabMergeRequestComment(nil), f.comments...), nil
+}
+
+func (f *fakeCommenter) CreateMergeRequestComment(_ context.Context, _ string, _ int, body string) (*models.GitLabMergeRequestComment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created++
+	comment := models.GitLabMergeRequestComment{ID: 100 + len(f.comments), Body: body}
+	f.comments = append(f.comments, comment)
+	return &comment, nil
+}
+
+func (f *fakeCommenter) UpdateMergeRequestComment(_ context.Context, _ string, _, noteID int, body string) (*models.GitLabMergeRequestComment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updated++
+	for i := range f.comments {
+		if f.comments[i].ID == noteID {
+			f.comments[i].Body = body
+			return &f.comments[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func mergeRequestHook(action, sha string) string {
+	return `{"object_kind":"merge_request","project":{"id":42},` +
+		`"object_attributes":{"iid":7,"action":"` + action + `","last_commit":{"id":"` + sha + `"}}}`
+}
+
+func TestGitLabWebhook(t *testing.T) {
+	commenter := &fakeCommenter{
+		comments: []models.GitLabMergeRequestComment{{ID: 1, Body: "LGTM"}},
+	}
+	var analyzed []string
+	analyze := func(_ context.Context, projectID string, mergeRequestIID int) (*models.MCPResponse, error) {
+		analyzed = append(analyzed, projectID)
+		return &models.MCPResponse{
+			Success:  true,
+			Analysis: "Risk: low",
+			ManifestFindings: []models.ManifestFinding{{
+				Rule:     models.RuleReplicasToZero,
+				Severity: models.FindingCritical,
+				Message:  "replicas is set to 0",
+				NewPath:  "deploy/web.yaml",
+				NewLine:  12,
+				Text:     "replicas: 0",
+			}},
+		}, nil
+	}
+
+	check := func(context.Context, string, int) (*models.PolicyResult, error) {
+		return &models.PolicyResult{
+			Verdict: models.PolicyFail,
+			Violations: []models.PolicyViolation{{
+				Rule:      "host-path",
+				Severity:  models.PolicyFail,
+				Message:   "Volume docker mounts /var/run/docker.sock from the node's filesystem",
+				Source:    "deploy",
+				Kind:      "Deployment",
+				Namespace: "prod",
+				Name:      "web",
+			}},
+		}, nil
+	}
+
+	logger := logging.NewLogger()
+	s := &Server{logger: logger}
+	s.gitlabWebhook = newGitLabWebhook("s3cret", analyze, check, commenter, logger)
+
+	send := func(token, event, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/gitlab", strings.NewReader(body))
+		req.Header.Set("X-Gitlab-Token", token)
+		req.Header.Set("X-Gitlab-Event", event)
+		rec := httptest.NewRecorder()
+		s.handleGitLabWebhook(rec, req)
+		return rec
+	}
+	drain := func() {
+		commenter.mu.Lock()
+		if n := len(s.gitlabWebhook.queue); n > 0 {
+			// The merge request's head is the newest commit queued
+			commenter.headSHA = s.gitlabWebhook.tracked["42!7"].head
+		}
+		commenter.mu.Unlock()
+		for len(s.gitlabWebhook.queue) > 0 {
+			s.gitlabWebhook.review(context.Background(), <-s.gitlabWebhook.queue)
+		}
+	}
+
+	if rec := send("wrong", gitlabMergeRequestEvent, mergeRequestHook("open", "aaaaaaaaaa")); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad token: status = %d, want 401", rec.Code)
+	}
+	if rec := send("s3cret", "Push Hook", `{}`); rec.Code != http.StatusOK || len(s.gitlabWebhook.queue) != 0 {
+		t.Fatalf("push event: status = %d, queued = %d", rec.Code, len(s.gitlabWebhook.queue))
+	}
+
+	if rec := send("s3cret", gitlabMergeRequestEvent, mergeRequestHook("open", "aaaaaaaaaa")); rec.Code != http.StatusAccepted {
+		t.Fatalf("open: status = %d, want 202", rec.Code)
+	}
+	// A redelivery or an update that does not push commits is not reviewed again
+	if rec := send("s3cret", gitlabMergeRequestEvent, mergeRequestHook("update", "aaaaaaaaaa")); rec.Code != http.StatusOK {
+		t.Fatalf("duplicate: status = %d, want 200", rec.Code)
+	}
+	drain()
+
+	if len(analyzed) != 1 || analyzed[0] != "42" {
+		t.Fatalf("analyzed = %v, want one review of project 42", analyzed)
+	}
+	if commenter.created != 1 || !strings.Contains(commenter.comments[1].Body, "`aaaaaaaa`") ||
+		!strings.Contains(commenter.comments[1].Body, "Risk: low") {
+		t.Fatalf("unexpected review comment: %+v", commenter.comments)
+	}
+
+	if !strings.Contains(commenter.comments[1].Body, "Policy verdict: **fail** (1 failing check)") ||
+		!strings.Contains(commenter.comments[1].Body, "Deployment prod/web") {
+		t.Fatalf("review comment has no policy verdict: %q", commenter.comments[1].Body)
+	}
+	if want := []string{"aaaaaaaaaa running", "aaaaaaaaaa failed"}; !reflect.DeepEqual(commenter.statuses, want) {
+		t.Fatalf("commit statuses = %v, want %v", commenter.statuses, want)
+	}
+
+	if len(commenter.positions) != 1 {
+		t.Fatalf("diff discussions = %d, want 1", len(commenter.positions))
+	}
+	if p := commenter.positions[0]; p.NewPath != "deploy/web.yaml" || p.NewLine != 12 || p.OldPath != "deploy/web.yaml" ||
+		p.BaseSHA != "base" || p.StartSHA != "start" || p.HeadSHA != "aaaaaaaaaa" || p.PositionType != "text" {
+		t.Fatalf("unexpected diff position: %+v", p)
+	}
+
+	// A push updates the existing comment rather than posting another
+	if rec := send("s3cret", gitlabMergeRequestEvent, mergeRequestHook("update", "bbbbbbbbbb")); rec.Code != http.StatusAccepted {
+		t.Fatalf("update: status = %d, want 202", rec.Code)
+	}
+	drain()
+
+	if commenter.created != 1 || commenter.updated != 1 || !strings.Contains(commenter.comments[1].Body, "`bbbbbbbb`") {
+		t.Fatalf("review comment not updated: created=%d updated=%d comments=%+v",
+			commenter.created, commenter.updated, commenter.comments)
+	}
+	// The finding is already under discussion, so it is not raised again
+	if len(commenter.positions) != 1 {
+		t.Fatalf("diff discussions = %d after a second review, want 1", len(commenter.positions))
+	}
+
+	// A review overtaken by a newer push is skipped
+	if rec := send("s3cret", gitlabMergeRequestEvent, mergeRequestHook("update", "cccccccccc")); rec.Code != http.StatusAccepted {
+		t.Fatalf("update: status = %d, want 202", rec.Code)
+	}
+	if rec := send("s3cret", gitlabMergeRequestEvent, mergeRequestHook("update", "dddddddddd")); rec.Code != http.StatusAccepted {
+		t.Fatalf("update: status = %d, want 202", rec.Code)
+	}
+	drain()
+
+	if len(analyzed) != 3 || !strings.Contains(commenter.comments[1].Body, "`dddddddd`") {
+		t.Fatalf("analyzed %d times, comment %q; want the superseded review skipped", len(analyzed), commenter.comments[1].Body)
+	}
+}
+
+func TestGitLabWebhookPolicyCheckError(t *testing.T) {
+	commenter := &fakeCommenter{}
+	check := func(context.Context, string, int) (*models.PolicyResult, error) {
+		return nil, errors.New("failed to render chart")
+	}
+	webhook := newGitLabWebhook("s3cret", nil, check, commenter, logging.NewLogger())
+
+	review := mergeRequestReview{projectID: "42", iid: 7, headSHA: "aaaaaaaaaa"}
+	webhook.enqueue(review)
+	if result := webhook.postPolicyStatus(context.Background(), review); result != nil {
+		t.Fatalf("postPolicyStatus = %+v, want no result", result)
+	}
+	// The head was never evaluated, so it is not marked as failing the policy
+	if want := []string{"aaaaaaaaaa running", "aaaaaaaaaa canceled"}; !reflect.DeepEqual(commenter.statuses, want) {
+		t.Errorf("commit statuses = %v, want %v", commenter.statuses, want)
+	}
+}
+
+func TestGitLabWebhookExpiresMergeRequests(t *testing.T) {
+	webhook := newGitLabWebhook("s3cret", nil, nil, &fakeCommenter{}, logging.NewLogger())
+
+	abandoned := mergeRequestReview{projectID: "42", iid: 7, headSHA: "aaaaaaaaaa"}
+	webhook.enqueue(abandoned)
+	webhook.tracked[abandoned.key()].noteID = 3
+	webhook.tracked[abandoned.key()].lastSeen = time.Now().Add(-mergeRequestStateTTL - time.Hour)
+
+	active := mergeRequestReview{projectID: "42", iid: 8, headSHA: "bbbbbbbbbb"}
+	webhook.enqueue(active)
+	if _, ok := webhook.tracked[abandoned.key()]; ok || len(webhook.tracked) != 1 {
+		t.Errorf("tracked = %v, want only the active merge request", webhook.tracked)
+	}
+}
+
+func TestFormatReviewCommentFindings(t *testing.T) {
+	review := mergeRequestReview{projectID: "42", iid: 7, headSHA: "aaaaaaaaaa"}
+	response := &models.MCPResponse{Analysis: "Risk: low", ManifestFindings: make([]models.ManifestFinding, 25)}
+
+	tests := []struct {
+		pinned int
+		want   string
+	}{
+		{25, "25 risky changed lines are flagged in diff discussions."},
+		{maxInlineFindings, "25 risky changed lines were found; 20 are flagged in diff discussions."},
+		{0, "25 risky changed lines were found but could not be flagged in diff discussions."},
+	}
+	for _, tt := range tests {
+		if body := formatReviewComment(review, response, nil, tt.pinned); !strings.Contains(body, tt.want) {
+			t.Errorf("pinned %d: comment %q does not contain %q", tt.pinned, body, tt.want)
+		}
+	}
+}
@@ -54,6 +54,12 @@ func (c *Client) GetApplication(ctx context.Context, name string) (*models.ArgoA
 	return &app, nil
 }
 
+// ApplicationURL returns the page of an application in the ArgoCD UI, which is served
+// from the same address as the API
+func (c *Client) ApplicationURL(name string) string {
+	return strings.TrimSuffix(c.baseURL, "/") + "/applications/" + url.PathEscape(name)
+}
+
 // GetResourceTree returns the resource hierarchy for an application
 func (c *Client) GetResourceTree(ctx context.Context, name string) (*models.ArgoResourceTree, error) {
 	c.logger.Debug("Getting resource tree for application", "name", name)
@@ -74,13 +80,41 @@ func (c *Client) GetResourceTree(ctx context.Context, name string) (*models.Argo
 	return &tree, nil
 }
 
-// FindApplicationsByResource finds all ArgoCD applications that manage a specific Kubernetes resource
+// GetManagedResources returns the resources an application manages with their target
+// and live states
+func (c *Client) GetManagedResources(ctx context.Context, name string) ([]models.ArgoManagedResource, error) {
+	c.logger.Debug("Getting managed resources for application", "name", name)
+
+	endpoint := fmt.Sprintf("/api/v1/applications/%s/managed-resources", url.PathEscape(name))
+	resp, err := c.doRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result struct {
+		Items []models.ArgoManagedResource `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Debug("Retrieved managed resources", "name", name, "count", len(result.Items))
+	return result.Items, nil
+}
+
+// FindApplicationsByResource finds all ArgoCD applications that manage a specific Kubernetes
+// resource. While the resource index is fresh, lookups are served from it alone.
 func (c *Client) FindApplicationsByResource(ctx context.Context, kind, name, namespace string) ([]models.ArgoApplication, error) {
 	c.logger.Debug("Finding applications by resource",
 		"kind", kind,
 		"name", name,
 		"namespace", namespace)
 
+	if apps, ok := c.findApplicationsInIndex(ctx, kind, name, namespace); ok {
+		return apps, nil
+	}
+
 	// First try to use the resource API endpoint if available
 	endpoint := fmt.Sprintf("/api/v1/applications/resource/%s/%s/%s/%s/%s",
 		url.PathEscape(""),
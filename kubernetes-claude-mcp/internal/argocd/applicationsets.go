@@ -0,0 +1,52 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// ListApplicationSets returns all ArgoCD ApplicationSets
+func (c *Client) ListApplicationSets(ctx context.Context) ([]models.ArgoApplicationSet, error) {
+	c.logger.Debug("Listing ArgoCD ApplicationSets")
+
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/applicationsets", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result struct {
+		Items []models.ArgoApplicationSet `json:"items"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Debug("Listed ArgoCD ApplicationSets", "count", len(result.Items))
+	return result.Items, nil
+}
+
+// GetApplicationSet returns a specific ArgoCD ApplicationSet
+func (c *Client) GetApplicationSet(ctx context.Context, name string) (*models.ArgoApplicationSet, error) {
+	c.logger.Debug("Getting ArgoCD ApplicationSet", "name", name)
+
+	endpoint := fmt.Sprintf("/api/v1/applicationsets/%s", url.PathEscape(name))
+	resp, err := c.doRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var appSet models.ArgoApplicationSet
+	if err := json.NewDecoder(resp.Body).Decode(&appSet); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &appSet, nil
+}
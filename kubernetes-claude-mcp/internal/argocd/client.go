@@ -24,6 +24,7 @@ type Client struct {
 	httpClient         *http.Client
 	credentialProvider *auth.CredentialProvider
 	config             *config.ArgoCDConfig
+	index              *ResourceIndex
 	logger             *logging.Logger
 }
 
@@ -40,7 +41,7 @@ func NewClient(cfg *config.ArgoCDConfig, credProvider *auth.CredentialProvider,
 		},
 	}
 
-	return &Client{
+	client := &Client{
 		baseURL: cfg.URL,
 		httpClient: &http.Client{
 			Timeout:   30 * time.Second,
@@ -50,6 +51,15 @@ func NewClient(cfg *config.ArgoCDConfig, credProvider *auth.CredentialProvider,
 		config:             cfg,
 		logger:             logger,
 	}
+
+	// Serve resource-to-application lookups from memory when the index is enabled
+	if cfg.Index.Enabled {
+		refresh := time.Duration(cfg.Index.RefreshSeconds) * time.Second
+		treeTTL := time.Duration(cfg.Index.TreeTTLSeconds) * time.Second
+		client.index = NewResourceIndex(client, refresh, logger.Named("index")).WithTreeTTL(treeTTL)
+	}
+
+	return client
 }
 
 // CheckConnectivity tests the connection to the ArgoCD API
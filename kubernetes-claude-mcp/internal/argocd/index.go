@@ -0,0 +1,401 @@
+package argocd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// defaultIndexRefresh is how often the index is refreshed when no interval is configured
+const defaultIndexRefresh = 60 * time.Second
+
+// defaultTreeTTL is how long a resource tree is kept when its application has not
+// changed. Pods and ReplicaSets come and go without changing the application, so every
+// tree is fetched again once it is this old.
+const defaultTreeTTL = 10 * time.Minute
+
+// maxStaleRefreshes is how many refresh intervals may pass since the last successful
+// refresh before the index stops answering for resources it does not contain
+const maxStaleRefreshes = 3
+
+// ResourceKey identifies a Kubernetes resource in an application's resource tree.
+// Kind is compared case-insensitively, so it is stored in lower case.
+type ResourceKey struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// nameKey groups the resources with the same kind and name, for lookups that do not
+// know the group or namespace
+type nameKey struct {
+	kind string
+	name string
+}
+
+// IndexStatus reports the state of the resource index. Staleness is the time since
+// the last successful refresh.
+type IndexStatus struct {
+	Enabled          bool      `json:"enabled"`
+	Ready            bool      `json:"ready"`
+	Applications     int       `json:"applications"`
+	Resources        int       `json:"resources"`
+	LastRefresh      time.Time `json:"lastRefresh,omitzero"`
+	StalenessSeconds int       `json:"stalenessSeconds"`
+	Error            string    `json:"error,omitempty"`
+}
+
+// indexedApp is the fingerprint of an application when its tree was fetched, when that
+// was, and the resources in that tree
+type indexedApp struct {
+	fingerprint string
+	fetchedAt   time.Time
+	resources   []ResourceKey
+}
+
+// ResourceIndex maps the resources in ArgoCD application resource trees to the
+// applications that manage them, so lookups do not fetch every application's tree.
+// Each refresh lists the applications and fetches trees only for those whose spec
+// or status changed since the last refresh, or whose tree is older than the tree TTL.
+type ResourceIndex struct {
+	client      *Client
+	interval    time.Duration
+	treeTTL     time.Duration
+	mu          sync.RWMutex
+	apps        map[string]*indexedApp
+	resources   map[ResourceKey]map[string]struct{}
+	byName      map[nameKey]map[ResourceKey]struct{}
+	ready       bool
+	lastRefresh time.Time
+	lastError   string
+	logger      *logging.Logger
+}
+
+// NewResourceIndex creates an index of the applications the client can see. The
+// index is empty until Start is called and the first refresh completes.
+func NewResourceIndex(client *Client, interval time.Duration, logger *logging.Logger) *ResourceIndex {
+	if logger == nil {
+		logger = logging.NewLogger().Named("argocd-index")
+	}
+	if interval <= 0 {
+		interval = defaultIndexRefresh
+	}
+
+	return &ResourceIndex{
+		client:    client,
+		interval:  interval,
+		treeTTL:   defaultTreeTTL,
+		apps:      make(map[string]*indexedApp),
+		resources: make(map[ResourceKey]map[string]struct{}),
+		byName:    make(map[nameKey]map[ResourceKey]struct{}),
+		logger:    logger,
+	}
+}
+
+// WithTreeTTL sets how long an unchanged application's resource tree is kept before
+// it is fetched again. A zero TTL keeps the default.
+func (idx *ResourceIndex) WithTreeTTL(ttl time.Duration) *ResourceIndex {
+	if ttl > 0 {
+		idx.treeTTL = ttl
+	}
+	return idx
+}
+
+// Start refreshes the index now and then every refresh interval until ctx is done.
+// It returns immediately; use Ready or Status to see when lookups are served.
+func (idx *ResourceIndex) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(idx.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := idx.Refresh(ctx); err != nil && ctx.Err() == nil {
+				idx.logger.Warn("Failed to refresh ArgoCD resource index", "error", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Refresh lists the applications, fetches the resource trees of new and changed
+// applications and of those fetched longer than the tree TTL ago, and drops
+// applications that no longer exist. An application whose
+// tree cannot be fetched keeps its previous entries and is retried next refresh.
+func (idx *ResourceIndex) Refresh(ctx context.Context) error {
+	apps, err := idx.client.ListApplications(ctx)
+	if err != nil {
+		idx.mu.Lock()
+		idx.lastError = err.Error()
+		idx.mu.Unlock()
+		return err
+	}
+
+	idx.mu.RLock()
+	known := make(map[string]indexedApp, len(idx.apps))
+	for name, app := range idx.apps {
+		known[name] = indexedApp{fingerprint: app.fingerprint, fetchedAt: app.fetchedAt}
+	}
+	idx.mu.RUnlock()
+
+	seen := make(map[string]bool, len(apps))
+	updated := make(map[string]*indexedApp)
+	for i := range apps {
+		app := &apps[i]
+		name := appName(app)
+		seen[name] = true
+
+		fingerprint := appFingerprint(app)
+		if previous, ok := known[name]; ok && previous.fingerprint == fingerprint &&
+			time.Since(previous.fetchedAt) < idx.treeTTL {
+			continue
+		}
+
+		tree, err := idx.client.GetResourceTree(ctx, name)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			idx.logger.Warn("Failed to get resource tree for index", "application", name, "error", err)
+			continue
+		}
+		updated[name] = &indexedApp{fingerprint: fingerprint, fetchedAt: time.Now(), resources: treeResources(tree)}
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	removed := 0
+	for name := range idx.apps {
+		if !seen[name] {
+			idx.remove(name)
+			removed++
+		}
+	}
+	for name, app := range updated {
+		idx.remove(name)
+		idx.add(name, app)
+	}
+	idx.ready = true
+	idx.lastRefresh = time.Now()
+	idx.lastError = ""
+
+	idx.logger.Debug("Refreshed ArgoCD resource index",
+		"applications", len(idx.apps),
+		"updated", len(updated),
+		"removed", removed,
+		"resources", len(idx.resources))
+	return nil
+}
+
+// add indexes an application's resources; the caller holds the write lock
+func (idx *ResourceIndex) add(name string, app *indexedApp) {
+	idx.apps[name] = app
+	for _, key := range app.resources {
+		if idx.resources[key] == nil {
+			idx.resources[key] = make(map[string]struct{})
+		}
+		idx.resources[key][name] = struct{}{}
+
+		nk := nameKey{kind: key.Kind, name: key.Name}
+		if idx.byName[nk] == nil {
+			idx.byName[nk] = make(map[ResourceKey]struct{})
+		}
+		idx.byName[nk][key] = struct{}{}
+	}
+}
+
+// remove drops an application's resources; the caller holds the write lock
+func (idx *ResourceIndex) remove(name string) {
+	app, ok := idx.apps[name]
+	if !ok {
+		return
+	}
+	delete(idx.apps, name)
+
+	for _, key := range app.resources {
+		delete(idx.resources[key], name)
+		if len(idx.resources[key]) > 0 {
+			continue
+		}
+		delete(idx.resources, key)
+
+		nk := nameKey{kind: key.Kind, name: key.Name}
+		delete(idx.byName[nk], key)
+		if len(idx.byName[nk]) == 0 {
+			delete(idx.byName, nk)
+		}
+	}
+}
+
+// Lookup returns the names of the applications whose resource trees contain a
+// resource, sorted. An empty group or namespace matches any.
+func (idx *ResourceIndex) Lookup(group, kind, namespace, name string) []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	names := make(map[string]struct{})
+	for key := range idx.byName[nameKey{kind: strings.ToLower(kind), name: name}] {
+		if (group != "" && key.Group != group) || (namespace != "" && key.Namespace != namespace) {
+			continue
+		}
+		for app := range idx.resources[key] {
+			names[app] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(names))
+	for app := range names {
+		result = append(result, app)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Ready reports whether the index has completed a refresh and can serve lookups
+func (idx *ResourceIndex) Ready() bool {
+	if idx == nil {
+		return false
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.ready
+}
+
+// Fresh reports whether the index is ready and refreshed within the last few refresh
+// intervals, so that a resource it does not contain can be taken to have no
+// application
+func (idx *ResourceIndex) Fresh() bool {
+	if idx == nil {
+		return false
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return idx.ready && time.Since(idx.lastRefresh) <= maxStaleRefreshes*idx.interval
+}
+
+// Status reports the size and staleness of the index
+func (idx *ResourceIndex) Status() IndexStatus {
+	if idx == nil {
+		return IndexStatus{}
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	status := IndexStatus{
+		Enabled:      true,
+		Ready:        idx.ready,
+		Applications: len(idx.apps),
+		Resources:    len(idx.resources),
+		LastRefresh:  idx.lastRefresh,
+		Error:        idx.lastError,
+	}
+	if !idx.lastRefresh.IsZero() {
+		status.StalenessSeconds = int(time.Since(idx.lastRefresh).Seconds())
+	}
+	return status
+}
+
+// appName returns an application's name, which the list API reports in its metadata
+func appName(app *models.ArgoApplication) string {
+	if app.Metadata.Name != "" {
+		return app.Metadata.Name
+	}
+	return app.Name
+}
+
+// appFingerprint hashes the parts of an application that change when its resources
+// do: the spec, sync and health status and the managed resource list. Fields such
+// as the reconcile time, which ArgoCD updates on every refresh, are not decoded and
+// so do not cause a refetch. Pod and ReplicaSet churn does not change it either, which
+// the tree TTL covers.
+func appFingerprint(app *models.ArgoApplication) string {
+	data, err := json.Marshal(struct {
+		Spec   interface{} `json:"spec"`
+		Status interface{} `json:"status"`
+	}{app.Spec, app.Status})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// treeResources returns the keys of the resources in a resource tree
+func treeResources(tree *models.ArgoResourceTree) []ResourceKey {
+	seen := make(map[ResourceKey]bool, len(tree.Nodes))
+	keys := make([]ResourceKey, 0, len(tree.Nodes))
+	for i := range tree.Nodes {
+		node := &tree.Nodes[i]
+		key := ResourceKey{
+			Group:     node.Group,
+			Kind:      strings.ToLower(node.Kind),
+			Namespace: node.Namespace,
+			Name:      node.Name,
+		}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// StartIndex starts the client's resource index, if one is configured
+func (c *Client) StartIndex(ctx context.Context) {
+	if c.index != nil {
+		c.index.Start(ctx)
+	}
+}
+
+// IndexStatus reports the state of the client's resource index
+func (c *Client) IndexStatus() IndexStatus {
+	return c.index.Status()
+}
+
+// findApplicationsInIndex serves FindApplicationsByResource from the resource index,
+// fetching each matching application so its sync and health status are current. A
+// resource the index does not contain is managed by no application, as most resources
+// are not. It reports false when there is no index, it is not fresh, or none of the
+// matching applications could be fetched.
+func (c *Client) findApplicationsInIndex(ctx context.Context, kind, name, namespace string) ([]models.ArgoApplication, bool) {
+	if !c.index.Fresh() {
+		return nil, false
+	}
+
+	names := c.index.Lookup("", kind, namespace, name)
+	var apps []models.ArgoApplication
+	for _, appName := range names {
+		app, err := c.GetApplication(ctx, appName)
+		if err != nil {
+			c.logger.Warn("Failed to get application details",
+				"name", appName,
+				"error", err)
+			continue
+		}
+		apps = append(apps, *app)
+	}
+
+	if len(apps) == 0 && len(names) > 0 {
+		return nil, false
+	}
+
+	c.logger.Debug("Found applications managing resource in index",
+		"resourceKind", kind,
+		"resourceName", name,
+		"count", len(apps))
+	return apps, true
+}
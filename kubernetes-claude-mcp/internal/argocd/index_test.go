@@ -0,0 +1,198 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// fakeArgoCD serves application lists and resource trees and counts tree requests
+type fakeArgoCD struct {
+	mu        sync.Mutex
+	apps      map[string]string   // name -> sync revision
+	trees     map[string][]string // name -> "group/Kind/namespace/name"
+	treeCalls map[string]int
+	listCalls int
+}
+
+func (f *fakeArgoCD) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path == "/api/v1/applications" {
+		f.listCalls++
+		var items []map[string]interface{}
+		for name, revision := range f.apps {
+			items = append(items, map[string]interface{}{
+				"metadata": map[string]interface{}{"name": name},
+				"status":   map[string]interface{}{"sync": map[string]interface{}{"status": "Synced", "revision": revision}},
+			})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+		return
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v1/applications/"), "/resource-tree")
+	f.treeCalls[name]++
+	var nodes []map[string]string
+	for _, resource := range f.trees[name] {
+		parts := strings.Split(resource, "/")
+		nodes = append(nodes, map[string]string{"group": parts[0], "kind": parts[1], "namespace": parts[2], "name": parts[3]})
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"nodes": nodes})
+}
+
+func newTestClient(t *testing.T, url string) *Client {
+	t.Helper()
+
+	cfg := config.ArgoCDConfig{URL: url, AuthToken: "test-token"}
+	credProvider := auth.NewCredentialProvider(&config.Config{
+		ArgoCD: cfg,
+		Claude: config.ClaudeConfig{APIKey: "test-claude-key"},
+	})
+	if err := credProvider.LoadCredentials(context.Background()); err != nil {
+		t.Fatalf("Failed to load credentials: %v", err)
+	}
+	return NewClient(&cfg, credProvider, logging.NewLogger())
+}
+
+func TestResourceIndexRefresh(t *testing.T) {
+	fake := &fakeArgoCD{
+		apps: map[string]string{"web": "aaa", "api": "bbb"},
+		trees: map[string][]string{
+			"web": {"apps/Deployment/prod/web", "/Service/prod/web", "/ConfigMap/prod/shared"},
+			"api": {"apps/Deployment/prod/api", "/ConfigMap/prod/shared"},
+		},
+		treeCalls: make(map[string]int),
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	index := NewResourceIndex(newTestClient(t, server.URL), 0, logging.NewLogger())
+	if index.Ready() {
+		t.Fatal("index is ready before its first refresh")
+	}
+	if err := index.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	tests := []struct {
+		group, kind, namespace, name string
+		want                         []string
+	}{
+		{"apps", "Deployment", "prod", "web", []string{"web"}},
+		{"", "deployment", "", "api", []string{"api"}},
+		{"", "ConfigMap", "prod", "shared", []string{"api", "web"}},
+		{"", "ConfigMap", "staging", "shared", []string{}},
+		{"batch", "Deployment", "prod", "web", []string{}},
+	}
+	for _, tt := range tests {
+		if got := index.Lookup(tt.group, tt.kind, tt.namespace, tt.name); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Lookup(%q, %q, %q, %q) = %v, want %v", tt.group, tt.kind, tt.namespace, tt.name, got, tt.want)
+		}
+	}
+
+	// Only the changed application's tree is fetched again, and deleted applications
+	// are dropped
+	fake.mu.Lock()
+	fake.apps = map[string]string{"web": "ccc"}
+	fake.trees["web"] = []string{"apps/Deployment/prod/web", "/Service/prod/web-canary"}
+	fake.mu.Unlock()
+
+	if err := index.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if fake.treeCalls["web"] != 2 || fake.treeCalls["api"] != 1 {
+		t.Errorf("tree calls = %v, want web fetched twice and api once", fake.treeCalls)
+	}
+	if got := index.Lookup("", "ConfigMap", "prod", "shared"); len(got) != 0 {
+		t.Errorf("removed resources are still indexed: %v", got)
+	}
+	if got := index.Lookup("", "Service", "prod", "web-canary"); !reflect.DeepEqual(got, []string{"web"}) {
+		t.Errorf("Lookup(web-canary) = %v, want [web]", got)
+	}
+
+	// An unchanged application is not fetched at all
+	if err := index.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if fake.treeCalls["web"] != 2 {
+		t.Errorf("unchanged application was fetched again: %d calls", fake.treeCalls["web"])
+	}
+
+	// A tree older than the TTL is fetched again to pick up new pods
+	fake.mu.Lock()
+	fake.trees["web"] = append(fake.trees["web"], "/Pod/prod/web-6b2c-x2k")
+	fake.mu.Unlock()
+	index.WithTreeTTL(time.Nanosecond)
+	if err := index.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := index.Lookup("", "Pod", "prod", "web-6b2c-x2k"); !reflect.DeepEqual(got, []string{"web"}) {
+		t.Errorf("Lookup(new pod) = %v, want [web]", got)
+	}
+
+	status := index.Status()
+	if !status.Enabled || !status.Ready || status.Applications != 1 || status.Resources != 3 || status.LastRefresh.IsZero() {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+}
+
+func TestFindApplicationsByResourceIndexMiss(t *testing.T) {
+	fake := &fakeArgoCD{
+		apps:      map[string]string{"web": "aaa"},
+		trees:     map[string][]string{"web": {"apps/Deployment/prod/web"}},
+		treeCalls: make(map[string]int),
+	}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	client := newTestClient(t, server.URL)
+	client.index = NewResourceIndex(client, time.Minute, logging.NewLogger())
+
+	// Until the index has refreshed, lookups scan the applications
+	if _, err := client.FindApplicationsByResource(context.Background(), "Pod", "web-7f3a-z9q", "prod"); err != nil {
+		t.Fatalf("FindApplicationsByResource: %v", err)
+	}
+	if fake.listCalls != 1 {
+		t.Errorf("list calls before the first refresh = %d, want 1", fake.listCalls)
+	}
+
+	if err := client.index.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	fake.mu.Lock()
+	fake.listCalls, fake.treeCalls = 0, make(map[string]int)
+	fake.mu.Unlock()
+
+	// A resource no application manages is answered by a fresh index alone
+	apps, err := client.FindApplicationsByResource(context.Background(), "ConfigMap", "kube-root-ca.crt", "prod")
+	if err != nil {
+		t.Fatalf("FindApplicationsByResource: %v", err)
+	}
+	if len(apps) != 0 || fake.listCalls != 0 || len(fake.treeCalls) != 0 {
+		t.Errorf("miss = %v with %d list and %v tree calls, want no applications and no calls", apps, fake.listCalls, fake.treeCalls)
+	}
+
+	// A stale index leaves the lookup to ArgoCD again
+	client.index.mu.Lock()
+	client.index.lastRefresh = time.Now().Add(-time.Hour)
+	client.index.mu.Unlock()
+	if _, err := client.FindApplicationsByResource(context.Background(), "ConfigMap", "kube-root-ca.crt", "prod"); err != nil {
+		t.Fatalf("FindApplicationsByResource: %v", err)
+	}
+	if fake.listCalls != 1 {
+		t.Errorf("list calls with a stale index = %d, want 1", fake.listCalls)
+	}
+}
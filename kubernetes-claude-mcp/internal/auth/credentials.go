@@ -19,6 +19,7 @@ const (
 	ServiceKubernetes ServiceType = "kubernetes"
 	ServiceArgoCD     ServiceType = "argocd"
 	ServiceGitLab     ServiceType = "gitlab"
+	ServiceGitHub     ServiceType = "github"
 	ServiceClaude     ServiceType = "claude"
 )
 
@@ -83,6 +84,10 @@ func (p *CredentialProvider) LoadCredentials(ctx context.Context) error {
 		return fmt.Errorf("failed to load GitLab credentials: %w", err)
 	}
 
+	if err := p.loadGitHubCredentials(ctx); err != nil {
+		return fmt.Errorf("failed to load GitHub credentials: %w", err)
+	}
+
 	if err := p.loadClaudeCredentials(ctx); err != nil {
 		return fmt.Errorf("failed to load Claude credentials: %w", err)
 	}
@@ -266,6 +271,60 @@ func (p *CredentialProvider) loadGitLabCredentials(ctx context.Context) error {
 	return nil
 }
 
+// loadGitHubCredentials loads GitHub authentication credentials. Without a token the
+// GitHub client makes unauthenticated requests, which only reach public repositories.
+func (p *CredentialProvider) loadGitHubCredentials(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Try to load from secrets manager if available
+	if p.secretsManager != nil && p.secretsManager.IsAvailable() {
+		creds, err := p.secretsManager.GetCredentials(ctx, "github")
+		if err == nil && creds != nil {
+			p.credentials[ServiceGitHub] = creds
+			p.logger.Info("Loaded GitHub credentials from secrets manager")
+			return nil
+		}
+	}
+
+	// Try to load from vault if available
+	if p.vaultManager != nil && p.vaultManager.IsAvailable() {
+		creds, err := p.vaultManager.GetCredentials(ctx, "github")
+		if err == nil && creds != nil {
+			p.credentials[ServiceGitHub] = creds
+			p.logger.Info("Loaded GitHub credentials from vault")
+			return nil
+		}
+	}
+
+	// Primary source: Environment variables
+	// Check both GITHUB_TOKEN and GH_TOKEN, as the gh CLI does
+	token := os.Getenv("GITHUB_TOKEN")
+	if token == "" {
+		token = os.Getenv("GH_TOKEN")
+	}
+	if token != "" {
+		p.credentials[ServiceGitHub] = &Credentials{
+			Token: token,
+		}
+		p.logger.Info("Loaded GitHub credentials from environment")
+		return nil
+	}
+
+	// Secondary source: Config file
+	if p.config.GitHub.AuthToken != "" {
+		p.credentials[ServiceGitHub] = &Credentials{
+			Token: p.config.GitHub.AuthToken,
+		}
+		p.logger.Info("Loaded GitHub credentials from config file")
+		return nil
+	}
+
+	// GitHub integration is optional
+	p.credentials[ServiceGitHub] = &Credentials{}
+	return nil
+}
+
 // loadClaudeCredentials loads Claude API credentials
 func (p *CredentialProvider) loadClaudeCredentials(ctx context.Context) error {
 	p.mu.Lock()
@@ -310,6 +369,12 @@ func (p *CredentialProvider) loadClaudeCredentials(ctx context.Context) error {
 		return nil
 	}
 
+	// Claude is optional when the MCP client does the reasoning
+	if !p.config.ClaudeRequired() {
+		p.logger.Info("No Claude API key found, Claude features are disabled")
+		return nil
+	}
+
 	p.logger.Warn("No Claude API key found")
 	return fmt.Errorf("no Claude API key found")
 }
@@ -0,0 +1,401 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+const (
+	defaultJWKSCacheDuration = time.Hour
+	defaultClockSkew         = time.Minute
+	// jwksMinRefreshInterval limits refetches triggered by tokens with an unknown key ID
+	jwksMinRefreshInterval = 10 * time.Second
+)
+
+// TokenClaims holds the verified claims of an OIDC token that the server uses
+type TokenClaims struct {
+	Subject  string
+	Username string
+	Groups   []string
+	Expiry   time.Time
+}
+
+// OIDCVerifier validates JWT bearer tokens issued by an OIDC provider. Signing keys are
+// fetched from the provider's JWKS endpoint and cached.
+type OIDCVerifier struct {
+	issuer        string
+	audience      string
+	jwksURL       string
+	usernameClaim string
+	groupsClaim   string
+	cacheDuration time.Duration
+	clockSkew     time.Duration
+	httpClient    *http.Client
+	now           func() time.Time
+	mu            sync.Mutex
+	keys          map[string]crypto.PublicKey
+	fetchedAt     time.Time
+	logger        *logging.Logger
+}
+
+// NewOIDCVerifier creates a verifier for the configured issuer. No request is made
+// to the issuer until the first token is verified.
+func NewOIDCVerifier(cfg config.OIDCConfig, logger *logging.Logger) *OIDCVerifier {
+	if logger == nil {
+		logger = logging.NewLogger().Named("oidc")
+	}
+
+	v := &OIDCVerifier{
+		issuer:        strings.TrimSuffix(cfg.IssuerURL, "/"),
+		audience:      cfg.Audience,
+		jwksURL:       cfg.JWKSURL,
+		usernameClaim: cfg.UsernameClaim,
+		groupsClaim:   cfg.GroupsClaim,
+		cacheDuration: time.Duration(cfg.JWKSCacheSeconds) * time.Second,
+		clockSkew:     time.Duration(cfg.ClockSkewSeconds) * time.Second,
+		httpClient:    &http.Client{Timeout: 10 * time.Second},
+		now:           time.Now,
+		logger:        logger,
+	}
+	if v.usernameClaim == "" {
+		v.usernameClaim = "sub"
+	}
+	if v.groupsClaim == "" {
+		v.groupsClaim = "groups"
+	}
+	if v.cacheDuration == 0 {
+		v.cacheDuration = defaultJWKSCacheDuration
+	}
+	if v.clockSkew == 0 {
+		v.clockSkew = defaultClockSkew
+	}
+	return v
+}
+
+// jwtHeader is the JOSE header of a signed token
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verify checks a token's signature, issuer, audience and validity period and returns its claims
+func (v *OIDCVerifier) Verify(ctx context.Context, token string) (*TokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid token header: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid token signature encoding: %w", err)
+	}
+
+	key, err := v.signingKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, key, parts[0]+"."+parts[1], signature); err != nil {
+		return nil, err
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid token claims: %w", err)
+	}
+	return v.validateClaims(claims)
+}
+
+// validateClaims checks the registered claims and extracts the username and groups
+func (v *OIDCVerifier) validateClaims(claims map[string]interface{}) (*TokenClaims, error) {
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != v.issuer {
+		return nil, fmt.Errorf("unexpected token issuer: %s", iss)
+	}
+	if !containsAudience(claims["aud"], v.audience) {
+		return nil, fmt.Errorf("token is not intended for audience %s", v.audience)
+	}
+
+	now := v.now()
+	exp, ok := numericDate(claims["exp"])
+	if !ok {
+		return nil, fmt.Errorf("token has no expiry")
+	}
+	if now.After(exp.Add(v.clockSkew)) {
+		return nil, fmt.Errorf("token expired at %s", exp.Format(time.RFC3339))
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && now.Add(v.clockSkew).Before(nbf) {
+		return nil, fmt.Errorf("token is not valid before %s", nbf.Format(time.RFC3339))
+	}
+
+	result := &TokenClaims{Expiry: exp}
+	result.Subject, _ = claims["sub"].(string)
+	result.Username, _ = claims[v.usernameClaim].(string)
+	if result.Username == "" {
+		result.Username = result.Subject
+	}
+
+	switch groups := claims[v.groupsClaim].(type) {
+	case []interface{}:
+		for _, g := range groups {
+			if group, ok := g.(string); ok {
+				result.Groups = append(result.Groups, group)
+			}
+		}
+	case string:
+		result.Groups = []string{groups}
+	}
+
+	return result, nil
+}
+
+// signingKey returns the key for a key ID, refreshing the JWKS when the cache has
+// expired or the key is unknown. A token without a key ID is accepted when the
+// issuer publishes a single key.
+func (v *OIDCVerifier) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	age := v.now().Sub(v.fetchedAt)
+	key, found := v.lookupKey(kid)
+	if v.keys == nil || age > v.cacheDuration || (!found && age > jwksMinRefreshInterval) {
+		if err := v.refreshKeys(ctx); err != nil {
+			// Keep serving a stale key set rather than locking everyone out
+			if !found {
+				return nil, err
+			}
+			v.logger.Warn("Failed to refresh OIDC signing keys, using cached keys", "error", err)
+		}
+		key, found = v.lookupKey(kid)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("unknown token signing key: %s", kid)
+	}
+	return key, nil
+}
+
+func (v *OIDCVerifier) lookupKey(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok && kid != ""
+}
+
+// refreshKeys fetches the issuer's signing keys, discovering the JWKS URL first if
+// it is not configured. The caller holds v.mu.
+func (v *OIDCVerifier) refreshKeys(ctx context.Context) error {
+	if v.jwksURL == "" {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, v.issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+			return fmt.Errorf("failed to discover OIDC configuration: %w", err)
+		}
+		if strings.TrimSuffix(discovery.Issuer, "/") != v.issuer {
+			return fmt.Errorf("OIDC discovery returned issuer %s, expected %s", discovery.Issuer, v.issuer)
+		}
+		if discovery.JWKSURI == "" {
+			return fmt.Errorf("OIDC discovery document has no jwks_uri")
+		}
+		v.jwksURL = discovery.JWKSURI
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, v.jwksURL, &jwks); err != nil {
+		return fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			v.logger.Warn("Skipping unusable OIDC signing key", "kid", jwk.Kid, "error", err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	v.keys = keys
+	v.fetchedAt = v.now()
+	v.logger.Debug("Fetched OIDC signing keys", "count", len(keys))
+	return nil
+}
+
+func (v *OIDCVerifier) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jsonWebKey is an RSA or EC public key from a JWKS document
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type: %s", k.Kty)
+	}
+}
+
+// verifySignature checks an RS* or ES* signature. Symmetric and unsigned tokens are
+// rejected, since only the issuer's public keys are trusted.
+func verifySignature(alg string, key crypto.PublicKey, signingInput string, signature []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported token signing algorithm: %s", alg)
+	}
+
+	h := hash.New()
+	h.Write([]byte(signingInput))
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			return fmt.Errorf("algorithm %s does not match an RSA key", alg)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return fmt.Errorf("invalid token signature")
+		}
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			return fmt.Errorf("algorithm %s does not match an EC key", alg)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return fmt.Errorf("invalid token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return fmt.Errorf("invalid token signature")
+		}
+	default:
+		return fmt.Errorf("unsupported signing key")
+	}
+	return nil
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// numericDate reads a JWT NumericDate claim
+func numericDate(value interface{}) (time.Time, bool) {
+	seconds, ok := value.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// containsAudience reports whether an aud claim, a string or a list, includes the audience
+func containsAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// fakeIssuer is a local OIDC provider serving discovery and JWKS documents
+type fakeIssuer struct {
+	server      *httptest.Server
+	rsaKey      *rsa.PrivateKey
+	ecKey       *ecdsa.PrivateKey
+	jwksFetches atomic.Int32
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate EC key: %v", err)
+	}
+
+	issuer := &fakeIssuer{rsaKey: rsaKey, ecKey: ecKey}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":   issuer.server.URL,
+			"jwks_uri": issuer.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		issuer.jwksFetches.Add(1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kid": "rsa-1", "kty": "RSA", "use": "sig",
+					"n": b64(rsaKey.N.Bytes()),
+					"e": b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+				},
+				{
+					"kid": "ec-1", "kty": "EC", "crv": "P-256",
+					"x": b64(ecKey.X.FillBytes(make([]byte, 32))),
+					"y": b64(ecKey.Y.FillBytes(make([]byte, 32))),
+				},
+			},
+		})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// sign creates a token with the given claims, signed by the issuer's RSA or EC key
+func (f *fakeIssuer) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		sig, err := rsa.SignPKCS1v15(rand.Reader, f.rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		signature = sig
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, f.ecKey, digest[:])
+		if err != nil {
+			t.Fatalf("Failed to sign token: %v", err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return input + "." + b64(signature)
+}
+
+// tamper swaps a token's payload for one claiming a different subject
+func tamper(token string) string {
+	parts := strings.Split(token, ".")
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	parts[1] = b64([]byte(strings.Replace(string(payload), "user-123", "admin", 1)))
+	return strings.Join(parts, ".")
+}
+
+func (f *fakeIssuer) claims(overrides map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss":    f.server.URL,
+		"aud":    "k8s-mcp",
+		"sub":    "user-123",
+		"email":  "jane@example.com",
+		"groups": []string{"developers", "sre"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(claims, k)
+		} else {
+			claims[k] = v
+		}
+	}
+	return claims
+}
+
+func TestOIDCVerifierVerify(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	verifier := NewOIDCVerifier(config.OIDCConfig{
+		IssuerURL:     issuer.server.URL,
+		Audience:      "k8s-mcp",
+		UsernameClaim: "email",
+	}, logging.NewLogger())
+	ctx := context.Background()
+
+	claims, err := verifier.Verify(ctx, issuer.sign(t, "RS256", "rsa-1", issuer.claims(nil)))
+	if err != nil {
+		t.Fatalf("Expected a valid RS256 token, got: %v", err)
+	}
+	if claims.Username != "jane@example.com" || len(claims.Groups) != 2 {
+		t.Errorf("Unexpected claims %+v", claims)
+	}
+
+	if _, err := verifier.Verify(ctx, issuer.sign(t, "ES256", "ec-1", issuer.claims(map[string]interface{}{
+		"aud": []string{"other", "k8s-mcp"},
+	}))); err != nil {
+		t.Errorf("Expected a valid ES256 token with an audience list, got: %v", err)
+	}
+
+	if fetches := issuer.jwksFetches.Load(); fetches != 1 {
+		t.Errorf("Expected the signing keys to be fetched once and cached, got %d fetches", fetches)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"expired", issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()}))},
+		{"no expiry", issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"exp": nil}))},
+		{"not yet valid", issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"nbf": time.Now().Add(time.Hour).Unix()}))},
+		{"wrong audience", issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"aud": "other"}))},
+		{"wrong issuer", issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{"iss": "https://evil.example.com"}))},
+		{"unknown key", issuer.sign(t, "RS256", "rsa-2", issuer.claims(nil))},
+		{"algorithm mismatch", issuer.sign(t, "ES256", "rsa-1", issuer.claims(nil))},
+		{"tampered claims", tamper(issuer.sign(t, "RS256", "rsa-1", issuer.claims(nil)))},
+		{"none algorithm", strings.Split(issuer.sign(t, "RS256", "rsa-1", issuer.claims(nil)), ".")[0] + ".e30."},
+		{"malformed", "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(ctx, tt.token); err == nil {
+				t.Error("Expected the token to be rejected")
+			}
+		})
+	}
+}
+
+func TestAuthenticateTokenGroupRoles(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	a := NewAuthenticator(config.AuthConfig{
+		APIKey: "admin-key",
+		Roles: []config.RoleConfig{
+			{Name: "viewer", Actions: []string{ActionRead}},
+			{Name: "operator", Actions: []string{"*"}},
+		},
+		OIDC: config.OIDCConfig{
+			IssuerURL: issuer.server.URL,
+			Audience:  "k8s-mcp",
+			GroupRoles: []config.GroupRoleConfig{
+				{Group: "sre", Role: "operator"},
+				{Group: "developers", Role: "viewer"},
+			},
+		},
+	}, logging.NewLogger())
+	ctx := context.Background()
+
+	identity, err := a.AuthenticateToken(ctx, issuer.sign(t, "RS256", "rsa-1", issuer.claims(nil)))
+	if err != nil {
+		t.Fatalf("Expected the token to authenticate, got: %v", err)
+	}
+	if identity.Name != "user-123" || identity.Role.Name != "operator" {
+		t.Errorf("Expected user-123 with the first matching role, got %s with %s", identity.Name, identity.Role.Name)
+	}
+
+	if _, err := a.AuthenticateToken(ctx, issuer.sign(t, "RS256", "rsa-1", issuer.claims(map[string]interface{}{
+		"groups": []string{"marketing"},
+	}))); err == nil {
+		t.Error("Expected a user without a mapped group or default role to be rejected")
+	}
+
+	if identity, err := a.AuthenticateToken(ctx, "admin-key"); err != nil || identity.Role.Name != AdminRole {
+		t.Errorf("Expected a plain bearer API key to still authenticate, got %v, %v", identity, err)
+	}
+}
@@ -0,0 +1,287 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// Actions a role can be granted
+const (
+	ActionRead    = "read"    // read Kubernetes, ArgoCD and GitLab data
+	ActionQuery   = "query"   // run Claude analyses (MCP queries, troubleshooting)
+	ActionSync    = "sync"    // trigger ArgoCD syncs
+	ActionComment = "comment" // post comments on merge requests
+)
+
+// AdminRole is the built-in role with unrestricted access. The legacy single
+// server.auth.apiKey is granted this role.
+const AdminRole = "admin"
+
+// wildcard allows every action, namespace or kind
+const wildcard = "*"
+
+// Role limits the namespaces, resource kinds and actions available to a caller
+type Role struct {
+	Name       string
+	Namespaces []string
+	Kinds      []string
+	Actions    []string
+}
+
+// Identity is an authenticated API caller. KubernetesUser and KubernetesGroups, when
+// set, are impersonated for the caller's Kubernetes requests.
+type Identity struct {
+	Name             string
+	Role             *Role
+	KubernetesUser   string
+	KubernetesGroups []string
+}
+
+// Authenticator maps API keys and OIDC bearer tokens to identities
+type Authenticator struct {
+	keys        []apiKey
+	roles       map[string]*Role
+	oidc        *OIDCVerifier
+	groupRoles  []config.GroupRoleConfig
+	defaultRole string
+}
+
+type apiKey struct {
+	key      []byte
+	identity *Identity
+}
+
+// NewAuthenticator builds the key table from the auth configuration and, when an
+// issuer is configured, an OIDC verifier for bearer tokens
+func NewAuthenticator(cfg config.AuthConfig, logger *logging.Logger) *Authenticator {
+	if logger == nil {
+		logger = logging.NewLogger().Named("auth")
+	}
+
+	roles := map[string]*Role{
+		AdminRole: {Name: AdminRole, Namespaces: []string{wildcard}, Kinds: []string{wildcard}, Actions: []string{wildcard}},
+	}
+	for _, role := range cfg.Roles {
+		roles[role.Name] = &Role{
+			Name:       role.Name,
+			Namespaces: role.Namespaces,
+			Kinds:      role.Kinds,
+			Actions:    role.Actions,
+		}
+	}
+
+	a := &Authenticator{roles: roles}
+	if cfg.OIDC.IssuerURL != "" {
+		a.oidc = NewOIDCVerifier(cfg.OIDC, logger.Named("oidc"))
+		a.groupRoles = cfg.OIDC.GroupRoles
+		a.defaultRole = cfg.OIDC.DefaultRole
+	}
+	if cfg.APIKey != "" {
+		a.keys = append(a.keys, apiKey{
+			key:      []byte(cfg.APIKey),
+			identity: &Identity{Name: "default", Role: roles[AdminRole]},
+		})
+	}
+	for _, key := range cfg.Keys {
+		role, ok := roles[key.Role]
+		if !ok {
+			continue
+		}
+		a.keys = append(a.keys, apiKey{
+			key: []byte(key.Key),
+			identity: &Identity{
+				Name:             key.Name,
+				Role:             role,
+				KubernetesUser:   key.KubernetesUser,
+				KubernetesGroups: key.KubernetesGroups,
+			},
+		})
+	}
+	return a
+}
+
+// Authenticate returns the identity for an API key
+func (a *Authenticator) Authenticate(key string) (*Identity, bool) {
+	if a == nil || key == "" {
+		return nil, false
+	}
+
+	// Compare against every key in constant time so timing does not reveal which key matched
+	var found *Identity
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(k.key, []byte(key)) == 1 && found == nil {
+			found = k.identity
+		}
+	}
+	return found, found != nil
+}
+
+// AuthenticateToken returns the identity for a bearer token. When OIDC is configured,
+// tokens shaped like a JWT are verified against the issuer; any other token is
+// treated as an API key.
+func (a *Authenticator) AuthenticateToken(ctx context.Context, token string) (*Identity, error) {
+	if a == nil {
+		return nil, fmt.Errorf("authentication is not configured")
+	}
+	if a.oidc == nil || strings.Count(token, ".") != 2 {
+		if identity, ok := a.Authenticate(token); ok {
+			return identity, nil
+		}
+		return nil, fmt.Errorf("invalid API key")
+	}
+
+	claims, err := a.oidc.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	role := a.roleForGroups(claims.Groups)
+	if role == nil {
+		return nil, fmt.Errorf("user %s has no role", claims.Username)
+	}
+	return &Identity{
+		Name:             claims.Username,
+		Role:             role,
+		KubernetesUser:   claims.Username,
+		KubernetesGroups: claims.Groups,
+	}, nil
+}
+
+// roleForGroups returns the role of the first group mapping that matches, falling
+// back to the default role
+func (a *Authenticator) roleForGroups(groups []string) *Role {
+	for _, mapping := range a.groupRoles {
+		if contains(groups, mapping.Group) {
+			return a.roles[mapping.Role]
+		}
+	}
+	return a.roles[a.defaultRole]
+}
+
+// Authorize checks whether the identity may perform an action on a kind in a namespace.
+// An empty namespace means all namespaces and an empty kind skips the kind check.
+func (i *Identity) Authorize(action, namespace, kind string) error {
+	if err := i.AuthorizeAction(action, kind); err != nil {
+		return err
+	}
+	if namespace == "" && !i.AllNamespaces() {
+		return fmt.Errorf("role %s is limited to namespaces %s", i.Role.Name, strings.Join(i.Role.Namespaces, ", "))
+	}
+	if namespace != "" && !i.NamespaceAllowed(namespace) {
+		return fmt.Errorf("role %s may not access namespace %s", i.Role.Name, namespace)
+	}
+	return nil
+}
+
+// AuthorizeAction checks the action and kind but not the namespace. Callers that list
+// across namespaces use it and then filter the results with NamespaceAllowed.
+func (i *Identity) AuthorizeAction(action, kind string) error {
+	if i == nil || i.Role == nil {
+		return fmt.Errorf("no authenticated identity")
+	}
+	if !i.Role.allowsAction(action) {
+		return fmt.Errorf("role %s may not %s", i.Role.Name, action)
+	}
+	if kind != "" && !i.KindAllowed(kind) {
+		return fmt.Errorf("role %s may not access %s resources", i.Role.Name, kind)
+	}
+	return nil
+}
+
+// NamespaceAllowed reports whether the identity may access a namespace
+func (i *Identity) NamespaceAllowed(namespace string) bool {
+	if i == nil || i.Role == nil {
+		return false
+	}
+	if len(i.Role.Namespaces) == 0 {
+		return true
+	}
+	for _, pattern := range i.Role.Namespaces {
+		if ok, err := path.Match(pattern, namespace); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// KindAllowed reports whether the identity may access a resource kind
+func (i *Identity) KindAllowed(kind string) bool {
+	return i != nil && i.Role != nil && i.Role.allowsKind(kind)
+}
+
+// AllNamespaces reports whether the identity is not limited to a set of namespaces
+func (i *Identity) AllNamespaces() bool {
+	if i == nil || i.Role == nil {
+		return false
+	}
+	if len(i.Role.Namespaces) == 0 {
+		return true
+	}
+	for _, pattern := range i.Role.Namespaces {
+		if pattern == wildcard {
+			return true
+		}
+	}
+	return false
+}
+
+// FullReadAccess reports whether the identity may read and query every kind in every
+// namespace, as the MCP tools require
+func (i *Identity) FullReadAccess() bool {
+	if i == nil || i.Role == nil || !i.AllNamespaces() {
+		return false
+	}
+	return i.Role.allowsAction(ActionRead) && i.Role.allowsAction(ActionQuery) && contains(i.Role.Kinds, wildcard)
+}
+
+func (r *Role) allowsAction(action string) bool {
+	return contains(r.Actions, wildcard) || contains(r.Actions, action)
+}
+
+// allowsKind matches a kind against the role's kinds, accepting singular or plural
+// forms in any case. Secrets are only allowed when listed explicitly or by "*".
+func (r *Role) allowsKind(kind string) bool {
+	kind = strings.ToLower(kind)
+	if len(r.Kinds) == 0 {
+		return !sameKind("secret", kind)
+	}
+	for _, allowed := range r.Kinds {
+		if allowed == wildcard || sameKind(strings.ToLower(allowed), kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameKind compares two lower-case kind names, ignoring a plural suffix
+func sameKind(a, b string) bool {
+	return a == b || a+"s" == b || a+"es" == b || b+"s" == a || b+"es" == a
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type identityKey struct{}
+
+// WithIdentity returns a context carrying the authenticated identity
+func WithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the authenticated identity, or nil if there is none
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+func testAuthenticator() *Authenticator {
+	return NewAuthenticator(config.AuthConfig{
+		APIKey: "admin-key",
+		Roles: []config.RoleConfig{
+			{Name: "viewer", Namespaces: []string{"apps-*"}, Actions: []string{ActionRead}},
+			{Name: "analyst", Kinds: []string{"Deployment", "pods"}, Actions: []string{ActionRead, ActionQuery}},
+		},
+		Keys: []config.APIKeyConfig{
+			{Name: "oncall", Key: "viewer-key", Role: "viewer"},
+			{Name: "sre", Key: "analyst-key", Role: "analyst"},
+		},
+	}, logging.NewLogger())
+}
+
+func TestAuthenticate(t *testing.T) {
+	a := testAuthenticator()
+
+	if identity, ok := a.Authenticate("viewer-key"); !ok || identity.Name != "oncall" {
+		t.Errorf("Expected the viewer key to authenticate as oncall, got %v", identity)
+	}
+	if identity, ok := a.Authenticate("admin-key"); !ok || !identity.FullReadAccess() {
+		t.Error("Expected the legacy API key to have full access")
+	}
+	if _, ok := a.Authenticate("wrong"); ok {
+		t.Error("Expected an unknown key to be rejected")
+	}
+	if _, ok := a.Authenticate(""); ok {
+		t.Error("Expected an empty key to be rejected")
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	a := testAuthenticator()
+	viewer, _ := a.Authenticate("viewer-key")
+	analyst, _ := a.Authenticate("analyst-key")
+	admin, _ := a.Authenticate("admin-key")
+
+	tests := []struct {
+		name      string
+		identity  *Identity
+		action    string
+		namespace string
+		kind      string
+		allowed   bool
+	}{
+		{"viewer reads in its namespace", viewer, ActionRead, "apps-web", "pod", true},
+		{"viewer reads elsewhere", viewer, ActionRead, "kube-system", "pod", false},
+		{"viewer across namespaces", viewer, ActionRead, "", "pod", false},
+		{"viewer queries", viewer, ActionQuery, "apps-web", "pod", false},
+		{"viewer reads secrets by default", viewer, ActionRead, "apps-web", "secrets", false},
+		{"analyst plural kind", analyst, ActionQuery, "default", "deployments", true},
+		{"analyst singular kind", analyst, ActionRead, "default", "Pod", true},
+		{"analyst other kind", analyst, ActionRead, "default", "configmap", false},
+		{"analyst syncs", analyst, ActionSync, "default", "", false},
+		{"admin reads secrets", admin, ActionRead, "kube-system", "secret", true},
+		{"admin comments", admin, ActionComment, "", "", true},
+		{"no identity", nil, ActionRead, "default", "pod", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.identity.Authorize(tt.action, tt.namespace, tt.kind)
+			if (err == nil) != tt.allowed {
+				t.Errorf("Authorize(%s, %q, %q) = %v, want allowed=%v", tt.action, tt.namespace, tt.kind, err, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestIdentityContext(t *testing.T) {
+	if IdentityFromContext(context.Background()) != nil {
+		t.Error("Expected no identity in an empty context")
+	}
+	identity := &Identity{Name: "oncall"}
+	if got := IdentityFromContext(WithIdentity(context.Background(), identity)); got != identity {
+		t.Errorf("Expected the stored identity, got %v", got)
+	}
+}
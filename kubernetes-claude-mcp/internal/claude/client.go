@@ -20,7 +20,9 @@ type Client struct {
 	maxTokens   int
 	temperature float64
 	httpClient  *http.Client
-	logger      *logging.Logger
+	// streamClient has no overall timeout so long generations are not cut off
+	streamClient *http.Client
+	logger       *logging.Logger
 }
 
 // Message represents a message in the Claude conversation
@@ -36,6 +38,7 @@ type CompletionRequest struct {
 	Messages    []Message `json:"messages"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Temperature float64   `json:"temperature,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
 }
 
 // ContentItem represents an item in the content array of a response
@@ -65,6 +68,9 @@ func NewClient(cfg ClaudeConfig, logger *logging.Logger) *Client {
 		logger = logging.NewLogger().Named("claude")
 	}
 
+	streamTransport := http.DefaultTransport.(*http.Transport).Clone()
+	streamTransport.ResponseHeaderTimeout = 120 * time.Second
+
 	return &Client{
 		apiKey:      cfg.APIKey,
 		baseURL:     cfg.BaseURL,
@@ -74,6 +80,9 @@ func NewClient(cfg ClaudeConfig, logger *logging.Logger) *Client {
 		httpClient: &http.Client{
 			Timeout: 120 * time.Second,
 		},
+		streamClient: &http.Client{
+			Transport: streamTransport,
+		},
 		logger: logger,
 	}
 }
@@ -95,7 +104,44 @@ func (c *Client) Complete(ctx context.Context, messages []Message) (string, erro
 		"model", c.modelID,
 		"messageCount", len(messages))
 
-	// Extract system message if present
+	systemPrompt, userMessages := splitSystemMessage(messages)
+
+	reqBody := CompletionRequest{
+		Model:       c.modelID,
+		System:      systemPrompt,
+		Messages:    userMessages,
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+	}
+
+	body, err := c.sendMessagesRequest(ctx, reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	var completionResponse CompletionResponse
+	if err := json.Unmarshal(body, &completionResponse); err != nil {
+		return "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	// Extract text from content array
+	var responseText string
+	for _, content := range completionResponse.Content {
+		if content.Type == "text" {
+			responseText += content.Text
+		}
+	}
+
+	c.logger.Debug("Received completion response",
+		"model", completionResponse.Model,
+		"inputTokens", completionResponse.Usage.InputTokens,
+		"outputTokens", completionResponse.Usage.OutputTokens)
+
+	return responseText, nil
+}
+
+// splitSystemMessage extracts the system message, which the API takes as a separate field
+func splitSystemMessage(messages []Message) (string, []Message) {
 	var systemPrompt string
 	var userMessages []Message
 
@@ -107,17 +153,14 @@ func (c *Client) Complete(ctx context.Context, messages []Message) (string, erro
 		}
 	}
 
-	reqBody := CompletionRequest{
-		Model:       c.modelID,
-		System:      systemPrompt,
-		Messages:    userMessages,
-		MaxTokens:   c.maxTokens,
-		Temperature: c.temperature,
-	}
+	return systemPrompt, userMessages
+}
 
+// newMessagesRequest builds an authenticated request to the messages endpoint
+func (c *Client) newMessagesRequest(ctx context.Context, reqBody interface{}) (*http.Request, error) {
 	reqJSON, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	req, err := http.NewRequestWithContext(
@@ -127,45 +170,37 @@ func (c *Client) Complete(ctx context.Context, messages []Message) (string, erro
 		bytes.NewBuffer(reqJSON),
 	)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("x-api-key", c.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
 
+	return req, nil
+}
+
+// sendMessagesRequest posts a request body to the messages endpoint and returns the raw response body
+func (c *Client) sendMessagesRequest(ctx context.Context, reqBody interface{}) ([]byte, error) {
+	req, err := c.newMessagesRequest(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, body)
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, body)
 	}
 
-	var completionResponse CompletionResponse
-	if err := json.Unmarshal(body, &completionResponse); err != nil {
-		return "", fmt.Errorf("failed to unmarshal response: %w", err)
-	}
-
-	// Extract text from content array
-	var responseText string
-	for _, content := range completionResponse.Content {
-		if content.Type == "text" {
-			responseText += content.Text
-		}
-	}
-
-	c.logger.Debug("Received completion response",
-		"model", completionResponse.Model,
-		"inputTokens", completionResponse.Usage.InputTokens,
-		"outputTokens", completionResponse.Usage.OutputTokens)
-
-	return responseText, nil
+	return body, nil
 }
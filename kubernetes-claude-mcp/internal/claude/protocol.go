@@ -21,6 +21,27 @@ func NewProtocolHandler(client *Client) *ProtocolHandler {
 
 // GetCompletion gets a completion from Claude with context management
 func (h *ProtocolHandler) GetCompletion(ctx context.Context, systemPrompt, userPrompt string) (string, error) {
+	// Get completion
+	response, err := h.client.Complete(ctx, buildMessages(systemPrompt, userPrompt))
+	if err != nil {
+		return "", fmt.Errorf("claude completion failed: %w", err)
+	}
+
+	return response, nil
+}
+
+// GetCompletionStream gets a completion from Claude, passing text deltas to onDelta as they arrive
+func (h *ProtocolHandler) GetCompletionStream(ctx context.Context, systemPrompt, userPrompt string, onDelta StreamHandler) (string, error) {
+	response, err := h.client.CompleteStream(ctx, buildMessages(systemPrompt, userPrompt), onDelta)
+	if err != nil {
+		return "", fmt.Errorf("claude streaming completion failed: %w", err)
+	}
+
+	return response, nil
+}
+
+// buildMessages truncates the prompts to fit the context window and builds the message list
+func buildMessages(systemPrompt, userPrompt string) []Message {
 	// Check if combined prompts are too large and truncate if needed
 	const maxPromptSize = 100000
 
@@ -38,7 +59,7 @@ func (h *ProtocolHandler) GetCompletion(ctx context.Context, systemPrompt, userP
 	}
 
 	// Create messages
-	messages := []Message{
+	return []Message{
 		{
 			Role:    "system",
 			Content: systemPrompt,
@@ -48,14 +69,6 @@ func (h *ProtocolHandler) GetCompletion(ctx context.Context, systemPrompt, userP
 			Content: userPrompt,
 		},
 	}
-
-	// Get completion
-	response, err := h.client.Complete(ctx, messages)
-	if err != nil {
-		return "", fmt.Errorf("claude completion failed: %w", err)
-	}
-
-	return response, nil
 }
 
 // TruncateContent ensures the content fits within Claude's context window
@@ -0,0 +1,169 @@
+package claude
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// StreamHandler receives text deltas as Claude generates them. Returning an
+// error aborts the stream.
+type StreamHandler func(text string) error
+
+// streamEvent is the subset of the messages streaming event payloads the client uses
+type streamEvent struct {
+	Type    string `json:"type"`
+	Message struct {
+		Model string `json:"model"`
+		Usage Usage  `json:"usage"`
+	} `json:"message"`
+	Delta struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage Usage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// CompleteStream sends a streaming completion request to the Claude API and calls
+// onDelta for every text delta. It returns the full text once the message is complete.
+func (c *Client) CompleteStream(ctx context.Context, messages []Message, onDelta StreamHandler) (string, error) {
+	c.logger.Debug("Sending streaming completion request",
+		"model", c.modelID,
+		"messageCount", len(messages))
+
+	systemPrompt, userMessages := splitSystemMessage(messages)
+
+	reqBody := CompletionRequest{
+		Model:       c.modelID,
+		System:      systemPrompt,
+		Messages:    userMessages,
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+		Stream:      true,
+	}
+
+	req, err := c.newMessagesRequest(ctx, reqBody)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	text, usage, err := parseStream(resp.Body, onDelta)
+	if err != nil {
+		return "", err
+	}
+
+	c.logger.Debug("Received streaming completion response",
+		"inputTokens", usage.InputTokens,
+		"outputTokens", usage.OutputTokens)
+
+	return text, nil
+}
+
+// parseStream reads server-sent events from the messages API, forwarding text
+// deltas to onDelta, and returns the accumulated text and token usage
+func parseStream(r io.Reader, onDelta StreamHandler) (string, Usage, error) {
+	var (
+		text  strings.Builder
+		usage Usage
+		data  strings.Builder
+	)
+
+	// handleEvent processes one complete event; done reports message_stop
+	handleEvent := func(payload string) (bool, error) {
+		var event streamEvent
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			return false, fmt.Errorf("failed to parse stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			usage.InputTokens = event.Message.Usage.InputTokens
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				return false, nil
+			}
+			text.WriteString(event.Delta.Text)
+			if onDelta != nil {
+				if err := onDelta(event.Delta.Text); err != nil {
+					return false, fmt.Errorf("stream handler failed: %w", err)
+				}
+			}
+		case "message_delta":
+			usage.OutputTokens = event.Usage.OutputTokens
+		case "message_stop":
+			return true, nil
+		case "error":
+			return false, fmt.Errorf("stream error (%s): %s", event.Error.Type, event.Error.Message)
+		}
+		return false, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// A blank line terminates an event
+		if line == "" {
+			if data.Len() == 0 {
+				continue
+			}
+			done, err := handleEvent(data.String())
+			if err != nil {
+				return "", usage, err
+			}
+			if done {
+				return text.String(), usage, nil
+			}
+			data.Reset()
+			continue
+		}
+
+		if strings.HasPrefix(line, "data:") {
+			if data.Len() > 0 {
+				data.WriteByte('\n')
+			}
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+		// "event:" lines duplicate the type field in the payload; comments and ids are ignored
+	}
+
+	if err := scanner.Err(); err != nil {
+		return "", usage, fmt.Errorf("failed to read stream: %w", err)
+	}
+
+	// Flush a trailing event without a terminating blank line
+	if data.Len() > 0 {
+		done, err := handleEvent(data.String())
+		if err != nil {
+			return "", usage, err
+		}
+		if done {
+			return text.String(), usage, nil
+		}
+	}
+
+	return "", usage, fmt.Errorf("stream ended before the message was complete")
+}
@@ -0,0 +1,120 @@
+package claude
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+const sampleStream = `event: message_start
+data: {"type":"message_start","message":{"model":"test-model","usage":{"input_tokens":25,"output_tokens":1}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: ping
+data: {"type":"ping"}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":", world"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":15}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestParseStream(t *testing.T) {
+	var deltas []string
+	text, usage, err := parseStream(strings.NewReader(sampleStream), func(delta string) error {
+		deltas = append(deltas, delta)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("parseStream failed: %v", err)
+	}
+
+	if text != "Hello, world" {
+		t.Errorf("Expected 'Hello, world', got %q", text)
+	}
+	if len(deltas) != 2 || deltas[0] != "Hello" || deltas[1] != ", world" {
+		t.Errorf("Unexpected deltas: %q", deltas)
+	}
+	if usage.InputTokens != 25 || usage.OutputTokens != 15 {
+		t.Errorf("Unexpected usage: %+v", usage)
+	}
+}
+
+func TestParseStreamErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+	}{
+		{
+			name:   "error event",
+			stream: "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n",
+		},
+		{
+			name:   "truncated stream",
+			stream: "data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"partial\"}}\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := parseStream(strings.NewReader(tt.stream), nil); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+
+	// A failing handler aborts the stream
+	_, _, err := parseStream(strings.NewReader(sampleStream), func(string) error {
+		return fmt.Errorf("client went away")
+	})
+	if err == nil {
+		t.Error("Expected handler error to abort the stream")
+	}
+}
+
+func TestCompleteStream(t *testing.T) {
+	var streamRequested bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		streamRequested = strings.Contains(string(body), `"stream":true`)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(sampleStream))
+	}))
+	defer server.Close()
+
+	client := NewClient(ClaudeConfig{APIKey: "test", BaseURL: server.URL, ModelID: "test-model", MaxTokens: 100}, logging.NewLogger())
+
+	text, err := client.CompleteStream(context.Background(), []Message{
+		{Role: "system", Content: "system"},
+		{Role: "user", Content: "hi"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("CompleteStream failed: %v", err)
+	}
+	if !streamRequested {
+		t.Error("Expected the request to set stream: true")
+	}
+	if text != "Hello, world" {
+		t.Errorf("Expected 'Hello, world', got %q", text)
+	}
+}
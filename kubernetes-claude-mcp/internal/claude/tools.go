@@ -0,0 +1,139 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// Content block types used in tool-use conversations
+const (
+	ContentTypeText       = "text"
+	ContentTypeToolUse    = "tool_use"
+	ContentTypeToolResult = "tool_result"
+)
+
+// StopReasonToolUse is returned when the model stopped to call one or more tools
+const StopReasonToolUse = "tool_use"
+
+// ToolDefinition describes a tool the model may call
+type ToolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+// ToolChoice controls whether and how the model uses tools
+type ToolChoice struct {
+	Type string `json:"type"`
+}
+
+// ContentBlock is a single block of message content: text, a tool call or a tool result
+type ContentBlock struct {
+	Type string `json:"type"`
+
+	// text
+	Text string `json:"text,omitempty"`
+
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// tool_result
+	ToolUseID string `json:"tool_use_id,omitempty"`
+	Content   string `json:"content,omitempty"`
+	IsError   bool   `json:"is_error,omitempty"`
+}
+
+// ConversationMessage is a message whose content is a list of blocks
+type ConversationMessage struct {
+	Role    string         `json:"role"`
+	Content []ContentBlock `json:"content"`
+}
+
+// ToolCompletionRequest represents a messages request with tool definitions
+type ToolCompletionRequest struct {
+	Model       string                `json:"model"`
+	System      string                `json:"system,omitempty"`
+	Messages    []ConversationMessage `json:"messages"`
+	MaxTokens   int                   `json:"max_tokens,omitempty"`
+	Temperature float64               `json:"temperature,omitempty"`
+	Tools       []ToolDefinition      `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice           `json:"tool_choice,omitempty"`
+}
+
+// ToolCompletionResponse represents a messages response that may contain tool calls
+type ToolCompletionResponse struct {
+	ID         string         `json:"id"`
+	Type       string         `json:"type"`
+	Model      string         `json:"model"`
+	Content    []ContentBlock `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      Usage          `json:"usage"`
+}
+
+// Text returns the concatenated text blocks of the response
+func (r *ToolCompletionResponse) Text() string {
+	var text string
+	for _, block := range r.Content {
+		if block.Type == ContentTypeText {
+			text += block.Text
+		}
+	}
+	return text
+}
+
+// ToolUses returns the tool_use blocks of the response
+func (r *ToolCompletionResponse) ToolUses() []ContentBlock {
+	var uses []ContentBlock
+	for _, block := range r.Content {
+		if block.Type == ContentTypeToolUse {
+			uses = append(uses, block)
+		}
+	}
+	return uses
+}
+
+// CompleteWithTools sends a conversation with tool definitions to the Claude API and
+// returns the full response so the caller can execute any requested tools
+func (c *Client) CompleteWithTools(
+	ctx context.Context,
+	systemPrompt string,
+	messages []ConversationMessage,
+	tools []ToolDefinition,
+	toolChoice *ToolChoice,
+) (*ToolCompletionResponse, error) {
+	c.logger.Debug("Sending tool completion request",
+		"model", c.modelID,
+		"messageCount", len(messages),
+		"toolCount", len(tools))
+
+	reqBody := ToolCompletionRequest{
+		Model:       c.modelID,
+		System:      systemPrompt,
+		Messages:    messages,
+		MaxTokens:   c.maxTokens,
+		Temperature: c.temperature,
+		Tools:       tools,
+		ToolChoice:  toolChoice,
+	}
+
+	body, err := c.sendMessagesRequest(ctx, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	var completionResponse ToolCompletionResponse
+	if err := json.Unmarshal(body, &completionResponse); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	c.logger.Debug("Received tool completion response",
+		"model", completionResponse.Model,
+		"stopReason", completionResponse.StopReason,
+		"inputTokens", completionResponse.Usage.InputTokens,
+		"outputTokens", completionResponse.Usage.OutputTokens)
+
+	return &completionResponse, nil
+}
@@ -0,0 +1,309 @@
+package correlator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
+)
+
+// Kinds of application an ApplicationSet git generator produces
+const (
+	gitDirectoriesGenerator = "git directories"
+	gitFilesGenerator       = "git files"
+)
+
+// applicationSetOrigin describes the ApplicationSet that generated an application. The
+// generator is identified when there is only one, or when a git directory generator's
+// patterns match one of the application's source paths.
+func applicationSetOrigin(appSet *models.ArgoApplicationSet, app *models.ArgoApplication) *models.ArgoApplicationSetOrigin {
+	template := appSet.Spec.Template
+	origin := &models.ArgoApplicationSetOrigin{
+		Name:            appSet.Metadata.Name,
+		Namespace:       appSet.Metadata.Namespace,
+		Generators:      []string{},
+		TemplateName:    template.Metadata.Name,
+		TemplateSources: template.Spec.Sources,
+	}
+	if len(origin.TemplateSources) == 0 && template.Spec.Source.RepoURL != "" {
+		origin.TemplateSources = []models.ArgoApplicationSource{template.Spec.Source}
+	}
+
+	for _, generator := range appSet.Spec.Generators {
+		origin.Generators = append(origin.Generators, describeGenerator(generator))
+	}
+	if len(origin.Generators) == 1 {
+		origin.Generator = origin.Generators[0]
+		return origin
+	}
+
+	for i, generator := range appSet.Spec.Generators {
+		if generatorMatchesApp(generator, app) {
+			origin.Generator = origin.Generators[i]
+			break
+		}
+	}
+	return origin
+}
+
+// describeGenerator summarizes a generator, such as "git directories apps/* in
+// <repo>" or "matrix(git files envs/*.json in <repo>, clusters)"
+func describeGenerator(generator models.ArgoGenerator) string {
+	generatorType := generator.Type()
+
+	if git, ok := generator.Git(); ok {
+		var patterns []string
+		kind := gitFilesGenerator
+		for _, file := range git.Files {
+			patterns = append(patterns, file.Path)
+		}
+		if len(git.Directories) > 0 {
+			kind = gitDirectoriesGenerator
+			for _, dir := range git.Directories {
+				if dir.Exclude {
+					patterns = append(patterns, "!"+dir.Path)
+				} else {
+					patterns = append(patterns, dir.Path)
+				}
+			}
+		}
+		return fmt.Sprintf("%s %s in %s", kind, strings.Join(patterns, ", "), git.RepoURL)
+	}
+
+	if children := generator.Children(); len(children) > 0 {
+		descriptions := make([]string, 0, len(children))
+		for _, child := range children {
+			descriptions = append(descriptions, describeGenerator(child))
+		}
+		return fmt.Sprintf("%s(%s)", generatorType, strings.Join(descriptions, ", "))
+	}
+
+	if generatorType == "list" {
+		var list struct {
+			Elements []json.RawMessage `json:"elements"`
+		}
+		if err := json.Unmarshal(generator["list"], &list); err == nil {
+			return fmt.Sprintf("list of %d elements", len(list.Elements))
+		}
+	}
+	return generatorType
+}
+
+// generatorMatchesApp reports whether a generator, or one nested in it, is a git
+// directory generator whose patterns match a source path of the application
+func generatorMatchesApp(generator models.ArgoGenerator, app *models.ArgoApplication) bool {
+	if git, ok := generator.Git(); ok {
+		for _, source := range app.AllSources() {
+			if source.Path != "" && matchesDirectories(git, source.Path) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, child := range generator.Children() {
+		if generatorMatchesApp(child, app) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitGenerators returns the git generators among generators, including those nested
+// in matrix and merge generators
+func gitGenerators(generators []models.ArgoGenerator) []*models.ArgoGitGenerator {
+	var result []*models.ArgoGitGenerator
+	for _, generator := range generators {
+		if git, ok := generator.Git(); ok {
+			result = append(result, git)
+			continue
+		}
+		result = append(result, gitGenerators(generator.Children())...)
+	}
+	return result
+}
+
+// ApplicationSetChanges finds the applications ApplicationSets would add or remove once
+// a merge request is merged: those generated by a git generator on the merge request's
+// project from a directory the merge request creates or empties, or from a file it adds
+// or deletes
+func (c *GitOpsCorrelator) ApplicationSetChanges(ctx context.Context, projectID string, mergeRequestIID int) ([]models.ApplicationSetChange, error) {
+	c.logger.Info("Finding ApplicationSet changes", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+	if c.argoClient == nil {
+		return nil, nil
+	}
+
+	mr, err := c.repos.GetMergeRequestChanges(ctx, projectID, mergeRequestIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request changes: %w", err)
+	}
+	appSets, err := c.argoClient.ListApplicationSets(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ArgoCD ApplicationSets: %w", err)
+	}
+
+	_, host, _ := c.repos.ForProject(projectID)
+	projectPath := c.projectPath(ctx, projectID)
+
+	// Directory listings are shared between generators
+	listed := make(map[string]bool)
+	dirExists := func(dir, ref string) (bool, bool) {
+		key := ref + ":" + dir
+		if exists, ok := listed[key]; ok {
+			return exists, true
+		}
+		entries, err := c.repos.ListRepositoryTree(ctx, projectID, dir, ref, false)
+		if err != nil && !scm.IsNotFound(err) {
+			c.logger.Warn("Failed to list directory", "dir", dir, "ref", ref, "error", err)
+			return false, false
+		}
+		listed[key] = err == nil && len(entries) > 0
+		return listed[key], true
+	}
+
+	var changes []models.ApplicationSetChange
+	seen := make(map[models.ApplicationSetChange]bool)
+	for _, appSet := range appSets {
+		for _, git := range gitGenerators(appSet.Spec.Generators) {
+			if !c.repos.SameRepository(git.RepoURL, host, projectPath) {
+				continue
+			}
+			for _, change := range gitGeneratorChanges(git, mr.Changes, mr.DiffRefs.BaseSHA, mr.DiffRefs.HeadSHA, dirExists) {
+				change.ApplicationSet = appSet.Metadata.Name
+				if !seen[change] {
+					seen[change] = true
+					changes = append(changes, change)
+				}
+			}
+		}
+	}
+
+	c.logger.Info("Found ApplicationSet changes",
+		"projectID", projectID,
+		"mergeRequestIID", mergeRequestIID,
+		"count", len(changes))
+	return changes, nil
+}
+
+// gitGeneratorChanges finds the applications a git generator would add or remove for
+// a set of changed files. A matching file that is added or deleted adds or removes an
+// application. A matching directory gains or loses an application when it exists at
+// only one of the base and head refs; dirExists reports whether a directory has any
+// entries at a ref, and false as its second result when that is unknown.
+func gitGeneratorChanges(
+	git *models.ArgoGitGenerator,
+	diffs []models.GitLabDiff,
+	baseRef, headRef string,
+	dirExists func(dir, ref string) (bool, bool),
+) []models.ApplicationSetChange {
+	var changes []models.ApplicationSetChange
+
+	if len(git.Files) > 0 {
+		for _, diff := range diffs {
+			if (diff.DeletedFile || diff.RenamedFile) && matchesFiles(git, diff.OldPath) {
+				changes = append(changes, models.ApplicationSetChange{Generator: gitFilesGenerator, Path: diff.OldPath, Change: "removed"})
+			}
+			if (diff.NewFile || diff.RenamedFile) && matchesFiles(git, diff.NewPath) {
+				changes = append(changes, models.ApplicationSetChange{Generator: gitFilesGenerator, Path: diff.NewPath, Change: "added"})
+			}
+		}
+	}
+
+	if len(git.Directories) > 0 {
+		// Only adding or removing files can create or empty a directory
+		candidates := make(map[string]bool)
+		var dirs []string
+		for _, diff := range diffs {
+			var files []string
+			if diff.NewFile || diff.RenamedFile {
+				files = append(files, diff.NewPath)
+			}
+			if diff.DeletedFile || diff.RenamedFile {
+				files = append(files, diff.OldPath)
+			}
+			for _, file := range files {
+				for dir := path.Dir(file); dir != "." && dir != "/"; dir = path.Dir(dir) {
+					if !candidates[dir] && matchesDirectories(git, dir) {
+						candidates[dir] = true
+						dirs = append(dirs, dir)
+					}
+				}
+			}
+		}
+
+		for _, dir := range dirs {
+			before, ok := dirExists(dir, baseRef)
+			if !ok {
+				continue
+			}
+			after, ok := dirExists(dir, headRef)
+			if !ok {
+				continue
+			}
+			switch {
+			case after && !before:
+				changes = append(changes, models.ApplicationSetChange{Generator: gitDirectoriesGenerator, Path: dir, Change: "added"})
+			case before && !after:
+				changes = append(changes, models.ApplicationSetChange{Generator: gitDirectoriesGenerator, Path: dir, Change: "removed"})
+			}
+		}
+	}
+
+	return changes
+}
+
+// matchesDirectories reports whether a directory matches one of a git generator's
+// directory patterns and none of its exclusions
+func matchesDirectories(git *models.ArgoGitGenerator, dir string) bool {
+	included := false
+	for _, pattern := range git.Directories {
+		if !matchGlob(pattern.Path, dir) {
+			continue
+		}
+		if pattern.Exclude {
+			return false
+		}
+		included = true
+	}
+	return included
+}
+
+// matchesFiles reports whether a file matches one of a git generator's file patterns
+func matchesFiles(git *models.ArgoGitGenerator, file string) bool {
+	for _, pattern := range git.Files {
+		if matchGlob(pattern.Path, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated path against a glob pattern in which "**"
+// matches any number of directories and other segments follow path.Match
+func matchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(strings.Trim(pattern, "/"), "/"), strings.Split(strings.Trim(name, "/"), "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,137 @@
+package correlator
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+func decodeApplicationSet(t *testing.T, data string) *models.ArgoApplicationSet {
+	t.Helper()
+	var appSet models.ArgoApplicationSet
+	if err := json.Unmarshal([]byte(data), &appSet); err != nil {
+		t.Fatalf("Failed to decode ApplicationSet: %v", err)
+	}
+	return &appSet
+}
+
+func TestAppPathsMultiSource(t *testing.T) {
+	c := &GitOpsCorrelator{repos: scm.NewRegistry("gitlab.com", nil, logging.NewLogger())}
+
+	var app models.ArgoApplication
+	err := json.Unmarshal([]byte(`{"spec": {"sources": [
+		{"repoURL": "https://charts.example.com", "chart": "api", "helm": {"valueFiles": ["$values/envs/prod/api.yaml"]}},
+		{"repoURL": "https://gitlab.com/acme/config.git", "ref": "values"}
+	]}}`), &app)
+	if err != nil {
+		t.Fatalf("Failed to decode application: %v", err)
+	}
+
+	paths := c.appPaths(&app, "acme/config", "acme/config")
+	if !paths.sourced {
+		t.Fatal("Expected the application to be sourced from the values repository")
+	}
+	if len(paths.dirs) != 0 {
+		t.Errorf("Expected no source paths for a ref source, got %v", paths.dirs)
+	}
+	if !paths.contains("envs/prod/api.yaml") {
+		t.Error("Expected the referenced value file to affect the application")
+	}
+	if paths.contains("envs/staging/api.yaml") {
+		t.Error("Expected other value files not to affect the application")
+	}
+
+	if other := c.appPaths(&app, "acme/other", "acme/other"); other.sourced {
+		t.Error("Expected the application not to be sourced from another repository")
+	}
+}
+
+func TestGitGeneratorChanges(t *testing.T) {
+	appSet := decodeApplicationSet(t, `{"spec": {"generators": [{"matrix": {"generators": [
+		{"git": {"repoURL": "https://gitlab.com/acme/config.git", "directories": [{"path": "apps/*"}, {"path": "apps/legacy", "exclude": true}]}},
+		{"git": {"repoURL": "https://gitlab.com/acme/config.git", "files": [{"path": "clusters/**/config.json"}]}}
+	]}}]}}`)
+
+	diffs := []models.GitLabDiff{
+		{NewPath: "apps/payments/kustomization.yaml", OldPath: "apps/payments/kustomization.yaml", NewFile: true},
+		{NewPath: "apps/search/deployment.yaml", OldPath: "apps/search/deployment.yaml", DeletedFile: true},
+		{NewPath: "apps/legacy/deployment.yaml", OldPath: "apps/legacy/deployment.yaml", NewFile: true},
+		{NewPath: "apps/api/deployment.yaml", OldPath: "apps/api/deployment.yaml"},
+		{NewPath: "clusters/eu/west/config.json", OldPath: "clusters/eu/west/config.json", NewFile: true},
+	}
+	existing := map[string]bool{"base:apps/search": true, "head:apps/payments": true}
+	dirExists := func(dir, ref string) (bool, bool) {
+		return existing[ref+":"+dir], true
+	}
+
+	var changes []models.ApplicationSetChange
+	for _, git := range gitGenerators(appSet.Spec.Generators) {
+		changes = append(changes, gitGeneratorChanges(git, diffs, "base", "head", dirExists)...)
+	}
+
+	expected := []models.ApplicationSetChange{
+		{Generator: gitDirectoriesGenerator, Path: "apps/payments", Change: "added"},
+		{Generator: gitDirectoriesGenerator, Path: "apps/search", Change: "removed"},
+		{Generator: gitFilesGenerator, Path: "clusters/eu/west/config.json", Change: "added"},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes %+v, got %+v", expected, changes)
+	}
+}
+
+func TestApplicationSetOrigin(t *testing.T) {
+	appSet := decodeApplicationSet(t, `{
+		"metadata": {"name": "platform", "namespace": "argocd"},
+		"spec": {
+			"generators": [
+				{"list": {"elements": [{"cluster": "a"}, {"cluster": "b"}]}},
+				{"git": {"repoURL": "https://gitlab.com/acme/config.git", "directories": [{"path": "apps/*"}]}}
+			],
+			"template": {
+				"metadata": {"name": "{{path.basename}}"},
+				"spec": {"source": {"repoURL": "https://gitlab.com/acme/config.git", "path": "{{path}}"}}
+			}
+		}
+	}`)
+
+	var app models.ArgoApplication
+	app.Spec.Source = models.ArgoApplicationSource{RepoURL: "https://gitlab.com/acme/config.git", Path: "apps/payments"}
+
+	origin := applicationSetOrigin(appSet, &app)
+	if origin.Name != "platform" || origin.TemplateName != "{{path.basename}}" {
+		t.Errorf("Unexpected origin %+v", origin)
+	}
+	expectedGenerators := []string{"list of 2 elements", "git directories apps/* in https://gitlab.com/acme/config.git"}
+	if !reflect.DeepEqual(origin.Generators, expectedGenerators) {
+		t.Errorf("Expected generators %v, got %v", expectedGenerators, origin.Generators)
+	}
+	if origin.Generator != expectedGenerators[1] {
+		t.Errorf("Expected the git generator to be identified, got %q", origin.Generator)
+	}
+	if len(origin.TemplateSources) != 1 || origin.TemplateSources[0].Path != "{{path}}" {
+		t.Errorf("Expected the template source, got %+v", origin.TemplateSources)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"apps/*", "apps/api", true},
+		{"apps/*", "apps/api/overlays", false},
+		{"apps/**", "apps/api/overlays", true},
+		{"**/config.json", "config.json", true},
+		{"clusters/**/config.json", "clusters/eu/west/config.json", true},
+		{"clusters/*.json", "clusters/eu.yaml", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
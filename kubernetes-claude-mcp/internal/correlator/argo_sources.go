@@ -0,0 +1,98 @@
+package correlator
+
+import (
+	"path"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// appPaths are the parts of a repository an ArgoCD application deploys from: the
+// paths of its sources in the repository, and the Helm value files its sources read
+// from the repository, including through a ref source
+type appPaths struct {
+	// sourced is set when any source of the application is in the repository
+	sourced bool
+
+	// dirs are source paths; "" is the repository root
+	dirs []string
+
+	// files are Helm value files outside the source paths
+	files []string
+}
+
+// appPaths finds the paths an application reads from a project. Sources in other
+// repositories, such as Helm chart repositories, are skipped, but the value files they
+// take from a ref source in the project are included.
+func (c *GitOpsCorrelator) appPaths(app *models.ArgoApplication, projectID, projectPath string) appPaths {
+	_, host, _ := c.repos.ForProject(projectID)
+	inProject := func(repoURL string) bool {
+		return c.repos.SameRepository(repoURL, host, projectPath)
+	}
+
+	sources := app.AllSources()
+	refs := make(map[string]models.ArgoApplicationSource)
+	for _, source := range sources {
+		if source.Ref != "" {
+			refs[source.Ref] = source
+		}
+	}
+
+	var paths appPaths
+	for _, source := range sources {
+		sourceInProject := inProject(source.RepoURL)
+		if sourceInProject {
+			paths.sourced = true
+			if !source.IsRefOnly() {
+				paths.dirs = append(paths.dirs, source.Path)
+			}
+		}
+		if source.Helm == nil {
+			continue
+		}
+
+		for _, valueFile := range source.Helm.ValueFiles {
+			switch {
+			case strings.HasPrefix(valueFile, "$"):
+				// "$values/envs/prod.yaml" is a file in the source whose ref is "values"
+				refName, file, _ := strings.Cut(valueFile[1:], "/")
+				ref, ok := refs[refName]
+				if ok && inProject(ref.RepoURL) {
+					paths.sourced = true
+					paths.files = append(paths.files, path.Join(ref.Path, file))
+				}
+			case sourceInProject && !strings.Contains(valueFile, "://"):
+				// Value files are relative to the source path and may sit outside it
+				paths.files = append(paths.files, path.Join(source.Path, valueFile))
+			}
+		}
+	}
+	return paths
+}
+
+// contains reports whether a file is under one of the source paths or is one of the
+// value files
+func (p *appPaths) contains(file string) bool {
+	for _, dir := range p.dirs {
+		if dir == "" || dir == "." || strings.HasPrefix(file, dir) {
+			// An application deploying the repository root is affected by any file
+			return true
+		}
+	}
+	for _, valueFile := range p.files {
+		if file == valueFile {
+			return true
+		}
+	}
+	return false
+}
+
+// affectedBy reports whether a diff touches any of the paths
+func (p *appPaths) affectedBy(diffs []models.GitLabDiff) bool {
+	for _, diff := range diffs {
+		if p.contains(diff.NewPath) || p.contains(diff.OldPath) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package correlator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/argocd"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/flux"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/manifest"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// GitOpsSource is the Git repository a GitOps object deploys a resource from
+type GitOpsSource struct {
+	RepoURL     string
+	Path        string
+	Revision    string
+	Environment string
+}
+
+// GitOpsBackend finds the GitOps object that deploys a live resource, such as an ArgoCD
+// application or a Flux Kustomization
+type GitOpsBackend interface {
+	// Name returns the backend name used in messages, such as "ArgoCD" or "Flux"
+	Name() string
+
+	// TraceResource fills in the resource context from the object that deploys the
+	// resource and returns the object's Git source. It returns nil when the backend
+	// does not manage the resource. The resource is nil when it could not be read. An
+	// error returned with a source means the trace is incomplete.
+	TraceResource(
+		ctx context.Context,
+		k8sClient *k8s.Client,
+		resource *unstructured.Unstructured,
+		rc *models.ResourceContext,
+	) (*GitOpsSource, error)
+}
+
+// maxDriftResources caps how many drifted resources are added to a trace
+const maxDriftResources = 20
+
+// argoBackend traces resources to the ArgoCD applications that sync them
+type argoBackend struct {
+	client            *argocd.Client
+	selectApplication func(apps []models.ArgoApplication, cluster string) (models.ArgoApplication, bool)
+	mask              manifest.Masker
+	logger            *logging.Logger
+}
+
+func (b *argoBackend) Name() string {
+	return "ArgoCD"
+}
+
+func (b *argoBackend) TraceResource(
+	ctx context.Context,
+	_ *k8s.Client,
+	_ *unstructured.Unstructured,
+	rc *models.ResourceContext,
+) (*GitOpsSource, error) {
+	argoApps, err := b.client.FindApplicationsByResource(ctx, rc.Kind, rc.Name, rc.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find ArgoCD applications: %w", err)
+	}
+	app, ok := b.selectApplication(argoApps, rc.Cluster)
+	if !ok {
+		return nil, nil
+	}
+
+	rc.ArgoApplication = &app
+	rc.ArgoSyncStatus = app.Status.Sync.Status
+	rc.ArgoHealthStatus = app.Status.Health.Status
+
+	b.logger.Debug("Found ArgoCD application",
+		"appName", app.Name,
+		"syncStatus", app.Status.Sync.Status,
+		"healthStatus", app.Status.Health.Status)
+
+	primary, revision := app.PrimarySource()
+	source := &GitOpsSource{
+		RepoURL:     primary.RepoURL,
+		Path:        primary.Path,
+		Revision:    revision,
+		Environment: extractEnvironmentFromArgoApp(&app),
+	}
+
+	var errs []error
+
+	// Applications generated by an ApplicationSet show the generator and template
+	// they came from
+	if appSetName := app.ApplicationSetName(); appSetName != "" {
+		appSet, err := b.client.GetApplicationSet(ctx, appSetName)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to get ApplicationSet %s: %w", appSetName, err))
+		} else {
+			rc.ArgoApplicationSet = applicationSetOrigin(appSet, &app)
+		}
+	}
+
+	// Show what drifted when the live state no longer matches Git
+	if app.Status.Sync.Status == "OutOfSync" {
+		drift, err := b.drift(ctx, &app, rc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to diff live state: %w", err))
+		}
+		rc.ArgoDrift = drift
+	}
+
+	// Get recent syncs
+	history, err := b.client.GetApplicationHistory(ctx, app.Name)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to get application history: %w", err))
+	} else {
+		// Limit to recent syncs (last 5)
+		if len(history) > 5 {
+			history = history[:5]
+		}
+		rc.ArgoSyncHistory = history
+	}
+
+	return source, errors.Join(errs...)
+}
+
+// drift compares the live and target states of the resources an application manages
+// and returns those that differ, the traced resource first. Hooks are skipped.
+func (b *argoBackend) drift(ctx context.Context, app *models.ArgoApplication, rc *models.ResourceContext) ([]models.ResourceDrift, error) {
+	resources, err := b.client.GetManagedResources(ctx, app.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var drifts []models.ResourceDrift
+	for i := range resources {
+		resource := &resources[i]
+		if resource.Hook {
+			continue
+		}
+		target, live, err := resource.States()
+		if err != nil {
+			b.logger.Warn("Failed to decode managed resource state",
+				"app", app.Name,
+				"kind", resource.Kind,
+				"name", resource.Name,
+				"error", err)
+			continue
+		}
+
+		drift := models.ResourceDrift{
+			Group:     resource.Group,
+			Kind:      resource.Kind,
+			Namespace: resource.Namespace,
+			Name:      resource.Name,
+		}
+		switch {
+		case target == nil && live == nil:
+			continue
+		case live == nil:
+			drift.Status = models.DriftMissing
+		case target == nil:
+			drift.Status = models.DriftExtraneous
+		default:
+			drift.Fields = manifest.DiffLive(target, live, b.mask)
+			if len(drift.Fields) == 0 {
+				continue
+			}
+			drift.Status = models.DriftModified
+		}
+		drifts = append(drifts, drift)
+	}
+
+	sort.SliceStable(drifts, func(i, j int) bool {
+		return isTracedResource(&drifts[i], rc) && !isTracedResource(&drifts[j], rc)
+	})
+	if len(drifts) > maxDriftResources {
+		drifts = drifts[:maxDriftResources]
+	}
+
+	b.logger.Debug("Diffed live state", "app", app.Name, "driftedResources", len(drifts))
+	return drifts, nil
+}
+
+func isTracedResource(drift *models.ResourceDrift, rc *models.ResourceContext) bool {
+	return strings.EqualFold(drift.Kind, rc.Kind) && drift.Name == rc.Name && drift.Namespace == rc.Namespace
+}
+
+// fluxBackend traces resources to the Flux Kustomization or HelmRelease that applied
+// them, found from the labels Flux's controllers set
+type fluxBackend struct {
+	logger *logging.Logger
+}
+
+func (b *fluxBackend) Name() string {
+	return "Flux"
+}
+
+func (b *fluxBackend) TraceResource(
+	ctx context.Context,
+	k8sClient *k8s.Client,
+	resource *unstructured.Unstructured,
+	rc *models.ResourceContext,
+) (*GitOpsSource, error) {
+	if resource == nil {
+		return nil, nil
+	}
+
+	reader := flux.NewReader(k8sClient.GetDynamicClient(), b.logger)
+	owner, err := reader.Trace(ctx, resource.GetLabels())
+	if owner == nil {
+		return nil, err
+	}
+	rc.FluxObject = owner
+
+	b.logger.Debug("Found Flux owner",
+		"kind", owner.Kind,
+		"name", owner.Name,
+		"namespace", owner.Namespace,
+		"ready", owner.Ready)
+
+	source := &GitOpsSource{
+		Path:     owner.Path,
+		Revision: owner.LastAppliedRevision,
+	}
+	if owner.Source != nil && owner.Source.Kind == flux.KindGitRepository {
+		source.RepoURL = owner.Source.URL
+	}
+	targetNamespace := owner.TargetNamespace
+	if targetNamespace == "" {
+		targetNamespace = rc.Namespace
+	}
+	source.Environment = extractEnvironment(owner.Labels, targetNamespace, owner.Path)
+
+	return source, err
+}
@@ -0,0 +1,93 @@
+package correlator
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// maxImpactedResources caps the blast radius of a merge request, keeping the highest
+// ranked resources
+const maxImpactedResources = 100
+
+// impactScope is a namespace of a cluster whose resource graph is walked
+type impactScope struct {
+	cluster   string
+	namespace string
+}
+
+// MergeRequestBlastRadius maps the objects a merge request modifies or removes onto
+// the live resource graph and returns the ranked resources they transitively affect.
+// Changed objects are located among the live resources of the affected ArgoCD
+// applications, which also give the cluster and namespace of objects rendered without
+// one; objects the merge request adds are not live yet and affect nothing. The graph
+// is read as the caller when impersonation is enabled.
+func (c *GitOpsCorrelator) MergeRequestBlastRadius(
+	ctx context.Context,
+	diffs []models.ManifestDiff,
+	resources []models.ResourceContext,
+) []models.ImpactedResource {
+	changed := make(map[impactScope][]k8s.ResourceRef)
+	for _, diff := range diffs {
+		for _, resource := range diff.Resources {
+			if resource.Change == models.ResourceAdded {
+				continue
+			}
+			for _, live := range resources {
+				if !strings.EqualFold(live.Kind, resource.Kind) || live.Name != resource.Name ||
+					(resource.Namespace != "" && live.Namespace != resource.Namespace) {
+					continue
+				}
+				scope := impactScope{cluster: live.Cluster, namespace: live.Namespace}
+				ref := k8s.ResourceRef{Kind: live.Kind, Namespace: live.Namespace, Name: live.Name}
+				if !containsRef(changed[scope], ref) {
+					changed[scope] = append(changed[scope], ref)
+				}
+			}
+		}
+	}
+
+	var impacted []models.ImpactedResource
+	for scope, refs := range changed {
+		client, err := c.clusters.ForContext(ctx, scope.cluster)
+		if err != nil {
+			c.logger.Warn("Failed to get cluster for blast radius", "cluster", scope.cluster, "error", err)
+			continue
+		}
+		relationships, err := client.ResourceMapper.GetRelationships(ctx, scope.namespace)
+		if err != nil {
+			c.logger.Warn("Failed to map resource relationships",
+				"cluster", scope.cluster,
+				"namespace", scope.namespace,
+				"error", err)
+			continue
+		}
+
+		for _, resource := range k8s.Impact(relationships, refs) {
+			resource.Cluster = scope.cluster
+			impacted = append(impacted, resource)
+		}
+	}
+
+	k8s.RankImpact(impacted)
+	if len(impacted) > maxImpactedResources {
+		c.logger.Debug("Truncating blast radius", "resources", len(impacted), "limit", maxImpactedResources)
+		impacted = impacted[:maxImpactedResources]
+	}
+
+	c.logger.Info("Mapped merge request blast radius",
+		"changedScopes", len(changed),
+		"impactedResources", len(impacted))
+	return impacted
+}
+
+func containsRef(refs []k8s.ResourceRef, ref k8s.ResourceRef) bool {
+	for _, r := range refs {
+		if r == ref {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,506 @@
+package correlator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// maxChangeResources caps the resources listed for one change
+const maxChangeResources = 50
+
+// changedApp is an ArgoCD application deploying into the scope of a change report,
+// with the revisions it synced and the resources it manages
+type changedApp struct {
+	app         *models.ArgoApplication
+	environment string
+	revisions   map[string]bool
+	resources   []k8s.ResourceRef
+}
+
+// DetectChanges reports what changed in a namespace, or across a cluster when
+// namespace is empty, between since and until. Changes come from the cluster itself
+// (new ReplicaSets and pod template revisions, written ConfigMaps and Secrets), from
+// the ArgoCD applications that deploy into the scope (syncs), and from their
+// repositories (merged merge requests and deployments to the applications'
+// environments). Each change is followed through the live resource graph to the
+// resources it touched and scored by how likely it is to have caused the scope's
+// Warning events. Sources that could not be read are listed in the report's errors.
+//
+// When kindAllowed is set, resources of other kinds are left out: changes to them are
+// dropped, they are not listed as touched and their warnings do not score changes.
+func (c *GitOpsCorrelator) DetectChanges(
+	ctx context.Context,
+	cluster, namespace string,
+	since, until time.Time,
+	kindAllowed func(kind string) bool,
+) (*models.ChangeReport, error) {
+	c.logger.Info("Detecting changes", "cluster", cluster, "namespace", namespace, "since", since, "until", until)
+
+	k8sClient, err := c.clusters.ForContext(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
+	if cluster == "" {
+		cluster = c.clusters.DefaultName()
+	}
+
+	report := &models.ChangeReport{
+		Cluster:   cluster,
+		Namespace: namespace,
+		Since:     since,
+		Until:     until,
+		Changes:   []models.DetectedChange{},
+	}
+	inWindow := func(t time.Time) bool {
+		return !t.IsZero() && !t.Before(since) && !t.After(until)
+	}
+	if kindAllowed == nil {
+		kindAllowed = func(string) bool { return true }
+	}
+
+	clusterChanges, errs := k8sClient.DetectChanges(ctx, namespace, since, until)
+	for _, err := range errs {
+		if apierrors.IsForbidden(err) && len(clusterChanges) == 0 {
+			// The caller's impersonated identity may not read the scope
+			return nil, err
+		}
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	apps := c.changedApps(ctx, cluster, namespace, report)
+	for _, app := range apps {
+		history, err := c.argoClient.GetApplicationHistory(ctx, app.app.Name)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to get history of %s: %v", app.app.Name, err))
+			continue
+		}
+		for _, sync := range history {
+			app.revisions[sync.Revision] = true
+			if !inWindow(sync.DeployedAt) {
+				continue
+			}
+			clusterChanges = append(clusterChanges, k8s.ClusterChange{
+				DetectedChange: models.DetectedChange{
+					Time:     sync.DeployedAt,
+					Type:     models.ChangeSync,
+					Source:   "argocd",
+					Subject:  "Application/" + app.app.Name,
+					Summary:  fmt.Sprintf("Application %s synced revision %s", app.app.Name, shortRevision(sync.Revision)),
+					Revision: sync.Revision,
+					URL:      c.argoClient.ApplicationURL(app.app.Name),
+				},
+				Changed: app.resources,
+			})
+		}
+	}
+	clusterChanges = append(clusterChanges, c.repositoryChanges(ctx, apps, since, inWindow, report)...)
+
+	// Follow each change from the resources it changed to the ones that depend on them
+	relationships := make(map[string][]k8s.ResourceRelationship)
+	mapper := k8s.NewResourceMapper(k8sClient)
+	for i := range clusterChanges {
+		change := &clusterChanges[i]
+		if kind := subjectKind(change); kind != "" && !kindAllowed(kind) {
+			continue
+		}
+		var resources []string
+		for _, ref := range change.Changed {
+			if kindAllowed(ref.Kind) {
+				resources = appendResource(resources, ref)
+			}
+		}
+		for _, ref := range change.Changed {
+			rels, ok := relationships[ref.Namespace]
+			if !ok {
+				rels, err = mapper.GetRelationships(ctx, ref.Namespace)
+				if err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("Failed to map resources in %s: %v", ref.Namespace, err))
+				}
+				relationships[ref.Namespace] = rels
+			}
+		}
+		byNamespace := make(map[string][]k8s.ResourceRef)
+		for _, ref := range change.Changed {
+			byNamespace[ref.Namespace] = append(byNamespace[ref.Namespace], ref)
+		}
+		for ns, refs := range byNamespace {
+			impacted := k8s.Impact(relationships[ns], refs)
+			k8s.RankImpact(impacted)
+			for _, resource := range impacted {
+				if kindAllowed(resource.Kind) {
+					resources = appendResource(resources, k8s.ResourceRef{Kind: resource.Kind, Namespace: resource.Namespace, Name: resource.Name})
+				}
+			}
+		}
+		if len(resources) > maxChangeResources {
+			resources = resources[:maxChangeResources]
+		}
+		change.Resources = resources
+		report.Changes = append(report.Changes, change.DetectedChange)
+	}
+
+	var warnings []models.K8sEvent
+	for _, event := range c.warningEvents(ctx, k8sClient, namespace, since, until, report) {
+		if kindAllowed(event.Object.Kind) {
+			warnings = append(warnings, event)
+		}
+	}
+	report.Warnings = len(warnings)
+	warningsStarted, warned := warningOnset(warnings, since)
+	if !warningsStarted.IsZero() {
+		report.WarningsStarted = &warningsStarted
+	}
+	RankChanges(report.Changes, warningsStarted, warned)
+
+	c.logger.Info("Detected changes",
+		"cluster", cluster,
+		"namespace", namespace,
+		"changes", len(report.Changes),
+		"warnings", report.Warnings,
+		"errors", len(report.Errors))
+	return report, nil
+}
+
+// changedApps returns the ArgoCD applications that deploy into the report's cluster
+// and namespace
+func (c *GitOpsCorrelator) changedApps(ctx context.Context, cluster, namespace string, report *models.ChangeReport) []*changedApp {
+	if c.argoClient == nil {
+		return nil
+	}
+	apps, err := c.argoClient.ListApplications(ctx)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("Failed to list ArgoCD applications: %v", err))
+		return nil
+	}
+
+	var result []*changedApp
+	for i := range apps {
+		app := &apps[i]
+		if namespace != "" && app.Spec.Destination.Namespace != namespace {
+			continue
+		}
+		if appCluster, ok := c.clusterForApp(app); !ok || appCluster != cluster {
+			continue
+		}
+
+		changed := &changedApp{
+			app:         app,
+			environment: extractEnvironmentFromArgoApp(app),
+			revisions:   make(map[string]bool),
+		}
+		for _, resource := range app.Status.Resources {
+			resourceNamespace := resource.Namespace
+			if resourceNamespace == "" {
+				resourceNamespace = app.Spec.Destination.Namespace
+			}
+			if resourceNamespace == "" {
+				// Cluster-scoped resources are not in the resource graph
+				continue
+			}
+			changed.resources = append(changed.resources, k8s.ResourceRef{
+				Kind:      resource.Kind,
+				Namespace: resourceNamespace,
+				Name:      resource.Name,
+			})
+		}
+		result = append(result, changed)
+	}
+	return result
+}
+
+// repositoryChanges finds the merge requests merged into, and the deployments made
+// from, the repositories of the applications. A merge request touches the resources
+// of the applications that synced its merge commit, and a deployment those of the
+// applications deploying its environment from its repository.
+func (c *GitOpsCorrelator) repositoryChanges(
+	ctx context.Context,
+	apps []*changedApp,
+	since time.Time,
+	inWindow func(time.Time) bool,
+	report *models.ChangeReport,
+) []k8s.ClusterChange {
+	type repository struct {
+		provider    string
+		projectID   string
+		projectPath string
+		apps        []*changedApp
+	}
+	var repositories []*repository
+	byPath := make(map[string]*repository)
+	for _, app := range apps {
+		for _, source := range app.app.AllSources() {
+			provider, host, projectPath, ok := c.repos.ForRepoURL(source.RepoURL)
+			if !ok {
+				continue
+			}
+			key := host + "/" + projectPath
+			repo, seen := byPath[key]
+			if !seen {
+				project, err := provider.GetProjectByPath(ctx, projectPath)
+				if err != nil {
+					report.Errors = append(report.Errors, fmt.Sprintf("Failed to get %s project %s: %v", provider.Name(), projectPath, err))
+					byPath[key] = nil
+					continue
+				}
+				repo = &repository{
+					provider:    provider.Name(),
+					projectID:   c.repos.QualifiedID(host, project),
+					projectPath: projectPath,
+				}
+				byPath[key] = repo
+				repositories = append(repositories, repo)
+			}
+			if repo != nil && !containsApp(repo.apps, app) {
+				repo.apps = append(repo.apps, app)
+			}
+		}
+	}
+
+	var changes []k8s.ClusterChange
+	for _, repo := range repositories {
+		mergeRequests, err := c.repos.ListMergedMergeRequests(ctx, repo.projectID, since)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("Failed to list merged merge requests of %s: %v", repo.projectPath, err))
+		}
+		for _, mr := range mergeRequests {
+			mergedAt, ok := timestamp(mr.MergedAt)
+			if !ok || !inWindow(mergedAt) {
+				continue
+			}
+			var touched []k8s.ResourceRef
+			for _, app := range repo.apps {
+				if app.revisions[mr.MergeCommitSHA] || app.revisions[mr.SquashCommitSHA] {
+					touched = append(touched, app.resources...)
+				}
+			}
+			revision := mr.MergeCommitSHA
+			if revision == "" {
+				revision = mr.SquashCommitSHA
+			}
+			changes = append(changes, k8s.ClusterChange{
+				DetectedChange: models.DetectedChange{
+					Time:     mergedAt,
+					Type:     models.ChangeMergeRequest,
+					Source:   repo.provider,
+					Subject:  fmt.Sprintf("%s!%d", repo.projectPath, mr.IID),
+					Summary:  fmt.Sprintf("Merged %q by %s into %s", mr.Title, mr.Author.Name, mr.TargetBranch),
+					Revision: revision,
+					URL:      mr.WebURL,
+				},
+				Changed: touched,
+			})
+		}
+
+		environments := make(map[string][]*changedApp)
+		for _, app := range repo.apps {
+			if app.environment != "" {
+				environments[app.environment] = append(environments[app.environment], app)
+			}
+		}
+		for environment, envApps := range environments {
+			deployments, err := c.repos.FindRecentDeployments(ctx, repo.projectID, environment)
+			if err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("Failed to find deployments of %s to %s: %v", repo.projectPath, environment, err))
+				continue
+			}
+			var touched []k8s.ResourceRef
+			for _, app := range envApps {
+				touched = append(touched, app.resources...)
+			}
+			for _, deployment := range deployments {
+				deployedAt, ok := timestamp(deployment.CreatedAt)
+				if !ok || !inWindow(deployedAt) {
+					continue
+				}
+				changes = append(changes, k8s.ClusterChange{
+					DetectedChange: models.DetectedChange{
+						Time:     deployedAt,
+						Type:     models.ChangeDeployment,
+						Source:   repo.provider,
+						Subject:  repo.projectPath,
+						Summary:  fmt.Sprintf("Deployment %d to %s of %s", deployment.ID, environment, shortRevision(deployment.Commit.ID)),
+						Revision: deployment.Commit.ID,
+					},
+					Changed: touched,
+				})
+			}
+		}
+	}
+	return changes
+}
+
+// warningEvents returns the Warning events of the scope that were still occurring
+// within the window
+func (c *GitOpsCorrelator) warningEvents(
+	ctx context.Context,
+	k8sClient *k8s.Client,
+	namespace string,
+	since, until time.Time,
+	report *models.ChangeReport,
+) []models.K8sEvent {
+	var events []models.K8sEvent
+	var err error
+	if namespace == "" {
+		events, err = k8sClient.GetRecentWarningEvents(ctx, time.Since(since))
+	} else {
+		events, err = k8sClient.GetNamespaceEvents(ctx, namespace)
+	}
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("Failed to get warning events: %v", err))
+		return nil
+	}
+
+	var warnings []models.K8sEvent
+	for _, event := range events {
+		if event.Type != "Warning" || event.LastTime.Before(since) || event.FirstTime.After(until) {
+			continue
+		}
+		warnings = append(warnings, event)
+	}
+	return warnings
+}
+
+// warningOnset returns when the earliest warning that first occurred within the window
+// started, and the resources, as namespace/Kind/name, with any warning. A warning that
+// started before the window, such as a crash loop running for days, marks its resource
+// but does not set the onset, since no change in the window can have caused it.
+func warningOnset(warnings []models.K8sEvent, since time.Time) (time.Time, map[string]bool) {
+	warned := make(map[string]bool)
+	var onset time.Time
+	for _, event := range warnings {
+		warned[event.Object.Namespace+"/"+event.Object.Kind+"/"+event.Object.Name] = true
+		if event.FirstTime.Before(since) {
+			continue
+		}
+		if onset.IsZero() || event.FirstTime.Before(onset) {
+			onset = event.FirstTime
+		}
+	}
+	return onset, warned
+}
+
+// RankChanges scores changes by how likely each is to have caused the warnings, given
+// when the warnings started and the resources, as namespace/Kind/name, that have
+// warnings. Changes shortly before the warnings started score highest, and ones after
+// them lowest; touching a warned resource, or applying to the cluster directly rather
+// than upstream in a repository, adds to the score. When the warnings started before
+// the window only the resources they touch are scored, and without warnings the most
+// recent changes rank first. The changes are ordered and numbered in place.
+func RankChanges(changes []models.DetectedChange, warningsStarted time.Time, warned map[string]bool) {
+	for i := range changes {
+		change := &changes[i]
+		change.Suspicion, change.Reasons = 0, nil
+		if warningsStarted.IsZero() && len(warned) == 0 {
+			continue
+		}
+
+		lead := warningsStarted.Sub(change.Time)
+		switch {
+		case warningsStarted.IsZero():
+		case lead < 0:
+			change.Suspicion = 5
+			change.Reasons = append(change.Reasons, fmt.Sprintf("%s after warnings started", formatLead(-lead)))
+		case lead <= 30*time.Minute:
+			change.Suspicion = 60
+			change.Reasons = append(change.Reasons, fmt.Sprintf("%s before warnings started", formatLead(lead)))
+		case lead <= 2*time.Hour:
+			change.Suspicion = 40
+			change.Reasons = append(change.Reasons, fmt.Sprintf("%s before warnings started", formatLead(lead)))
+		default:
+			change.Suspicion = 20
+			change.Reasons = append(change.Reasons, fmt.Sprintf("%s before warnings started", formatLead(lead)))
+		}
+
+		var warnedResources []string
+		for _, resource := range change.Resources {
+			if warned[resource] {
+				warnedResources = append(warnedResources, resource)
+			}
+		}
+		if len(warnedResources) > 0 {
+			change.Suspicion += 30
+			if len(warnedResources) > 3 {
+				warnedResources = append(warnedResources[:3], fmt.Sprintf("%d more", len(warnedResources)-3))
+			}
+			change.Reasons = append(change.Reasons, "touches resources with warnings: "+strings.Join(warnedResources, ", "))
+		}
+
+		switch change.Type {
+		case models.ChangeRollout, models.ChangeConfig, models.ChangeSync:
+			change.Suspicion += 10
+			change.Reasons = append(change.Reasons, "applied to the cluster directly")
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if a.Suspicion != b.Suspicion {
+			return a.Suspicion > b.Suspicion
+		}
+		if !warningsStarted.IsZero() {
+			if da, db := absDuration(warningsStarted.Sub(a.Time)), absDuration(warningsStarted.Sub(b.Time)); da != db {
+				return da < db
+			}
+		}
+		return a.Time.After(b.Time)
+	})
+	for i := range changes {
+		changes[i].Rank = i + 1
+	}
+}
+
+// subjectKind returns the kind of resource a change is to, or "" for changes to a
+// repository. Rollouts and config changes list the resource they are to first.
+func subjectKind(change *k8s.ClusterChange) string {
+	switch change.Type {
+	case models.ChangeRollout, models.ChangeConfig:
+		if len(change.Changed) > 0 {
+			return change.Changed[0].Kind
+		}
+	case models.ChangeSync:
+		return "application"
+	}
+	return ""
+}
+
+func appendResource(resources []string, ref k8s.ResourceRef) []string {
+	resource := ref.Namespace + "/" + ref.Kind + "/" + ref.Name
+	for _, existing := range resources {
+		if existing == resource {
+			return resources
+		}
+	}
+	return append(resources, resource)
+}
+
+func containsApp(apps []*changedApp, app *changedApp) bool {
+	for _, a := range apps {
+		if a == app {
+			return true
+		}
+	}
+	return false
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// formatLead formats the time between a change and the first warning to the minute
+func formatLead(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Minute {
+		return "under a minute"
+	}
+	return strings.TrimSuffix(d.String(), "0s")
+}
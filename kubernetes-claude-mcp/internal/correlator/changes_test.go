@@ -0,0 +1,128 @@
+package correlator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+func TestRankChanges(t *testing.T) {
+	warningsStarted := time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+	warned := map[string]bool{"prod/Pod/web-6b2c-x2k": true}
+
+	changes := []models.DetectedChange{
+		{Time: warningsStarted.Add(-5 * time.Hour), Type: models.ChangeConfig, Subject: "ConfigMap/settings",
+			Resources: []string{"prod/ConfigMap/settings"}},
+		{Time: warningsStarted.Add(-20 * time.Minute), Type: models.ChangeMergeRequest, Subject: "team/web!12",
+			Resources: []string{"prod/Deployment/web"}},
+		{Time: warningsStarted.Add(-10 * time.Minute), Type: models.ChangeRollout, Subject: "Deployment/web",
+			Resources: []string{"prod/Deployment/web", "prod/ReplicaSet/web-6b2c", "prod/Pod/web-6b2c-x2k"}},
+		{Time: warningsStarted.Add(15 * time.Minute), Type: models.ChangeSync, Subject: "Application/web"},
+		{Time: warningsStarted.Add(-90 * time.Minute), Type: models.ChangeDeployment, Subject: "team/api"},
+	}
+	RankChanges(changes, warningsStarted, warned)
+
+	type ranked struct {
+		rank      int
+		subject   string
+		suspicion int
+	}
+	var got []ranked
+	for _, c := range changes {
+		got = append(got, ranked{c.Rank, c.Subject, c.Suspicion})
+	}
+	want := []ranked{
+		{1, "Deployment/web", 100},
+		{2, "team/web!12", 60},
+		{3, "team/api", 40},
+		{4, "ConfigMap/settings", 30},
+		{5, "Application/web", 15},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ranking = %v, want %v", got, want)
+	}
+	if reasons := changes[0].Reasons; len(reasons) != 3 || reasons[0] != "10m before warnings started" {
+		t.Errorf("reasons = %q", reasons)
+	}
+
+	// Without warnings the most recent change ranks first
+	RankChanges(changes, time.Time{}, nil)
+	if changes[0].Subject != "Application/web" || changes[0].Suspicion != 0 || changes[0].Reasons != nil {
+		t.Errorf("first change without warnings = %+v", changes[0])
+	}
+}
+
+func TestRankChangesWarningsBeforeWindow(t *testing.T) {
+	since := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+
+	crashLoop := models.K8sEvent{Reason: "BackOff", Type: "Warning", FirstTime: since.Add(-72 * time.Hour), LastTime: since.Add(time.Hour)}
+	crashLoop.Object.Kind = "Pod"
+	crashLoop.Object.Namespace = "prod"
+	crashLoop.Object.Name = "worker-7f3a-z9q"
+	probe := models.K8sEvent{Reason: "Unhealthy", Type: "Warning", FirstTime: since.Add(2 * time.Hour), LastTime: since.Add(3 * time.Hour)}
+	probe.Object.Kind = "Pod"
+	probe.Object.Namespace = "prod"
+	probe.Object.Name = "web-6b2c-x2k"
+
+	// The crash loop predates the window, so the probe failures set the onset
+	onset, warned := warningOnset([]models.K8sEvent{crashLoop, probe}, since)
+	if !onset.Equal(probe.FirstTime) {
+		t.Errorf("onset = %s, want %s", onset, probe.FirstTime)
+	}
+	if !warned["prod/Pod/worker-7f3a-z9q"] || !warned["prod/Pod/web-6b2c-x2k"] {
+		t.Errorf("warned = %v, want both pods", warned)
+	}
+
+	changes := []models.DetectedChange{
+		{Time: since.Add(30 * time.Minute), Type: models.ChangeConfig, Subject: "ConfigMap/settings"},
+		{Time: since.Add(100 * time.Minute), Type: models.ChangeRollout, Subject: "Deployment/web",
+			Resources: []string{"prod/Deployment/web", "prod/Pod/web-6b2c-x2k"}},
+	}
+	RankChanges(changes, onset, warned)
+	if changes[0].Subject != "Deployment/web" || changes[0].Suspicion != 100 || changes[1].Suspicion != 50 {
+		t.Errorf("ranking = %+v", changes)
+	}
+
+	// With only the old crash loop there is no onset, but touching it still counts
+	onset, warned = warningOnset([]models.K8sEvent{crashLoop}, since)
+	if !onset.IsZero() {
+		t.Errorf("onset = %s, want none for a warning that started before the window", onset)
+	}
+	changes = []models.DetectedChange{
+		{Time: since.Add(3 * time.Hour), Type: models.ChangeMergeRequest, Subject: "team/web!12"},
+		{Time: since.Add(time.Hour), Type: models.ChangeRollout, Subject: "Deployment/worker",
+			Resources: []string{"prod/Deployment/worker", "prod/Pod/worker-7f3a-z9q"}},
+	}
+	RankChanges(changes, onset, warned)
+	if changes[0].Subject != "Deployment/worker" || changes[0].Suspicion != 40 || changes[1].Suspicion != 0 {
+		t.Errorf("ranking without an onset = %+v", changes)
+	}
+	for _, reason := range changes[0].Reasons {
+		if strings.Contains(reason, "after warnings started") {
+			t.Errorf("change scored against a warning from before the window: %q", reason)
+		}
+	}
+}
+
+func TestSubjectKind(t *testing.T) {
+	tests := []struct {
+		change k8s.ClusterChange
+		want   string
+	}{
+		{k8s.ClusterChange{DetectedChange: models.DetectedChange{Type: models.ChangeConfig},
+			Changed: []k8s.ResourceRef{{Kind: "Secret", Namespace: "prod", Name: "web-tls"}}}, "Secret"},
+		{k8s.ClusterChange{DetectedChange: models.DetectedChange{Type: models.ChangeRollout},
+			Changed: []k8s.ResourceRef{{Kind: "Deployment", Namespace: "prod", Name: "web"}, {Kind: "ReplicaSet", Namespace: "prod", Name: "web-6b2c"}}}, "Deployment"},
+		{k8s.ClusterChange{DetectedChange: models.DetectedChange{Type: models.ChangeSync}}, "application"},
+		{k8s.ClusterChange{DetectedChange: models.DetectedChange{Type: models.ChangeMergeRequest}}, ""},
+	}
+	for _, tt := range tests {
+		if got := subjectKind(&tt.change); got != tt.want {
+			t.Errorf("subjectKind(%s) = %q, want %q", tt.change.Type, got, tt.want)
+		}
+	}
+}
@@ -7,40 +7,191 @@ import (
 	"time"
 
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/argocd"
-	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/gitlab"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/helm"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/manifest"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/policy"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
-// GitOpsCorrelator correlates data between Kubernetes, ArgoCD, and GitLab
+// GitOpsCorrelator correlates data between Kubernetes, the ArgoCD applications or Flux
+// objects that deploy resources, and the GitLab or GitHub repositories they deploy from
 type GitOpsCorrelator struct {
-	k8sClient      *k8s.Client
+	clusters       *k8s.ClusterRegistry
 	argoClient     *argocd.Client
-	gitlabClient   *gitlab.Client
+	repos          *scm.Registry
+	backends       []GitOpsBackend
 	helmCorrelator *HelmCorrelator
 	logger         *logging.Logger
 }
 
 // NewGitOpsCorrelator creates a new GitOps correlator
-func NewGitOpsCorrelator(k8sClient *k8s.Client, argoClient *argocd.Client, gitlabClient *gitlab.Client, logger *logging.Logger) *GitOpsCorrelator {
+func NewGitOpsCorrelator(clusters *k8s.ClusterRegistry, argoClient *argocd.Client, repos *scm.Registry, logger *logging.Logger) *GitOpsCorrelator {
 	if logger == nil {
 		logger = logging.NewLogger().Named("correlator")
 	}
 
 	correlator := &GitOpsCorrelator{
-		k8sClient:    k8sClient,
-		argoClient:   argoClient,
-		gitlabClient: gitlabClient,
-		logger:       logger,
+		clusters:   clusters,
+		argoClient: argoClient,
+		repos:      repos,
+		logger:     logger,
 	}
 
+	// Resources are traced through ArgoCD first, then through Flux's labels
+	if argoClient != nil {
+		correlator.backends = append(correlator.backends, &argoBackend{
+			client:            argoClient,
+			selectApplication: correlator.selectApplication,
+			mask: func(obj map[string]interface{}) map[string]interface{} {
+				return correlator.helmCorrelator.mask(obj)
+			},
+			logger: logger.Named("argocd"),
+		})
+	}
+	correlator.backends = append(correlator.backends, &fluxBackend{logger: logger.Named("flux")})
+
 	// Initialize the Helm correlator
-	correlator.helmCorrelator = NewHelmCorrelator(gitlabClient, logger.Named("helm"))
+	correlator.helmCorrelator = NewHelmCorrelator(repos, logger.Named("helm"))
 
 	return correlator
 }
 
+// WithBackend adds a GitOps backend, consulted after the built-in ones when tracing
+// resources
+func (c *GitOpsCorrelator) WithBackend(backend GitOpsBackend) *GitOpsCorrelator {
+	c.backends = append(c.backends, backend)
+	return c
+}
+
+// WithHelmCorrelator replaces the Helm correlator used to render charts
+func (c *GitOpsCorrelator) WithHelmCorrelator(helmCorrelator *HelmCorrelator) *GitOpsCorrelator {
+	c.helmCorrelator = helmCorrelator
+	return c
+}
+
+// DiffMergeRequestManifests renders the charts, kustomizations and manifest directories
+// a merge request touches at its base and head commits and returns the per-resource
+// differences. The kustomize overlays of ArgoCD applications that depend on a changed
+// file are rendered too.
+func (c *GitOpsCorrelator) DiffMergeRequestManifests(ctx context.Context, projectID string, mergeRequestIID int) ([]models.ManifestDiff, error) {
+	c.logger.Info("Diffing merge request manifests", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+
+	var overlays []string
+	mr, err := c.repos.GetMergeRequestChanges(ctx, projectID, mergeRequestIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request changes: %w", err)
+	}
+	var files []string
+	for _, change := range mr.Changes {
+		files = append(files, change.NewPath, change.OldPath)
+	}
+
+	argoApps, err := c.argoClient.ListApplications(ctx)
+	if err != nil {
+		c.logger.Warn("Failed to list ArgoCD applications for kustomize overlays", "error", err)
+	} else {
+		projectPath := c.projectPath(ctx, projectID)
+		kustomizeDeps := make(map[string]bool)
+		for _, app := range argoApps {
+			app := app // Create a copy to avoid memory aliasing
+			if paths := c.appPaths(&app, projectID, projectPath); paths.sourced {
+				overlays = append(overlays,
+					c.kustomizationsUsingFiles(ctx, projectID, mr.DiffRefs.HeadSHA, &app, paths.dirs, files, kustomizeDeps)...)
+			}
+		}
+	}
+
+	return c.helmCorrelator.DiffMergeRequest(ctx, projectID, mergeRequestIID, overlays)
+}
+
+// CheckMergeRequestPolicy renders the manifests a merge request touches and checks the
+// objects it adds, modifies and removes against the policy rules, without involving
+// Claude, so the verdict can gate the merge request
+func (c *GitOpsCorrelator) CheckMergeRequestPolicy(ctx context.Context, projectID string, mergeRequestIID int) (*models.PolicyResult, error) {
+	diffs, err := c.DiffMergeRequestManifests(ctx, projectID, mergeRequestIID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := policy.Result(diffs)
+	c.logger.Info("Checked merge request against policy",
+		"projectID", projectID,
+		"mergeRequestIID", mergeRequestIID,
+		"verdict", result.Verdict,
+		"violations", len(result.Violations))
+	return result, nil
+}
+
+// MergeRequestFindings flags risky lines in the YAML files a merge request changes,
+// each located by file and line so it can be pinned to the diff
+func (c *GitOpsCorrelator) MergeRequestFindings(ctx context.Context, projectID string, mergeRequestIID int) ([]models.ManifestFinding, error) {
+	mr, err := c.repos.GetMergeRequestChanges(ctx, projectID, mergeRequestIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request changes: %w", err)
+	}
+
+	findings := manifest.Findings(mr.Changes)
+	c.logger.Debug("Scanned merge request for risky changes",
+		"projectID", projectID,
+		"mergeRequestIID", mergeRequestIID,
+		"findings", len(findings))
+	return findings, nil
+}
+
+// projectPath returns a project's path with namespace, falling back to the ID
+func (c *GitOpsCorrelator) projectPath(ctx context.Context, projectID string) string {
+	project, err := c.repos.GetProject(ctx, projectID)
+	if err == nil && project != nil {
+		return project.PathWithNamespace
+	}
+	return projectID
+}
+
+// kustomizationsUsingFiles returns the source paths of an application that deploy a
+// kustomization that, at a ref, includes any of the files, such as an overlay whose
+// base changed. Results are cached by source path.
+func (c *GitOpsCorrelator) kustomizationsUsingFiles(
+	ctx context.Context,
+	projectID, ref string,
+	app *models.ArgoApplication,
+	sourcePaths, files []string,
+	cache map[string]bool,
+) []string {
+	if ref == "" {
+		return nil
+	}
+
+	var result []string
+	for _, sourcePath := range sourcePaths {
+		if sourcePath == "" {
+			continue
+		}
+		affected, ok := cache[sourcePath]
+		if !ok {
+			deps, found, err := c.helmCorrelator.KustomizationFiles(ctx, projectID, sourcePath, ref)
+			if err != nil {
+				c.logger.Warn("Failed to resolve kustomization", "app", app.Name, "path", sourcePath, "error", err)
+			} else if found {
+				for _, file := range files {
+					if _, ok := deps[file]; ok {
+						affected = true
+						break
+					}
+				}
+			}
+			cache[sourcePath] = affected
+		}
+		if affected {
+			result = append(result, sourcePath)
+		}
+	}
+	return result
+}
+
 // AnalyzeMergeRequest analyzes a GitLab merge request and identifies affected Kubernetes resources
 func (c *GitOpsCorrelator) AnalyzeMergeRequest(
 	ctx context.Context,
@@ -50,13 +201,15 @@ func (c *GitOpsCorrelator) AnalyzeMergeRequest(
 	c.logger.Info("Analyzing merge request", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
 
 	// Get merge request details
-	mergeRequest, err := c.gitlabClient.AnalyzeMergeRequest(ctx, projectID, mergeRequestIID)
+	mergeRequest, err := c.repos.AnalyzeMergeRequest(ctx, projectID, mergeRequestIID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to analyze merge request: %w", err)
 	}
 
-	// Check if the MR affects Helm charts or Kubernetes manifests
-	if !mergeRequest.MergeRequestContext.HelmChartAffected && !mergeRequest.MergeRequestContext.KubernetesManifest {
+	// Check if the MR affects Helm charts, kustomizations or Kubernetes manifests
+	if !mergeRequest.MergeRequestContext.HelmChartAffected &&
+		!mergeRequest.MergeRequestContext.KustomizeAffected &&
+		!mergeRequest.MergeRequestContext.KubernetesManifest {
 		c.logger.Info("Merge request does not affect Kubernetes resources")
 		return []models.ResourceContext{}, nil
 	}
@@ -68,11 +221,7 @@ func (c *GitOpsCorrelator) AnalyzeMergeRequest(
 	}
 
 	// Find the project path
-	projectPath := projectID
-	project, err := c.gitlabClient.GetProject(ctx, projectID)
-	if err == nil && project != nil {
-		projectPath = project.PathWithNamespace
-	}
+	projectPath := c.projectPath(ctx, projectID)
 
 	// For Helm-affected MRs, analyze Helm changes
 	var helmAffectedResources []string
@@ -88,15 +237,16 @@ func (c *GitOpsCorrelator) AnalyzeMergeRequest(
 
 	// Identify potentially affected applications
 	var affectedApps []models.ArgoApplication
+	kustomizeDeps := make(map[string]bool)
 	for _, app := range argoApps {
 		app := app // Create a copy to avoid memory aliasing
-		if isAppSourcedFromProject(&app, projectPath) {
+		if paths := c.appPaths(&app, projectID, projectPath); paths.sourced {
 			// For each file changed in the MR, check if it affects the app
 			isAffected := false
 
-			// Check if any changed file affects the app
+			// Check if any changed file is under a source path or is a value file
 			for _, file := range mergeRequest.MergeRequestContext.AffectedFiles {
-				if isFileInAppSourcePath(&app, file) {
+				if paths.contains(file) {
 					isAffected = true
 					break
 				}
@@ -109,6 +259,12 @@ func (c *GitOpsCorrelator) AnalyzeMergeRequest(
 				}
 			}
 
+			// Check kustomize overlays built from changed bases, components or patches
+			if !isAffected && len(c.kustomizationsUsingFiles(ctx, projectID, mergeRequest.DiffRefs.HeadSHA, &app,
+				paths.dirs, mergeRequest.MergeRequestContext.AffectedFiles, kustomizeDeps)) > 0 {
+				isAffected = true
+			}
+
 			if isAffected {
 				affectedApps = append(affectedApps, app)
 			}
@@ -118,8 +274,18 @@ func (c *GitOpsCorrelator) AnalyzeMergeRequest(
 	// For each affected app, identify the resources that would be affected
 	var result []models.ResourceContext
 	for _, app := range affectedApps {
+		app := app // Create a copy to avoid memory aliasing
 		c.logger.Info("Found potentially affected ArgoCD application", "app", app.Name)
 
+		// Resources live in the cluster the application deploys to
+		cluster, ok := c.clusterForApp(&app)
+		if !ok {
+			c.logger.Warn("ArgoCD application targets an unregistered cluster",
+				"app", app.Name,
+				"server", app.Spec.Destination.Server)
+			continue
+		}
+
 		// Get resources managed by this application
 		tree, err := c.argoClient.GetResourceTree(ctx, app.Name)
 		if err != nil {
@@ -135,13 +301,14 @@ func (c *GitOpsCorrelator) AnalyzeMergeRequest(
 			}
 
 			// Avoid unnecessary duplicates in the result
-			if isResourceAlreadyInResults(result, node.Kind, node.Name, node.Namespace) {
+			if isResourceAlreadyInResults(result, cluster, node.Kind, node.Name, node.Namespace) {
 				continue
 			}
 
 			// Trace the deployment for this resource
 			resourceContext, err := c.TraceResourceDeployment(
 				ctx,
+				cluster,
 				node.Namespace,
 				node.Kind,
 				node.Name,
@@ -179,14 +346,24 @@ func (c *GitOpsCorrelator) AnalyzeMergeRequest(
 	return result, nil
 }
 
-// TraceResourceDeployment traces the deployment history of a Kubernetes resource through GitOps
+// TraceResourceDeployment traces the deployment history of a Kubernetes resource through GitOps.
+// An empty cluster selects the default cluster.
 func (c *GitOpsCorrelator) TraceResourceDeployment(
 	ctx context.Context,
-	namespace, kind, name string,
+	cluster, namespace, kind, name string,
 ) (models.ResourceContext, error) {
-	c.logger.Info("Tracing resource deployment", "kind", kind, "name", name, "namespace", namespace)
+	c.logger.Info("Tracing resource deployment", "cluster", cluster, "kind", kind, "name", name, "namespace", namespace)
+
+	k8sClient, err := c.clusters.ForContext(ctx, cluster)
+	if err != nil {
+		return models.ResourceContext{}, err
+	}
+	if cluster == "" {
+		cluster = c.clusters.DefaultName()
+	}
 
 	resourceContext := models.ResourceContext{
+		Cluster:   cluster,
 		Kind:      kind,
 		Name:      name,
 		Namespace: namespace,
@@ -195,7 +372,11 @@ func (c *GitOpsCorrelator) TraceResourceDeployment(
 	var errors []string
 
 	// Get Kubernetes resource information with enhanced error handling
-	resource, err := c.k8sClient.GetResource(ctx, kind, namespace, name)
+	resource, err := k8sClient.GetResource(ctx, kind, namespace, name)
+	if apierrors.IsForbidden(err) {
+		// The caller's impersonated identity may not see this resource
+		return models.ResourceContext{}, err
+	}
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to get Kubernetes resource: %v", err)
 		errors = append(errors, errMsg)
@@ -205,7 +386,7 @@ func (c *GitOpsCorrelator) TraceResourceDeployment(
 		c.logger.Debug("Retrieved Kubernetes resource", "apiVersion", resourceContext.APIVersion)
 
 		// Get events related to this resource with better error handling
-		events, eventsErr := c.k8sClient.GetResourceEvents(ctx, namespace, kind, name)
+		events, eventsErr := k8sClient.GetResourceEvents(ctx, namespace, kind, name)
 		if eventsErr != nil {
 			errMsg := fmt.Sprintf("Failed to get resource events: %v", eventsErr)
 			errors = append(errors, errMsg)
@@ -215,102 +396,101 @@ func (c *GitOpsCorrelator) TraceResourceDeployment(
 			c.logger.Debug("Retrieved resource events", "eventCount", len(events))
 		}
 
+		// Resources installed by Helm point at their release, whose history shows which
+		// chart version and revision put them there
+		if releaseName, releaseNamespace, ok := helmOwner(resource.GetLabels(), resource.GetAnnotations(), namespace); ok {
+			releases := helm.NewReleases(k8sClient.GetClientset(), c.logger.Named("helm"))
+			release, releaseErr := releases.History(ctx, releaseNamespace, releaseName)
+			if releaseErr != nil {
+				errMsg := fmt.Sprintf("Failed to get Helm release %s: %v", releaseName, releaseErr)
+				errors = append(errors, errMsg)
+				c.logger.Warn(errMsg, "namespace", releaseNamespace)
+			} else {
+				resourceContext.HelmRelease = release
+				c.logger.Debug("Found Helm release",
+					"release", release.Name,
+					"revision", release.Revision,
+					"status", release.Status)
+			}
+		}
+
 		// TODO: Add related resources discovery in future enhancement
 	}
 
-	// Find the ArgoCD application managing this resource with enhanced error handling
-	argoApps, err := c.argoClient.FindApplicationsByResource(ctx, kind, name, namespace)
-	if err != nil {
-		errMsg := fmt.Sprintf("Failed to find ArgoCD applications: %v", err)
-		errors = append(errors, errMsg)
-		c.logger.Warn(errMsg, "kind", kind, "name", name, "namespace", namespace)
-	} else if len(argoApps) > 0 {
-		// Use the first application that manages this resource
-		app := argoApps[0]
-		resourceContext.ArgoApplication = &app
-		resourceContext.ArgoSyncStatus = app.Status.Sync.Status
-		resourceContext.ArgoHealthStatus = app.Status.Health.Status
-
-		c.logger.Debug("Found ArgoCD application",
-			"appName", app.Name,
-			"syncStatus", app.Status.Sync.Status,
-			"healthStatus", app.Status.Health.Status)
-
-		// Get recent syncs
-		history, err := c.argoClient.GetApplicationHistory(ctx, app.Name)
+	// Ask each GitOps backend for the object that deploys this resource; the first
+	// one found names the repository to read commits and pipelines from
+	var source *GitOpsSource
+	for _, backend := range c.backends {
+		found, err := backend.TraceResource(ctx, k8sClient, resource, &resourceContext)
 		if err != nil {
-			errMsg := fmt.Sprintf("Failed to get application history: %v", err)
+			errMsg := fmt.Sprintf("Failed to trace %s owner: %v", backend.Name(), err)
 			errors = append(errors, errMsg)
-			c.logger.Warn(errMsg)
-		} else {
-			// Limit to recent syncs (last 5)
-			if len(history) > 5 {
-				history = history[:5]
-			}
-			resourceContext.ArgoSyncHistory = history
+			c.logger.Warn(errMsg, "kind", kind, "name", name, "namespace", namespace)
+		}
+		if found != nil && source == nil {
+			source = found
 		}
+	}
 
-		// Connect to GitLab if we have source information
-		if app.Spec.Source.RepoURL != "" {
-			// Extract GitLab project path from repo URL
-			projectPath := extractGitLabProjectPath(app.Spec.Source.RepoURL)
-			if projectPath != "" {
-				project, err := c.gitlabClient.GetProjectByPath(ctx, projectPath)
+	// Connect to the repository's SCM provider if we have source information
+	if source != nil && source.RepoURL != "" {
+		provider, host, projectPath, ok := c.repos.ForRepoURL(source.RepoURL)
+		if ok {
+			project, err := provider.GetProjectByPath(ctx, projectPath)
+			if err != nil {
+				errMsg := fmt.Sprintf("Failed to get %s project: %v", provider.Name(), err)
+				errors = append(errors, errMsg)
+				c.logger.Warn(errMsg)
+			} else {
+				resourceContext.GitLabProject = project
+				projectID := c.repos.QualifiedID(host, project)
+
+				// Get recent pipelines
+				pipelines, err := c.repos.ListPipelines(ctx, projectID)
 				if err != nil {
-					errMsg := fmt.Sprintf("Failed to get GitLab project: %v", err)
+					errMsg := fmt.Sprintf("Failed to list pipelines: %v", err)
 					errors = append(errors, errMsg)
 					c.logger.Warn(errMsg)
-				} else {
-					resourceContext.GitLabProject = project
-
-					// Get recent pipelines
-					pipelines, err := c.gitlabClient.ListPipelines(ctx, fmt.Sprintf("%d", project.ID))
-					if err != nil {
-						errMsg := fmt.Sprintf("Failed to list pipelines: %v", err)
-						errors = append(errors, errMsg)
-						c.logger.Warn(errMsg)
-					} else if len(pipelines) > 0 {
-						// Get the latest pipeline
-						resourceContext.LastPipeline = &pipelines[0]
-					}
-
-					// Find environment from ArgoCD application
-					environment := extractEnvironmentFromArgoApp(&app)
-					if environment != "" {
-						// Get recent deployments to this environment
-						deployments, deploymentsErr := c.gitlabClient.FindRecentDeployments(
-							ctx,
-							fmt.Sprintf("%d", project.ID),
-							environment,
-						)
-						if deploymentsErr != nil {
-							errMsg := fmt.Sprintf("Failed to find deployments: %v", deploymentsErr)
-							errors = append(errors, errMsg)
-							c.logger.Warn(errMsg)
-						} else if len(deployments) > 0 {
-							resourceContext.LastDeployment = &deployments[0]
-						}
-					}
+				} else if len(pipelines) > 0 {
+					// Get the latest pipeline
+					resourceContext.LastPipeline = &pipelines[0]
+				}
 
-					// Get recent commits
-					sinceTime := time.Now().Add(-24 * time.Hour) // Last 24 hours
-					commits, err := c.gitlabClient.FindRecentChanges(
+				// Find recent deployments to the source's environment
+				if environment := source.Environment; environment != "" {
+					// Get recent deployments to this environment
+					deployments, deploymentsErr := c.repos.FindRecentDeployments(
 						ctx,
-						fmt.Sprintf("%d", project.ID),
-						sinceTime,
+						projectID,
+						environment,
 					)
-					if err != nil {
-						errMsg := fmt.Sprintf("Failed to find recent changes: %v", err)
+					if deploymentsErr != nil {
+						errMsg := fmt.Sprintf("Failed to find deployments: %v", deploymentsErr)
 						errors = append(errors, errMsg)
 						c.logger.Warn(errMsg)
-					} else {
-						// Here we'll limit to recent commits (last 5)...
-						if len(commits) > 5 {
-							commits = commits[:5]
-						}
-						resourceContext.RecentCommits = commits
+					} else if len(deployments) > 0 {
+						resourceContext.LastDeployment = &deployments[0]
 					}
 				}
+
+				// Get recent commits
+				sinceTime := time.Now().Add(-24 * time.Hour) // Last 24 hours
+				commits, err := c.repos.FindRecentChanges(
+					ctx,
+					projectID,
+					sinceTime,
+				)
+				if err != nil {
+					errMsg := fmt.Sprintf("Failed to find recent changes: %v", err)
+					errors = append(errors, errMsg)
+					c.logger.Warn(errMsg)
+				} else {
+					// Here we'll limit to recent commits (last 5)...
+					if len(commits) > 5 {
+						commits = commits[:5]
+					}
+					resourceContext.RecentCommits = commits
+				}
 			}
 		}
 	}
@@ -323,21 +503,46 @@ func (c *GitOpsCorrelator) TraceResourceDeployment(
 		"name", name,
 		"namespace", namespace,
 		"argoApp", resourceContext.ArgoApplication != nil,
+		"fluxObject", resourceContext.FluxObject != nil,
 		"gitlabProject", resourceContext.GitLabProject != nil,
 		"errors", len(errors))
 
 	return resourceContext, nil
 }
 
-// isFileInAppSourcePath checks if a file is in the application's source path
-func isFileInAppSourcePath(app *models.ArgoApplication, file string) bool {
-	sourcePath := app.Spec.Source.Path
-	if sourcePath == "" {
-		// If no specific path is provided, any file could affect the app
-		return true
+// clusterForApp returns the registered cluster an ArgoCD application deploys to. With a
+// single registered cluster every application is assumed to target it, since Argo often
+// reaches the cluster through a different URL than the local kubeconfig.
+func (c *GitOpsCorrelator) clusterForApp(app *models.ArgoApplication) (string, bool) {
+	destination := app.Spec.Destination
+	if destination.Server == "" && destination.Name != "" {
+		if _, err := c.clusters.Get(destination.Name); err == nil {
+			return destination.Name, true
+		}
+		if destination.Name == "in-cluster" {
+			return c.clusters.ClusterForServer(k8s.InClusterServer)
+		}
+	} else if cluster, ok := c.clusters.ClusterForServer(destination.Server); ok {
+		return cluster, true
+	}
+
+	if len(c.clusters.Names()) == 1 {
+		return c.clusters.DefaultName(), true
 	}
+	return "", false
+}
 
-	return strings.HasPrefix(file, sourcePath)
+// selectApplication picks the application that deploys to the given cluster. ArgoCD
+// matches resources by kind, name and namespace only, so the same resource can belong
+// to applications on several clusters.
+func (c *GitOpsCorrelator) selectApplication(apps []models.ArgoApplication, cluster string) (models.ArgoApplication, bool) {
+	for _, app := range apps {
+		app := app // Create a copy to avoid memory aliasing
+		if appCluster, ok := c.clusterForApp(&app); ok && appCluster == cluster {
+			return app, true
+		}
+	}
+	return models.ArgoApplication{}, false
 }
 
 // hasHelmChanges checks if any of the changed files are related to Helm charts
@@ -400,210 +605,269 @@ func (c *GitOpsCorrelator) FindResourcesAffectedByCommit(
 	projectID string,
 	commitSHA string,
 ) ([]models.ResourceContext, error) {
-	c.logger.Info("Finding resources affected by commit", "projectID", projectID, "commitSHA", commitSHA)
+	_, result, err := c.AnalyzeCommit(ctx, projectID, commitSHA)
+	return result, err
+}
 
-	var result []models.ResourceContext
+// AnalyzeCommit finds the ArgoCD applications, environments and live resources a commit
+// affects. An application is affected when a changed file is under one of its source
+// paths or is one of its Helm value files, or a changed Helm chart renders a resource
+// it manages. The resources each application
+// manages directly are traced and returned for analysis.
+func (c *GitOpsCorrelator) AnalyzeCommit(
+	ctx context.Context,
+	projectID string,
+	commitSHA string,
+) (*models.CommitImpact, []models.ResourceContext, error) {
+	c.logger.Info("Analyzing commit impact", "projectID", projectID, "commitSHA", commitSHA)
 
 	// Get commit information from GitLab
-	commit, err := c.gitlabClient.GetCommit(ctx, projectID, commitSHA)
+	commit, err := c.repos.GetCommit(ctx, projectID, commitSHA)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get commit: %w", err)
+		return nil, nil, fmt.Errorf("failed to get commit: %w", err)
 	}
 	c.logger.Info("Processing commit", "author", commit.AuthorName, "message", commit.Title)
 
 	// Get commit diff to see what files were changed
-	diffs, err := c.gitlabClient.GetCommitDiff(ctx, projectID, commitSHA)
+	diffs, err := c.repos.GetCommitDiff(ctx, projectID, commitSHA)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get commit diff: %w", err)
+		return nil, nil, fmt.Errorf("failed to get commit diff: %w", err)
 	}
 
 	// Get all ArgoCD applications
 	argoApps, err := c.argoClient.ListApplications(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list ArgoCD applications: %w", err)
+		return nil, nil, fmt.Errorf("failed to list ArgoCD applications: %w", err)
 	}
 
 	// Find applications that use this GitLab project as source
-	projectPath := projectID // This might need more parsing depending on projectID format
-	project, err := c.gitlabClient.GetProject(ctx, projectID)
+	projectPath := projectID
+	project, err := c.repos.GetProject(ctx, projectID)
 	if err == nil && project != nil {
 		projectPath = project.PathWithNamespace
 	}
 
-	// For each application, check if it's affected by the changed files
+	impact := &models.CommitImpact{
+		ProjectID:    projectID,
+		CommitSHA:    commitSHA,
+		Commit:       commit,
+		ChangedFiles: changedFiles(diffs),
+		Applications: []models.AffectedApplication{},
+		Environments: []string{},
+		Resources:    []models.AffectedResource{},
+		Diffs:        diffs,
+	}
+
+	// Render changed Helm charts to find the resources they produce
+	defer c.helmCorrelator.Cleanup()
+	helmResources, err := c.helmCorrelator.AnalyzeCommitHelmChanges(ctx, projectID, commitSHA)
+	if err != nil {
+		c.logger.Warn("Failed to analyze Helm changes in commit", "error", err)
+	} else if len(helmResources) > 0 {
+		impact.HelmResources = helmResources
+		c.logger.Info("Found resources affected by Helm changes in commit", "count", len(helmResources))
+	}
+
+	var result []models.ResourceContext
+	environments := make(map[string]bool)
+	kustomizeDeps := make(map[string]bool)
 	for _, app := range argoApps {
 		app := app // Create a copy to avoid memory aliasing
-		if !isAppSourcedFromProject(&app, projectPath) {
+		paths := c.appPaths(&app, projectID, projectPath)
+		if !paths.sourced {
 			continue
 		}
 
-		// Check if the commit affects files used by this application
-		if isAppAffectedByDiffs(&app, diffs) {
-			c.logger.Info("Found affected ArgoCD application", "app", app.Name)
+		var reason string
+		switch {
+		case paths.affectedBy(diffs):
+			reason = "path"
+		case len(helmResources) > 0 && appContainsAnyResource(ctx, c.argoClient, &app, helmResources):
+			reason = "helm"
+		case len(c.kustomizationsUsingFiles(ctx, projectID, commitSHA, &app, paths.dirs, impact.ChangedFiles, kustomizeDeps)) > 0:
+			reason = "kustomize"
+		default:
+			continue
+		}
+		c.logger.Info("Found affected ArgoCD application", "app", app.Name, "reason", reason)
+
+		source, _ := app.PrimarySource()
+		affected := models.AffectedApplication{
+			Name:           app.Name,
+			Namespace:      app.Spec.Destination.Namespace,
+			Environment:    extractEnvironmentFromArgoApp(&app),
+			SourcePath:     source.Path,
+			TargetRevision: source.TargetRevision,
+			SyncStatus:     app.Status.Sync.Status,
+			HealthStatus:   app.Status.Health.Status,
+			Reason:         reason,
+		}
+		if affected.Environment != "" && !environments[affected.Environment] {
+			environments[affected.Environment] = true
+			impact.Environments = append(impact.Environments, affected.Environment)
+		}
 
-			// Get resources managed by this application
-			tree, err := c.argoClient.GetResourceTree(ctx, app.Name)
-			if err != nil {
-				c.logger.Warn("Failed to get resource tree", "app", app.Name, "error", err)
+		// Resources live in the cluster the application deploys to
+		cluster, ok := c.clusterForApp(&app)
+		if !ok {
+			c.logger.Warn("ArgoCD application targets an unregistered cluster",
+				"app", app.Name,
+				"server", app.Spec.Destination.Server)
+			impact.Applications = append(impact.Applications, affected)
+			continue
+		}
+		affected.Cluster = cluster
+
+		// Get resources managed by this application
+		tree, err := c.argoClient.GetResourceTree(ctx, app.Name)
+		if err != nil {
+			c.logger.Warn("Failed to get resource tree", "app", app.Name, "error", err)
+			impact.Applications = append(impact.Applications, affected)
+			continue
+		}
+
+		for _, node := range tree.Nodes {
+			// Skip unnamed nodes and generated children such as ReplicaSets and Pods,
+			// which roll out with the resources that own them
+			if node.Kind == "" || node.Name == "" || len(node.ParentRefs) > 0 {
 				continue
 			}
 
-			// For each resource in the app, create a deployment info object
-			for _, node := range tree.Nodes {
-				// Skip non-Kubernetes resources or resources with no name/namespace
-				if node.Kind == "" || node.Name == "" {
-					continue
-				}
-
-				// Avoid unnecessary duplicates in the result
-				if isResourceAlreadyInResults(result, node.Kind, node.Name, node.Namespace) {
-					continue
-				}
+			affected.ResourceCount++
+			impact.Resources = append(impact.Resources, models.AffectedResource{
+				Cluster:     cluster,
+				Application: app.Name,
+				Kind:        node.Kind,
+				Namespace:   node.Namespace,
+				Name:        node.Name,
+				Health:      node.Health.Status,
+			})
 
-				// Trace the deployment for this resource
-				resourceContext, err := c.TraceResourceDeployment(
-					ctx,
-					node.Namespace,
-					node.Kind,
-					node.Name,
-				)
-				if err != nil {
-					c.logger.Warn("Failed to trace resource deployment",
-						"kind", node.Kind,
-						"name", node.Name,
-						"namespace", node.Namespace,
-						"error", err)
-					continue
-				}
+			// Avoid unnecessary duplicates in the result
+			if isResourceAlreadyInResults(result, cluster, node.Kind, node.Name, node.Namespace) {
+				continue
+			}
 
-				result = append(result, resourceContext)
+			// Trace the deployment for this resource
+			resourceContext, err := c.TraceResourceDeployment(
+				ctx,
+				cluster,
+				node.Namespace,
+				node.Kind,
+				node.Name,
+			)
+			if err != nil {
+				c.logger.Warn("Failed to trace resource deployment",
+					"kind", node.Kind,
+					"name", node.Name,
+					"namespace", node.Namespace,
+					"error", err)
+				continue
 			}
+
+			// Add source info
+			resourceContext.RelatedResources = append(resourceContext.RelatedResources,
+				fmt.Sprintf("Commit/%s", commitSHA))
+
+			result = append(result, resourceContext)
 		}
+
+		impact.Applications = append(impact.Applications, affected)
 	}
 
-	c.logger.Info("Found resources affected by commit",
+	c.logger.Info("Analysis of commit completed",
 		"projectID", projectID,
 		"commitSHA", commitSHA,
-		"resourceCount", len(result))
+		"applicationCount", len(impact.Applications),
+		"resourceCount", len(impact.Resources))
 
-	return result, nil
+	return impact, result, nil
 }
 
 // Helper functions
 
-// extractGitLabProjectPath extracts the GitLab project path from a repo URL
-func extractGitLabProjectPath(repoURL string) string {
-	// Handle different URL formats
-
-	// Format: https://gitlab.com/namespace/project.git
-	if strings.HasPrefix(repoURL, "https://") || strings.HasPrefix(repoURL, "http://") {
-		parts := strings.Split(repoURL, "/")
-		if len(parts) < 3 {
-			return ""
-		}
-
-		// Remove ".git" suffix if present
-		lastPart := parts[len(parts)-1]
-		if strings.HasSuffix(lastPart, ".git") {
-			parts[len(parts)-1] = lastPart[:len(lastPart)-4]
-		}
-
-		// Reconstruct path without protocol and domain
-		domainIndex := 2 // After http:// or https://
-		if len(parts) <= domainIndex+1 {
-			return ""
-		}
-
-		return strings.Join(parts[domainIndex+1:], "/")
-	}
-
-	// Format: git@gitlab.com:namespace/project.git
-	if strings.HasPrefix(repoURL, "git@") {
-		// Split at ":" to get the path part
-		parts := strings.Split(repoURL, ":")
-		if len(parts) != 2 {
-			return ""
-		}
-
-		// Remove ".git" suffix if present
-		pathPart := strings.TrimSuffix(parts[1], ".git")
-		return pathPart
-	}
-
-	return ""
-}
-
 // extractEnvironmentFromArgoApp tries to determine the environment from an ArgoCD application
 func extractEnvironmentFromArgoApp(app *models.ArgoApplication) string {
+	source, _ := app.PrimarySource()
+	return extractEnvironment(app.Metadata.Labels, app.Spec.Destination.Namespace, source.Path)
+}
+
+// extractEnvironment tries to determine the environment from a GitOps object's labels,
+// the namespace it deploys to and its source path
+func extractEnvironment(labels map[string]string, namespace, sourcePath string) string {
 	// Check for environment in labels
-	if env, ok := app.Metadata.Labels["environment"]; ok {
+	if env, ok := labels["environment"]; ok {
 		return env
 	}
-	if env, ok := app.Metadata.Labels["env"]; ok {
+	if env, ok := labels["env"]; ok {
 		return env
 	}
 
 	// Check if environment is in the destination namespace
-	if strings.Contains(app.Spec.Destination.Namespace, "prod") {
+	if strings.Contains(namespace, "prod") {
 		return "production"
 	}
-	if strings.Contains(app.Spec.Destination.Namespace, "staging") {
+	if strings.Contains(namespace, "staging") {
 		return "staging"
 	}
-	if strings.Contains(app.Spec.Destination.Namespace, "dev") {
+	if strings.Contains(namespace, "dev") {
 		return "development"
 	}
 
 	// Check path in source for environment indicators
-	if app.Spec.Source.Path != "" {
-		if strings.Contains(app.Spec.Source.Path, "prod") {
+	if sourcePath != "" {
+		if strings.Contains(sourcePath, "prod") {
 			return "production"
 		}
-		if strings.Contains(app.Spec.Source.Path, "staging") {
+		if strings.Contains(sourcePath, "staging") {
 			return "staging"
 		}
-		if strings.Contains(app.Spec.Source.Path, "dev") {
+		if strings.Contains(sourcePath, "dev") {
 			return "development"
 		}
 	}
 
 	// Default to destination namespace as a fallback
-	return app.Spec.Destination.Namespace
-}
-
-// isAppSourcedFromProject checks if an ArgoCD application uses a specific GitLab project
-func isAppSourcedFromProject(app *models.ArgoApplication, projectPath string) bool {
-	// Extract project path from app's repo URL
-	appProjectPath := extractGitLabProjectPath(app.Spec.Source.RepoURL)
-
-	// Compare paths
-	return strings.EqualFold(appProjectPath, projectPath)
+	return namespace
 }
 
-// isAppAffectedByDiffs checks if application manifests are affected by file changes
-func isAppAffectedByDiffs(app *models.ArgoApplication, diffs []models.GitLabDiff) bool {
-	sourcePath := app.Spec.Source.Path
-	if sourcePath == "" {
-		// If no specific path is provided, any change could affect the app
-		return true
-	}
-
-	// Check if any changed file is in the application's source path
+// changedFiles lists the paths touched by a diff, using the old path for deleted files
+func changedFiles(diffs []models.GitLabDiff) []string {
+	files := make([]string, 0, len(diffs))
 	for _, diff := range diffs {
-		if strings.HasPrefix(diff.NewPath, sourcePath) || strings.HasPrefix(diff.OldPath, sourcePath) {
-			return true
+		if diff.DeletedFile {
+			files = append(files, diff.OldPath)
+		} else {
+			files = append(files, diff.NewPath)
 		}
 	}
-
-	return false
+	return files
 }
 
 // isResourceAlreadyInResults checks if a resource is already in the results list
-func isResourceAlreadyInResults(results []models.ResourceContext, kind, name, namespace string) bool {
+func isResourceAlreadyInResults(results []models.ResourceContext, cluster, kind, name, namespace string) bool {
 	for _, rc := range results {
-		if rc.Kind == kind && rc.Name == name && rc.Namespace == namespace {
+		if rc.Cluster == cluster && rc.Kind == kind && rc.Name == name && rc.Namespace == namespace {
 			return true
 		}
 	}
 	return false
 }
+
+// helmOwner returns the release that installed a resource from the labels and
+// annotations Helm sets on it. Releases without a namespace annotation live in the
+// resource's namespace.
+func helmOwner(labels, annotations map[string]string, namespace string) (string, string, bool) {
+	if labels[helm.ManagedByLabel] != helm.ManagedByHelm {
+		return "", "", false
+	}
+	name := annotations[helm.ReleaseNameAnnotation]
+	if name == "" {
+		return "", "", false
+	}
+	releaseNamespace := annotations[helm.ReleaseNamespaceAnnotation]
+	if releaseNamespace == "" {
+		releaseNamespace = namespace
+	}
+	return name, releaseNamespace, releaseNamespace != ""
+}
@@ -5,41 +5,75 @@ package correlator
 import (
 	"context"
 	"fmt"
+	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
-	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/gitlab"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/helm"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/kustomize"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/manifest"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/policy"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/redact"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
 )
 
-// HelmCorrelator correlates Helm charts with Kubernetes resources
+// maxRenderFiles bounds how many files are fetched to render one chart or manifest directory
+const maxRenderFiles = 200
+
+// HelmCorrelator correlates Helm charts and kustomizations with Kubernetes resources
 type HelmCorrelator struct {
-	gitlabClient *gitlab.Client
-	helmParser   *helm.Parser
-	logger       *logging.Logger
+	repos             *scm.Registry
+	helmParser        *helm.Parser
+	kustomizeRenderer *kustomize.Renderer
+	renderOptions     helm.RenderOptions
+	redactor          *redact.Redactor
+	policy            *policy.Engine
+	logger            *logging.Logger
 }
 
 // NewHelmCorrelator creates a new Helm correlator
-func NewHelmCorrelator(gitlabClient *gitlab.Client, logger *logging.Logger) *HelmCorrelator {
+func NewHelmCorrelator(repos *scm.Registry, logger *logging.Logger) *HelmCorrelator {
 	if logger == nil {
 		logger = logging.NewLogger().Named("helm-correlator")
 	}
 
 	return &HelmCorrelator{
-		gitlabClient: gitlabClient,
-		helmParser:   helm.NewParser(logger.Named("helm")),
-		logger:       logger,
+		repos:             repos,
+		helmParser:        helm.NewParser(logger.Named("helm")),
+		kustomizeRenderer: kustomize.NewRenderer(logger.Named("kustomize")),
+		redactor:          redact.Default(),
+		policy:            policy.Default(),
+		logger:            logger,
 	}
 }
 
+// WithRenderOptions sets the capabilities and dependency handling used to render charts
+func (c *HelmCorrelator) WithRenderOptions(opts helm.RenderOptions) *HelmCorrelator {
+	c.renderOptions = opts
+	return c
+}
+
+// WithRedactor sets the redactor that masks values shown in manifest diffs
+func (c *HelmCorrelator) WithRedactor(redactor *redact.Redactor) *HelmCorrelator {
+	c.redactor = redactor
+	return c
+}
+
+// WithPolicy sets the policy engine that checks the objects merge requests render
+func (c *HelmCorrelator) WithPolicy(engine *policy.Engine) *HelmCorrelator {
+	c.policy = engine
+	return c
+}
+
 // AnalyzeCommitHelmChanges analyzes Helm changes in a commit
 func (c *HelmCorrelator) AnalyzeCommitHelmChanges(ctx context.Context, projectID, commitSHA string) ([]string, error) {
 	c.logger.Debug("Analyzing Helm changes in commit", "projectID", projectID, "commitSHA", commitSHA)
 
 	// Get commit diff
-	diffs, err := c.gitlabClient.GetCommitDiff(ctx, projectID, commitSHA)
+	diffs, err := c.repos.GetCommitDiff(ctx, projectID, commitSHA)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get commit diff: %w", err)
 	}
@@ -72,7 +106,7 @@ func (c *HelmCorrelator) AnalyzeMergeRequestHelmChanges(ctx context.Context, pro
 	c.logger.Debug("Analyzing Helm changes in merge request", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
 
 	// Get merge request changes
-	mrChanges, err := c.gitlabClient.GetMergeRequestChanges(ctx, projectID, mergeRequestIID)
+	mrChanges, err := c.repos.GetMergeRequestChanges(ctx, projectID, mergeRequestIID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get merge request changes: %w", err)
 	}
@@ -97,7 +131,7 @@ func (c *HelmCorrelator) AnalyzeMergeRequestHelmChanges(ctx context.Context, pro
 	}
 
 	// Get commits in the merge request
-	commits, err := c.gitlabClient.GetMergeRequestCommits(ctx, projectID, mergeRequestIID)
+	commits, err := c.repos.GetMergeRequestCommits(ctx, projectID, mergeRequestIID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get merge request commits: %w", err)
 	}
@@ -161,96 +195,324 @@ func (c *HelmCorrelator) identifyHelmCharts(diffs []models.GitLabDiff) map[strin
 	return helmCharts
 }
 
-// analyzeHelmChart analyzes changes in a Helm chart
+// analyzeHelmChart renders a chart at a commit and returns the resources it produces
 func (c *HelmCorrelator) analyzeHelmChart(ctx context.Context, projectID, commitSHA, chartPath string, changedFiles []string) ([]string, error) {
 	c.logger.Debug("Analyzing Helm chart", "chartPath", chartPath, "changedFiles", changedFiles)
 
-	// Determine chart structure
-	chartFiles := make(map[string]string)
-
-	// Get Chart.yaml
-	chartYaml, err := c.gitlabClient.GetFileContent(ctx, projectID, fmt.Sprintf("%s/Chart.yaml", chartPath), commitSHA)
+	objects, found, err := c.renderChart(ctx, projectID, chartPath, commitSHA)
 	if err != nil {
-		c.logger.Warn("Failed to get Chart.yaml", "error", err)
-		// Try to continue without Chart.yaml
-	} else {
-		chartFiles["Chart.yaml"] = chartYaml
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("chart %s not found at %s", chartPath, commitSHA)
 	}
 
-	// Get values.yaml
-	valuesYaml, err := c.gitlabClient.GetFileContent(ctx, projectID, fmt.Sprintf("%s/values.yaml", chartPath), commitSHA)
+	var resources []string
+	for _, obj := range objects {
+		_, kind, namespace, name := manifest.Identity(obj)
+		if kind == "" || name == "" {
+			continue
+		}
+		resource := fmt.Sprintf("%s/%s", kind, name)
+		if namespace != "" {
+			resource = fmt.Sprintf("%s/%s/%s", namespace, kind, name)
+		}
+		resources = append(resources, resource)
+	}
 
+	c.logger.Debug("Analyzed Helm chart", "chartPath", chartPath, "resourceCount", len(resources))
+	return resources, nil
+}
+
+// DiffMergeRequest renders every chart, kustomization and manifest directory a merge
+// request touches at the merge request's base and head commits and diffs the resulting
+// objects. Extra kustomization directories, such as overlays that ArgoCD applications
+// deploy from a changed base, are rendered as well.
+func (c *HelmCorrelator) DiffMergeRequest(ctx context.Context, projectID string, mergeRequestIID int, kustomizations []string) ([]models.ManifestDiff, error) {
+	c.logger.Debug("Diffing merge request manifests", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+
+	mr, err := c.repos.GetMergeRequestChanges(ctx, projectID, mergeRequestIID)
 	if err != nil {
-		c.logger.Warn("Failed to get values.yaml", "error", err)
-		// Try to continue without values.yaml
-	} else {
-		chartFiles["values.yaml"] = valuesYaml
+		return nil, fmt.Errorf("failed to get merge request changes: %w", err)
+	}
+	baseSHA, headSHA := mr.DiffRefs.BaseSHA, mr.DiffRefs.HeadSHA
+	if baseSHA == "" || headSHA == "" {
+		return nil, fmt.Errorf("merge request %d has no base and head commits", mergeRequestIID)
+	}
+
+	var changed []string
+	for _, change := range mr.Changes {
+		changed = append(changed, change.NewPath)
+		if change.OldPath != change.NewPath {
+			changed = append(changed, change.OldPath)
+		}
+	}
+
+	units := c.findRenderUnits(ctx, projectID, changed, []string{headSHA, baseSHA})
+	for _, dir := range kustomizations {
+		unit := renderUnit{path: path.Clean(dir), unitType: models.ManifestSourceKustomize}
+		if !containsUnit(units, unit) {
+			units = append(units, unit)
+		}
+	}
+
+	var diffs []models.ManifestDiff
+	for _, unit := range units {
+		diff := c.diffUnit(ctx, projectID, unit, baseSHA, headSHA)
+		if len(diff.Resources) > 0 || len(diff.Errors) > 0 {
+			diffs = append(diffs, diff)
+		}
+	}
+
+	c.logger.Debug("Diffed merge request manifests", "mergeRequestIID", mergeRequestIID, "units", len(diffs))
+	return diffs, nil
+}
+
+// renderUnit is a chart or plain manifest directory that a change touches
+type renderUnit struct {
+	path     string
+	unitType string
+}
+
+// findRenderUnits maps changed files to the outermost chart containing them, the
+// nearest kustomization above them, or their directory when they are plain manifests
+func (c *HelmCorrelator) findRenderUnits(ctx context.Context, projectID string, files, refs []string) []renderUnit {
+	markers := make(map[string]dirMarkers)
+	var units []renderUnit
+
+	for _, file := range files {
+		var chartUnit, kustomizeUnit renderUnit
+		for dir := path.Dir(file); ; dir = path.Dir(dir) {
+			found := c.findDirMarkers(ctx, projectID, dir, refs, markers)
+			if found.chart {
+				chartUnit = renderUnit{path: dir, unitType: models.ManifestSourceHelm}
+			}
+			if found.kustomization && kustomizeUnit.path == "" {
+				kustomizeUnit = renderUnit{path: dir, unitType: models.ManifestSourceKustomize}
+			}
+			if dir == "." || dir == "/" {
+				break
+			}
+		}
+
+		var unit renderUnit
+		switch {
+		case chartUnit.path != "":
+			unit = chartUnit
+		case kustomizeUnit.path != "":
+			unit = kustomizeUnit
+		case isManifestFile(file):
+			unit = renderUnit{path: path.Dir(file), unitType: models.ManifestSourceManifests}
+		default:
+			continue
+		}
+
+		if !containsUnit(units, unit) {
+			units = append(units, unit)
+		}
+	}
+	return units
+}
+
+func containsUnit(units []renderUnit, unit renderUnit) bool {
+	for _, u := range units {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// dirMarkers records whether a directory holds a chart or a kustomization
+type dirMarkers struct {
+	chart         bool
+	kustomization bool
+}
+
+// findDirMarkers looks for a Chart.yaml or kustomization file in a directory at any of
+// the refs
+func (c *HelmCorrelator) findDirMarkers(ctx context.Context, projectID, dir string, refs []string, cache map[string]dirMarkers) dirMarkers {
+	if found, ok := cache[dir]; ok {
+		return found
 	}
 
-	// Get template files
-	for _, file := range changedFiles {
-		if strings.Contains(file, "templates/") {
-			content, fileErr := c.gitlabClient.GetFileContent(ctx, projectID, file, commitSHA)
-			if fileErr != nil {
-				c.logger.Warn("Failed to get template file", "file", file, "error", fileErr)
+	var found dirMarkers
+	for _, ref := range refs {
+		entries, err := c.repos.ListRepositoryTree(ctx, projectID, treePath(dir), ref, false)
+		if err != nil {
+			if !scm.IsNotFound(err) {
+				c.logger.Debug("Failed to list directory", "dir", dir, "ref", ref, "error", err)
+			}
+			continue
+		}
+		for _, entry := range entries {
+			if entry.Type != "blob" {
 				continue
 			}
+			if entry.Name == "Chart.yaml" {
+				found.chart = true
+			}
+			if kustomize.IsKustomizationFile(entry.Name) {
+				found.kustomization = true
+			}
+		}
+	}
+	cache[dir] = found
+	return found
+}
+
+// diffUnit renders a unit at both commits, diffs the objects and checks the changes
+// against the policy. A unit that does not exist at one of the commits was added or
+// removed by the merge request.
+func (c *HelmCorrelator) diffUnit(ctx context.Context, projectID string, unit renderUnit, baseSHA, headSHA string) models.ManifestDiff {
+	diff := models.ManifestDiff{
+		Path:      unit.path,
+		Type:      unit.unitType,
+		BaseSHA:   baseSHA,
+		HeadSHA:   headSHA,
+		Resources: []models.ResourceDiff{},
+	}
 
-			// Store template file relative to chart path
-			relPath := strings.TrimPrefix(file, chartPath+"/")
-			chartFiles[relPath] = content
+	before, _, baseErr := c.render(ctx, projectID, unit, baseSHA)
+	if baseErr != nil {
+		diff.Errors = append(diff.Errors, fmt.Sprintf("failed to render at base %s: %v", baseSHA, baseErr))
+	}
+	after, _, headErr := c.render(ctx, projectID, unit, headSHA)
+	if headErr != nil {
+		diff.Errors = append(diff.Errors, fmt.Sprintf("failed to render at head %s: %v", headSHA, headErr))
+	}
+	if baseErr != nil || headErr != nil {
+		// A one-sided diff would report every object as added or removed
+		c.logger.Warn("Failed to render manifests for diff", "path", unit.path, "errors", diff.Errors)
+		return diff
+	}
+
+	diff.Resources = append(diff.Resources, manifest.Diff(before, after, c.mask)...)
+	diff.Violations = c.policy.Check(unit.path, before, after)
+	return diff
+}
+
+// render produces the objects of a unit at a ref, reporting whether the unit exists there
+func (c *HelmCorrelator) render(ctx context.Context, projectID string, unit renderUnit, ref string) ([]map[string]interface{}, bool, error) {
+	switch unit.unitType {
+	case models.ManifestSourceHelm:
+		return c.renderChart(ctx, projectID, unit.path, ref)
+	case models.ManifestSourceKustomize:
+		return c.renderKustomization(ctx, projectID, unit.path, ref)
+	default:
+		return c.readManifests(ctx, projectID, unit.path, ref)
+	}
+}
+
+// renderChart fetches a whole chart at a ref and renders it with the Helm engine
+func (c *HelmCorrelator) renderChart(ctx context.Context, projectID, chartPath, ref string) ([]map[string]interface{}, bool, error) {
+	entries, err := c.repos.ListRepositoryTree(ctx, projectID, treePath(chartPath), ref, true)
+	if err != nil {
+		if scm.IsNotFound(err) {
+			return nil, false, nil
 		}
+		return nil, false, fmt.Errorf("failed to list chart files: %w", err)
 	}
 
-	// Write chart files to disk for processing
+	chartFiles := make(map[string]string)
+	for _, entry := range entries {
+		if entry.Type != "blob" {
+			continue
+		}
+		if len(chartFiles) >= maxRenderFiles {
+			return nil, true, fmt.Errorf("chart %s has more than %d files", chartPath, maxRenderFiles)
+		}
+		content, err := c.repos.GetFileContent(ctx, projectID, entry.Path, ref)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to get chart file %s: %w", entry.Path, err)
+		}
+		chartFiles[relativePath(chartPath, entry.Path)] = content
+	}
+	if _, ok := chartFiles["Chart.yaml"]; !ok {
+		return nil, false, nil
+	}
+
+	if c.helmParser == nil {
+		return nil, true, fmt.Errorf("helm parser is not available")
+	}
 	chartDir, err := c.helmParser.WriteChartFiles(chartFiles)
 	if err != nil {
-		return nil, fmt.Errorf("failed to write chart files: %w", err)
+		return nil, true, fmt.Errorf("failed to write chart files: %w", err)
 	}
+	defer func() { _ = os.RemoveAll(chartDir) }()
 
-	// Parse chart to get manifests
-	manifests, err := c.helmParser.ParseChart(ctx, chartDir, nil, nil)
+	manifests, err := c.helmParser.RenderChart(ctx, chartDir, c.renderOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse chart: %w", err)
+		return nil, true, fmt.Errorf("failed to render chart: %w", err)
 	}
 
-	// Extract resources from manifests
-	var resources []string
-	for _, manifest := range manifests {
-		// Extract resource information
-		kind, name, namespace := c.extractResourceInfo(manifest)
-		if kind != "" && name != "" {
-			resource := fmt.Sprintf("%s/%s", kind, name)
-			if namespace != "" {
-				resource = fmt.Sprintf("%s/%s/%s", namespace, kind, name)
-			}
-			resources = append(resources, resource)
+	objects, err := manifest.Parse(strings.Join(manifests, "\n---\n"))
+	if err != nil {
+		return nil, true, err
+	}
+	return objects, true, nil
+}
+
+// readManifests parses the YAML and JSON files directly in a directory at a ref
+func (c *HelmCorrelator) readManifests(ctx context.Context, projectID, dir, ref string) ([]map[string]interface{}, bool, error) {
+	entries, err := c.repos.ListRepositoryTree(ctx, projectID, treePath(dir), ref, false)
+	if err != nil {
+		if scm.IsNotFound(err) {
+			return nil, false, nil
 		}
+		return nil, false, fmt.Errorf("failed to list manifest files: %w", err)
 	}
 
-	c.logger.Debug("Analyzed Helm chart", "chartPath", chartPath, "resourceCount", len(resources))
-	return resources, nil
+	var objects []map[string]interface{}
+	files := 0
+	for _, entry := range entries {
+		if entry.Type != "blob" || !isManifestFile(entry.Path) {
+			continue
+		}
+		if files++; files > maxRenderFiles {
+			return nil, true, fmt.Errorf("directory %s has more than %d manifest files", dir, maxRenderFiles)
+		}
+		content, err := c.repos.GetFileContent(ctx, projectID, entry.Path, ref)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to get manifest file %s: %w", entry.Path, err)
+		}
+		parsed, err := manifest.Parse(content)
+		if err != nil {
+			c.logger.Warn("Skipping file that is not a valid manifest", "file", entry.Path, "ref", ref, "error", err)
+			continue
+		}
+		objects = append(objects, parsed...)
+	}
+	return objects, len(entries) > 0, nil
 }
 
-// extractResourceInfo extracts kind, name, and namespace from a YAML manifest
-func (c *HelmCorrelator) extractResourceInfo(manifest string) (kind, name, namespace string) {
-	// Simple parsing - in a real implementation, use proper YAML parsing
-	lines := strings.Split(manifest, "\n")
+// mask redacts an object for display in a diff
+func (c *HelmCorrelator) mask(obj map[string]interface{}) map[string]interface{} {
+	masked, _ := c.redactor.RedactObject(obj)
+	return masked
+}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+func isManifestFile(file string) bool {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
 
-		switch {
-		case strings.HasPrefix(line, "kind:"):
-			kind = strings.TrimSpace(strings.TrimPrefix(line, "kind:"))
-		case strings.HasPrefix(line, "name:"):
-			name = strings.TrimSpace(strings.TrimPrefix(line, "name:"))
-		case strings.HasPrefix(line, "namespace:"):
-			namespace = strings.TrimSpace(strings.TrimPrefix(line, "namespace:"))
-		}
+// treePath converts a directory to the form the repository tree API expects
+func treePath(dir string) string {
+	if dir == "." || dir == "/" {
+		return ""
 	}
+	return dir
+}
 
-	return kind, name, namespace
+func relativePath(dir, file string) string {
+	if dir = treePath(dir); dir == "" {
+		return file
+	}
+	return strings.TrimPrefix(file, dir+"/")
 }
 
 // Cleanup cleans up temporary resources
@@ -0,0 +1,113 @@
+package correlator
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/kustomize"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/manifest"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
+)
+
+// kustomizationFetch collects the files of a kustomization and everything it references
+type kustomizationFetch struct {
+	projectID string
+	ref       string
+	files     map[string]string
+	dirs      map[string]bool
+}
+
+// KustomizationFiles fetches a kustomization at a ref along with the bases, components,
+// patches and generator inputs it references, keyed by repository path. It reports
+// whether the directory holds a kustomization at that ref.
+func (c *HelmCorrelator) KustomizationFiles(ctx context.Context, projectID, dir, ref string) (map[string]string, bool, error) {
+	fetch := &kustomizationFetch{
+		projectID: projectID,
+		ref:       ref,
+		files:     make(map[string]string),
+		dirs:      make(map[string]bool),
+	}
+	found, err := c.fetchKustomization(ctx, fetch, path.Clean(dir))
+	if err != nil || !found {
+		return nil, found, err
+	}
+	return fetch.files, true, nil
+}
+
+func (c *HelmCorrelator) fetchKustomization(ctx context.Context, fetch *kustomizationFetch, dir string) (bool, error) {
+	if fetch.dirs[dir] {
+		return true, nil
+	}
+	fetch.dirs[dir] = true
+
+	var content, kustomizationPath string
+	for _, name := range kustomize.FileNames {
+		filePath := path.Join(dir, name)
+		fileContent, err := c.repos.GetFileContent(ctx, fetch.projectID, filePath, fetch.ref)
+		if err == nil {
+			content, kustomizationPath = fileContent, filePath
+			break
+		}
+		if !scm.IsNotFound(err) {
+			return false, fmt.Errorf("failed to get %s: %w", filePath, err)
+		}
+	}
+	if kustomizationPath == "" {
+		return false, nil
+	}
+	fetch.files[kustomizationPath] = content
+
+	k, err := kustomize.Parse(content)
+	if err != nil {
+		return true, fmt.Errorf("%s: %w", kustomizationPath, err)
+	}
+
+	for _, ref := range k.References() {
+		target := path.Join(dir, ref)
+		if _, ok := fetch.files[target]; ok || fetch.dirs[target] {
+			continue
+		}
+		if len(fetch.files) >= maxRenderFiles {
+			return true, fmt.Errorf("kustomization %s references more than %d files", dir, maxRenderFiles)
+		}
+
+		// A reference is a file, or else a directory holding another kustomization
+		fileContent, err := c.repos.GetFileContent(ctx, fetch.projectID, target, fetch.ref)
+		if err == nil {
+			fetch.files[target] = fileContent
+			continue
+		}
+		if !scm.IsNotFound(err) {
+			return true, fmt.Errorf("failed to get %s: %w", target, err)
+		}
+
+		found, err := c.fetchKustomization(ctx, fetch, target)
+		if err != nil {
+			return true, err
+		}
+		if !found {
+			return true, fmt.Errorf("kustomization %s references %s, which does not exist", dir, ref)
+		}
+	}
+	return true, nil
+}
+
+// renderKustomization builds a kustomization at a ref
+func (c *HelmCorrelator) renderKustomization(ctx context.Context, projectID, dir, ref string) ([]map[string]interface{}, bool, error) {
+	files, found, err := c.KustomizationFiles(ctx, projectID, dir, ref)
+	if err != nil || !found {
+		return nil, found, err
+	}
+
+	output, err := c.kustomizeRenderer.Build(files, path.Clean(dir))
+	if err != nil {
+		return nil, true, err
+	}
+
+	objects, err := manifest.Parse(output)
+	if err != nil {
+		return nil, true, err
+	}
+	return objects, true, nil
+}
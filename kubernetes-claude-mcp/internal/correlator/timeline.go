@@ -0,0 +1,516 @@
+package correlator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/helm"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// DefaultTimelineWindow is how far back a timeline reaches when no start is given
+const DefaultTimelineWindow = 24 * time.Hour
+
+// TimelineWindow parses the window of a timeline. Since is either a time in RFC 3339
+// format or a duration such as 6h, counted back from until; until is a time and
+// defaults to now.
+func TimelineWindow(since, until string, now time.Time) (time.Time, time.Time, error) {
+	end := now
+	if until != "" {
+		parsed, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid end of timeline %q: %w", until, err)
+		}
+		end = parsed
+	}
+
+	start := end.Add(-DefaultTimelineWindow)
+	if since != "" {
+		if window, err := time.ParseDuration(since); err == nil {
+			if window <= 0 {
+				return time.Time{}, time.Time{}, fmt.Errorf("timeline window %q is not positive", since)
+			}
+			start = end.Add(-window)
+		} else if parsed, err := time.Parse(time.RFC3339, since); err == nil {
+			start = parsed
+		} else {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid start of timeline %q: want a time or a duration", since)
+		}
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("timeline starts at %s, after it ends at %s",
+			start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	return start, end, nil
+}
+
+// timelineBuilder collects entries within a time window, dropping ones already
+// collected from another source, such as a commit shared by two applications
+type timelineBuilder struct {
+	since    time.Time
+	until    time.Time
+	entries  []models.TimelineEntry
+	seen     map[string]bool
+	projects map[string]bool
+	errors   []string
+}
+
+func newTimelineBuilder(since, until time.Time) *timelineBuilder {
+	return &timelineBuilder{
+		since:    since,
+		until:    until,
+		seen:     make(map[string]bool),
+		projects: make(map[string]bool),
+	}
+}
+
+// add keeps an entry that happened within the window and was not seen under the same key
+func (b *timelineBuilder) add(key string, entry models.TimelineEntry) {
+	if entry.Time.IsZero() || entry.Time.Before(b.since) || entry.Time.After(b.until) || b.seen[key] {
+		return
+	}
+	b.seen[key] = true
+	b.entries = append(b.entries, entry)
+}
+
+// addEvents adds Kubernetes events. A recurring event is placed at its first
+// occurrence, and kept when it recurred within the window even if it started earlier.
+func (b *timelineBuilder) addEvents(cluster string, events []models.K8sEvent) {
+	for _, event := range events {
+		at := event.FirstTime
+		if at.Before(b.since) && !event.LastTime.Before(b.since) {
+			at = b.since
+		}
+		object := event.Object.Kind + "/" + event.Object.Name
+		summary := fmt.Sprintf("%s: %s", event.Reason, event.Message)
+		if event.Count > 1 {
+			summary += fmt.Sprintf(" (x%d, last at %s)", event.Count, event.LastTime.Format(time.RFC3339))
+		}
+		b.add(strings.Join([]string{models.TimelineEvent, cluster, event.Object.Namespace, object, event.Reason, event.Message}, "|"),
+			models.TimelineEntry{
+				Time:    at,
+				Type:    models.TimelineEvent,
+				Source:  "kubernetes",
+				Subject: object,
+				Summary: summary,
+				Status:  event.Type,
+			})
+	}
+}
+
+// addSyncs adds the syncs of an ArgoCD application
+func (b *timelineBuilder) addSyncs(app, appURL string, history []models.ArgoApplicationHistory) {
+	for _, sync := range history {
+		b.add(fmt.Sprintf("%s|argocd|%s|%d|%s", models.TimelineSync, app, sync.ID, sync.Revision),
+			models.TimelineEntry{
+				Time:     sync.DeployedAt,
+				Type:     models.TimelineSync,
+				Source:   "argocd",
+				Subject:  "Application/" + app,
+				Summary:  fmt.Sprintf("Synced to revision %s", shortRevision(sync.Revision)),
+				Status:   sync.Status,
+				Revision: sync.Revision,
+				URL:      appURL,
+			})
+	}
+}
+
+// addFluxReconcile adds the last time a Flux object became ready or failed, the
+// only point in its history Flux keeps
+func (b *timelineBuilder) addFluxReconcile(obj *models.FluxObject) {
+	for _, condition := range obj.Conditions {
+		if condition.Type != "Ready" {
+			continue
+		}
+		at, ok := timestamp(condition.LastTransitionTime)
+		if !ok {
+			continue
+		}
+		summary := fmt.Sprintf("Ready=%s", condition.Status)
+		if condition.Reason != "" {
+			summary += fmt.Sprintf(" (%s)", condition.Reason)
+		}
+		if condition.Message != "" {
+			summary += ": " + condition.Message
+		}
+		subject := obj.Kind + "/" + obj.Name
+		b.add(strings.Join([]string{models.TimelineSync, "flux", obj.Namespace, subject, condition.LastTransitionTime}, "|"),
+			models.TimelineEntry{
+				Time:     at,
+				Type:     models.TimelineSync,
+				Source:   "flux",
+				Subject:  subject,
+				Summary:  summary,
+				Status:   condition.Status,
+				Revision: obj.LastAppliedRevision,
+			})
+	}
+}
+
+// addHelmRelease adds the revisions of a Helm release
+func (b *timelineBuilder) addHelmRelease(release *models.HelmReleaseSummary) {
+	revisions := release.History
+	if len(revisions) == 0 {
+		revisions = []models.HelmRelease{release.HelmRelease}
+	}
+	for _, revision := range revisions {
+		summary := fmt.Sprintf("Revision %d of chart %s-%s", revision.Revision, revision.Chart, revision.ChartVersion)
+		if revision.Description != "" {
+			summary += ": " + revision.Description
+		}
+		b.add(fmt.Sprintf("%s|%s|%s|%d", models.TimelineHelmRelease, revision.Namespace, revision.Name, revision.Revision),
+			models.TimelineEntry{
+				Time:     revision.Updated,
+				Type:     models.TimelineHelmRelease,
+				Source:   "helm",
+				Subject:  "Release/" + revision.Name,
+				Summary:  summary,
+				Status:   revision.Status,
+				Revision: strconv.Itoa(revision.Revision),
+			})
+	}
+}
+
+// addCommits adds commits to a project's repository
+func (b *timelineBuilder) addCommits(provider, project string, commits []models.GitLabCommit) {
+	for _, commit := range commits {
+		at, _ := timestamp(commit.CreatedAt)
+		b.add(models.TimelineCommit+"|"+commit.ID, models.TimelineEntry{
+			Time:     at,
+			Type:     models.TimelineCommit,
+			Source:   provider,
+			Subject:  project,
+			Summary:  fmt.Sprintf("%s by %s", commit.Title, commit.AuthorName),
+			Revision: commit.ID,
+			URL:      commit.WebURL,
+		})
+	}
+}
+
+// addPipelines adds the pipelines of a project
+func (b *timelineBuilder) addPipelines(provider, project string, pipelines []models.GitLabPipeline) {
+	for _, pipeline := range pipelines {
+		at, _ := timestamp(pipeline.CreatedAt)
+		b.add(fmt.Sprintf("%s|%s|%d", models.TimelinePipeline, project, pipeline.ID), models.TimelineEntry{
+			Time:     at,
+			Type:     models.TimelinePipeline,
+			Source:   provider,
+			Subject:  project,
+			Summary:  fmt.Sprintf("Pipeline %d on %s at %s", pipeline.ID, pipeline.Ref, shortRevision(pipeline.SHA)),
+			Status:   pipeline.Status,
+			Revision: pipeline.SHA,
+			URL:      pipeline.WebURL,
+		})
+	}
+}
+
+// addDeployments adds a project's deployments to an environment, linking to the
+// environment page when one is given
+func (b *timelineBuilder) addDeployments(provider, project, environmentURL string, deployments []models.GitLabDeployment) {
+	for _, deployment := range deployments {
+		at, _ := timestamp(deployment.CreatedAt)
+		var link string
+		if environmentURL != "" {
+			link = fmt.Sprintf("%s/%d", environmentURL, deployment.Environment.ID)
+		}
+		b.add(fmt.Sprintf("%s|%s|%d", models.TimelineDeployment, project, deployment.ID), models.TimelineEntry{
+			Time:     at,
+			Type:     models.TimelineDeployment,
+			Source:   provider,
+			Subject:  project,
+			Summary:  fmt.Sprintf("Deployment %d to %s of %s", deployment.ID, deployment.Environment.Name, shortRevision(deployment.Commit.ID)),
+			Status:   deployment.Status,
+			Revision: deployment.Commit.ID,
+			URL:      link,
+		})
+	}
+}
+
+// sorted returns the entries oldest first
+func (b *timelineBuilder) sorted() []models.TimelineEntry {
+	entries := append([]models.TimelineEntry{}, b.entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries
+}
+
+// BuildTimeline merges what happened to a resource, an ArgoCD application or a
+// namespace between since and until into one list, oldest first. Kubernetes events,
+// ArgoCD syncs, Flux reconciliations and Helm release revisions come from the
+// cluster and its GitOps objects; commits, pipelines and deployments come from the
+// repositories they deploy from. Sources that could not be read are listed in the
+// timeline's errors rather than failing it.
+func (c *GitOpsCorrelator) BuildTimeline(
+	ctx context.Context,
+	scope models.TimelineScope,
+	since, until time.Time,
+) (*models.Timeline, error) {
+	c.logger.Info("Building timeline",
+		"cluster", scope.Cluster,
+		"namespace", scope.Namespace,
+		"kind", scope.Kind,
+		"name", scope.Name,
+		"application", scope.Application,
+		"since", since,
+		"until", until)
+
+	b := newTimelineBuilder(since, until)
+
+	var err error
+	switch {
+	case scope.Application != "":
+		scope, err = c.applicationTimeline(ctx, b, scope)
+	case scope.Kind != "" && scope.Name != "":
+		scope, err = c.resourceTimeline(ctx, b, scope)
+	case scope.Namespace != "":
+		scope, err = c.namespaceTimeline(ctx, b, scope)
+	default:
+		return nil, fmt.Errorf("a timeline needs a resource, an application or a namespace")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &models.Timeline{
+		TimelineScope: scope,
+		Since:         since,
+		Until:         until,
+		Entries:       b.sorted(),
+		Errors:        b.errors,
+	}
+
+	c.logger.Info("Timeline built",
+		"entries", len(timeline.Entries),
+		"errors", len(timeline.Errors))
+	return timeline, nil
+}
+
+// resourceTimeline traces a resource to the objects that deploy it and adds their
+// history, along with the resource's events
+func (c *GitOpsCorrelator) resourceTimeline(ctx context.Context, b *timelineBuilder, scope models.TimelineScope) (models.TimelineScope, error) {
+	rc, err := c.TraceResourceDeployment(ctx, scope.Cluster, scope.Namespace, scope.Kind, scope.Name)
+	if err != nil {
+		return scope, err
+	}
+	scope.Cluster = rc.Cluster
+	b.errors = append(b.errors, rc.Errors...)
+
+	b.addEvents(rc.Cluster, rc.Events)
+	if rc.HelmRelease != nil {
+		b.addHelmRelease(rc.HelmRelease)
+	}
+	if rc.FluxObject != nil {
+		b.addFluxReconcile(rc.FluxObject)
+	}
+
+	// The trace keeps the last few syncs and a day of commits; read the whole window
+	if rc.ArgoApplication != nil {
+		c.addApplicationSyncs(ctx, b, rc.ArgoApplication.Name)
+		c.addApplicationRepositories(ctx, b, rc.ArgoApplication)
+	}
+	if rc.GitLabProject != nil {
+		c.addRepositoryActivity(ctx, b, rc.GitLabProject.WebURL, "")
+	}
+	return scope, nil
+}
+
+// applicationTimeline adds an ArgoCD application's syncs and the activity of its
+// repositories, along with the events of the resources it manages
+func (c *GitOpsCorrelator) applicationTimeline(ctx context.Context, b *timelineBuilder, scope models.TimelineScope) (models.TimelineScope, error) {
+	if c.argoClient == nil {
+		return scope, fmt.Errorf("ArgoCD is not configured")
+	}
+	app, err := c.argoClient.GetApplication(ctx, scope.Application)
+	if err != nil {
+		return scope, fmt.Errorf("failed to get ArgoCD application %s: %w", scope.Application, err)
+	}
+	if scope.Cluster == "" {
+		if cluster, ok := c.clusterForApp(app); ok {
+			scope.Cluster = cluster
+		}
+	}
+	scope.Namespace = app.Spec.Destination.Namespace
+
+	c.addApplicationSyncs(ctx, b, app.Name)
+	c.addApplicationRepositories(ctx, b, app)
+
+	// Events are read from the destination namespace and kept for the resources in
+	// the application's tree, which includes the ReplicaSets and Pods it creates
+	if scope.Namespace == "" {
+		return scope, nil
+	}
+	k8sClient, err := c.clusters.ForContext(ctx, scope.Cluster)
+	if err != nil {
+		return scope, err
+	}
+	events, err := k8sClient.GetNamespaceEvents(ctx, scope.Namespace)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("Failed to get namespace events: %v", err))
+		return scope, nil
+	}
+
+	managed := make(map[string]bool)
+	tree, err := c.argoClient.GetResourceTree(ctx, app.Name)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("Failed to get resource tree of %s: %v", app.Name, err))
+		for _, resource := range app.Status.Resources {
+			managed[resource.Kind+"/"+resource.Name] = true
+		}
+	} else {
+		for _, node := range tree.Nodes {
+			managed[node.Kind+"/"+node.Name] = true
+		}
+	}
+
+	var appEvents []models.K8sEvent
+	for _, event := range events {
+		if managed[event.Object.Kind+"/"+event.Object.Name] {
+			appEvents = append(appEvents, event)
+		}
+	}
+	b.addEvents(scope.Cluster, appEvents)
+	return scope, nil
+}
+
+// namespaceTimeline adds the events and Helm releases of a namespace, and the history
+// of the ArgoCD applications that deploy into it
+func (c *GitOpsCorrelator) namespaceTimeline(ctx context.Context, b *timelineBuilder, scope models.TimelineScope) (models.TimelineScope, error) {
+	k8sClient, err := c.clusters.ForContext(ctx, scope.Cluster)
+	if err != nil {
+		return scope, err
+	}
+	if scope.Cluster == "" {
+		scope.Cluster = c.clusters.DefaultName()
+	}
+
+	events, err := k8sClient.GetNamespaceEvents(ctx, scope.Namespace)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("Failed to get namespace events: %v", err))
+	} else {
+		b.addEvents(scope.Cluster, events)
+	}
+
+	releases, err := helm.NewReleases(k8sClient.GetClientset(), c.logger.Named("helm")).List(ctx, scope.Namespace)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("Failed to list Helm releases: %v", err))
+	}
+	for i := range releases {
+		b.addHelmRelease(&releases[i])
+	}
+
+	if c.argoClient == nil {
+		return scope, nil
+	}
+	apps, err := c.argoClient.ListApplications(ctx)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("Failed to list ArgoCD applications: %v", err))
+		return scope, nil
+	}
+	for i := range apps {
+		app := &apps[i]
+		if app.Spec.Destination.Namespace != scope.Namespace {
+			continue
+		}
+		if cluster, ok := c.clusterForApp(app); !ok || cluster != scope.Cluster {
+			continue
+		}
+		c.addApplicationSyncs(ctx, b, app.Name)
+		c.addApplicationRepositories(ctx, b, app)
+	}
+	return scope, nil
+}
+
+// addApplicationSyncs adds the sync history of an ArgoCD application
+func (c *GitOpsCorrelator) addApplicationSyncs(ctx context.Context, b *timelineBuilder, name string) {
+	history, err := c.argoClient.GetApplicationHistory(ctx, name)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("Failed to get history of %s: %v", name, err))
+		return
+	}
+	b.addSyncs(name, c.argoClient.ApplicationURL(name), history)
+}
+
+// addApplicationRepositories adds the activity of each repository an ArgoCD
+// application deploys from
+func (c *GitOpsCorrelator) addApplicationRepositories(ctx context.Context, b *timelineBuilder, app *models.ArgoApplication) {
+	environment := extractEnvironmentFromArgoApp(app)
+	for _, source := range app.AllSources() {
+		c.addRepositoryActivity(ctx, b, source.RepoURL, environment)
+	}
+}
+
+// addRepositoryActivity adds a repository's commits, pipelines and deployments to the
+// environment. Each repository is read once, however many objects deploy from it.
+func (c *GitOpsCorrelator) addRepositoryActivity(ctx context.Context, b *timelineBuilder, repoURL, environment string) {
+	provider, host, projectPath, ok := c.repos.ForRepoURL(repoURL)
+	if !ok || b.projects[host+"/"+projectPath] {
+		return
+	}
+	b.projects[host+"/"+projectPath] = true
+
+	project, err := provider.GetProjectByPath(ctx, projectPath)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("Failed to get %s project %s: %v", provider.Name(), projectPath, err))
+		return
+	}
+	projectID := c.repos.QualifiedID(host, project)
+
+	commits, err := c.repos.FindRecentChanges(ctx, projectID, b.since)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("Failed to find recent changes in %s: %v", projectPath, err))
+	} else {
+		b.addCommits(provider.Name(), projectPath, commits)
+	}
+
+	pipelines, err := c.repos.ListPipelines(ctx, projectID)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("Failed to list pipelines of %s: %v", projectPath, err))
+	} else {
+		b.addPipelines(provider.Name(), projectPath, pipelines)
+	}
+
+	if environment == "" {
+		return
+	}
+	deployments, err := c.repos.FindRecentDeployments(ctx, projectID, environment)
+	if err != nil {
+		b.errors = append(b.errors, fmt.Sprintf("Failed to find deployments of %s: %v", projectPath, err))
+	} else {
+		// Only GitLab has a page per environment
+		var environmentURL string
+		if provider.Name() == "gitlab" && project.WebURL != "" {
+			environmentURL = strings.TrimSuffix(project.WebURL, "/") + "/-/environments"
+		}
+		b.addDeployments(provider.Name(), projectPath, environmentURL, deployments)
+	}
+}
+
+// timestamp reads a time from the loosely typed timestamps of SCM responses
+func timestamp(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, !v.IsZero()
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		return parsed, err == nil
+	case int64:
+		return time.Unix(v, 0), true
+	case float64:
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
+
+// shortRevision shortens a Git SHA for display
+func shortRevision(revision string) string {
+	if len(revision) > 8 {
+		return revision[:8]
+	}
+	return revision
+}
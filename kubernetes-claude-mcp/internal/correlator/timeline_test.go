@@ -0,0 +1,95 @@
+package correlator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+func TestTimelineBuilder(t *testing.T) {
+	since := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	until := since.Add(4 * time.Hour)
+	b := newTimelineBuilder(since, until)
+
+	backOff := models.K8sEvent{
+		Reason:    "BackOff",
+		Message:   "Back-off restarting failed container",
+		Type:      "Warning",
+		Count:     12,
+		FirstTime: since.Add(-time.Hour),
+		LastTime:  since.Add(2 * time.Hour),
+	}
+	backOff.Object.Kind = "Pod"
+	backOff.Object.Name = "web-5d8f-x2k"
+	stale := backOff
+	stale.Reason = "Pulled"
+	stale.LastTime = since.Add(-time.Minute)
+	b.addEvents("prod", []models.K8sEvent{backOff, stale})
+
+	b.addSyncs("web", "https://argocd.example.com/applications/web", []models.ArgoApplicationHistory{
+		{ID: 7, Revision: "abcdef1234567", DeployedAt: since.Add(90 * time.Minute), Status: "Synced"},
+	})
+
+	// The same commit reached through two applications is listed once
+	commits := []models.GitLabCommit{
+		{ID: "abcdef1234567", Title: "Raise memory limit", AuthorName: "Sam", CreatedAt: "2026-10-16T09:15:00Z"},
+		{ID: "0123456789abc", Title: "Old change", AuthorName: "Sam", CreatedAt: "2026-10-15T09:15:00Z"},
+	}
+	b.addCommits("gitlab", "team/web", commits)
+	b.addCommits("gitlab", "team/web", commits)
+
+	b.addPipelines("gitlab", "team/web", []models.GitLabPipeline{
+		{ID: 42, Status: "success", Ref: "main", SHA: "abcdef1234567", CreatedAt: "2026-10-16T09:20:00Z"},
+	})
+
+	type entry struct {
+		time    time.Time
+		kind    string
+		subject string
+	}
+	var got []entry
+	for _, e := range b.sorted() {
+		got = append(got, entry{e.Time, e.Type, e.Subject})
+	}
+	want := []entry{
+		{since, models.TimelineEvent, "Pod/web-5d8f-x2k"},
+		{since.Add(75 * time.Minute), models.TimelineCommit, "team/web"},
+		{since.Add(80 * time.Minute), models.TimelinePipeline, "team/web"},
+		{since.Add(90 * time.Minute), models.TimelineSync, "Application/web"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("timeline =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestTimelineWindow(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		since, until string
+		wantSince    time.Time
+		wantUntil    time.Time
+		wantErr      bool
+	}{
+		{"", "", now.Add(-DefaultTimelineWindow), now, false},
+		{"6h", "", now.Add(-6 * time.Hour), now, false},
+		{"2h", "2026-10-16T10:00:00Z", now.Add(-4 * time.Hour), now.Add(-2 * time.Hour), false},
+		{"2026-10-16T09:30:00Z", "", now.Add(-150 * time.Minute), now, false},
+		{"-1h", "", time.Time{}, time.Time{}, true},
+		{"yesterday", "", time.Time{}, time.Time{}, true},
+		{"2026-10-16T13:00:00Z", "", time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		since, until, err := TimelineWindow(tt.since, tt.until, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("TimelineWindow(%q, %q) error = %v, want error %v", tt.since, tt.until, err, tt.wantErr)
+			continue
+		}
+		if !since.Equal(tt.wantSince) || !until.Equal(tt.wantUntil) {
+			t.Errorf("TimelineWindow(%q, %q) = %s, %s, want %s, %s",
+				tt.since, tt.until, since, until, tt.wantSince, tt.wantUntil)
+		}
+	}
+}
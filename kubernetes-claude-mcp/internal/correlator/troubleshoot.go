@@ -8,41 +8,51 @@ import (
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // TroubleshootCorrelator provides specialized logic for troubleshooting
 type TroubleshootCorrelator struct {
 	gitOpsCorrelator *GitOpsCorrelator
-	k8sClient        *k8s.Client
+	clusters         *k8s.ClusterRegistry
 	logger           *logging.Logger
 }
 
 // NewTroubleshootCorrelator creates a new troubleshooting correlator
-func NewTroubleshootCorrelator(gitOpsCorrelator *GitOpsCorrelator, k8sClient *k8s.Client, logger *logging.Logger) *TroubleshootCorrelator {
+func NewTroubleshootCorrelator(gitOpsCorrelator *GitOpsCorrelator, clusters *k8s.ClusterRegistry, logger *logging.Logger) *TroubleshootCorrelator {
 	if logger == nil {
 		logger = logging.NewLogger().Named("troubleshoot")
 	}
 
 	return &TroubleshootCorrelator{
 		gitOpsCorrelator: gitOpsCorrelator,
-		k8sClient:        k8sClient,
+		clusters:         clusters,
 		logger:           logger,
 	}
 }
 
-// TroubleshootResource analyzes a resource for common issues
-func (tc *TroubleshootCorrelator) TroubleshootResource(ctx context.Context, namespace, kind, name string) (*models.TroubleshootResult, error) {
-	tc.logger.Info("Troubleshooting resource", "kind", kind, "name", name, "namespace", namespace)
+// TroubleshootResource analyzes a resource for common issues. An empty cluster selects the default cluster.
+func (tc *TroubleshootCorrelator) TroubleshootResource(ctx context.Context, cluster, namespace, kind, name string) (*models.TroubleshootResult, error) {
+	tc.logger.Info("Troubleshooting resource", "cluster", cluster, "kind", kind, "name", name, "namespace", namespace)
+
+	k8sClient, err := tc.clusters.ForContext(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
 
 	// First, trace the resource deployment
-	resourceContext, err := tc.gitOpsCorrelator.TraceResourceDeployment(ctx, namespace, kind, name)
+	resourceContext, err := tc.gitOpsCorrelator.TraceResourceDeployment(ctx, cluster, namespace, kind, name)
 	if err != nil {
 		return nil, fmt.Errorf("failed to trace resource deployment: %w", err)
 	}
 
 	// Get the raw resource for detailed analysis
-	resource, err := tc.k8sClient.GetResource(ctx, kind, namespace, name)
+	resource, err := k8sClient.GetResource(ctx, kind, namespace, name)
+	if apierrors.IsForbidden(err) {
+		// The caller's impersonated identity may not see this resource
+		return nil, err
+	}
 	if err != nil {
 		tc.logger.Warn("Failed to get resource for detailed analysis", "error", err)
 	}
@@ -61,7 +71,7 @@ func (tc *TroubleshootCorrelator) TroubleshootResource(ctx context.Context, name
 	if resource != nil {
 		// Pod-specific analysis
 		if strings.EqualFold(kind, "pod") {
-			tc.analyzePodStatus(ctx, resource, result)
+			tc.analyzePodStatus(ctx, k8sClient, resource, result)
 		}
 
 		// Deployment-specific analysis
@@ -73,6 +83,12 @@ func (tc *TroubleshootCorrelator) TroubleshootResource(ctx context.Context, name
 	// Analyze ArgoCD sync status
 	tc.analyzeArgoStatus(&resourceContext, result)
 
+	// Analyze the Flux object and source status
+	tc.analyzeFluxStatus(&resourceContext, result)
+
+	// Analyze the Helm release status
+	tc.analyzeHelmRelease(&resourceContext, result)
+
 	// Analyze GitLab pipeline status
 	tc.analyzeGitLabStatus(&resourceContext, result)
 
@@ -192,7 +208,12 @@ func (tc *TroubleshootCorrelator) analyzeDeploymentStatus(deployment *unstructur
 }
 
 // analyzePodStatus analyzes pod-specific status information
-func (tc *TroubleshootCorrelator) analyzePodStatus(ctx context.Context, pod *unstructured.Unstructured, result *models.TroubleshootResult) {
+func (tc *TroubleshootCorrelator) analyzePodStatus(
+	ctx context.Context,
+	k8sClient *k8s.Client,
+	pod *unstructured.Unstructured,
+	result *models.TroubleshootResult,
+) {
 	// Check pod phase
 	phase, found, _ := unstructured.NestedString(pod.Object, "status", "phase")
 	if found && phase != "Running" && phase != "Succeeded" {
@@ -311,7 +332,7 @@ func (tc *TroubleshootCorrelator) analyzePodStatus(ctx context.Context, pod *uns
 		// If PVC volumes found, check their status
 		if len(pvcVolumes) > 0 {
 			for _, pvcName := range pvcVolumes {
-				pvc, err := tc.k8sClient.GetResource(ctx, "persistentvolumeclaim", pod.GetNamespace(), pvcName)
+				pvc, err := k8sClient.GetResource(ctx, "persistentvolumeclaim", pod.GetNamespace(), pvcName)
 				if err != nil {
 					issue := models.Issue{
 						Source:      "Kubernetes",
@@ -508,6 +529,9 @@ func (tc *TroubleshootCorrelator) analyzeArgoStatus(rc *models.ResourceContext,
 			Title:       "ArgoCD Sync Issue",
 			Description: fmt.Sprintf("Application %s is not synced (status: %s)", rc.ArgoApplication.Name, rc.ArgoSyncStatus),
 		}
+		if len(rc.ArgoDrift) > 0 {
+			issue.Description += fmt.Sprintf("; %d resources differ from Git", len(rc.ArgoDrift))
+		}
 		result.Issues = append(result.Issues, issue)
 	}
 
@@ -539,6 +563,97 @@ func (tc *TroubleshootCorrelator) analyzeArgoStatus(rc *models.ResourceContext,
 	}
 }
 
+// analyzeFluxStatus looks for issues in the Flux object that applied the resource and
+// in its chart and source: suspended reconciliation, objects that are not ready, and a
+// newer revision that failed to apply
+func (tc *TroubleshootCorrelator) analyzeFluxStatus(rc *models.ResourceContext, result *models.TroubleshootResult) {
+	obj := rc.FluxObject
+	if obj == nil {
+		return
+	}
+
+	if obj.Suspended {
+		result.Issues = append(result.Issues, models.Issue{
+			Source:      "Flux",
+			Category:    "FluxSuspended",
+			Severity:    "Warning",
+			Title:       "Flux Reconciliation Suspended",
+			Description: fmt.Sprintf("%s %s/%s is suspended; changes in Git are not applied", obj.Kind, obj.Namespace, obj.Name),
+		})
+	}
+
+	if obj.Ready == "False" {
+		result.Issues = append(result.Issues, models.Issue{
+			Source:      "Flux",
+			Category:    "FluxNotReady",
+			Severity:    "Error",
+			Title:       fmt.Sprintf("Flux %s Not Ready", obj.Kind),
+			Description: fmt.Sprintf("%s %s/%s is not ready: %s", obj.Kind, obj.Namespace, obj.Name, fluxReadyMessage(obj.Conditions)),
+		})
+	}
+
+	if obj.LastAttemptedRevision != "" && obj.LastAttemptedRevision != obj.LastAppliedRevision {
+		result.Issues = append(result.Issues, models.Issue{
+			Source:      "Flux",
+			Category:    "FluxRevisionNotApplied",
+			Severity:    "Warning",
+			Title:       "Flux Revision Not Applied",
+			Description: fmt.Sprintf("%s %s/%s last attempted revision %s but is still running %s", obj.Kind, obj.Namespace, obj.Name, obj.LastAttemptedRevision, obj.LastAppliedRevision),
+		})
+	}
+
+	for _, source := range []*models.FluxSource{obj.Chart, obj.Source} {
+		if source == nil || source.Ready != "False" {
+			continue
+		}
+		result.Issues = append(result.Issues, models.Issue{
+			Source:      "Flux",
+			Category:    "FluxSourceNotReady",
+			Severity:    "Error",
+			Title:       fmt.Sprintf("Flux %s Not Ready", source.Kind),
+			Description: fmt.Sprintf("%s %s/%s is not ready: %s", source.Kind, source.Namespace, source.Name, fluxReadyMessage(source.Conditions)),
+		})
+	}
+}
+
+// fluxReadyMessage returns the reason and message of a Ready condition
+func fluxReadyMessage(conditions []models.FluxCondition) string {
+	for _, condition := range conditions {
+		if condition.Type == "Ready" {
+			return fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+		}
+	}
+	return "no Ready condition"
+}
+
+// analyzeHelmRelease reports a Helm release whose latest revision failed or is stuck
+// mid-operation
+func (tc *TroubleshootCorrelator) analyzeHelmRelease(rc *models.ResourceContext, result *models.TroubleshootResult) {
+	release := rc.HelmRelease
+	if release == nil {
+		return
+	}
+
+	switch {
+	case release.Status == "failed":
+		result.Issues = append(result.Issues, models.Issue{
+			Source:      "Helm",
+			Category:    "ReleaseFailure",
+			Severity:    "Error",
+			Title:       "Helm Release Failed",
+			Description: fmt.Sprintf("Revision %d of release %s (chart %s %s) failed: %s", release.Revision, release.Name, release.Chart, release.ChartVersion, release.Description),
+		})
+	case strings.HasPrefix(release.Status, "pending-"):
+		result.Issues = append(result.Issues, models.Issue{
+			Source:      "Helm",
+			Category:    "ReleasePending",
+			Severity:    "Warning",
+			Title:       "Helm Release Pending",
+			Description: fmt.Sprintf("Revision %d of release %s is %s; an interrupted operation can leave a release stuck and block further upgrades", release.Revision, release.Name, release.Status),
+		})
+	}
+}
+
 // analyzeGitLabStatus looks for issues in GitLab pipelines and deployments
 func (tc *TroubleshootCorrelator) analyzeGitLabStatus(rc *models.ResourceContext, result *models.TroubleshootResult) {
 	if rc.GitLabProject == nil {
@@ -608,6 +723,16 @@ func (tc *TroubleshootCorrelator) generateRecommendations(result *models.Trouble
 			recommendationMap["Check ArgoCD application manifest for errors."] = true
 			recommendationMap["Verify that the target revision exists in the Git repository."] = true
 
+		case "FluxSuspended":
+			recommendationMap["Resume the Flux object with `flux resume` once the reason for suspending it is resolved."] = true
+
+		case "FluxNotReady", "FluxRevisionNotApplied":
+			recommendationMap["Check the Flux object's conditions and the kustomize or helm controller logs for the apply error."] = true
+			recommendationMap["Run `flux reconcile` with --with-source after fixing the manifests in Git."] = true
+
+		case "FluxSourceNotReady":
+			recommendationMap["Verify the Flux source URL, ref and credentials Secret."] = true
+
 		case "PipelineIssue":
 			recommendationMap["Review GitLab pipeline logs for errors."] = true
 			recommendationMap["Check if the pipeline configuration is valid."] = true
@@ -0,0 +1,309 @@
+package flux
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// Labels the kustomize and helm controllers set on the objects they apply
+const (
+	KustomizeNameLabel      = "kustomize.toolkit.fluxcd.io/name"
+	KustomizeNamespaceLabel = "kustomize.toolkit.fluxcd.io/namespace"
+	HelmNameLabel           = "helm.toolkit.fluxcd.io/name"
+	HelmNamespaceLabel      = "helm.toolkit.fluxcd.io/namespace"
+)
+
+// Flux object kinds
+const (
+	KindKustomization  = "Kustomization"
+	KindHelmRelease    = "HelmRelease"
+	KindGitRepository  = "GitRepository"
+	KindHelmChart      = "HelmChart"
+	KindHelmRepository = "HelmRepository"
+	KindOCIRepository  = "OCIRepository"
+	KindBucket         = "Bucket"
+)
+
+// apiResource is a Flux kind's group and resource with the API versions to try,
+// newest first, so clusters on older Flux releases still resolve
+type apiResource struct {
+	group    string
+	resource string
+	versions []string
+}
+
+var apiResources = map[string]apiResource{
+	KindKustomization:  {"kustomize.toolkit.fluxcd.io", "kustomizations", []string{"v1", "v1beta2"}},
+	KindHelmRelease:    {"helm.toolkit.fluxcd.io", "helmreleases", []string{"v2", "v2beta2", "v2beta1"}},
+	KindGitRepository:  {"source.toolkit.fluxcd.io", "gitrepositories", []string{"v1", "v1beta2"}},
+	KindHelmChart:      {"source.toolkit.fluxcd.io", "helmcharts", []string{"v1", "v1beta2"}},
+	KindHelmRepository: {"source.toolkit.fluxcd.io", "helmrepositories", []string{"v1", "v1beta2"}},
+	KindOCIRepository:  {"source.toolkit.fluxcd.io", "ocirepositories", []string{"v1", "v1beta2"}},
+	KindBucket:         {"source.toolkit.fluxcd.io", "buckets", []string{"v1", "v1beta2"}},
+}
+
+// ErrObjectNotFound is returned when a Flux object does not exist at any known API
+// version, including when Flux is not installed
+var ErrObjectNotFound = errors.New("flux object not found")
+
+// Owner returns the Flux object that applied a resource from the labels the
+// controllers set. When both are present the HelmRelease is the nearer owner.
+func Owner(labels map[string]string) (kind, namespace, name string, ok bool) {
+	if name := labels[HelmNameLabel]; name != "" {
+		return KindHelmRelease, labels[HelmNamespaceLabel], name, true
+	}
+	if name := labels[KustomizeNameLabel]; name != "" {
+		return KindKustomization, labels[KustomizeNamespaceLabel], name, true
+	}
+	return "", "", "", false
+}
+
+// Reader reads Flux objects through the dynamic client
+type Reader struct {
+	client dynamic.Interface
+	logger *logging.Logger
+}
+
+// NewReader creates a reader for the Flux objects in a cluster
+func NewReader(client dynamic.Interface, logger *logging.Logger) *Reader {
+	if logger == nil {
+		logger = logging.NewLogger().Named("flux")
+	}
+
+	return &Reader{
+		client: client,
+		logger: logger,
+	}
+}
+
+// Get returns a Flux object, trying each API version its kind is served at
+func (r *Reader) Get(ctx context.Context, kind, namespace, name string) (*unstructured.Unstructured, error) {
+	res, ok := apiResources[kind]
+	if !ok {
+		return nil, fmt.Errorf("unsupported Flux kind %q", kind)
+	}
+
+	for _, version := range res.versions {
+		gvr := schema.GroupVersionResource{Group: res.group, Version: version, Resource: res.resource}
+		obj, err := r.client.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			return obj, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to get %s %s/%s: %w", kind, namespace, name, err)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s %s/%s", ErrObjectNotFound, kind, namespace, name)
+}
+
+// Trace finds the Flux object that applied a resource from the resource's labels,
+// and the chart and source behind it. It returns nil when Flux does not manage the
+// resource. Failing to read the chart or source does not fail the trace: the object
+// is returned with the error.
+func (r *Reader) Trace(ctx context.Context, labels map[string]string) (*models.FluxObject, error) {
+	kind, namespace, name, ok := Owner(labels)
+	if !ok {
+		return nil, nil
+	}
+	r.logger.Debug("Tracing Flux owner", "kind", kind, "namespace", namespace, "name", name)
+
+	obj, err := r.Get(ctx, kind, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	owner := toObject(obj)
+
+	// References without a namespace are to the referring object's namespace
+	var errs []error
+	refNamespace := owner.Namespace
+	sourceKind, sourceNamespace, sourceName := sourceRef(obj, "spec", "sourceRef")
+	if kind == KindHelmRelease {
+		chart, chartErr := r.helmChart(ctx, obj)
+		if chartErr != nil {
+			errs = append(errs, chartErr)
+		}
+		chartRefKind, chartRefNamespace, chartRefName := sourceRef(obj, "spec", "chartRef")
+		switch {
+		case chart != nil:
+			owner.Chart = toSource(chart)
+			refNamespace = chart.GetNamespace()
+			sourceKind, sourceNamespace, sourceName = sourceRef(chart, "spec", "sourceRef")
+		case chartRefKind != "" && chartRefKind != KindHelmChart:
+			// A chart referenced from an OCIRepository comes straight from the source
+			sourceKind, sourceNamespace, sourceName = chartRefKind, chartRefNamespace, chartRefName
+		case chartRefKind == "":
+			// The chart template names the source even when the HelmChart is missing
+			sourceKind, sourceNamespace, sourceName = sourceRef(obj, "spec", "chart", "spec", "sourceRef")
+		}
+	}
+
+	if sourceKind != "" && sourceName != "" {
+		if sourceNamespace == "" {
+			sourceNamespace = refNamespace
+		}
+		source, sourceErr := r.Get(ctx, sourceKind, sourceNamespace, sourceName)
+		if sourceErr != nil {
+			errs = append(errs, sourceErr)
+		} else {
+			owner.Source = toSource(source)
+		}
+	}
+
+	return owner, errors.Join(errs...)
+}
+
+// helmChart returns the HelmChart a HelmRelease installs: the chart named in its
+// status, a chart it references directly, or the chart the helm controller creates
+// for the release's chart template
+func (r *Reader) helmChart(ctx context.Context, release *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if kind, namespace, name := sourceRef(release, "spec", "chartRef"); kind != "" {
+		if namespace == "" {
+			namespace = release.GetNamespace()
+		}
+		if kind != KindHelmChart {
+			// An OCIRepository chart is a source; there is no HelmChart in between
+			return nil, nil
+		}
+		return r.Get(ctx, kind, namespace, name)
+	}
+
+	namespace, name := release.GetNamespace(), release.GetNamespace()+"-"+release.GetName()
+	if chartNamespace, _, _ := unstructured.NestedString(release.Object, "spec", "chart", "spec", "sourceRef", "namespace"); chartNamespace != "" {
+		namespace = chartNamespace
+	}
+	if status, _, _ := unstructured.NestedString(release.Object, "status", "helmChart"); status != "" {
+		if ns, n, ok := strings.Cut(status, "/"); ok {
+			namespace, name = ns, n
+		}
+	}
+	return r.Get(ctx, KindHelmChart, namespace, name)
+}
+
+// sourceRef reads a kind, namespace and name reference at a field path
+func sourceRef(obj *unstructured.Unstructured, fields ...string) (kind, namespace, name string) {
+	ref, found, err := unstructured.NestedStringMap(obj.Object, fields...)
+	if !found || err != nil {
+		return "", "", ""
+	}
+	return ref["kind"], ref["namespace"], ref["name"]
+}
+
+// toObject converts a Kustomization or HelmRelease
+func toObject(obj *unstructured.Unstructured) *models.FluxObject {
+	owner := &models.FluxObject{
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		Labels:     obj.GetLabels(),
+		Conditions: conditions(obj),
+	}
+	owner.Path, _, _ = unstructured.NestedString(obj.Object, "spec", "path")
+	owner.TargetNamespace, _, _ = unstructured.NestedString(obj.Object, "spec", "targetNamespace")
+	owner.Interval, _, _ = unstructured.NestedString(obj.Object, "spec", "interval")
+	owner.Suspended, _, _ = unstructured.NestedBool(obj.Object, "spec", "suspend")
+	owner.LastAppliedRevision, _, _ = unstructured.NestedString(obj.Object, "status", "lastAppliedRevision")
+	owner.LastAttemptedRevision, _, _ = unstructured.NestedString(obj.Object, "status", "lastAttemptedRevision")
+	owner.Ready = readyStatus(owner.Conditions)
+	return owner
+}
+
+// toSource converts a HelmChart or a source object
+func toSource(obj *unstructured.Unstructured) *models.FluxSource {
+	source := &models.FluxSource{
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		Conditions: conditions(obj),
+	}
+	source.URL, _, _ = unstructured.NestedString(obj.Object, "spec", "url")
+	source.Chart, _, _ = unstructured.NestedString(obj.Object, "spec", "chart")
+	source.Version, _, _ = unstructured.NestedString(obj.Object, "spec", "version")
+	source.Suspended, _, _ = unstructured.NestedBool(obj.Object, "spec", "suspend")
+	source.Revision, _, _ = unstructured.NestedString(obj.Object, "status", "artifact", "revision")
+	source.Ready = readyStatus(source.Conditions)
+	source.Ref = reference(obj)
+	if source.Kind == KindGitRepository {
+		source.Commit = CommitSHA(source.Revision)
+	}
+	return source
+}
+
+// reference describes the ref a GitRepository or OCIRepository follows, preferring
+// the most specific one set
+func reference(obj *unstructured.Unstructured) string {
+	ref, found, err := unstructured.NestedStringMap(obj.Object, "spec", "ref")
+	if !found || err != nil {
+		return ""
+	}
+	for _, key := range []string{"commit", "digest", "name", "tag", "semver", "branch"} {
+		if value := ref[key]; value != "" {
+			return key + ":" + value
+		}
+	}
+	return ""
+}
+
+// conditions reads status.conditions
+func conditions(obj *unstructured.Unstructured) []models.FluxCondition {
+	items, found, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if !found || err != nil {
+		return nil
+	}
+
+	var result []models.FluxCondition
+	for _, item := range items {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		str := func(key string) string {
+			value, _ := condition[key].(string)
+			return value
+		}
+		result = append(result, models.FluxCondition{
+			Type:               str("type"),
+			Status:             str("status"),
+			Reason:             str("reason"),
+			Message:            str("message"),
+			LastTransitionTime: str("lastTransitionTime"),
+		})
+	}
+	return result
+}
+
+// readyStatus returns the status of the Ready condition, or "Unknown" without one
+func readyStatus(conditions []models.FluxCondition) string {
+	for _, condition := range conditions {
+		if condition.Type == "Ready" {
+			return condition.Status
+		}
+	}
+	return "Unknown"
+}
+
+// CommitSHA extracts the commit from a GitRepository artifact revision, which is
+// "<ref>@sha1:<commit>" since Flux 2.0 and "<branch>/<commit>" before it
+func CommitSHA(revision string) string {
+	if i := strings.LastIndex(revision, "@"); i >= 0 {
+		revision = revision[i+1:]
+	}
+	if _, sha, ok := strings.Cut(revision, ":"); ok {
+		return sha
+	}
+	if i := strings.LastIndex(revision, "/"); i >= 0 {
+		return revision[i+1:]
+	}
+	return revision
+}
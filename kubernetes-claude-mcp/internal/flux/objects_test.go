@@ -0,0 +1,178 @@
+package flux
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func object(apiVersion, kind, namespace, name string, spec, status map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": apiVersion,
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": name, "namespace": namespace},
+		"spec":       spec,
+		"status":     status,
+	}}
+}
+
+func ready(status, reason, message string) map[string]interface{} {
+	return map[string]interface{}{
+		"type":    "Ready",
+		"status":  status,
+		"reason":  reason,
+		"message": message,
+	}
+}
+
+func newTestReader(objects ...runtime.Object) *Reader {
+	return NewReader(dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...), nil)
+}
+
+func TestTraceKustomization(t *testing.T) {
+	reader := newTestReader(
+		object("kustomize.toolkit.fluxcd.io/v1", KindKustomization, "flux-system", "apps", map[string]interface{}{
+			"path":      "./apps/production",
+			"interval":  "10m",
+			"sourceRef": map[string]interface{}{"kind": KindGitRepository, "name": "fleet"},
+		}, map[string]interface{}{
+			"lastAppliedRevision":   "main@sha1:1111111",
+			"lastAttemptedRevision": "main@sha1:2222222",
+			"conditions":            []interface{}{ready("False", "ReconciliationFailed", "Deployment/shop/api dry-run failed")},
+		}),
+		object("source.toolkit.fluxcd.io/v1", KindGitRepository, "flux-system", "fleet", map[string]interface{}{
+			"url": "https://github.com/example/fleet",
+			"ref": map[string]interface{}{"branch": "main"},
+		}, map[string]interface{}{
+			"artifact":   map[string]interface{}{"revision": "main@sha1:2222222"},
+			"conditions": []interface{}{ready("True", "Succeeded", "stored artifact")},
+		}),
+	)
+
+	owner, err := reader.Trace(context.Background(), map[string]string{
+		KustomizeNameLabel:      "apps",
+		KustomizeNamespaceLabel: "flux-system",
+	})
+	if err != nil {
+		t.Fatalf("Trace failed: %v", err)
+	}
+
+	if owner.Kind != KindKustomization || owner.Path != "./apps/production" || owner.Ready != "False" {
+		t.Errorf("unexpected owner: %+v", owner)
+	}
+	if owner.LastAppliedRevision != "main@sha1:1111111" || owner.LastAttemptedRevision != "main@sha1:2222222" {
+		t.Errorf("unexpected revisions: %+v", owner)
+	}
+	if len(owner.Conditions) != 1 || owner.Conditions[0].Reason != "ReconciliationFailed" {
+		t.Errorf("unexpected conditions: %+v", owner.Conditions)
+	}
+
+	source := owner.Source
+	if source == nil {
+		t.Fatal("expected the GitRepository source")
+	}
+	if source.URL != "https://github.com/example/fleet" || source.Ref != "branch:main" ||
+		source.Commit != "2222222" || source.Ready != "True" {
+		t.Errorf("unexpected source: %+v", source)
+	}
+}
+
+func TestTraceHelmRelease(t *testing.T) {
+	// An older Flux release serves HelmRelease at v2beta1 only
+	reader := newTestReader(
+		object("helm.toolkit.fluxcd.io/v2beta1", KindHelmRelease, "shop", "api", map[string]interface{}{
+			"chart": map[string]interface{}{"spec": map[string]interface{}{
+				"chart":     "api",
+				"sourceRef": map[string]interface{}{"kind": KindHelmRepository, "name": "charts", "namespace": "flux-system"},
+			}},
+		}, map[string]interface{}{
+			"helmChart":  "flux-system/shop-api",
+			"conditions": []interface{}{ready("True", "ReconciliationSucceeded", "Release reconciliation succeeded")},
+		}),
+		object("source.toolkit.fluxcd.io/v1", KindHelmChart, "flux-system", "shop-api", map[string]interface{}{
+			"chart":     "api",
+			"version":   "1.x",
+			"sourceRef": map[string]interface{}{"kind": KindHelmRepository, "name": "charts"},
+		}, map[string]interface{}{
+			"artifact": map[string]interface{}{"revision": "1.4.0"},
+		}),
+		object("source.toolkit.fluxcd.io/v1", KindHelmRepository, "flux-system", "charts", map[string]interface{}{
+			"url": "https://charts.example.com",
+		}, map[string]interface{}{
+			"conditions": []interface{}{ready("False", "IndexationFailed", "failed to fetch index")},
+		}),
+	)
+
+	owner, err := reader.Trace(context.Background(), map[string]string{
+		HelmNameLabel:           "api",
+		HelmNamespaceLabel:      "shop",
+		KustomizeNameLabel:      "apps",
+		KustomizeNamespaceLabel: "flux-system",
+	})
+	if err != nil {
+		t.Fatalf("Trace failed: %v", err)
+	}
+
+	if owner.Kind != KindHelmRelease || owner.Ready != "True" {
+		t.Errorf("unexpected owner: %+v", owner)
+	}
+	if owner.Chart == nil || owner.Chart.Chart != "api" || owner.Chart.Version != "1.x" || owner.Chart.Revision != "1.4.0" {
+		t.Errorf("unexpected chart: %+v", owner.Chart)
+	}
+	if owner.Source == nil || owner.Source.Kind != KindHelmRepository || owner.Source.Ready != "False" || owner.Source.Commit != "" {
+		t.Errorf("unexpected source: %+v", owner.Source)
+	}
+}
+
+func TestTraceMissingSource(t *testing.T) {
+	reader := newTestReader(
+		object("kustomize.toolkit.fluxcd.io/v1", KindKustomization, "flux-system", "apps", map[string]interface{}{
+			"sourceRef": map[string]interface{}{"kind": KindGitRepository, "name": "missing"},
+		}, nil),
+	)
+
+	owner, err := reader.Trace(context.Background(), map[string]string{
+		KustomizeNameLabel:      "apps",
+		KustomizeNamespaceLabel: "flux-system",
+	})
+	if owner == nil || owner.Source != nil || owner.Ready != "Unknown" {
+		t.Errorf("expected the Kustomization without its source, got %+v", owner)
+	}
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected a not found error for the source, got %v", err)
+	}
+}
+
+func TestTraceUnmanaged(t *testing.T) {
+	owner, err := newTestReader().Trace(context.Background(), map[string]string{"app": "api"})
+	if owner != nil || err != nil {
+		t.Errorf("expected no owner for a resource without Flux labels, got %+v, %v", owner, err)
+	}
+
+	_, err = newTestReader().Trace(context.Background(), map[string]string{
+		KustomizeNameLabel:      "apps",
+		KustomizeNamespaceLabel: "flux-system",
+	})
+	if !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("expected a not found error for a missing Kustomization, got %v", err)
+	}
+}
+
+func TestCommitSHA(t *testing.T) {
+	for revision, want := range map[string]string{
+		"main@sha1:0123abcd":            "0123abcd",
+		"refs/heads/main@sha1:0123abcd": "0123abcd",
+		"v1.2.0@sha1:0123abcd":          "0123abcd",
+		"sha1:0123abcd":                 "0123abcd",
+		"main/0123abcd":                 "0123abcd",
+		"0123abcd":                      "0123abcd",
+	} {
+		if got := CommitSHA(revision); got != want {
+			t.Errorf("CommitSHA(%q) = %q, want %q", revision, got, want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// Media types and the REST API version requested from GitHub
+const (
+	mediaTypeJSON = "application/vnd.github+json"
+	mediaTypeRaw  = "application/vnd.github.raw+json"
+	apiVersion    = "2022-11-28"
+)
+
+// perPage is the page size for list endpoints, GitHub's maximum
+const perPage = 100
+
+// APIError is an error response from the GitHub API
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("GitHub API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// NotFound reports whether the error is a 404
+func (e *APIError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsNotFound reports whether an error is a GitHub 404, such as a file or path that
+// does not exist at a ref
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// Client handles communication with the GitHub REST API. Repositories are addressed as
+// owner/repo; pull requests stand in for merge requests and workflow runs for
+// pipelines.
+type Client struct {
+	baseURL            string
+	httpClient         *http.Client
+	credentialProvider *auth.CredentialProvider
+	logger             *logging.Logger
+}
+
+// NewClient creates a new GitHub API client
+func NewClient(cfg *config.GitHubConfig, credProvider *auth.CredentialProvider, logger *logging.Logger) *Client {
+	if logger == nil {
+		logger = logging.NewLogger().Named("github")
+	}
+
+	return &Client{
+		baseURL: strings.TrimSuffix(cfg.APIURL(), "/"),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		credentialProvider: credProvider,
+		logger:             logger,
+	}
+}
+
+// Name returns the SCM provider name
+func (c *Client) Name() string {
+	return scm.ProviderGitHub
+}
+
+// CheckConnectivity tests the connection to the GitHub API
+func (c *Client) CheckConnectivity(ctx context.Context) error {
+	c.logger.Debug("Checking GitHub connectivity")
+
+	// The rate limit endpoint answers with or without a token and does not count
+	// against the limit
+	var rateLimit struct {
+		Resources struct {
+			Core struct {
+				Limit     int `json:"limit"`
+				Remaining int `json:"remaining"`
+			} `json:"core"`
+		} `json:"resources"`
+	}
+	if err := c.getJSON(ctx, "rate_limit", &rateLimit); err != nil {
+		return fmt.Errorf("failed to connect to GitHub: %w", err)
+	}
+
+	c.logger.Debug("GitHub connectivity check successful",
+		"rateLimit", rateLimit.Resources.Core.Limit,
+		"remaining", rateLimit.Resources.Core.Remaining)
+	return nil
+}
+
+// repoPath returns the API path of a repository given as owner/repo, or as a numeric
+// repository ID
+func repoPath(projectID string) (string, error) {
+	projectID = strings.Trim(projectID, "/")
+	if _, err := strconv.Atoi(projectID); err == nil {
+		return "repositories/" + projectID, nil
+	}
+
+	owner, repo, ok := strings.Cut(projectID, "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", fmt.Errorf("invalid GitHub repository %q: expected owner/repo", projectID)
+	}
+	return "repos/" + url.PathEscape(owner) + "/" + url.PathEscape(strings.TrimSuffix(repo, ".git")), nil
+}
+
+// getJSON fetches an endpoint and decodes the JSON response into v
+func (c *Client) getJSON(ctx context.Context, endpoint string, v interface{}) error {
+	resp, err := c.doRequest(ctx, http.MethodGet, endpoint, mediaTypeJSON, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// getPages fetches up to maxPages pages of a list endpoint, calling decode with each
+// response body. decode returns how many items the page held; a short page ends the
+// listing.
+func (c *Client) getPages(ctx context.Context, endpoint string, maxPages int, decode func(io.Reader) (int, error)) error {
+	separator := "?"
+	if strings.Contains(endpoint, "?") {
+		separator = "&"
+	}
+
+	for page := 1; page <= maxPages; page++ {
+		pageEndpoint := fmt.Sprintf("%s%sper_page=%d&page=%d", endpoint, separator, perPage, page)
+		resp, err := c.doRequest(ctx, http.MethodGet, pageEndpoint, mediaTypeJSON, nil)
+		if err != nil {
+			return err
+		}
+
+		count, err := decode(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		if count < perPage {
+			return nil
+		}
+	}
+
+	c.logger.Warn("GitHub listing truncated", "endpoint", endpoint, "maxPages", maxPages)
+	return nil
+}
+
+// doRequest performs an HTTP request to the GitHub API with authentication and retry logic
+func (c *Client) doRequest(ctx context.Context, method, endpoint, accept string, body []byte) (*http.Response, error) {
+	const maxRetries = 3
+	const baseDelay = 1 * time.Second
+
+	var lastErr error
+	var resp *http.Response
+
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		resp, lastErr = c.attemptRequest(ctx, method, endpoint, accept, body)
+		if lastErr == nil {
+			return resp, nil
+		}
+
+		if attempt < maxRetries-1 && shouldRetry(lastErr) {
+			delay := time.Duration(1<<uint(attempt)) * baseDelay // Exponential backoff
+			c.logger.Debug("Retrying GitHub request", "attempt", attempt+1, "delay", delay, "error", lastErr)
+
+			select {
+			case <-time.After(delay):
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		break
+	}
+
+	return resp, lastErr
+}
+
+// shouldRetry retries network errors, secondary rate limits and server errors
+func shouldRetry(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// attemptRequest makes a single request attempt
+func (c *Client) attemptRequest(ctx context.Context, method, endpoint, accept string, body []byte) (*http.Response, error) {
+	requestURL := c.baseURL + "/" + strings.TrimPrefix(endpoint, "/")
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", accept)
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	c.addAuth(req)
+
+	c.logger.Debug("Sending request to GitHub API", "method", method, "url", requestURL)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if resp.StatusCode >= 400 {
+		defer func() { _ = resp.Body.Close() }()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("GitHub API error (status %d): failed to read response body: %w", resp.StatusCode, err)
+		}
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	return resp, nil
+}
+
+// addAuth adds the token, if one is configured. Without one, requests are anonymous
+// and only reach public repositories.
+func (c *Client) addAuth(req *http.Request) {
+	if c.credentialProvider == nil {
+		return
+	}
+
+	creds, err := c.credentialProvider.GetCredentials(auth.ServiceGitHub)
+	if err == nil && creds.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+creds.Token)
+	}
+}
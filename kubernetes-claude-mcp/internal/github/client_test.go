@@ -0,0 +1,167 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return NewClient(&config.GitHubConfig{URL: server.URL}, nil, logging.NewLogger())
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestGetMergeRequestChanges(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-GitHub-Api-Version") != apiVersion {
+			t.Errorf("missing API version header on %s", r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/repos/owner/repo/pulls/7":
+			writeJSON(w, map[string]interface{}{
+				"id":              1007,
+				"number":          7,
+				"title":           "Bump chart",
+				"state":           "closed",
+				"merged_at":       "2026-10-01T10:00:00Z",
+				"merged_by":       map[string]interface{}{"id": 2, "login": "merger"},
+				"user":            map[string]interface{}{"id": 1, "login": "author"},
+				"mergeable_state": "dirty",
+				"head":            map[string]interface{}{"ref": "feature", "sha": "headsha", "repo": map[string]interface{}{"id": 9}},
+				"base":            map[string]interface{}{"ref": "main", "sha": "basesha", "repo": map[string]interface{}{"id": 9}},
+			})
+		case "/repos/owner/repo/compare/basesha...headsha":
+			writeJSON(w, map[string]interface{}{"merge_base_commit": map[string]interface{}{"sha": "mergebase"}})
+		case "/repos/owner/repo/pulls/7/files":
+			writeJSON(w, []map[string]interface{}{
+				{"filename": "charts/app/values.yaml", "status": "modified", "patch": "@@ -1 +1 @@"},
+				{"filename": "charts/app/new.yaml", "previous_filename": "charts/app/old.yaml", "status": "renamed"},
+				{"filename": "charts/app/gone.yaml", "status": "removed"},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	mr, err := client.GetMergeRequestChanges(context.Background(), "owner/repo", 7)
+	if err != nil {
+		t.Fatalf("GetMergeRequestChanges failed: %v", err)
+	}
+
+	if mr.IID != 7 || mr.State != "merged" || mr.SourceBranch != "feature" || mr.TargetBranch != "main" {
+		t.Errorf("unexpected merge request: %+v", mr)
+	}
+	if mr.Author.Username != "author" || mr.MergedBy == nil || mr.MergedBy.Username != "merger" {
+		t.Errorf("unexpected users: author %+v, merged by %+v", mr.Author, mr.MergedBy)
+	}
+	if !mr.HasConflicts {
+		t.Error("expected a dirty pull request to have conflicts")
+	}
+	if mr.DiffRefs.BaseSHA != "mergebase" || mr.DiffRefs.StartSHA != "basesha" || mr.DiffRefs.HeadSHA != "headsha" {
+		t.Errorf("unexpected diff refs: %+v", mr.DiffRefs)
+	}
+	if len(mr.Changes) != 3 {
+		t.Fatalf("expected 3 changes, got %d", len(mr.Changes))
+	}
+	if renamed := mr.Changes[1]; !renamed.RenamedFile || renamed.OldPath != "charts/app/old.yaml" {
+		t.Errorf("unexpected rename: %+v", renamed)
+	}
+	if deleted := mr.Changes[2]; !deleted.DeletedFile || deleted.OldPath != "charts/app/gone.yaml" {
+		t.Errorf("unexpected deletion: %+v", deleted)
+	}
+}
+
+func TestListRepositoryTree(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/git/trees/main" {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, map[string]interface{}{
+			"tree": []map[string]interface{}{
+				{"path": "charts", "type": "tree", "sha": "1"},
+				{"path": "charts/app", "type": "tree", "sha": "2"},
+				{"path": "charts/app/Chart.yaml", "type": "blob", "sha": "3"},
+				{"path": "charts/app/templates", "type": "tree", "sha": "4"},
+				{"path": "charts/app/templates/deployment.yaml", "type": "blob", "sha": "5"},
+				{"path": "charts/application.yaml", "type": "blob", "sha": "6"},
+			},
+		})
+	})
+	ctx := context.Background()
+
+	entries, err := client.ListRepositoryTree(ctx, "owner/repo", "charts/app", "main", false)
+	if err != nil {
+		t.Fatalf("ListRepositoryTree failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Path != "charts/app/Chart.yaml" || entries[1].Name != "templates" {
+		t.Errorf("unexpected direct children: %+v", entries)
+	}
+
+	entries, err = client.ListRepositoryTree(ctx, "owner/repo", "charts/app", "main", true)
+	if err != nil {
+		t.Fatalf("ListRepositoryTree failed: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 entries below charts/app, got %+v", entries)
+	}
+
+	_, err = client.ListRepositoryTree(ctx, "owner/repo", "charts/missing", "main", false)
+	if !scm.IsNotFound(err) {
+		t.Errorf("expected a not found error for a missing path, got %v", err)
+	}
+}
+
+func TestGetFileContent(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/repos/owner/repo/contents/charts/app/values.yaml" && r.URL.Query().Get("ref") == "main" {
+			if r.Header.Get("Accept") != mediaTypeRaw {
+				t.Errorf("expected the raw media type, got %q", r.Header.Get("Accept"))
+			}
+			_, _ = w.Write([]byte("replicaCount: 2\n"))
+			return
+		}
+		http.NotFound(w, r)
+	})
+	ctx := context.Background()
+
+	content, err := client.GetFileContent(ctx, "owner/repo", "charts/app/values.yaml", "main")
+	if err != nil || content != "replicaCount: 2\n" {
+		t.Errorf("GetFileContent = %q, %v", content, err)
+	}
+
+	_, err = client.GetFileContent(ctx, "owner/repo", "charts/app/missing.yaml", "main")
+	if !IsNotFound(err) || !scm.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+}
+
+func TestRepoPath(t *testing.T) {
+	for projectID, want := range map[string]string{
+		"owner/repo":     "repos/owner/repo",
+		"owner/repo.git": "repos/owner/repo",
+		"12345":          "repositories/12345",
+	} {
+		got, err := repoPath(projectID)
+		if err != nil || got != want {
+			t.Errorf("repoPath(%q) = %q, %v, want %q", projectID, got, err, want)
+		}
+	}
+
+	if _, err := repoPath("group/sub/project"); err == nil {
+		t.Error("expected an error for a nested path")
+	}
+}
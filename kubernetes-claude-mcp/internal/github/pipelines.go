@@ -0,0 +1,167 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// workflowRun is a GitHub Actions workflow run as the API returns it
+type workflowRun struct {
+	ID         int         `json:"id"`
+	Status     string      `json:"status"`
+	Conclusion string      `json:"conclusion"`
+	HeadBranch string      `json:"head_branch"`
+	HeadSHA    string      `json:"head_sha"`
+	HTMLURL    string      `json:"html_url"`
+	CreatedAt  interface{} `json:"created_at"`
+	UpdatedAt  interface{} `json:"updated_at"`
+}
+
+// pipelineStatus maps a workflow run's status and conclusion to a GitLab pipeline
+// status
+func (r *workflowRun) pipelineStatus() string {
+	if r.Status != "completed" {
+		switch r.Status {
+		case "in_progress":
+			return "running"
+		default: // queued, waiting, pending, requested
+			return "pending"
+		}
+	}
+
+	switch r.Conclusion {
+	case "success", "neutral":
+		return "success"
+	case "failure", "timed_out", "startup_failure":
+		return "failed"
+	case "cancelled":
+		return "canceled"
+	case "skipped":
+		return "skipped"
+	case "action_required":
+		return "manual"
+	default:
+		return r.Conclusion
+	}
+}
+
+// ListPipelines returns the recent GitHub Actions workflow runs of a repository
+func (c *Client) ListPipelines(ctx context.Context, projectID string) ([]models.GitLabPipeline, error) {
+	c.logger.Debug("Listing workflow runs", "projectID", projectID)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		WorkflowRuns []workflowRun `json:"workflow_runs"`
+	}
+	if err := c.getJSON(ctx, repoEndpoint+"/actions/runs?per_page=20", &result); err != nil {
+		return nil, err
+	}
+
+	pipelines := make([]models.GitLabPipeline, 0, len(result.WorkflowRuns))
+	for i := range result.WorkflowRuns {
+		run := &result.WorkflowRuns[i]
+		pipelines = append(pipelines, models.GitLabPipeline{
+			ID:        run.ID,
+			Status:    run.pipelineStatus(),
+			Ref:       run.HeadBranch,
+			SHA:       run.HeadSHA,
+			WebURL:    run.HTMLURL,
+			CreatedAt: run.CreatedAt,
+			UpdatedAt: run.UpdatedAt,
+		})
+	}
+
+	c.logger.Debug("Listed workflow runs", "projectID", projectID, "count", len(pipelines))
+	return pipelines, nil
+}
+
+// deployment is a GitHub deployment as the API returns it
+type deployment struct {
+	ID          int         `json:"id"`
+	SHA         string      `json:"sha"`
+	Ref         string      `json:"ref"`
+	Environment string      `json:"environment"`
+	CreatedAt   interface{} `json:"created_at"`
+	UpdatedAt   interface{} `json:"updated_at"`
+}
+
+// deploymentStatus maps the state of a deployment's latest status to a GitLab
+// deployment status
+func deploymentStatus(state string) string {
+	switch state {
+	case "success", "inactive":
+		return "success"
+	case "failure", "error":
+		return "failed"
+	case "in_progress":
+		return "running"
+	case "queued", "pending", "":
+		return "created"
+	default:
+		return state
+	}
+}
+
+// FindRecentDeployments finds recent deployments to a specific environment
+func (c *Client) FindRecentDeployments(ctx context.Context, projectID, environment string) ([]models.GitLabDeployment, error) {
+	c.logger.Debug("Finding recent deployments",
+		"projectID", projectID,
+		"environment", environment)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("environment", environment)
+	q.Set("per_page", "10")
+
+	var results []deployment
+	if err := c.getJSON(ctx, repoEndpoint+"/deployments?"+q.Encode(), &results); err != nil {
+		return nil, err
+	}
+
+	deployments := make([]models.GitLabDeployment, 0, len(results))
+	for _, result := range results {
+		// The deployment itself carries no state; it is on the latest status
+		var statuses []struct {
+			State string `json:"state"`
+		}
+		statusEndpoint := fmt.Sprintf("%s/deployments/%d/statuses?per_page=1", repoEndpoint, result.ID)
+		if err := c.getJSON(ctx, statusEndpoint, &statuses); err != nil {
+			c.logger.Warn("Failed to get deployment status", "deploymentID", result.ID, "error", err)
+		}
+		state := ""
+		if len(statuses) > 0 {
+			state = statuses[0].State
+		}
+
+		d := models.GitLabDeployment{
+			ID:        result.ID,
+			Status:    deploymentStatus(state),
+			CreatedAt: result.CreatedAt,
+			UpdatedAt: result.UpdatedAt,
+		}
+		d.Environment.Name = result.Environment
+		d.Deployable.Ref = result.Ref
+		d.Commit.ID = result.SHA
+		if len(result.SHA) > 8 {
+			d.Commit.ShortID = result.SHA[:8]
+		}
+		deployments = append(deployments, d)
+	}
+
+	c.logger.Debug("Found deployments",
+		"projectID", projectID,
+		"environment", environment,
+		"count", len(deployments))
+	return deployments, nil
+}
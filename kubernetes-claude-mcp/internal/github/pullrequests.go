@@ -0,0 +1,332 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// Page limits for pull request listings; GitHub returns at most 3000 files and 250
+// commits for a pull request
+const (
+	maxPullFilePages    = 30
+	maxPullCommitPages  = 3
+	maxPullCommentPages = 5
+)
+
+// user is a GitHub account as the API returns it
+type user struct {
+	ID    int    `json:"id"`
+	Login string `json:"login"`
+	Name  string `json:"name"`
+}
+
+func (u *user) toUser() models.GitLabUser {
+	name := u.Name
+	if name == "" {
+		name = u.Login
+	}
+	return models.GitLabUser{ID: u.ID, Username: u.Login, Name: name}
+}
+
+// pullRequest is a GitHub pull request as the API returns it
+type pullRequest struct {
+	ID             int         `json:"id"`
+	Number         int         `json:"number"`
+	Title          string      `json:"title"`
+	Body           string      `json:"body"`
+	State          string      `json:"state"`
+	Merged         bool        `json:"merged"`
+	MergedAt       interface{} `json:"merged_at"`
+	MergedBy       *user       `json:"merged_by"`
+	MergeCommitSHA string      `json:"merge_commit_sha"`
+	CreatedAt      interface{} `json:"created_at"`
+	UpdatedAt      interface{} `json:"updated_at"`
+	User           user        `json:"user"`
+	Assignees      []user      `json:"assignees"`
+	HTMLURL        string      `json:"html_url"`
+	MergeableState string      `json:"mergeable_state"`
+	Comments       int         `json:"comments"`
+	Head           pullRef     `json:"head"`
+	Base           pullRef     `json:"base"`
+}
+
+type pullRef struct {
+	Ref  string `json:"ref"`
+	SHA  string `json:"sha"`
+	Repo *struct {
+		ID int `json:"id"`
+	} `json:"repo"`
+}
+
+func (r *pullRef) repoID() int {
+	if r.Repo == nil {
+		// The head repository of a pull request from a deleted fork
+		return 0
+	}
+	return r.Repo.ID
+}
+
+func (pr *pullRequest) toMergeRequest() *models.GitLabMergeRequest {
+	state := "opened"
+	switch {
+	case pr.Merged || pr.MergedAt != nil:
+		state = "merged"
+	case pr.State == "closed":
+		state = "closed"
+	}
+
+	mr := &models.GitLabMergeRequest{
+		ID:              pr.ID,
+		IID:             pr.Number,
+		ProjectID:       pr.Base.repoID(),
+		Title:           pr.Title,
+		Description:     pr.Body,
+		State:           state,
+		MergedAt:        pr.MergedAt,
+		MergeCommitSHA:  pr.MergeCommitSHA,
+		CreatedAt:       pr.CreatedAt,
+		UpdatedAt:       pr.UpdatedAt,
+		TargetBranch:    pr.Base.Ref,
+		SourceBranch:    pr.Head.Ref,
+		Author:          pr.User.toUser(),
+		SourceProjectID: pr.Head.repoID(),
+		TargetProjectID: pr.Base.repoID(),
+		WebURL:          pr.HTMLURL,
+		MergeStatus:     pr.MergeableState,
+		UserNotesCount:  pr.Comments,
+		HasConflicts:    pr.MergeableState == "dirty",
+	}
+	if pr.MergedBy != nil {
+		mergedBy := pr.MergedBy.toUser()
+		mr.MergedBy = &mergedBy
+	}
+	for i := range pr.Assignees {
+		mr.Assignees = append(mr.Assignees, pr.Assignees[i].toUser())
+	}
+	mr.DiffRefs.HeadSHA = pr.Head.SHA
+	mr.DiffRefs.StartSHA = pr.Base.SHA
+	mr.DiffRefs.BaseSHA = pr.Base.SHA
+	return mr
+}
+
+// GetMergeRequest returns a pull request. The base SHA in its diff refs is the merge
+// base of the head and base branches, as GitLab reports it, so diffs against it show
+// only the pull request's own changes.
+func (c *Client) GetMergeRequest(ctx context.Context, projectID string, mergeRequestIID int) (*models.GitLabMergeRequest, error) {
+	c.logger.Debug("Getting pull request", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pr pullRequest
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/pulls/%d", repoEndpoint, mergeRequestIID), &pr); err != nil {
+		return nil, err
+	}
+	mr := pr.toMergeRequest()
+
+	var comparison struct {
+		MergeBaseCommit struct {
+			SHA string `json:"sha"`
+		} `json:"merge_base_commit"`
+	}
+	compareEndpoint := fmt.Sprintf("%s/compare/%s...%s?per_page=1",
+		repoEndpoint, url.PathEscape(pr.Base.SHA), url.PathEscape(pr.Head.SHA))
+	if err := c.getJSON(ctx, compareEndpoint, &comparison); err != nil {
+		c.logger.Warn("Failed to find pull request merge base, using base branch head",
+			"projectID", projectID,
+			"mergeRequestIID", mergeRequestIID,
+			"error", err)
+	} else if comparison.MergeBaseCommit.SHA != "" {
+		mr.DiffRefs.BaseSHA = comparison.MergeBaseCommit.SHA
+	}
+
+	return mr, nil
+}
+
+// ListMergedMergeRequests returns the merged pull requests among the 50 most recently
+// updated closed ones, most recently updated first. GitHub cannot filter pull
+// requests by time, so some may have been updated before updatedAfter; callers filter
+// on MergedAt.
+func (c *Client) ListMergedMergeRequests(ctx context.Context, projectID string, updatedAfter time.Time) ([]models.GitLabMergeRequest, error) {
+	c.logger.Debug("Listing merged pull requests",
+		"projectID", projectID,
+		"updatedAfter", updatedAfter.Format(time.RFC3339))
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pulls []pullRequest
+	if err := c.getJSON(ctx, repoEndpoint+"/pulls?state=closed&sort=updated&direction=desc&per_page=50", &pulls); err != nil {
+		return nil, err
+	}
+
+	var mergeRequests []models.GitLabMergeRequest
+	for i := range pulls {
+		if pulls[i].MergedAt == nil {
+			continue
+		}
+		mergeRequests = append(mergeRequests, *pulls[i].toMergeRequest())
+	}
+
+	c.logger.Debug("Listed merged pull requests", "projectID", projectID, "count", len(mergeRequests))
+	return mergeRequests, nil
+}
+
+// GetMergeRequestChanges returns a pull request with its changed files
+func (c *Client) GetMergeRequestChanges(ctx context.Context, projectID string, mergeRequestIID int) (*models.GitLabMergeRequest, error) {
+	c.logger.Debug("Getting pull request changes", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+
+	mr, err := c.GetMergeRequest(ctx, projectID, mergeRequestIID)
+	if err != nil {
+		return nil, err
+	}
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/pulls/%d/files", repoEndpoint, mergeRequestIID)
+	err = c.getPages(ctx, endpoint, maxPullFilePages, func(body io.Reader) (int, error) {
+		var files []commitFile
+		if err := json.NewDecoder(body).Decode(&files); err != nil {
+			return 0, err
+		}
+		for i := range files {
+			mr.Changes = append(mr.Changes, files[i].toDiff())
+		}
+		return len(files), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("Got pull request changes",
+		"projectID", projectID,
+		"mergeRequestIID", mergeRequestIID,
+		"count", len(mr.Changes))
+	return mr, nil
+}
+
+// GetMergeRequestCommits returns the commits in a pull request
+func (c *Client) GetMergeRequestCommits(ctx context.Context, projectID string, mergeRequestIID int) ([]models.GitLabCommit, error) {
+	c.logger.Debug("Getting pull request commits", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []models.GitLabCommit
+	endpoint := fmt.Sprintf("%s/pulls/%d/commits", repoEndpoint, mergeRequestIID)
+	err = c.getPages(ctx, endpoint, maxPullCommitPages, func(body io.Reader) (int, error) {
+		var results []commit
+		if err := json.NewDecoder(body).Decode(&results); err != nil {
+			return 0, err
+		}
+		for i := range results {
+			commits = append(commits, *results[i].toCommit())
+		}
+		return len(results), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("Got pull request commits", "projectID", projectID, "mergeRequestIID", mergeRequestIID, "count", len(commits))
+	return commits, nil
+}
+
+// issueComment is a conversation comment on a pull request
+type issueComment struct {
+	ID        int    `json:"id"`
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	User      user   `json:"user"`
+}
+
+func (ic *issueComment) toComment(mergeRequestIID int) models.GitLabMergeRequestComment {
+	comment := models.GitLabMergeRequestComment{
+		ID:           ic.ID,
+		Body:         ic.Body,
+		CreatedAt:    ic.CreatedAt,
+		UpdatedAt:    ic.UpdatedAt,
+		NoteableID:   mergeRequestIID,
+		NoteableType: "MergeRequest", //nolint:misspell // GitLab API uses "noteable"
+	}
+	comment.Author.ID = ic.User.ID
+	comment.Author.Username = ic.User.Login
+	comment.Author.Name = ic.User.toUser().Name
+	return comment
+}
+
+// GetMergeRequestComments returns the conversation comments on a pull request
+func (c *Client) GetMergeRequestComments(ctx context.Context, projectID string, mergeRequestIID int) ([]models.GitLabMergeRequestComment, error) {
+	c.logger.Debug("Getting pull request comments", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []models.GitLabMergeRequestComment
+	endpoint := fmt.Sprintf("%s/issues/%d/comments", repoEndpoint, mergeRequestIID)
+	err = c.getPages(ctx, endpoint, maxPullCommentPages, func(body io.Reader) (int, error) {
+		var results []issueComment
+		if err := json.NewDecoder(body).Decode(&results); err != nil {
+			return 0, err
+		}
+		for i := range results {
+			comments = append(comments, results[i].toComment(mergeRequestIID))
+		}
+		return len(results), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("Got pull request comments", "projectID", projectID, "mergeRequestIID", mergeRequestIID, "count", len(comments))
+	return comments, nil
+}
+
+// CreateMergeRequestComment creates a new conversation comment on a pull request
+func (c *Client) CreateMergeRequestComment(ctx context.Context, projectID string, mergeRequestIID int, body string) (*models.GitLabMergeRequestComment, error) {
+	c.logger.Debug("Creating pull request comment", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBody, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/issues/%d/comments", repoEndpoint, mergeRequestIID)
+	resp, err := c.doRequest(ctx, http.MethodPost, endpoint, mediaTypeJSON, jsonBody)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var result issueComment
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	comment := result.toComment(mergeRequestIID)
+	return &comment, nil
+}
@@ -0,0 +1,345 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
+)
+
+// Page limits for listings that can be long
+const (
+	maxRepositoryPages = 5
+	maxCommitFilePages = 30 // GitHub returns at most 3000 files for a commit
+)
+
+// repository is a GitHub repository as the API returns it
+type repository struct {
+	ID            int    `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	HTMLURL       string `json:"html_url"`
+	DefaultBranch string `json:"default_branch"`
+	Visibility    string `json:"visibility"`
+}
+
+func (r *repository) toProject() *models.GitLabProject {
+	return &models.GitLabProject{
+		Provider:          scm.ProviderGitHub,
+		ID:                r.ID,
+		Name:              r.Name,
+		Path:              r.Name,
+		PathWithNamespace: r.FullName,
+		WebURL:            r.HTMLURL,
+		DefaultBranch:     r.DefaultBranch,
+		Visibility:        r.Visibility,
+	}
+}
+
+// commit is a GitHub commit as the API returns it
+type commit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Message string    `json:"message"`
+		Author  signature `json:"author"`
+		// Committer is who applied the commit, which differs from the author on rebases
+		Committer signature `json:"committer"`
+	} `json:"commit"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+	Files []commitFile `json:"files"`
+}
+
+type signature struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date"`
+}
+
+// commitFile is a changed file in a commit, comparison or pull request
+type commitFile struct {
+	Filename         string `json:"filename"`
+	PreviousFilename string `json:"previous_filename"`
+	Status           string `json:"status"`
+	Patch            string `json:"patch"`
+}
+
+func (c *commit) toCommit() *models.GitLabCommit {
+	title, _, _ := strings.Cut(c.Commit.Message, "\n")
+	shortID := c.SHA
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+
+	parents := make([]string, 0, len(c.Parents))
+	for _, parent := range c.Parents {
+		parents = append(parents, parent.SHA)
+	}
+
+	return &models.GitLabCommit{
+		ID:             c.SHA,
+		ShortID:        shortID,
+		Title:          title,
+		Message:        c.Commit.Message,
+		AuthorName:     c.Commit.Author.Name,
+		AuthorEmail:    c.Commit.Author.Email,
+		CommitterName:  c.Commit.Committer.Name,
+		CommitterEmail: c.Commit.Committer.Email,
+		CreatedAt:      c.Commit.Author.Date,
+		ParentIDs:      parents,
+		WebURL:         c.HTMLURL,
+	}
+}
+
+func (f *commitFile) toDiff() models.GitLabDiff {
+	oldPath := f.Filename
+	if f.PreviousFilename != "" {
+		oldPath = f.PreviousFilename
+	}
+	return models.GitLabDiff{
+		OldPath:     oldPath,
+		NewPath:     f.Filename,
+		Diff:        f.Patch,
+		NewFile:     f.Status == "added",
+		RenamedFile: f.Status == "renamed",
+		DeletedFile: f.Status == "removed",
+	}
+}
+
+// ListProjects returns the repositories the token's user can access
+func (c *Client) ListProjects(ctx context.Context) ([]models.GitLabProject, error) {
+	c.logger.Debug("Listing repositories")
+
+	var projects []models.GitLabProject
+	err := c.getPages(ctx, "user/repos?sort=updated", maxRepositoryPages, func(body io.Reader) (int, error) {
+		var repos []repository
+		if err := json.NewDecoder(body).Decode(&repos); err != nil {
+			return 0, err
+		}
+		for i := range repos {
+			projects = append(projects, *repos[i].toProject())
+		}
+		return len(repos), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("Listed repositories", "count", len(projects))
+	return projects, nil
+}
+
+// GetProject returns a repository given as owner/repo or by numeric ID
+func (c *Client) GetProject(ctx context.Context, projectID string) (*models.GitLabProject, error) {
+	c.logger.Debug("Getting repository", "projectID", projectID)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var repo repository
+	if err := c.getJSON(ctx, repoEndpoint, &repo); err != nil {
+		return nil, err
+	}
+	return repo.toProject(), nil
+}
+
+// GetProjectByPath returns a repository by its owner/repo path
+func (c *Client) GetProjectByPath(ctx context.Context, path string) (*models.GitLabProject, error) {
+	return c.GetProject(ctx, path)
+}
+
+// GetCommit returns details about a specific commit
+func (c *Client) GetCommit(ctx context.Context, projectID, sha string) (*models.GitLabCommit, error) {
+	c.logger.Debug("Getting commit", "projectID", projectID, "sha", sha)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var result commit
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/commits/%s?per_page=1", repoEndpoint, url.PathEscape(sha)), &result); err != nil {
+		return nil, err
+	}
+	return result.toCommit(), nil
+}
+
+// GetCommitDiff returns the changes in a specific commit
+func (c *Client) GetCommitDiff(ctx context.Context, projectID, sha string) ([]models.GitLabDiff, error) {
+	c.logger.Debug("Getting commit diff", "projectID", projectID, "sha", sha)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	var diffs []models.GitLabDiff
+	endpoint := fmt.Sprintf("%s/commits/%s", repoEndpoint, url.PathEscape(sha))
+	err = c.getPages(ctx, endpoint, maxCommitFilePages, func(body io.Reader) (int, error) {
+		var result commit
+		if err := json.NewDecoder(body).Decode(&result); err != nil {
+			return 0, err
+		}
+		for i := range result.Files {
+			diffs = append(diffs, result.Files[i].toDiff())
+		}
+		return len(result.Files), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.logger.Debug("Got commit diff", "projectID", projectID, "sha", sha, "count", len(diffs))
+	return diffs, nil
+}
+
+// FindRecentChanges finds the commits on the default branch since a time
+func (c *Client) FindRecentChanges(ctx context.Context, projectID string, since time.Time) ([]models.GitLabCommit, error) {
+	c.logger.Debug("Finding recent changes",
+		"projectID", projectID,
+		"since", since.Format(time.RFC3339))
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("since", since.Format(time.RFC3339))
+	q.Set("per_page", "20")
+
+	var results []commit
+	if err := c.getJSON(ctx, repoEndpoint+"/commits?"+q.Encode(), &results); err != nil {
+		return nil, err
+	}
+
+	commits := make([]models.GitLabCommit, 0, len(results))
+	for i := range results {
+		commits = append(commits, *results[i].toCommit())
+	}
+
+	c.logger.Debug("Found recent changes", "projectID", projectID, "count", len(commits))
+	return commits, nil
+}
+
+// GetFileContent returns the content of a file at a ref, or at the default branch when
+// ref is empty
+func (c *Client) GetFileContent(ctx context.Context, projectID, filePath, ref string) (string, error) {
+	c.logger.Debug("Getting file content",
+		"projectID", projectID,
+		"filePath", filePath,
+		"ref", ref)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := repoEndpoint + "/contents/" + (&url.URL{Path: strings.TrimPrefix(filePath, "/")}).EscapedPath()
+	if ref != "" {
+		endpoint += "?ref=" + url.QueryEscape(ref)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, endpoint, mediaTypeRaw, nil)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	// A directory comes back as a JSON listing even when the raw media type is asked for
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return "", &APIError{StatusCode: http.StatusNotFound, Body: fmt.Sprintf("%s is not a file", filePath)}
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file content: %w", err)
+	}
+	return string(content), nil
+}
+
+// treeEntry is an entry of a Git tree
+type treeEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Type string `json:"type"`
+	SHA  string `json:"sha"`
+}
+
+// ListRepositoryTree lists the files and directories under a path at a ref. Recursive
+// listings include every file below the path. The whole tree is fetched in one request
+// and filtered, so a path that does not exist is reported as a 404, as GitLab does.
+func (c *Client) ListRepositoryTree(ctx context.Context, projectID, treePath, ref string, recursive bool) ([]models.GitLabTreeEntry, error) {
+	c.logger.Debug("Listing repository tree",
+		"projectID", projectID,
+		"path", treePath,
+		"ref", ref,
+		"recursive", recursive)
+
+	repoEndpoint, err := repoPath(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if ref == "" {
+		ref = "HEAD"
+	}
+
+	var tree struct {
+		Tree      []treeEntry `json:"tree"`
+		Truncated bool        `json:"truncated"`
+	}
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/git/trees/%s?recursive=1", repoEndpoint, url.PathEscape(ref)), &tree); err != nil {
+		return nil, err
+	}
+	if tree.Truncated {
+		c.logger.Warn("Repository tree truncated by GitHub", "projectID", projectID, "ref", ref)
+	}
+
+	prefix := strings.Trim(treePath, "/")
+	found := prefix == ""
+	var entries []models.GitLabTreeEntry
+	for _, entry := range tree.Tree {
+		if entry.Path == prefix {
+			found = true
+			continue
+		}
+
+		rel := entry.Path
+		if prefix != "" {
+			if !strings.HasPrefix(entry.Path, prefix+"/") {
+				continue
+			}
+			rel = strings.TrimPrefix(entry.Path, prefix+"/")
+		}
+		if !recursive && strings.Contains(rel, "/") {
+			continue
+		}
+
+		found = true
+		entries = append(entries, models.GitLabTreeEntry{
+			ID:   entry.SHA,
+			Name: path.Base(entry.Path),
+			Type: entry.Type,
+			Path: entry.Path,
+			Mode: entry.Mode,
+		})
+	}
+	if !found {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Body: fmt.Sprintf("tree %s not found at %s", treePath, ref)}
+	}
+
+	c.logger.Debug("Listed repository tree", "projectID", projectID, "path", treePath, "count", len(entries))
+	return entries, nil
+}
@@ -3,6 +3,7 @@ package gitlab
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,10 +13,33 @@ import (
 	"time"
 
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
 )
 
+// APIError is an error response from the GitLab API
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("GitLab API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// NotFound reports whether the error is a 404
+func (e *APIError) NotFound() bool {
+	return e.StatusCode == http.StatusNotFound
+}
+
+// IsNotFound reports whether an error is a GitLab 404, such as a file or path that
+// does not exist at a ref
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
 // Client handles communication with the GitLab API
 type Client struct {
 	baseURL            string
@@ -42,6 +66,11 @@ func NewClient(cfg *config.GitLabConfig, credProvider *auth.CredentialProvider,
 	}
 }
 
+// Name returns the SCM provider name
+func (c *Client) Name() string {
+	return scm.ProviderGitLab
+}
+
 // CheckConnectivity tests the connection to the GitLab API
 func (c *Client) CheckConnectivity(ctx context.Context) error {
 	c.logger.Debug("Checking GitLab connectivity")
@@ -105,8 +134,12 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, body io
 
 // shouldRetry determines if a request should be retried based on the error and response
 func (c *Client) shouldRetry(err error, resp *http.Response) bool {
-	// Retry on network errors
-	if err != nil && resp == nil {
+	// Error responses are retried only for the status codes below
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		resp = &http.Response{StatusCode: apiErr.StatusCode}
+	} else if err != nil && resp == nil {
+		// Retry on network errors
 		return true
 	}
 
@@ -176,7 +209,7 @@ func (c *Client) attemptRequest(ctx context.Context, method, endpoint string, bo
 		if err != nil {
 			return nil, fmt.Errorf("GitLab API error (status %d): failed to read response body: %w", resp.StatusCode, err)
 		}
-		return nil, fmt.Errorf("GitLab API error (status %d): %s", resp.StatusCode, string(body))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	return resp, nil
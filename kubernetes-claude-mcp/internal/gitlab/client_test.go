@@ -174,3 +174,38 @@ func TestAttemptRequestWithAPIPrefix(t *testing.T) {
 		t.Errorf("Expected path %q, got %q", expectedPath, receivedPath)
 	}
 }
+
+func TestDoRequestDoesNotRetryNotFound(t *testing.T) {
+	// Create a test server that counts requests
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"404 File Not Found"}`))
+	}))
+	defer server.Close()
+
+	cfg := &config.GitLabConfig{
+		URL:        server.URL,
+		AuthToken:  "test-token",
+		APIVersion: "v4",
+	}
+
+	credProvider := auth.NewCredentialProvider(&config.Config{
+		GitLab: *cfg,
+		Claude: config.ClaudeConfig{APIKey: "test-claude-key"},
+	})
+	if err := credProvider.LoadCredentials(context.Background()); err != nil {
+		t.Fatalf("Failed to load credentials: %v", err)
+	}
+
+	client := NewClient(cfg, credProvider, logging.NewLogger())
+
+	_, err := client.GetFileContent(context.Background(), "123", "charts/api/Chart.yaml", "main")
+	if !IsNotFound(err) {
+		t.Fatalf("Expected a not found error, got %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("Expected a 404 not to be retried, got %d requests", requests)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
 )
@@ -49,6 +50,44 @@ func (c *Client) ListMergeRequests(ctx context.Context, projectID, state string)
 	return mergeRequests, nil
 }
 
+// ListMergedMergeRequests returns the merged merge requests of a project updated
+// after a time, most recently updated first. Every merge request merged since then is
+// included, along with older ones updated since; callers filter on MergedAt.
+func (c *Client) ListMergedMergeRequests(ctx context.Context, projectID string, updatedAfter time.Time) ([]models.GitLabMergeRequest, error) {
+	c.logger.Debug("Listing merged merge requests",
+		"projectID", projectID,
+		"updatedAfter", updatedAfter.Format(time.RFC3339))
+
+	endpoint := fmt.Sprintf("projects/%s/merge_requests", url.PathEscape(projectID))
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+
+	q := u.Query()
+	q.Set("state", "merged")
+	q.Set("updated_after", updatedAfter.Format(time.RFC3339))
+	q.Set("order_by", "updated_at")
+	q.Set("sort", "desc")
+	q.Set("per_page", "50")
+	u.RawQuery = q.Encode()
+
+	resp, err := c.doRequest(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var mergeRequests []models.GitLabMergeRequest
+	if err := json.NewDecoder(resp.Body).Decode(&mergeRequests); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Debug("Listed merged merge requests", "projectID", projectID, "count", len(mergeRequests))
+	return mergeRequests, nil
+}
+
 // GetMergeRequest returns details about a specific merge request
 func (c *Client) GetMergeRequest(ctx context.Context, projectID string, mergeRequestIID int) (*models.GitLabMergeRequest, error) {
 	c.logger.Debug("Getting merge request", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
@@ -106,11 +145,12 @@ func (c *Client) GetMergeRequestApprovals(ctx context.Context, projectID string,
 	return &approvals, nil
 }
 
-// GetMergeRequestComments returns comments on a merge request
+// GetMergeRequestComments returns up to 100 comments on a merge request, oldest first
 func (c *Client) GetMergeRequestComments(ctx context.Context, projectID string, mergeRequestIID int) ([]models.GitLabMergeRequestComment, error) {
 	c.logger.Debug("Getting merge request comments", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
 
-	endpoint := fmt.Sprintf("projects/%s/merge_requests/%d/notes", url.PathEscape(projectID), mergeRequestIID)
+	endpoint := fmt.Sprintf("projects/%s/merge_requests/%d/notes?sort=asc&order_by=created_at&per_page=100",
+		url.PathEscape(projectID), mergeRequestIID)
 	resp, err := c.doRequest(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
@@ -146,79 +186,114 @@ func (c *Client) GetMergeRequestCommits(ctx context.Context, projectID string, m
 	return commits, nil
 }
 
-// AnalyzeMergeRequest analyzes a merge request for Kubernetes/Helm changes
-func (c *Client) AnalyzeMergeRequest(ctx context.Context, projectID string, mergeRequestIID int) (*models.GitLabMergeRequest, error) {
-	c.logger.Debug("Analyzing merge request", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+// CreateMergeRequestComment creates a new comment on a merge request
+func (c *Client) CreateMergeRequestComment(ctx context.Context, projectID string, mergeRequestIID int, body string) (*models.GitLabMergeRequestComment, error) {
+	c.logger.Debug("Creating merge request comment", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+
+	endpoint := fmt.Sprintf("projects/%s/merge_requests/%d/notes", url.PathEscape(projectID), mergeRequestIID)
 
-	// Get basic merge request data
-	mr, err := c.GetMergeRequest(ctx, projectID, mergeRequestIID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get merge request: %w", err)
+	// Create request payload
+	reqBody := map[string]string{
+		"body": body,
 	}
 
-	// Get changes
-	mrChanges, err := c.GetMergeRequestChanges(ctx, projectID, mergeRequestIID)
+	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get merge request changes: %w", err)
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	// Copy changes to the original merge request
-	mr.Changes = mrChanges.Changes
-
-	// Initialize context analysis
-	mr.MergeRequestContext.AffectedFiles = make([]string, 0)
-	mr.MergeRequestContext.HelmChartAffected = false
-	mr.MergeRequestContext.KubernetesManifest = false
+	resp, err := c.doRequest(ctx, http.MethodPost, endpoint, strings.NewReader(string(jsonBody)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
 
-	// Analyze changes
-	for _, change := range mr.Changes {
-		mr.MergeRequestContext.AffectedFiles = append(mr.MergeRequestContext.AffectedFiles, change.NewPath)
+	var comment models.GitLabMergeRequestComment
+	if err := json.NewDecoder(resp.Body).Decode(&comment); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
 
-		// Check for Helm charts
-		if strings.Contains(change.NewPath, "Chart.yaml") ||
-			strings.Contains(change.NewPath, "values.yaml") ||
-			(strings.Contains(change.NewPath, "templates/") && strings.HasSuffix(change.NewPath, ".yaml")) {
-			mr.MergeRequestContext.HelmChartAffected = true
-		}
+	return &comment, nil
+}
 
-		// Check for Kubernetes manifests
-		if strings.HasSuffix(change.NewPath, ".yaml") || strings.HasSuffix(change.NewPath, ".yml") {
-			// Look for Kubernetes kind in the file content
-			if strings.Contains(change.Diff, "kind:") &&
-				(strings.Contains(change.Diff, "Deployment") ||
-					strings.Contains(change.Diff, "Service") ||
-					strings.Contains(change.Diff, "ConfigMap") ||
-					strings.Contains(change.Diff, "Secret") ||
-					strings.Contains(change.Diff, "Pod")) {
-				mr.MergeRequestContext.KubernetesManifest = true
-			}
-		}
+// UpdateMergeRequestComment replaces the body of an existing comment on a merge request
+func (c *Client) UpdateMergeRequestComment(
+	ctx context.Context,
+	projectID string,
+	mergeRequestIID, noteID int,
+	body string,
+) (*models.GitLabMergeRequestComment, error) {
+	c.logger.Debug("Updating merge request comment",
+		"projectID", projectID,
+		"mergeRequestIID", mergeRequestIID,
+		"noteID", noteID)
+
+	endpoint := fmt.Sprintf("projects/%s/merge_requests/%d/notes/%d", url.PathEscape(projectID), mergeRequestIID, noteID)
+
+	jsonBody, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	// Get commits
-	commits, err := c.GetMergeRequestCommits(ctx, projectID, mergeRequestIID)
+	resp, err := c.doRequest(ctx, http.MethodPut, endpoint, strings.NewReader(string(jsonBody)))
 	if err != nil {
-		c.logger.Warn("Failed to get merge request commits", "error", err)
-	} else {
-		// Extract commit messages
-		mr.MergeRequestContext.CommitMessages = make([]string, 0)
-		for _, commit := range commits {
-			mr.MergeRequestContext.CommitMessages = append(mr.MergeRequestContext.CommitMessages, commit.Title)
-		}
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var comment models.GitLabMergeRequestComment
+	if err := json.NewDecoder(resp.Body).Decode(&comment); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return mr, nil
+	return &comment, nil
 }
 
-// CreateMergeRequestComment creates a new comment on a merge request
-func (c *Client) CreateMergeRequestComment(ctx context.Context, projectID string, mergeRequestIID int, body string) (*models.GitLabMergeRequestComment, error) {
-	c.logger.Debug("Creating merge request comment", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+// ListMergeRequestDiscussions returns up to 100 discussion threads on a merge request
+func (c *Client) ListMergeRequestDiscussions(ctx context.Context, projectID string, mergeRequestIID int) ([]models.GitLabDiscussion, error) {
+	c.logger.Debug("Listing merge request discussions", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
 
-	endpoint := fmt.Sprintf("projects/%s/merge_requests/%d/notes", url.PathEscape(projectID), mergeRequestIID)
+	endpoint := fmt.Sprintf("projects/%s/merge_requests/%d/discussions?per_page=100", url.PathEscape(projectID), mergeRequestIID)
+	resp, err := c.doRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
 
-	// Create request payload
-	reqBody := map[string]string{
-		"body": body,
+	var discussions []models.GitLabDiscussion
+	if err := json.NewDecoder(resp.Body).Decode(&discussions); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	c.logger.Debug("Listed merge request discussions",
+		"projectID", projectID,
+		"mergeRequestIID", mergeRequestIID,
+		"count", len(discussions))
+	return discussions, nil
+}
+
+// CreateMergeRequestDiscussion starts a discussion thread on a merge request. With a
+// position the thread is pinned to that line of the diff; without one it is a
+// general thread.
+func (c *Client) CreateMergeRequestDiscussion(
+	ctx context.Context,
+	projectID string,
+	mergeRequestIID int,
+	body string,
+	position *models.GitLabDiffPosition,
+) (*models.GitLabDiscussion, error) {
+	c.logger.Debug("Creating merge request discussion", "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+
+	endpoint := fmt.Sprintf("projects/%s/merge_requests/%d/discussions", url.PathEscape(projectID), mergeRequestIID)
+
+	reqBody := struct {
+		Body     string                     `json:"body"`
+		Position *models.GitLabDiffPosition `json:"position,omitempty"`
+	}{body, position}
+	if position != nil && position.PositionType == "" {
+		pinned := *position
+		pinned.PositionType = "text"
+		reqBody.Position = &pinned
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -232,10 +307,10 @@ func (c *Client) CreateMergeRequestComment(ctx context.Context, projectID string
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	var comment models.GitLabMergeRequestComment
-	if err := json.NewDecoder(resp.Body).Decode(&comment); err != nil {
+	var discussion models.GitLabDiscussion
+	if err := json.NewDecoder(resp.Body).Decode(&discussion); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	return &comment, nil
+	return &discussion, nil
 }
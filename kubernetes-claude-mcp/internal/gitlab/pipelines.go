@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
 )
@@ -140,3 +141,37 @@ func (c *Client) GetJobLogs(ctx context.Context, projectID string, jobID int) (s
 
 	return string(logs), nil
 }
+
+// SetCommitStatus reports the state of an external check on a commit. Status is one
+// of pending, running, success, failed or canceled, and the check is shown under its
+// name in the commit's and merge request's pipeline status.
+func (c *Client) SetCommitStatus(ctx context.Context, projectID, sha string, status models.GitLabCommitStatus) (*models.GitLabCommitStatus, error) {
+	c.logger.Debug("Setting commit status", "projectID", projectID, "sha", sha, "name", status.Name, "state", status.Status)
+
+	endpoint := fmt.Sprintf("projects/%s/statuses/%s", url.PathEscape(projectID), url.PathEscape(sha))
+
+	reqBody := struct {
+		State       string `json:"state"`
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		TargetURL   string `json:"target_url,omitempty"`
+	}{status.Status, status.Name, status.Description, status.TargetURL}
+
+	jsonBody, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodPost, endpoint, strings.NewReader(string(jsonBody)))
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var created models.GitLabCommitStatus
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &created, nil
+}
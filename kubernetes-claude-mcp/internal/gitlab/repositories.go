@@ -7,9 +7,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
 )
 
 // ListProjects returns a list of GitLab projects
@@ -42,6 +44,10 @@ func (c *Client) ListProjects(ctx context.Context) ([]models.GitLabProject, erro
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
+	for i := range projects {
+		projects[i].Provider = scm.ProviderGitLab
+	}
+
 	c.logger.Debug("Listed projects", "count", len(projects))
 	return projects, nil
 }
@@ -61,6 +67,7 @@ func (c *Client) GetProject(ctx context.Context, projectID string) (*models.GitL
 	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	project.Provider = scm.ProviderGitLab
 
 	return &project, nil
 }
@@ -83,6 +90,7 @@ func (c *Client) GetProjectByPath(ctx context.Context, path string) (*models.Git
 	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
+	project.Provider = scm.ProviderGitLab
 
 	return &project, nil
 }
@@ -165,6 +173,50 @@ func (c *Client) GetFileContent(ctx context.Context, projectID, filePath, ref st
 	return string(content), nil
 }
 
+// maxTreePages bounds how many pages of a repository tree are fetched
+const maxTreePages = 20
+
+// ListRepositoryTree lists the files and directories under a path at a ref. Recursive
+// listings include every file below the path.
+func (c *Client) ListRepositoryTree(ctx context.Context, projectID, treePath, ref string, recursive bool) ([]models.GitLabTreeEntry, error) {
+	c.logger.Debug("Listing repository tree",
+		"projectID", projectID,
+		"path", treePath,
+		"ref", ref,
+		"recursive", recursive)
+
+	var entries []models.GitLabTreeEntry
+	for page := 1; page <= maxTreePages; page++ {
+		q := url.Values{}
+		q.Set("path", treePath)
+		q.Set("ref", ref)
+		q.Set("recursive", strconv.FormatBool(recursive))
+		q.Set("per_page", "100")
+		q.Set("page", strconv.Itoa(page))
+		endpoint := fmt.Sprintf("projects/%s/repository/tree?%s", url.PathEscape(projectID), q.Encode())
+
+		resp, err := c.doRequest(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var batch []models.GitLabTreeEntry
+		err = json.NewDecoder(resp.Body).Decode(&batch)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+
+		entries = append(entries, batch...)
+		if len(batch) < 100 {
+			break
+		}
+	}
+
+	c.logger.Debug("Listed repository tree", "projectID", projectID, "path", treePath, "count", len(entries))
+	return entries, nil
+}
+
 // FindRecentChanges finds recent changes (commits) for a project
 func (c *Client) FindRecentChanges(ctx context.Context, projectID string, since time.Time) ([]models.GitLabCommit, error) {
 	c.logger.Debug("Finding recent changes",
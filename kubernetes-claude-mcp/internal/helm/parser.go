@@ -7,11 +7,21 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v2"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/downloader"
+	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
+	"helm.sh/helm/v3/pkg/releaseutil"
 
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
 )
@@ -41,53 +51,268 @@ func NewParser(logger *logging.Logger) *Parser {
 	}
 }
 
+// RenderOptions controls how a chart is rendered. Values is a nested map merged over
+// the chart's values after ValuesFiles, as another values file would be. KubeVersion
+// and APIVersions set the capabilities templates see. BuildDependencies fetches the
+// dependencies a chart declares but does not vendor before rendering it.
+type RenderOptions struct {
+	ReleaseName       string
+	Namespace         string
+	ValuesFiles       []string
+	Values            map[string]interface{}
+	KubeVersion       string
+	APIVersions       []string
+	BuildDependencies bool
+}
+
+// TemplateError is a chart rendering failure attributed to a template
+type TemplateError struct {
+	Template string
+	Line     int
+	Column   int
+	Message  string
+}
+
+func (e *TemplateError) Error() string {
+	location := e.Template
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, e.Line)
+		if e.Column > 0 {
+			location = fmt.Sprintf("%s:%d", location, e.Column)
+		}
+	}
+	return fmt.Sprintf("template %s: %s", location, e.Message)
+}
+
+// Helm engine error formats that name the failing template
+var (
+	executeErrorPattern = regexp.MustCompile(`template: ([^:\s]+):(\d+):(\d+): (.*)`)
+	failErrorPattern    = regexp.MustCompile(`execution error at \(([^:\s]+):(\d+)(?::(\d+))?\): (.*)`)
+	parseErrorPattern   = regexp.MustCompile(`parse error at \(([^:\s]+):(\d+)\): (.*)`)
+	yamlErrorPattern    = regexp.MustCompile(`YAML parse error on ([^:\s]+): (.*)`)
+	yamlLinePattern     = regexp.MustCompile(`line (\d+)`)
+)
+
 // ParseChart renders a Helm chart and returns the resulting Kubernetes manifests
 func (p *Parser) ParseChart(ctx context.Context, chartPath string, valuesFiles []string, values map[string]interface{}) ([]string, error) {
-	p.logger.Debug("Parsing Helm chart", "chartPath", chartPath, "valuesFiles", valuesFiles)
+	return p.RenderChart(ctx, chartPath, RenderOptions{ValuesFiles: valuesFiles, Values: values})
+}
+
+// RenderChart renders a Helm chart in process with the Helm chart loader and template
+// engine, as helm template would, and returns the resulting Kubernetes manifests,
+// hooks included. Failures in a template are returned as a *TemplateError.
+func (p *Parser) RenderChart(ctx context.Context, chartPath string, opts RenderOptions) ([]string, error) {
+	p.logger.Debug("Parsing Helm chart", "chartPath", chartPath, "valuesFiles", opts.ValuesFiles)
 
-	// Check if helm command is available
-	if _, err := exec.LookPath("helm"); err != nil {
-		return nil, fmt.Errorf("helm command not found in PATH: %w", err)
+	chrt, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
 	}
 
-	// Prepare helm template command
-	args := []string{"template", "release", chartPath}
+	if missing := missingDependencies(chrt); len(missing) > 0 {
+		if !opts.BuildDependencies {
+			return nil, fmt.Errorf("chart dependencies are not vendored in charts/: %s", strings.Join(missing, ", "))
+		}
+		if err := p.buildDependencies(ctx, chartPath); err != nil {
+			return nil, err
+		}
+		if chrt, err = loader.Load(chartPath); err != nil {
+			return nil, fmt.Errorf("failed to load chart: %w", err)
+		}
+	}
 
-	// Add values files
-	for _, valuesFile := range valuesFiles {
-		args = append(args, "-f", valuesFile)
+	values, err := renderValuesFrom(opts)
+	if err != nil {
+		return nil, err
 	}
 
-	// Add --set arguments for values
-	for k, v := range values {
-		args = append(args, "--set", fmt.Sprintf("%s=%v", k, v))
+	// Resolve enabled subcharts and their imported values, as an install would
+	if err := chartutil.ProcessDependenciesWithMerge(chrt, values); err != nil {
+		return nil, fmt.Errorf("failed to process chart dependencies: %w", err)
 	}
 
-	// Execute helm template command
-	cmd := exec.CommandContext(ctx, "helm", args...) //nolint:gosec // Helm command with controlled arguments
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	caps := chartutil.DefaultCapabilities.Copy()
+	if opts.KubeVersion != "" {
+		kubeVersion, err := chartutil.ParseKubeVersion(opts.KubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kube version %q: %w", opts.KubeVersion, err)
+		}
+		caps.KubeVersion = *kubeVersion
+	}
+	caps.APIVersions = append(caps.APIVersions, opts.APIVersions...)
+
+	releaseName := opts.ReleaseName
+	if releaseName == "" {
+		releaseName = "release"
+	}
+	namespace := opts.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	renderValues, err := chartutil.ToRenderValues(chrt, values, chartutil.ReleaseOptions{
+		Name:      releaseName,
+		Namespace: namespace,
+		Revision:  1,
+		IsInstall: true,
+	}, caps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute chart values: %w", err)
+	}
 
-	p.logger.Debug("Executing helm template command", "args", args)
-	err := cmd.Run()
+	rendered, err := engine.Render(chrt, renderValues)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute helm template: %s, error: %w", stderr.String(), err)
+		return nil, renderError(err)
 	}
 
-	// Parse the rendered templates
-	manifests := p.splitYAMLDocuments(stdout.String())
+	// Notes are not manifests
+	for name := range rendered {
+		if strings.HasSuffix(name, "NOTES.txt") {
+			delete(rendered, name)
+		}
+	}
+	hooks, sorted, err := releaseutil.SortManifests(rendered, caps.APIVersions, releaseutil.InstallOrder)
+	if err != nil {
+		return nil, renderError(err)
+	}
+
+	var manifests []string
+	for _, hook := range hooks {
+		manifests = append(manifests, p.splitYAMLDocuments(fmt.Sprintf("# Source: %s\n%s", hook.Path, hook.Manifest))...)
+	}
+	for _, manifest := range sorted {
+		manifests = append(manifests, p.splitYAMLDocuments(fmt.Sprintf("# Source: %s\n%s", manifest.Name, manifest.Content))...)
+	}
 	p.logger.Debug("Parsed Helm chart", "manifestCount", len(manifests))
 
 	return manifests, nil
 }
 
-// WriteChartFiles writes chart files to the working directory for processing
+// missingDependencies returns the dependencies a chart declares but does not contain
+func missingDependencies(chrt *chart.Chart) []string {
+	loaded := make(map[string]bool)
+	for _, dependency := range chrt.Dependencies() {
+		loaded[dependency.Name()] = true
+	}
+	var missing []string
+	for _, dependency := range chrt.Metadata.Dependencies {
+		if !loaded[dependency.Name] {
+			missing = append(missing, dependency.Name)
+		}
+	}
+	return missing
+}
+
+// renderValuesFrom reads the values files in order and merges the values given inline
+// over them, nested maps key by key. Null values are kept, so that they delete the
+// chart's defaults when coalesced.
+func renderValuesFrom(opts RenderOptions) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	for _, valuesFile := range opts.ValuesFiles {
+		fileValues, err := chartutil.ReadValuesFile(valuesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %s: %w", filepath.Base(valuesFile), err)
+		}
+		values = overlayValues(values, fileValues)
+	}
+	return overlayValues(values, opts.Values), nil
+}
+
+// overlayValues merges b over a, recursing into maps present in both
+func overlayValues(a, b map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(a))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		if bv, ok := v.(map[string]interface{}); ok {
+			if av, ok := out[k].(map[string]interface{}); ok {
+				out[k] = overlayValues(av, bv)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// buildDependencies fetches the dependencies a chart declares into its charts/
+// directory, from the lock file when there is one, as helm dependency build does
+func (p *Parser) buildDependencies(ctx context.Context, chartPath string) error {
+	p.logger.Debug("Building chart dependencies", "chartPath", chartPath)
+
+	settings := cli.New()
+	registryClient, err := registry.NewClient(registry.ClientOptCredentialsFile(settings.RegistryConfig))
+	if err != nil {
+		return fmt.Errorf("failed to create registry client: %w", err)
+	}
+	var out bytes.Buffer
+	manager := &downloader.Manager{
+		Out:              &out,
+		ChartPath:        chartPath,
+		Getters:          getter.All(settings),
+		RegistryClient:   registryClient,
+		RepositoryConfig: settings.RepositoryConfig,
+		RepositoryCache:  settings.RepositoryCache,
+	}
+
+	// The downloader does not take a context, so a cancelled request only stops waiting
+	done := make(chan error, 1)
+	go func() { done <- manager.Build() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to build chart dependencies: %s, error: %w", strings.TrimSpace(out.String()), err)
+		}
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to build chart dependencies: %w", ctx.Err())
+	}
+}
+
+// renderError returns a rendering failure as a *TemplateError when it names a template
+func renderError(err error) error {
+	if templateErr := parseTemplateError(err.Error()); templateErr != nil {
+		return templateErr
+	}
+	return fmt.Errorf("failed to render chart: %w", err)
+}
+
+// parseTemplateError extracts the failing template from a Helm engine error
+func parseTemplateError(output string) *TemplateError {
+	if m := executeErrorPattern.FindStringSubmatch(output); m != nil {
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		return &TemplateError{Template: m[1], Line: line, Column: column, Message: strings.TrimSpace(m[4])}
+	}
+	if m := failErrorPattern.FindStringSubmatch(output); m != nil {
+		line, _ := strconv.Atoi(m[2])
+		column, _ := strconv.Atoi(m[3])
+		return &TemplateError{Template: m[1], Line: line, Column: column, Message: strings.TrimSpace(m[4])}
+	}
+	if m := parseErrorPattern.FindStringSubmatch(output); m != nil {
+		line, _ := strconv.Atoi(m[2])
+		return &TemplateError{Template: m[1], Line: line, Message: strings.TrimSpace(m[3])}
+	}
+	if m := yamlErrorPattern.FindStringSubmatch(output); m != nil {
+		templateErr := &TemplateError{Template: m[1], Message: strings.TrimSpace(m[2])}
+		if lm := yamlLinePattern.FindStringSubmatch(m[2]); lm != nil {
+			templateErr.Line, _ = strconv.Atoi(lm[1])
+		}
+		return templateErr
+	}
+	return nil
+}
+
+// WriteChartFiles writes chart files to a new directory under the working directory,
+// so several charts or versions of a chart can be rendered side by side
 func (p *Parser) WriteChartFiles(files map[string]string) (string, error) {
-	chartDir := filepath.Join(p.workDir, "chart")
+	// The working directory may have been removed by an earlier Cleanup
+	if err := os.MkdirAll(p.workDir, 0o750); err != nil { //nolint:gosec // Directory permissions for chart files
+		return "", fmt.Errorf("failed to create working directory: %w", err)
+	}
 
-	// Create chart directory if not exists
-	if err := os.MkdirAll(chartDir, 0o750); err != nil { //nolint:gosec // Directory permissions for chart files
+	chartDir, err := os.MkdirTemp(p.workDir, "chart-*")
+	if err != nil {
 		return "", fmt.Errorf("failed to create chart directory: %w", err)
 	}
 
@@ -110,15 +335,23 @@ func (p *Parser) WriteChartFiles(files map[string]string) (string, error) {
 	return chartDir, nil
 }
 
-// WriteValuesFile writes a values file to the working directory
+// WriteValuesFile writes a values file to a new file in the working directory
 func (p *Parser) WriteValuesFile(content string) (string, error) {
-	valuesFile := filepath.Join(p.workDir, "values.yaml")
+	if err := os.MkdirAll(p.workDir, 0o750); err != nil { //nolint:gosec // Directory permissions for chart files
+		return "", fmt.Errorf("failed to create working directory: %w", err)
+	}
 
-	if err := os.WriteFile(valuesFile, []byte(content), 0o600); err != nil {
+	valuesFile, err := os.CreateTemp(p.workDir, "values-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("failed to create values file: %w", err)
+	}
+	defer func() { _ = valuesFile.Close() }()
+
+	if _, err := valuesFile.WriteString(content); err != nil {
 		return "", fmt.Errorf("failed to write values file: %w", err)
 	}
 
-	return valuesFile, nil
+	return valuesFile.Name(), nil
 }
 
 // ParseYAML parses a YAML file to extract Kubernetes resources
@@ -149,16 +382,15 @@ func (p *Parser) ParseYAML(content string) ([]map[string]interface{}, error) {
 	return resources, nil
 }
 
+// documentSeparator matches a YAML document separator line, optionally followed by a comment
+var documentSeparator = regexp.MustCompile(`(?m)^---[ \t]*(#.*)?$`)
+
 // splitYAMLDocuments splits multi-document YAML into individual documents
 func (p *Parser) splitYAMLDocuments(content string) []string {
-	// Simple implementation - in a real system, use a proper YAML parser
 	var documents []string
 
-	// Split on document separator
-	parts := strings.Split(content, "---")
-
-	for _, part := range parts {
-		// Trim whitespace
+	// Split only on separator lines, so values such as PEM blocks stay intact
+	for _, part := range documentSeparator.Split(content, -1) {
 		trimmed := strings.TrimSpace(part)
 		if trimmed != "" {
 			documents = append(documents, trimmed)
@@ -0,0 +1,365 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/manifest"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// Labels and annotations Helm sets on release Secrets and on the objects it manages
+const (
+	ReleaseSecretType          = "helm.sh/release.v1"
+	ManagedByLabel             = "app.kubernetes.io/managed-by"
+	ManagedByHelm              = "Helm"
+	ReleaseNameAnnotation      = "meta.helm.sh/release-name"
+	ReleaseNamespaceAnnotation = "meta.helm.sh/release-namespace"
+)
+
+// ErrReleaseNotFound is returned when a release or revision does not exist
+var ErrReleaseNotFound = errors.New("helm release not found")
+
+// ErrNoEarlierRevision is returned when a diff defaults its from revision but the to
+// revision is the release's first
+var ErrNoEarlierRevision = errors.New("helm release has no earlier revision")
+
+// gzipMagic starts every gzip stream; Helm compresses releases before encoding them
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
+
+// Release is a Helm release revision as Helm stores it in a release Secret
+type Release struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		FirstDeployed time.Time `json:"first_deployed"`
+		LastDeployed  time.Time `json:"last_deployed"`
+		Description   string    `json:"description"`
+		Status        string    `json:"status"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name       string `json:"name"`
+			Version    string `json:"version"`
+			AppVersion string `json:"appVersion"`
+		} `json:"metadata"`
+		Values map[string]interface{} `json:"values"`
+	} `json:"chart"`
+	Config   map[string]interface{} `json:"config"`
+	Manifest string                 `json:"manifest"`
+}
+
+// DecodeRelease decodes the release key of a release Secret: base64 over an optionally
+// gzipped JSON document
+func DecodeRelease(data []byte) (*Release, error) {
+	decoded, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode release: %w", err)
+	}
+
+	if bytes.HasPrefix(decoded, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(decoded))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress release: %w", err)
+		}
+		defer func() { _ = reader.Close() }()
+		decoded, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress release: %w", err)
+		}
+	}
+
+	var release Release
+	if err := json.Unmarshal(decoded, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release: %w", err)
+	}
+	return &release, nil
+}
+
+// Summary returns the release metadata
+func (r *Release) Summary() models.HelmRelease {
+	return models.HelmRelease{
+		Name:         r.Name,
+		Namespace:    r.Namespace,
+		Revision:     r.Version,
+		Status:       r.Info.Status,
+		Chart:        r.Chart.Metadata.Name,
+		ChartVersion: r.Chart.Metadata.Version,
+		AppVersion:   r.Chart.Metadata.AppVersion,
+		Description:  r.Info.Description,
+		Updated:      r.Info.LastDeployed,
+	}
+}
+
+// ComputedValues returns the chart's default values overridden by the values supplied
+// for the release, as Helm computes them when rendering
+func (r *Release) ComputedValues() map[string]interface{} {
+	return mergeValues(r.Chart.Values, r.Config)
+}
+
+// Objects parses the manifest Helm rendered for the release
+func (r *Release) Objects() ([]map[string]interface{}, error) {
+	return manifest.Parse(r.Manifest)
+}
+
+// mergeValues deep-merges overrides into a copy of defaults. A null override deletes
+// the key, as it does in Helm.
+func mergeValues(defaults, overrides map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(defaults)+len(overrides))
+	for key, value := range defaults {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		overrideMap, isMap := value.(map[string]interface{})
+		defaultMap, defaultIsMap := merged[key].(map[string]interface{})
+		if isMap && defaultIsMap {
+			merged[key] = mergeValues(defaultMap, overrideMap)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// Releases reads Helm releases from the release Secrets in a cluster
+type Releases struct {
+	clientset kubernetes.Interface
+	logger    *logging.Logger
+}
+
+// NewReleases creates a reader for the Helm releases in a cluster
+func NewReleases(clientset kubernetes.Interface, logger *logging.Logger) *Releases {
+	if logger == nil {
+		logger = logging.NewLogger().Named("helm")
+	}
+
+	return &Releases{
+		clientset: clientset,
+		logger:    logger,
+	}
+}
+
+// List returns the latest revision of every release in a namespace, or in all
+// namespaces when namespace is empty, with each release's revision history
+func (r *Releases) List(ctx context.Context, namespace string) ([]models.HelmReleaseSummary, error) {
+	releases, err := r.load(ctx, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+
+	byRelease := make(map[string][]*Release)
+	var keys []string
+	for _, release := range releases {
+		key := release.Namespace + "/" + release.Name
+		if _, ok := byRelease[key]; !ok {
+			keys = append(keys, key)
+		}
+		byRelease[key] = append(byRelease[key], release)
+	}
+	sort.Strings(keys)
+
+	summaries := make([]models.HelmReleaseSummary, 0, len(keys))
+	for _, key := range keys {
+		summaries = append(summaries, summarize(byRelease[key]))
+	}
+	return summaries, nil
+}
+
+// History returns a release's latest revision and its revision history
+func (r *Releases) History(ctx context.Context, namespace, name string) (*models.HelmReleaseSummary, error) {
+	releases, err := r.load(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("%w: %s in namespace %s", ErrReleaseNotFound, name, namespace)
+	}
+
+	summary := summarize(releases)
+	return &summary, nil
+}
+
+// Get returns the values and manifest of a release revision, or of the latest
+// revision when revision is 0
+func (r *Releases) Get(ctx context.Context, namespace, name string, revision int) (*models.HelmReleaseDetail, error) {
+	release, err := r.revision(ctx, namespace, name, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	objects, err := release.Objects()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of %s revision %d: %w", name, release.Version, err)
+	}
+
+	userValues := release.Config
+	if userValues == nil {
+		userValues = map[string]interface{}{}
+	}
+	return &models.HelmReleaseDetail{
+		HelmRelease: release.Summary(),
+		Values:      release.ComputedValues(),
+		UserValues:  userValues,
+		Manifest:    objects,
+	}, nil
+}
+
+// Diff compares the computed values and rendered objects of two revisions of a
+// release. A 0 from revision means the one before to, and a 0 to revision the latest.
+// Values are reported through mask, as manifest.Diff does.
+func (r *Releases) Diff(ctx context.Context, namespace, name string, from, to int, mask manifest.Masker) (*models.HelmReleaseDiff, error) {
+	releases, err := r.load(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("%w: %s in namespace %s", ErrReleaseNotFound, name, namespace)
+	}
+
+	if to == 0 {
+		to = releases[0].Version
+	}
+	if from == 0 {
+		if to <= 1 {
+			return nil, fmt.Errorf("%w: revision %d of %s is its first", ErrNoEarlierRevision, to, name)
+		}
+		from = to - 1
+	}
+
+	fromRelease, err := findRevision(releases, name, from)
+	if err != nil {
+		return nil, err
+	}
+	toRelease, err := findRevision(releases, name, to)
+	if err != nil {
+		return nil, err
+	}
+
+	fromObjects, err := fromRelease.Objects()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of %s revision %d: %w", name, from, err)
+	}
+	toObjects, err := toRelease.Objects()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of %s revision %d: %w", name, to, err)
+	}
+
+	return &models.HelmReleaseDiff{
+		Name:      name,
+		Namespace: namespace,
+		From:      fromRelease.Summary(),
+		To:        toRelease.Summary(),
+		Values:    manifest.DiffFields(fromRelease.ComputedValues(), toRelease.ComputedValues(), mask),
+		Resources: manifest.Diff(fromObjects, toObjects, mask),
+	}, nil
+}
+
+// revision loads one revision of a release, or the latest when revision is 0
+func (r *Releases) revision(ctx context.Context, namespace, name string, revision int) (*Release, error) {
+	releases, err := r.load(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(releases) == 0 {
+		return nil, fmt.Errorf("%w: %s in namespace %s", ErrReleaseNotFound, name, namespace)
+	}
+	if revision == 0 {
+		return releases[0], nil
+	}
+	return findRevision(releases, name, revision)
+}
+
+// load decodes the release Secrets in a namespace, optionally for a single release,
+// newest revision first. Secrets that fail to decode are logged and skipped.
+func (r *Releases) load(ctx context.Context, namespace, name string) ([]*Release, error) {
+	selector := "owner=helm"
+	if name != "" {
+		selector += ",name=" + name
+	}
+
+	secrets, err := r.clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector,
+		FieldSelector: "type=" + ReleaseSecretType,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Helm release secrets: %w", err)
+	}
+
+	releases := make([]*Release, 0, len(secrets.Items))
+	for i := range secrets.Items {
+		release, err := decodeSecret(&secrets.Items[i])
+		if err != nil {
+			r.logger.Warn("Skipping undecodable Helm release secret",
+				"namespace", secrets.Items[i].Namespace,
+				"secret", secrets.Items[i].Name,
+				"error", err)
+			continue
+		}
+		releases = append(releases, release)
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].Version > releases[j].Version
+	})
+	return releases, nil
+}
+
+// decodeSecret decodes a release Secret, filling in the namespace for releases stored
+// by Helm versions that left it out of the release itself
+func decodeSecret(secret *corev1.Secret) (*Release, error) {
+	if secret.Type != ReleaseSecretType {
+		return nil, fmt.Errorf("secret has type %s, not %s", secret.Type, ReleaseSecretType)
+	}
+	data, ok := secret.Data["release"]
+	if !ok {
+		return nil, fmt.Errorf("secret has no release key")
+	}
+
+	release, err := DecodeRelease(data)
+	if err != nil {
+		return nil, err
+	}
+	if release.Namespace == "" {
+		release.Namespace = secret.Namespace
+	}
+	return release, nil
+}
+
+// summarize builds a summary from the revisions of one release, newest first
+func summarize(revisions []*Release) models.HelmReleaseSummary {
+	summary := models.HelmReleaseSummary{
+		HelmRelease: revisions[0].Summary(),
+		History:     make([]models.HelmRelease, 0, len(revisions)),
+	}
+	for _, revision := range revisions {
+		summary.History = append(summary.History, revision.Summary())
+	}
+	return summary
+}
+
+func findRevision(releases []*Release, name string, revision int) (*Release, error) {
+	for _, release := range releases {
+		if release.Version == revision {
+			return release, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s has no revision %d", ErrReleaseNotFound, name, revision)
+}
@@ -0,0 +1,200 @@
+package helm
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const releaseManifest = `---
+# Source: api/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  replicas: %d
+  template:
+    spec:
+      containers:
+      - name: api
+        image: registry.example.com/api:%s
+`
+
+// releaseSecret encodes a release revision the way Helm stores it
+func releaseSecret(t *testing.T, name string, revision int, status string, config map[string]interface{}, replicas int, tag string) *corev1.Secret {
+	t.Helper()
+
+	release := map[string]interface{}{
+		"name":      name,
+		"namespace": "shop",
+		"version":   revision,
+		"info": map[string]interface{}{
+			"status":        status,
+			"description":   "Upgrade complete",
+			"last_deployed": "2026-03-01T10:00:00Z",
+		},
+		"chart": map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "api", "version": "1.2." + strconv.Itoa(revision), "appVersion": tag},
+			"values": map[string]interface{}{
+				"replicas": 1,
+				"image":    map[string]interface{}{"repository": "registry.example.com/api", "tag": "latest"},
+				"debug":    false,
+			},
+		},
+		"config":   config,
+		"manifest": fmt.Sprintf(releaseManifest, replicas, tag),
+	}
+	raw, err := json.Marshal(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sh.helm.release.v1." + name + ".v" + strconv.Itoa(revision),
+			Namespace: "shop",
+			Labels: map[string]string{
+				"owner":   "helm",
+				"name":    name,
+				"status":  status,
+				"version": strconv.Itoa(revision),
+			},
+		},
+		Type: ReleaseSecretType,
+		Data: map[string][]byte{
+			"release": []byte(base64.StdEncoding.EncodeToString(compressed.Bytes())),
+		},
+	}
+}
+
+func newTestReleases(t *testing.T) *Releases {
+	clientset := fake.NewClientset(
+		releaseSecret(t, "api", 1, "superseded", map[string]interface{}{"replicas": 2}, 2, "1.0.0"),
+		releaseSecret(t, "api", 2, "deployed", map[string]interface{}{
+			"replicas": 3,
+			"image":    map[string]interface{}{"tag": "1.1.0"},
+			"debug":    nil,
+		}, 3, "1.1.0"),
+		releaseSecret(t, "worker", 1, "failed", nil, 1, "0.9.0"),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-creds", Namespace: "shop"},
+			Data:       map[string][]byte{"password": []byte("hunter2")},
+		},
+	)
+	return NewReleases(clientset, nil)
+}
+
+func TestReleasesList(t *testing.T) {
+	releases, err := newTestReleases(t).List(context.Background(), "shop")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(releases) != 2 {
+		t.Fatalf("Expected 2 releases, got %d", len(releases))
+	}
+
+	api := releases[0]
+	if api.Name != "api" || api.Revision != 2 || api.Status != "deployed" || api.ChartVersion != "1.2.2" {
+		t.Errorf("Unexpected latest revision: %+v", api.HelmRelease)
+	}
+	if len(api.History) != 2 || api.History[0].Revision != 2 || api.History[1].Revision != 1 {
+		t.Errorf("Expected history newest first, got %+v", api.History)
+	}
+	if releases[1].Name != "worker" || releases[1].Status != "failed" {
+		t.Errorf("Unexpected second release: %+v", releases[1].HelmRelease)
+	}
+}
+
+func TestReleasesGet(t *testing.T) {
+	detail, err := newTestReleases(t).Get(context.Background(), "shop", "api", 0)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if detail.Revision != 2 {
+		t.Errorf("Expected the latest revision, got %d", detail.Revision)
+	}
+
+	image, _ := detail.Values["image"].(map[string]interface{})
+	if image["tag"] != "1.1.0" || image["repository"] != "registry.example.com/api" {
+		t.Errorf("Expected user values merged over chart defaults, got %v", image)
+	}
+	if _, ok := detail.Values["debug"]; ok {
+		t.Error("Expected a null user value to remove the chart default")
+	}
+	if len(detail.Manifest) != 1 || detail.Manifest[0]["kind"] != "Deployment" {
+		t.Errorf("Expected the rendered Deployment, got %v", detail.Manifest)
+	}
+
+	_, err = newTestReleases(t).Get(context.Background(), "shop", "api", 7)
+	if !errors.Is(err, ErrReleaseNotFound) {
+		t.Errorf("Expected ErrReleaseNotFound for a missing revision, got %v", err)
+	}
+}
+
+func TestReleasesDiff(t *testing.T) {
+	diff, err := newTestReleases(t).Diff(context.Background(), "shop", "api", 0, 0, nil)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if diff.From.Revision != 1 || diff.To.Revision != 2 {
+		t.Errorf("Expected revisions 1 to 2, got %d to %d", diff.From.Revision, diff.To.Revision)
+	}
+
+	changed := make(map[string]bool)
+	for _, field := range diff.Values {
+		changed[field.Path] = true
+	}
+	for _, path := range []string{"replicas", "image.tag", "debug"} {
+		if !changed[path] {
+			t.Errorf("Expected a values change at %s, got %+v", path, diff.Values)
+		}
+	}
+
+	if len(diff.Resources) != 1 {
+		t.Fatalf("Expected 1 changed resource, got %d", len(diff.Resources))
+	}
+	resource := diff.Resources[0]
+	if len(resource.ImageChanges) != 1 || resource.ImageChanges[0].After != "registry.example.com/api:1.1.0" {
+		t.Errorf("Expected the image change, got %+v", resource.ImageChanges)
+	}
+	if resource.ReplicaChange == nil || *resource.ReplicaChange.After != 3 {
+		t.Errorf("Expected the replica change, got %+v", resource.ReplicaChange)
+	}
+
+	_, err = newTestReleases(t).Diff(context.Background(), "shop", "api", 0, 1, nil)
+	if !errors.Is(err, ErrNoEarlierRevision) {
+		t.Errorf("Expected ErrNoEarlierRevision diffing the first revision, got %v", err)
+	}
+}
+
+func TestDecodeReleaseUncompressed(t *testing.T) {
+	data := base64.StdEncoding.EncodeToString([]byte(`{"name":"api","version":4,"info":{"status":"deployed"}}`))
+	release, err := DecodeRelease([]byte(data))
+	if err != nil {
+		t.Fatalf("DecodeRelease failed: %v", err)
+	}
+	if release.Name != "api" || release.Version != 4 || release.Info.Status != "deployed" {
+		t.Errorf("Unexpected release: %+v", release.Summary())
+	}
+}
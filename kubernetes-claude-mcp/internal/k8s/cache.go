@@ -0,0 +1,465 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// defaultCachedResources are cached when the configuration does not list resource types.
+// Secrets are left out on purpose so they are never held in memory by default.
+var defaultCachedResources = []string{
+	"pods",
+	"services",
+	"deployments",
+	"replicasets",
+	"statefulsets",
+	"daemonsets",
+	"jobs",
+	"configmaps",
+	"persistentvolumeclaims",
+	"ingresses",
+	"events",
+}
+
+// cacheRetryInterval is how long the cache waits before retrying resource discovery
+const cacheRetryInterval = 30 * time.Second
+
+// cacheSyncTimeout bounds how long one attempt waits for the informers' initial list
+const cacheSyncTimeout = 2 * time.Minute
+
+// CacheStatus reports the state of an informer cache. Skipped lists the resource types,
+// by namespace when the cache is namespaced, that the server may not list and that are
+// read from the API server instead.
+type CacheStatus struct {
+	Enabled    bool     `json:"enabled"`
+	Synced     bool     `json:"synced"`
+	Resources  []string `json:"resources,omitempty"`
+	Namespaces []string `json:"namespaces,omitempty"`
+	Skipped    []string `json:"skipped,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// cachedResource holds the listers for one resource type, keyed by namespace
+// ("" when the cache watches the whole cluster)
+type cachedResource struct {
+	namespaced bool
+	listers    map[string]cache.GenericLister
+}
+
+// ResourceCache serves Kubernetes reads from shared dynamic informers. Reads for
+// resource types or namespaces the cache does not watch, or made before the
+// informers have synced, are not served and fall back to the API server.
+type ResourceCache struct {
+	dynamicClient   dynamic.Interface
+	discoveryClient discovery.DiscoveryInterface
+	resources       []string
+	namespaces      []string
+	resync          time.Duration
+	mu              sync.RWMutex
+	cached          map[schema.GroupVersionResource]*cachedResource
+	synced          bool
+	skipped         []string
+	lastError       string
+	logger          *logging.Logger
+}
+
+// NewResourceCache creates an informer cache for the configured resource types and namespaces.
+// The informers do not run until Start is called.
+func NewResourceCache(
+	dynamicClient dynamic.Interface,
+	discoveryClient discovery.DiscoveryInterface,
+	cfg config.CacheConfig,
+	logger *logging.Logger,
+) *ResourceCache {
+	if logger == nil {
+		logger = logging.NewLogger().Named("cache")
+	}
+
+	resources := cfg.Resources
+	if len(resources) == 0 {
+		resources = defaultCachedResources
+	}
+
+	return &ResourceCache{
+		dynamicClient:   dynamicClient,
+		discoveryClient: discoveryClient,
+		resources:       resources,
+		namespaces:      cfg.Namespaces,
+		resync:          time.Duration(cfg.ResyncSeconds) * time.Second,
+		cached:          make(map[schema.GroupVersionResource]*cachedResource),
+		logger:          logger,
+	}
+}
+
+// Start resolves the cached resource types and runs the informers until ctx is done.
+// It returns immediately; use Synced or Status to see when reads are served from memory.
+func (rc *ResourceCache) Start(ctx context.Context) {
+	go func() {
+		for {
+			err := rc.run(ctx)
+			if err == nil {
+				return
+			}
+
+			rc.mu.Lock()
+			rc.lastError = err.Error()
+			rc.mu.Unlock()
+			rc.logger.Warn("Informer cache failed to start, retrying", "error", err, "retryIn", cacheRetryInterval)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(cacheRetryInterval):
+			}
+		}
+	}()
+}
+
+// run builds one informer factory per watched namespace and waits for the informers to
+// sync. Resource types the server may not list in a namespace are left out rather than
+// blocking the sync. An attempt that does not sync within cacheSyncTimeout stops its
+// informers and returns an error, so Start retries with fresh ones.
+func (rc *ResourceCache) run(ctx context.Context) error {
+	resolved, err := rc.resolveResources()
+	if err != nil {
+		return err
+	}
+
+	namespaces := rc.namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+
+	// The informers run until ctx is done once they sync, and are stopped otherwise
+	runCtx, cancel := context.WithCancel(ctx)
+	synced := false
+	var factories []dynamicinformer.DynamicSharedInformerFactory
+	defer func() {
+		if !synced {
+			cancel()
+			for _, factory := range factories {
+				factory.Shutdown()
+			}
+		}
+	}()
+
+	cached := make(map[schema.GroupVersionResource]*cachedResource, len(resolved))
+	var skipped []string
+	for _, namespace := range namespaces {
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(rc.dynamicClient, rc.resync, namespace, nil)
+		registered := false
+
+		for gvr, namespaced := range resolved {
+			// Cluster-scoped resources can only be watched by a cluster-wide cache
+			if !namespaced && namespace != metav1.NamespaceAll {
+				continue
+			}
+			listable, err := rc.canList(runCtx, gvr, namespace)
+			if err != nil {
+				return err
+			}
+			if !listable {
+				skipped = append(skipped, skippedResource(gvr, namespace))
+				continue
+			}
+			if cached[gvr] == nil {
+				cached[gvr] = &cachedResource{namespaced: namespaced, listers: make(map[string]cache.GenericLister)}
+			}
+			cached[gvr].listers[namespace] = factory.ForResource(gvr).Lister()
+			registered = true
+		}
+
+		if registered {
+			factories = append(factories, factory)
+		}
+	}
+	if len(factories) == 0 {
+		return fmt.Errorf("none of the configured cache resources can be listed")
+	}
+
+	for _, factory := range factories {
+		factory.Start(runCtx.Done())
+	}
+	syncCtx, cancelSync := context.WithTimeout(runCtx, cacheSyncTimeout)
+	defer cancelSync()
+	for _, factory := range factories {
+		for gvr, ok := range factory.WaitForCacheSync(syncCtx.Done()) {
+			if !ok {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return fmt.Errorf("informer for %s did not sync within %s", gvr.Resource, cacheSyncTimeout)
+			}
+		}
+	}
+	synced = true
+	sort.Strings(skipped)
+
+	rc.mu.Lock()
+	rc.cached = cached
+	rc.synced = true
+	rc.skipped = skipped
+	rc.lastError = ""
+	rc.mu.Unlock()
+
+	rc.logger.Info("Informer cache synced",
+		"resources", len(cached),
+		"namespaces", strings.Join(rc.namespaces, ","),
+		"skipped", strings.Join(skipped, ","))
+
+	return nil
+}
+
+// canList reports whether the server may list a resource type in a namespace, so
+// informers are only started for types that can sync. Forbidden and vanished types
+// are logged and reported as not listable; other errors are returned.
+func (rc *ResourceCache) canList(ctx context.Context, gvr schema.GroupVersionResource, namespace string) (bool, error) {
+	_, err := rc.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	switch {
+	case err == nil:
+		return true, nil
+	case apierrors.IsForbidden(err) || apierrors.IsNotFound(err):
+		rc.logger.Warn("Leaving resource type out of the informer cache",
+			"resource", gvr.Resource,
+			"namespace", namespace,
+			"error", err)
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to list %s: %w", gvr.Resource, err)
+	}
+}
+
+// skippedResource names a resource type left out of the cache, with its namespace when
+// the cache is namespaced
+func skippedResource(gvr schema.GroupVersionResource, namespace string) string {
+	if namespace == metav1.NamespaceAll {
+		return gvr.Resource
+	}
+	return namespace + "/" + gvr.Resource
+}
+
+// resolveResources maps the configured resource names to GVRs using API discovery,
+// reporting whether each resource is namespaced
+func (rc *ResourceCache) resolveResources() (map[schema.GroupVersionResource]bool, error) {
+	lists, err := rc.discoveryClient.ServerPreferredResources()
+	if err != nil && len(lists) == 0 {
+		return nil, fmt.Errorf("failed to get server resources: %w", err)
+	}
+
+	resolved := make(map[schema.GroupVersionResource]bool)
+	for _, name := range rc.resources {
+		found := false
+		for _, list := range lists {
+			gv, parseErr := schema.ParseGroupVersion(list.GroupVersion)
+			if parseErr != nil {
+				continue
+			}
+			for _, r := range list.APIResources {
+				if strings.Contains(r.Name, "/") || !strings.Contains(r.Verbs.String(), "watch") {
+					continue
+				}
+				if strings.EqualFold(r.Name, name) || strings.EqualFold(r.SingularName, name) || strings.EqualFold(r.Kind, name) {
+					resolved[gv.WithResource(r.Name)] = r.Namespaced
+					found = true
+					break
+				}
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			rc.logger.Warn("Skipping unknown resource type for informer cache", "resource", name)
+		}
+	}
+
+	if len(resolved) == 0 {
+		return nil, fmt.Errorf("none of the configured cache resources are available")
+	}
+	return resolved, nil
+}
+
+// lister returns the lister that covers a namespace ("" for all namespaces), if any
+func (rc *ResourceCache) lister(gvr schema.GroupVersionResource, namespace string) (cache.GenericLister, bool) {
+	if rc == nil {
+		return nil, false
+	}
+
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	if !rc.synced {
+		return nil, false
+	}
+	resource, ok := rc.cached[gvr]
+	if !ok {
+		return nil, false
+	}
+
+	// A cluster-wide informer answers for every namespace
+	if lister, ok := resource.listers[metav1.NamespaceAll]; ok {
+		return lister, true
+	}
+	if namespace == metav1.NamespaceAll || !resource.namespaced {
+		return nil, false
+	}
+	lister, ok := resource.listers[namespace]
+	return lister, ok
+}
+
+// List returns cached objects of a resource type matching the selector. ok is false
+// when the cache does not cover the request and the caller should query the API server.
+func (rc *ResourceCache) List(gvr schema.GroupVersionResource, namespace string, selector labels.Selector) ([]unstructured.Unstructured, bool) {
+	lister, ok := rc.lister(gvr, namespace)
+	if !ok {
+		return nil, false
+	}
+	if selector == nil {
+		selector = labels.Everything()
+	}
+
+	var (
+		objects []runtime.Object
+		err     error
+	)
+	if namespace != metav1.NamespaceAll {
+		objects, err = lister.ByNamespace(namespace).List(selector)
+	} else {
+		objects, err = lister.List(selector)
+	}
+	if err != nil {
+		return nil, false
+	}
+
+	items := make([]unstructured.Unstructured, 0, len(objects))
+	for _, obj := range objects {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			// Copy so callers cannot modify the shared informer store
+			items = append(items, *u.DeepCopy())
+		}
+	}
+
+	// Keep the order stable, as a live LIST would be
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].GetNamespace() != items[j].GetNamespace() {
+			return items[i].GetNamespace() < items[j].GetNamespace()
+		}
+		return items[i].GetName() < items[j].GetName()
+	})
+
+	return items, true
+}
+
+// Get returns a cached object. ok is false when the cache does not cover the request;
+// otherwise a missing object is reported as a NotFound error.
+func (rc *ResourceCache) Get(gvr schema.GroupVersionResource, namespace, name string) (*unstructured.Unstructured, bool, error) {
+	lister, ok := rc.lister(gvr, namespace)
+	if !ok {
+		return nil, false, nil
+	}
+
+	var (
+		obj runtime.Object
+		err error
+	)
+	if namespace != metav1.NamespaceAll {
+		obj, err = lister.ByNamespace(namespace).Get(name)
+	} else {
+		obj, err = lister.Get(name)
+	}
+	if err != nil {
+		return nil, true, err
+	}
+
+	u, isUnstructured := obj.(*unstructured.Unstructured)
+	if !isUnstructured {
+		return nil, false, nil
+	}
+	return u.DeepCopy(), true, nil
+}
+
+// Synced reports whether the informers have completed their initial list
+func (rc *ResourceCache) Synced() bool {
+	if rc == nil {
+		return false
+	}
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.synced
+}
+
+// Status reports the configuration and sync state of the cache
+func (rc *ResourceCache) Status() CacheStatus {
+	if rc == nil {
+		return CacheStatus{}
+	}
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return CacheStatus{
+		Enabled:    true,
+		Synced:     rc.synced,
+		Resources:  rc.resources,
+		Namespaces: rc.namespaces,
+		Skipped:    rc.skipped,
+		Error:      rc.lastError,
+	}
+}
+
+// listResources lists objects of a resource type, from the informer cache when it covers
+// the request and from the API server otherwise
+func (c *Client) listResources(
+	ctx context.Context,
+	gvr schema.GroupVersionResource,
+	namespace, labelSelector string,
+) ([]unstructured.Unstructured, error) {
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector %q: %w", labelSelector, err)
+	}
+	if items, ok := c.cache.List(gvr, namespace, selector); ok {
+		return items, nil
+	}
+
+	opts := metav1.ListOptions{LabelSelector: labelSelector}
+	var list *unstructured.UnstructuredList
+	if namespace != "" {
+		list, err = c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, opts)
+	} else {
+		list, err = c.dynamicClient.Resource(gvr).List(ctx, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// StartCache starts the client's informer cache, if one is configured
+func (c *Client) StartCache(ctx context.Context) {
+	if c.cache != nil {
+		c.cache.Start(ctx)
+	}
+}
+
+// CacheStatus reports the state of the client's informer cache
+func (c *Client) CacheStatus() CacheStatus {
+	return c.cache.Status()
+}
@@ -0,0 +1,165 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// preferredDiscovery returns the fake's resources from ServerPreferredResources,
+// which the upstream fake leaves empty
+type preferredDiscovery struct {
+	*fakediscovery.FakeDiscovery
+}
+
+func (d preferredDiscovery) ServerPreferredResources() ([]*metav1.APIResourceList, error) {
+	return d.Resources, nil
+}
+
+func testPod(namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	pod := &unstructured.Unstructured{}
+	pod.SetAPIVersion("v1")
+	pod.SetKind("Pod")
+	pod.SetNamespace(namespace)
+	pod.SetName(name)
+	pod.SetLabels(labels)
+	return pod
+}
+
+func TestResourceCacheNamespaces(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	services := schema.GroupVersionResource{Version: "v1", Resource: "services"}
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			pods:     "PodList",
+			services: "ServiceList",
+		},
+		testPod("apps", "web-1", map[string]string{"app": "web"}),
+		testPod("apps", "worker-1", map[string]string{"app": "worker"}),
+		testPod("other", "web-2", map[string]string{"app": "web"}),
+	)
+	discoveryClient := preferredDiscovery{&fakediscovery.FakeDiscovery{Fake: &kubetesting.Fake{
+		Resources: []*metav1.APIResourceList{{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", SingularName: "pod", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"get", "list", "watch"}},
+				{Name: "services", SingularName: "service", Kind: "Service", Namespaced: true, Verbs: metav1.Verbs{"get", "list", "watch"}},
+			},
+		}},
+	}}}
+
+	rc := NewResourceCache(dynamicClient, discoveryClient, config.CacheConfig{
+		Enabled:    true,
+		Resources:  []string{"pods"},
+		Namespaces: []string{"apps"},
+	}, logging.NewLogger())
+
+	if _, ok := rc.List(pods, "apps", nil); ok {
+		t.Fatal("Expected reads to fall through before the cache has synced")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rc.Start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !rc.Synced() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Cache did not sync: %+v", rc.Status())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	items, ok := rc.List(pods, "apps", labels.SelectorFromSet(labels.Set{"app": "web"}))
+	if !ok {
+		t.Fatal("Expected pods in a watched namespace to be served from the cache")
+	}
+	if len(items) != 1 || items[0].GetName() != "web-1" {
+		t.Errorf("Unexpected cached pods %v", items)
+	}
+
+	if _, ok := rc.List(pods, "other", nil); ok {
+		t.Error("Expected an unwatched namespace not to be served from the cache")
+	}
+	if _, ok := rc.List(pods, metav1.NamespaceAll, nil); ok {
+		t.Error("Expected a cluster-wide list not to be served by a namespaced cache")
+	}
+	if _, ok := rc.List(services, "apps", nil); ok {
+		t.Error("Expected an uncached resource type not to be served from the cache")
+	}
+
+	obj, ok, err := rc.Get(pods, "apps", "worker-1")
+	if !ok || err != nil || obj.GetName() != "worker-1" {
+		t.Errorf("Expected worker-1 from the cache, got %v, %v, %v", obj, ok, err)
+	}
+	if _, ok, err := rc.Get(pods, "apps", "missing"); !ok || !apierrors.IsNotFound(err) {
+		t.Errorf("Expected a NotFound error for a missing pod, got %v, %v", ok, err)
+	}
+}
+
+func TestResourceCacheSkipsForbiddenResources(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			pods:    "PodList",
+			secrets: "SecretList",
+		},
+		testPod("apps", "web-1", nil),
+	)
+	// RBAC lets the server read pods but not secrets
+	dynamicClient.PrependReactor("list", "secrets", func(kubetesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", nil)
+	})
+	discoveryClient := preferredDiscovery{&fakediscovery.FakeDiscovery{Fake: &kubetesting.Fake{
+		Resources: []*metav1.APIResourceList{{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "pods", SingularName: "pod", Kind: "Pod", Namespaced: true, Verbs: metav1.Verbs{"get", "list", "watch"}},
+				{Name: "secrets", SingularName: "secret", Kind: "Secret", Namespaced: true, Verbs: metav1.Verbs{"get", "list", "watch"}},
+			},
+		}},
+	}}}
+
+	rc := NewResourceCache(dynamicClient, discoveryClient, config.CacheConfig{
+		Enabled:   true,
+		Resources: []string{"pods", "secrets"},
+	}, logging.NewLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rc.Start(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !rc.Synced() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Cache did not sync with a forbidden resource type: %+v", rc.Status())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if status := rc.Status(); len(status.Skipped) != 1 || status.Skipped[0] != "secrets" {
+		t.Errorf("Expected secrets to be skipped, got %+v", status)
+	}
+	if items, ok := rc.List(pods, "apps", nil); !ok || len(items) != 1 {
+		t.Errorf("Expected pods to be served from the cache, got %v, %v", items, ok)
+	}
+	if _, ok := rc.List(secrets, "apps", nil); ok {
+		t.Error("Expected a skipped resource type to fall through to the API server")
+	}
+}
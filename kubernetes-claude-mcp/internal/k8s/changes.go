@@ -0,0 +1,231 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ClusterChange is a change read from the cluster, with the resources it changed
+// directly
+type ClusterChange struct {
+	models.DetectedChange
+	Changed []ResourceRef
+}
+
+// leaderAnnotation marks ConfigMaps used for leader election, which are rewritten
+// every few seconds
+const leaderAnnotation = "control-plane.alpha.kubernetes.io/leader"
+
+// DetectChanges finds the changes made within a namespace, or across the cluster when
+// namespace is empty, between since and until: ReplicaSets created by a Deployment
+// rollout, ControllerRevisions recording a new StatefulSet or DaemonSet pod template,
+// and ConfigMaps and Secrets created or updated. When a ConfigMap or Secret was last
+// written is read from its managed fields, as its resourceVersion carries no time.
+// Kinds that cannot be listed are reported in the returned errors.
+func (c *Client) DetectChanges(ctx context.Context, namespace string, since, until time.Time) ([]ClusterChange, []error) {
+	inWindow := func(t time.Time) bool {
+		return !t.IsZero() && !t.Before(since) && !t.After(until)
+	}
+
+	var changes []ClusterChange
+	var errs []error
+
+	replicaSets, err := c.ListResources(ctx, "replicaset", namespace)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to list ReplicaSets: %w", err))
+	}
+	changes = append(changes, rollouts(replicaSets, inWindow)...)
+
+	revisions, err := c.ListResources(ctx, "controllerrevision", namespace)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to list ControllerRevisions: %w", err))
+	}
+	for i := range revisions {
+		revision := &revisions[i]
+		if !inWindow(revision.GetCreationTimestamp().Time) {
+			continue
+		}
+		number, _, _ := unstructured.NestedInt64(revision.Object, "revision")
+		owner := ownerRef(revision)
+		changes = append(changes, ClusterChange{
+			DetectedChange: models.DetectedChange{
+				Time:     revision.GetCreationTimestamp().Time,
+				Type:     models.ChangeRollout,
+				Source:   "kubernetes",
+				Subject:  owner.String(),
+				Summary:  fmt.Sprintf("%s changed its pod template (revision %d)", owner, number),
+				Revision: strconv.FormatInt(number, 10),
+			},
+			Changed: []ResourceRef{owner},
+		})
+	}
+
+	for _, kind := range []string{"configmap", "secret"} {
+		items, err := c.ListResources(ctx, kind, namespace)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list %ss: %w", kind, err))
+			continue
+		}
+		for i := range items {
+			obj := &items[i]
+			if _, ok := obj.GetAnnotations()[leaderAnnotation]; ok {
+				continue
+			}
+			if kind == "secret" && obj.Object["type"] == "kubernetes.io/service-account-token" {
+				continue
+			}
+
+			created := obj.GetCreationTimestamp().Time
+			written := lastWritten(obj)
+			if !inWindow(written) {
+				continue
+			}
+			verb := "updated"
+			if inWindow(created) && written.Sub(created) < time.Minute {
+				verb = "created"
+			}
+
+			ref := ResourceRef{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+			changes = append(changes, ClusterChange{
+				DetectedChange: models.DetectedChange{
+					Time:     written,
+					Type:     models.ChangeConfig,
+					Source:   "kubernetes",
+					Subject:  ref.String(),
+					Summary:  fmt.Sprintf("%s %s (resourceVersion %s)", ref, verb, obj.GetResourceVersion()),
+					Revision: obj.GetResourceVersion(),
+				},
+				Changed: []ResourceRef{ref},
+			})
+		}
+	}
+
+	return changes, errs
+}
+
+// rollouts finds the ReplicaSets created within the window and compares each one's
+// pod template images with the previous revision of the same Deployment
+func rollouts(replicaSets []unstructured.Unstructured, inWindow func(time.Time) bool) []ClusterChange {
+	byOwner := make(map[ResourceRef][]*unstructured.Unstructured)
+	for i := range replicaSets {
+		rs := &replicaSets[i]
+		owner := ownerRef(rs)
+		byOwner[owner] = append(byOwner[owner], rs)
+	}
+
+	var changes []ClusterChange
+	for owner, sets := range byOwner {
+		sort.Slice(sets, func(i, j int) bool {
+			return replicaSetRevision(sets[i]) < replicaSetRevision(sets[j])
+		})
+		for i, rs := range sets {
+			created := rs.GetCreationTimestamp().Time
+			if !inWindow(created) {
+				continue
+			}
+
+			revision := replicaSetRevision(rs)
+			summary := fmt.Sprintf("%s rolled out ReplicaSet %s", owner, rs.GetName())
+			if revision > 0 {
+				summary = fmt.Sprintf("%s rolled out revision %d (ReplicaSet %s)", owner, revision, rs.GetName())
+			}
+			if i > 0 {
+				if images := imageChanges(sets[i-1], rs); len(images) > 0 {
+					summary += ": " + strings.Join(images, ", ")
+				}
+			}
+
+			rsRef := ResourceRef{Kind: "ReplicaSet", Namespace: rs.GetNamespace(), Name: rs.GetName()}
+			changed := []ResourceRef{rsRef}
+			if owner != rsRef {
+				changed = []ResourceRef{owner, rsRef}
+			}
+			changes = append(changes, ClusterChange{
+				DetectedChange: models.DetectedChange{
+					Time:     created,
+					Type:     models.ChangeRollout,
+					Source:   "kubernetes",
+					Subject:  owner.String(),
+					Summary:  summary,
+					Revision: strconv.Itoa(revision),
+				},
+				Changed: changed,
+			})
+		}
+	}
+	return changes
+}
+
+// ownerRef returns the controller that owns an object, or the object itself when it
+// has none
+func ownerRef(obj *unstructured.Unstructured) ResourceRef {
+	for _, owner := range obj.GetOwnerReferences() {
+		if (owner.Controller != nil && *owner.Controller) || len(obj.GetOwnerReferences()) == 1 {
+			return ResourceRef{Kind: owner.Kind, Namespace: obj.GetNamespace(), Name: owner.Name}
+		}
+	}
+	return ResourceRef{Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+}
+
+func replicaSetRevision(rs *unstructured.Unstructured) int {
+	revision, _ := strconv.Atoi(rs.GetAnnotations()["deployment.kubernetes.io/revision"])
+	return revision
+}
+
+// imageChanges lists the containers whose image differs between two pod templates
+func imageChanges(before, after *unstructured.Unstructured) []string {
+	previous, current := containerImages(before), containerImages(after)
+	var changes []string
+	for _, container := range sortedKeys(current) {
+		image := current[container]
+		if old, ok := previous[container]; ok && old != image {
+			changes = append(changes, fmt.Sprintf("%s image %s -> %s", container, old, image))
+		} else if !ok {
+			changes = append(changes, fmt.Sprintf("container %s added with image %s", container, image))
+		}
+	}
+	return changes
+}
+
+func containerImages(rs *unstructured.Unstructured) map[string]string {
+	images := make(map[string]string)
+	containers, _, _ := unstructured.NestedSlice(rs.Object, "spec", "template", "spec", "containers")
+	for _, c := range containers {
+		container, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := container["name"].(string)
+		image, _ := container["image"].(string)
+		images[name] = image
+	}
+	return images
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// lastWritten returns the last time any manager wrote an object, or its creation time
+// when it has no managed fields
+func lastWritten(obj *unstructured.Unstructured) time.Time {
+	written := obj.GetCreationTimestamp().Time
+	for _, entry := range obj.GetManagedFields() {
+		if entry.Time != nil && entry.Time.After(written) {
+			written = entry.Time.Time
+		}
+	}
+	return written
+}
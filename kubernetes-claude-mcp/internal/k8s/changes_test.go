@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+func TestDetectChanges(t *testing.T) {
+	since := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	until := since.Add(4 * time.Hour)
+
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, kind := range []string{"replicaset", "controllerrevision", "configmap", "secret"} {
+		gvr := resourceMappings[kind]
+		listKinds[gvr] = gvr.Resource + "List"
+	}
+
+	replicaSet := func(name, revision, image string, created time.Time) *unstructured.Unstructured {
+		rs := testObject("apps/v1", "ReplicaSet", name, map[string]interface{}{
+			"spec": map[string]interface{}{"template": map[string]interface{}{"spec": map[string]interface{}{
+				"containers": []interface{}{map[string]interface{}{"name": "web", "image": image}},
+			}}},
+		})
+		rs.SetAnnotations(map[string]string{"deployment.kubernetes.io/revision": revision})
+		rs.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}})
+		rs.SetCreationTimestamp(metav1.NewTime(created))
+		return rs
+	}
+
+	revision := testObject("apps/v1", "ControllerRevision", "db-7c9", map[string]interface{}{"revision": int64(3)})
+	revision.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db"}})
+	revision.SetCreationTimestamp(metav1.NewTime(since.Add(2 * time.Hour)))
+
+	updated := testObject("v1", "ConfigMap", "web-config", map[string]interface{}{})
+	updated.SetCreationTimestamp(metav1.NewTime(since.Add(-48 * time.Hour)))
+	updated.SetResourceVersion("812")
+	writtenAt := metav1.NewTime(since.Add(30 * time.Minute))
+	updated.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl", Time: &writtenAt}})
+
+	leader := testObject("v1", "ConfigMap", "controller-leader", map[string]interface{}{})
+	leader.SetAnnotations(map[string]string{leaderAnnotation: "{}"})
+	leader.SetCreationTimestamp(metav1.NewTime(since.Add(time.Hour)))
+
+	unchanged := testObject("v1", "ConfigMap", "settings", map[string]interface{}{})
+	unchanged.SetCreationTimestamp(metav1.NewTime(since.Add(-48 * time.Hour)))
+
+	secret := testObject("v1", "Secret", "web-tls", map[string]interface{}{"type": "kubernetes.io/tls"})
+	secret.SetCreationTimestamp(metav1.NewTime(since.Add(3 * time.Hour)))
+	secret.SetResourceVersion("900")
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds,
+		replicaSet("web-5d8f", "4", "web:1.4", since.Add(-24*time.Hour)),
+		replicaSet("web-6b2c", "5", "web:1.5", since.Add(time.Hour)),
+		revision,
+		updated,
+		leader,
+		unchanged,
+		secret,
+	)
+	client := &Client{dynamicClient: dynamicClient, logger: logging.NewLogger()}
+
+	changes, errs := client.DetectChanges(context.Background(), "apps", since, until)
+	if len(errs) > 0 {
+		t.Fatalf("DetectChanges errors: %v", errs)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Time.Before(changes[j].Time) })
+
+	type change struct {
+		time    time.Time
+		subject string
+		summary string
+		changed []ResourceRef
+	}
+	var got []change
+	for _, c := range changes {
+		got = append(got, change{c.Time.UTC(), c.Subject, c.Summary, c.Changed})
+	}
+	want := []change{
+		{since.Add(30 * time.Minute), "ConfigMap/web-config", "ConfigMap/web-config updated (resourceVersion 812)",
+			[]ResourceRef{{Kind: "ConfigMap", Namespace: "apps", Name: "web-config"}}},
+		{since.Add(time.Hour), "Deployment/web", "Deployment/web rolled out revision 5 (ReplicaSet web-6b2c): web image web:1.4 -> web:1.5",
+			[]ResourceRef{{Kind: "Deployment", Namespace: "apps", Name: "web"}, {Kind: "ReplicaSet", Namespace: "apps", Name: "web-6b2c"}}},
+		{since.Add(2 * time.Hour), "StatefulSet/db", "StatefulSet/db changed its pod template (revision 3)",
+			[]ResourceRef{{Kind: "StatefulSet", Namespace: "apps", Name: "db"}}},
+		{since.Add(3 * time.Hour), "Secret/web-tls", "Secret/web-tls created (resourceVersion 900)",
+			[]ResourceRef{{Kind: "Secret", Namespace: "apps", Name: "web-tls"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("changes =\n%v\nwant\n%v", got, want)
+	}
+}
@@ -24,6 +24,7 @@ type Client struct {
 	discoveryClient *discovery.DiscoveryClient
 	restConfig      *rest.Config
 	defaultNS       string
+	cache           *ResourceCache
 	logger          *logging.Logger
 	ResourceMapper  *ResourceMapper
 }
@@ -51,16 +52,11 @@ func NewClient(cfg config.KubernetesConfig, logger *logging.Logger) (*Client, er
 		logger.Debug("Using in-cluster configuration")
 	} else {
 		// Use kubeconfig file
-		kubeconfigPath := cfg.KubeConfig
-		if kubeconfigPath == "" {
-			// Try to use default location if not specified
-			if home := homedir.HomeDir(); home != "" {
-				kubeconfigPath = filepath.Join(home, ".kube", "config")
-				logger.Debug("Using default kubeconfig path", "path", kubeconfigPath)
-			} else {
-				return nil, fmt.Errorf("kubeconfig not specified and home directory not found")
-			}
+		kubeconfigPath, err := resolveKubeconfigPath(cfg.KubeConfig)
+		if err != nil {
+			return nil, err
 		}
+		logger.Debug("Using kubeconfig", "path", kubeconfigPath)
 
 		// Build config from kubeconfig file
 		configLoadingRules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath}
@@ -122,12 +118,28 @@ func NewClient(cfg config.KubernetesConfig, logger *logging.Logger) (*Client, er
 		logger:          logger,
 	}
 
+	// Serve reads from shared informers when the cache is enabled
+	if cfg.Cache.Enabled {
+		client.cache = NewResourceCache(dynamicClient, discoveryClient, cfg.Cache, logger.Named("cache"))
+	}
+
 	// Initialize the ResourceMapper (ensure NewResourceMapper is defined in your package)
 	client.ResourceMapper = NewResourceMapper(client)
 
 	return client, nil
 }
 
+// resolveKubeconfigPath returns the kubeconfig path to use, falling back to ~/.kube/config
+func resolveKubeconfigPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	if home := homedir.HomeDir(); home != "" {
+		return filepath.Join(home, ".kube", "config"), nil
+	}
+	return "", fmt.Errorf("kubeconfig not specified and home directory not found")
+}
+
 // CheckConnectivity verifies connectivity to the Kubernetes API
 func (c *Client) CheckConnectivity(ctx context.Context) error {
 	c.logger.Debug("Checking Kubernetes connectivity")
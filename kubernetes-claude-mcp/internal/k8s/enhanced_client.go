@@ -78,7 +78,7 @@ func (c *Client) GetAllNamespaceResources(ctx context.Context, namespace string)
 				}
 
 				// List resources of this type
-				list, err := c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+				items, err := c.listResources(ctx, gvr, namespace, "")
 				if err != nil {
 					c.logger.Warn("Failed to list resources",
 						"namespace", namespace,
@@ -88,14 +88,14 @@ func (c *Client) GetAllNamespaceResources(ctx context.Context, namespace string)
 				}
 
 				// Skip if no resources found
-				if len(list.Items) == 0 {
+				if len(items) == 0 {
 					continue
 				}
 
 				// Add to collection with thread safety
 				mu.Lock()
-				collection.Resources[r.Kind] = list.Items
-				collection.Stats[r.Kind] = len(list.Items)
+				collection.Resources[r.Kind] = items
+				collection.Stats[r.Kind] = len(items)
 				mu.Unlock()
 			}
 		}(resourceList)
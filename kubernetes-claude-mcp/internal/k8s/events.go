@@ -9,8 +9,10 @@ import (
 
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 // GetResourceEvents returns events related to a specific resource
@@ -34,17 +36,28 @@ func (c *Client) GetResourceEvents(ctx context.Context, namespace, kind, name st
 		)
 	}
 
-	// Get events
-	eventList, err := c.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
-		FieldSelector: fieldSelector.String(),
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list events: %w", err)
+	// Get events, filtering cached events the way the field selector would
+	var items []corev1.Event
+	if cached, ok := c.cachedEvents(namespace); ok {
+		for _, event := range cached {
+			if event.InvolvedObject.Name == name && event.InvolvedObject.Kind == kind &&
+				(namespace == "" || event.InvolvedObject.Namespace == namespace) {
+				items = append(items, event)
+			}
+		}
+	} else {
+		eventList, err := c.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: fieldSelector.String(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events: %w", err)
+		}
+		items = eventList.Items
 	}
 
 	// Convert to our model
 	var events []models.K8sEvent
-	for _, event := range eventList.Items {
+	for _, event := range items {
 		e := models.K8sEvent{
 			Reason:    event.Reason,
 			Message:   event.Message,
@@ -83,14 +96,18 @@ func (c *Client) GetNamespaceEvents(ctx context.Context, namespace string) ([]mo
 	c.logger.Debug("Getting events for namespace", "namespace", namespace)
 
 	// Get events
-	eventList, err := c.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list events: %w", err)
+	items, ok := c.cachedEvents(namespace)
+	if !ok {
+		eventList, err := c.clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events: %w", err)
+		}
+		items = eventList.Items
 	}
 
 	// Convert to our model
 	var events []models.K8sEvent
-	for _, event := range eventList.Items {
+	for _, event := range items {
 		e := models.K8sEvent{
 			Reason:    event.Reason,
 			Message:   event.Message,
@@ -120,6 +137,26 @@ func (c *Client) GetNamespaceEvents(ctx context.Context, namespace string) ([]mo
 	return events, nil
 }
 
+// cachedEvents returns the events in a namespace ("" for all namespaces) from the
+// informer cache; ok is false when the cache does not cover them
+func (c *Client) cachedEvents(namespace string) ([]corev1.Event, bool) {
+	items, ok := c.cache.List(resourceMappings["event"], namespace, nil)
+	if !ok {
+		return nil, false
+	}
+
+	events := make([]corev1.Event, 0, len(items))
+	for i := range items {
+		var event corev1.Event
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(items[i].Object, &event); err != nil {
+			c.logger.Debug("Skipping cached event that failed to convert", "error", err)
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, true
+}
+
 // GetRecentWarningEvents returns recent warning events across all namespaces
 func (c *Client) GetRecentWarningEvents(ctx context.Context, timeWindow time.Duration) ([]models.K8sEvent, error) {
 	c.logger.Debug("Getting recent warning events", "timeWindow", timeWindow)
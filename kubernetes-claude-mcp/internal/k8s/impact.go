@@ -0,0 +1,152 @@
+package k8s
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// ResourceRef identifies a resource in the relationship graph
+type ResourceRef struct {
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+func (r ResourceRef) String() string {
+	return r.Kind + "/" + r.Name
+}
+
+// relationVerbs describe a relationship from its source to its target
+var relationVerbs = map[string]string{
+	"owns":       "owns",
+	"selects":    "selects",
+	"mounts":     "mounts",
+	"configures": "reads configuration from",
+	"routes":     "routes to",
+	"binds":      "is bound to",
+}
+
+// impactRank orders the kinds of equally distant impacted resources, putting the ones
+// that serve traffic first
+var impactRank = map[string]int{
+	"Ingress":     0,
+	"Service":     1,
+	"Deployment":  2,
+	"StatefulSet": 2,
+	"DaemonSet":   2,
+	"CronJob":     2,
+	"Job":         3,
+	"ReplicaSet":  3,
+	"Pod":         4,
+}
+
+// impactEdge is a relationship followed in the direction a change spreads
+type impactEdge struct {
+	to  ResourceRef
+	hop string
+}
+
+// Impact follows the relationship graph from changed resources to everything they
+// transitively affect. A change spreads from an owner to what it owns, and from any
+// other target to the sources that depend on it: from a ConfigMap to the Pods that
+// mount it, from those Pods to the Services that select them and from those Services
+// to the Ingresses that route to them. The changed resources themselves are not
+// listed, and the result is unranked.
+func Impact(relationships []ResourceRelationship, changed []ResourceRef) []models.ImpactedResource {
+	edges := make(map[ResourceRef][]impactEdge)
+	for _, rel := range relationships {
+		source := ResourceRef{Kind: rel.SourceKind, Namespace: rel.SourceNamespace, Name: rel.SourceName}
+		target := ResourceRef{Kind: rel.TargetKind, Namespace: rel.TargetNamespace, Name: rel.TargetName}
+		verb, ok := relationVerbs[rel.RelationType]
+		if !ok {
+			verb = rel.RelationType
+		}
+		hop := source.String() + " " + verb + " " + target.String()
+
+		if rel.RelationType == "owns" {
+			edges[source] = append(edges[source], impactEdge{to: target, hop: hop})
+		} else {
+			edges[target] = append(edges[target], impactEdge{to: source, hop: hop})
+		}
+	}
+
+	isChanged := make(map[ResourceRef]bool, len(changed))
+	for _, ref := range changed {
+		isChanged[ref] = true
+	}
+
+	impacted := make(map[ResourceRef]*models.ImpactedResource)
+	for ref := range isChanged {
+		// Breadth-first, so each resource is reached by its shortest path
+		paths := map[ResourceRef][]string{ref: nil}
+		queue := []ResourceRef{ref}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, edge := range edges[current] {
+				if _, seen := paths[edge.to]; seen || isChanged[edge.to] {
+					continue
+				}
+				path := append(append([]string{}, paths[current]...), edge.hop)
+				paths[edge.to] = path
+				queue = append(queue, edge.to)
+
+				existing, ok := impacted[edge.to]
+				if !ok {
+					impacted[edge.to] = &models.ImpactedResource{
+						Kind:      edge.to.Kind,
+						Namespace: edge.to.Namespace,
+						Name:      edge.to.Name,
+						Depth:     len(path),
+						Changes:   1,
+						Path:      path,
+					}
+					continue
+				}
+				existing.Changes++
+				if len(path) < existing.Depth {
+					existing.Depth = len(path)
+					existing.Path = path
+				}
+			}
+		}
+	}
+
+	result := make([]models.ImpactedResource, 0, len(impacted))
+	for _, resource := range impacted {
+		result = append(result, *resource)
+	}
+	return result
+}
+
+// RankImpact orders impacted resources and numbers them: nearer resources first, then
+// those reached by more changes, then Ingresses and Services ahead of workloads and
+// Pods
+func RankImpact(resources []models.ImpactedResource) {
+	kindRank := func(kind string) int {
+		if rank, ok := impactRank[kind]; ok {
+			return rank
+		}
+		return len(impactRank)
+	}
+
+	sort.SliceStable(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		if a.Depth != b.Depth {
+			return a.Depth < b.Depth
+		}
+		if a.Changes != b.Changes {
+			return a.Changes > b.Changes
+		}
+		if ra, rb := kindRank(a.Kind), kindRank(b.Kind); ra != rb {
+			return ra < rb
+		}
+		return strings.Join([]string{a.Cluster, a.Namespace, a.Kind, a.Name}, "/") <
+			strings.Join([]string{b.Cluster, b.Namespace, b.Kind, b.Name}, "/")
+	})
+	for i := range resources {
+		resources[i].Rank = i + 1
+	}
+}
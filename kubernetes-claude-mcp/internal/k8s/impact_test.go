@@ -0,0 +1,127 @@
+package k8s
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakedynamic "k8s.io/client-go/dynamic/fake"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+func testObject(apiVersion, kind, name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: fields}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace("apps")
+	obj.SetName(name)
+	return obj
+}
+
+func TestImpact(t *testing.T) {
+	listKinds := make(map[schema.GroupVersionResource]string)
+	for _, kind := range relationshipKinds {
+		gvr := resourceMappings[kind]
+		listKinds[gvr] = gvr.Resource + "List"
+	}
+
+	replicaSet := testObject("apps/v1", "ReplicaSet", "web-5d8f", map[string]interface{}{})
+	replicaSet.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}})
+	pod := testObject("v1", "Pod", "web-5d8f-x2k", map[string]interface{}{
+		"spec": map[string]interface{}{
+			"volumes": []interface{}{
+				map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "web-config"}},
+			},
+		},
+	})
+	pod.SetLabels(map[string]string{"app": "web"})
+	pod.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-5d8f"}})
+
+	dynamicClient := fakedynamic.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds,
+		testObject("apps/v1", "Deployment", "web", map[string]interface{}{}),
+		replicaSet,
+		pod,
+		testObject("v1", "Service", "web", map[string]interface{}{
+			"spec": map[string]interface{}{"selector": map[string]interface{}{"app": "web"}},
+		}),
+		testObject("networking.k8s.io/v1", "Ingress", "web", map[string]interface{}{
+			"spec": map[string]interface{}{"rules": []interface{}{map[string]interface{}{
+				"host": "web.example.com",
+				"http": map[string]interface{}{"paths": []interface{}{map[string]interface{}{
+					"path":    "/",
+					"backend": map[string]interface{}{"service": map[string]interface{}{"name": "web"}},
+				}}},
+			}}},
+		}),
+		testObject("v1", "Service", "worker", map[string]interface{}{
+			"spec": map[string]interface{}{"selector": map[string]interface{}{"app": "worker"}},
+		}),
+	)
+
+	client := &Client{dynamicClient: dynamicClient, logger: logging.NewLogger()}
+	mapper := NewResourceMapper(client)
+	relationships, err := mapper.GetRelationships(context.Background(), "apps")
+	if err != nil {
+		t.Fatalf("GetRelationships: %v", err)
+	}
+
+	impacted := Impact(relationships, []ResourceRef{
+		{Kind: "ConfigMap", Namespace: "apps", Name: "web-config"},
+		{Kind: "Deployment", Namespace: "apps", Name: "web"},
+	})
+	RankImpact(impacted)
+
+	type impact struct {
+		rank    int
+		name    string
+		depth   int
+		changes int
+	}
+	var got []impact
+	for _, resource := range impacted {
+		got = append(got, impact{resource.Rank, resource.Kind + "/" + resource.Name, resource.Depth, resource.Changes})
+	}
+	want := []impact{
+		{1, "Pod/web-5d8f-x2k", 1, 2},
+		{2, "ReplicaSet/web-5d8f", 1, 1},
+		{3, "Service/web", 2, 2},
+		{4, "Ingress/web", 3, 2},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Impact() =\n%v\nwant\n%v", got, want)
+	}
+
+	wantPath := []string{
+		"Pod/web-5d8f-x2k mounts ConfigMap/web-config",
+		"Service/web selects Pod/web-5d8f-x2k",
+		"Ingress/web routes to Service/web",
+	}
+	if ingress := impacted[3]; !reflect.DeepEqual(ingress.Path, wantPath) {
+		t.Errorf("Ingress path = %v, want %v", ingress.Path, wantPath)
+	}
+}
+
+// Ranking breaks ties between equally distant resources by kind, Ingresses first
+func TestRankImpact(t *testing.T) {
+	resources := []models.ImpactedResource{
+		{Kind: "Pod", Name: "a", Depth: 2, Changes: 1},
+		{Kind: "Service", Name: "b", Depth: 2, Changes: 1},
+		{Kind: "Ingress", Name: "c", Depth: 2, Changes: 1},
+		{Kind: "Pod", Name: "d", Depth: 1, Changes: 1},
+	}
+	RankImpact(resources)
+
+	var names []string
+	for _, resource := range resources {
+		names = append(names, resource.Name)
+	}
+	if want := []string{"d", "c", "b", "a"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("ranked %v, want %v", names, want)
+	}
+}
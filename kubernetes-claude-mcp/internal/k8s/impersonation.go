@@ -0,0 +1,71 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// impersonationKey is the context key for the user a request acts as
+type impersonationKey struct{}
+
+// impersonation is the Kubernetes user and groups a request acts as
+type impersonation struct {
+	user   string
+	groups []string
+}
+
+// WithImpersonation returns a context whose Kubernetes calls, made through
+// ClusterRegistry.ForContext, impersonate the given user and groups
+func WithImpersonation(ctx context.Context, user string, groups []string) context.Context {
+	return context.WithValue(ctx, impersonationKey{}, impersonation{user: user, groups: groups})
+}
+
+// ForUser returns a client whose requests impersonate a user and groups, so the
+// cluster's own RBAC decides what they may see. The informer cache is not used,
+// since it holds what the server's own credentials can see.
+func (c *Client) ForUser(user string, groups []string) (*Client, error) {
+	restConfig := rest.CopyConfig(c.restConfig)
+	restConfig.Impersonate = rest.ImpersonationConfig{
+		UserName: user,
+		Groups:   groups,
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create impersonating clientset: %w", err)
+	}
+	dynamicClient, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create impersonating dynamic client: %w", err)
+	}
+
+	client := &Client{
+		clientset:       clientset,
+		dynamicClient:   dynamicClient,
+		discoveryClient: c.discoveryClient,
+		restConfig:      restConfig,
+		defaultNS:       c.defaultNS,
+		logger:          c.logger.With("impersonate", user),
+	}
+	client.ResourceMapper = NewResourceMapper(client)
+	return client, nil
+}
+
+// ForContext returns the client for a named cluster, impersonating the context's user
+// when impersonation is enabled. An empty name selects the default cluster.
+func (r *ClusterRegistry) ForContext(ctx context.Context, name string) (*Client, error) {
+	client, err := r.Get(name)
+	if err != nil || !r.impersonate {
+		return client, err
+	}
+
+	imp, ok := ctx.Value(impersonationKey{}).(impersonation)
+	if !ok || imp.user == "" {
+		return client, nil
+	}
+	return client.ForUser(imp.user, imp.groups)
+}
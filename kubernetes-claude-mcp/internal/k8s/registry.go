@@ -0,0 +1,306 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// InClusterServer is the destination server ArgoCD uses for the cluster it runs in
+const InClusterServer = "https://kubernetes.default.svc"
+
+// DefaultClusterName names the only cluster when no cluster list is configured
+const DefaultClusterName = "default"
+
+// ClusterInfo describes a registered cluster
+type ClusterInfo struct {
+	Name    string `json:"name"`
+	Server  string `json:"server"`
+	Default bool   `json:"default"`
+}
+
+// ClusterRegistry holds a Kubernetes client for each named cluster
+type ClusterRegistry struct {
+	clients     map[string]*Client
+	servers     map[string]string
+	infos       []ClusterInfo
+	defaultName string
+	inCluster   string
+	impersonate bool
+	logger      *logging.Logger
+}
+
+// NewClusterRegistry creates clients for the configured clusters. Clusters come from
+// the explicit cluster list, from every kubeconfig context when allContexts is set,
+// or otherwise from the single top-level kubeconfig/in-cluster setting.
+func NewClusterRegistry(cfg config.KubernetesConfig, logger *logging.Logger) (*ClusterRegistry, error) {
+	if logger == nil {
+		logger = logging.NewLogger().Named("k8s")
+	}
+
+	r := &ClusterRegistry{
+		clients:     make(map[string]*Client),
+		servers:     make(map[string]string),
+		impersonate: cfg.Impersonate,
+		logger:      logger,
+	}
+
+	switch {
+	case len(cfg.Clusters) > 0:
+		if err := r.loadClusterList(cfg); err != nil {
+			return nil, err
+		}
+	case cfg.AllContexts:
+		if err := r.loadAllContexts(cfg); err != nil {
+			return nil, err
+		}
+	default:
+		client, err := NewClient(cfg, logger)
+		if err != nil {
+			return nil, err
+		}
+		name := cfg.DefaultCluster
+		if name == "" {
+			name = DefaultClusterName
+		}
+		r.add(name, client, "", cfg.InCluster)
+	}
+
+	if cfg.DefaultCluster != "" {
+		if _, ok := r.clients[cfg.DefaultCluster]; !ok {
+			return nil, fmt.Errorf("default cluster %s is not registered", cfg.DefaultCluster)
+		}
+		r.defaultName = cfg.DefaultCluster
+	}
+	if r.defaultName == "" {
+		r.defaultName = r.infos[0].Name
+	}
+	for i := range r.infos {
+		r.infos[i].Default = r.infos[i].Name == r.defaultName
+	}
+
+	logger.Info("Cluster registry initialized",
+		"clusters", len(r.infos),
+		"default", r.defaultName)
+
+	return r, nil
+}
+
+// loadClusterList creates a client for each entry in the configured cluster list
+func (r *ClusterRegistry) loadClusterList(cfg config.KubernetesConfig) error {
+	for _, cluster := range cfg.Clusters {
+		clientCfg := config.KubernetesConfig{
+			KubeConfig:       cluster.KubeConfig,
+			InCluster:        cluster.InCluster,
+			DefaultContext:   cluster.Context,
+			DefaultNamespace: cluster.DefaultNamespace,
+			Cache:            cfg.Cache,
+		}
+		if clientCfg.KubeConfig == "" && !cluster.InCluster {
+			clientCfg.KubeConfig = cfg.KubeConfig
+		}
+		if clientCfg.DefaultNamespace == "" {
+			clientCfg.DefaultNamespace = cfg.DefaultNamespace
+		}
+
+		client, err := NewClient(clientCfg, r.logger.Named(cluster.Name))
+		if err != nil {
+			return fmt.Errorf("failed to create client for cluster %s: %w", cluster.Name, err)
+		}
+		r.add(cluster.Name, client, cluster.Server, cluster.InCluster)
+	}
+	return nil
+}
+
+// loadAllContexts creates a client for every context in the kubeconfig, named after the context.
+// Contexts that cannot be loaded are skipped so one stale entry does not block startup.
+func (r *ClusterRegistry) loadAllContexts(cfg config.KubernetesConfig) error {
+	kubeconfigPath, err := resolveKubeconfigPath(cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+
+	rawConfig, err := clientcmd.LoadFromFile(kubeconfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+
+	contexts := make([]string, 0, len(rawConfig.Contexts))
+	for name := range rawConfig.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+
+	for _, name := range contexts {
+		namespace := rawConfig.Contexts[name].Namespace
+		if namespace == "" {
+			namespace = cfg.DefaultNamespace
+		}
+
+		client, err := NewClient(config.KubernetesConfig{
+			KubeConfig:       kubeconfigPath,
+			DefaultContext:   name,
+			DefaultNamespace: namespace,
+			Cache:            cfg.Cache,
+		}, r.logger.Named(name))
+		if err != nil {
+			r.logger.Warn("Skipping kubeconfig context", "context", name, "error", err)
+			continue
+		}
+		r.add(name, client, "", false)
+	}
+
+	if len(r.infos) == 0 {
+		return fmt.Errorf("no usable contexts found in kubeconfig %s", kubeconfigPath)
+	}
+
+	// Prefer the configured context, then the kubeconfig's current context
+	for _, name := range []string{cfg.DefaultContext, rawConfig.CurrentContext} {
+		if _, ok := r.clients[name]; ok && name != "" {
+			r.defaultName = name
+			break
+		}
+	}
+
+	return nil
+}
+
+// add registers a client under a name. server overrides the client's API server URL
+// when matching ArgoCD destinations, e.g. when Argo reaches the cluster through a proxy.
+func (r *ClusterRegistry) add(name string, client *Client, server string, inCluster bool) {
+	if server == "" {
+		server = client.GetRestConfig().Host
+	}
+
+	r.clients[name] = client
+	r.infos = append(r.infos, ClusterInfo{Name: name, Server: server})
+	if key := normalizeServer(server); key != "" {
+		r.servers[key] = name
+	}
+	if inCluster && r.inCluster == "" {
+		r.inCluster = name
+	}
+}
+
+// Get returns the client for a named cluster; an empty name selects the default cluster
+func (r *ClusterRegistry) Get(name string) (*Client, error) {
+	if r == nil || len(r.clients) == 0 {
+		return nil, fmt.Errorf("kubernetes client is not configured")
+	}
+	if name == "" {
+		name = r.defaultName
+	}
+
+	client, ok := r.clients[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown cluster: %s", name)
+	}
+	return client, nil
+}
+
+// Default returns the client for the default cluster
+func (r *ClusterRegistry) Default() *Client {
+	if r == nil {
+		return nil
+	}
+	return r.clients[r.defaultName]
+}
+
+// DefaultName returns the name of the default cluster
+func (r *ClusterRegistry) DefaultName() string {
+	if r == nil {
+		return ""
+	}
+	return r.defaultName
+}
+
+// Names returns the registered cluster names in registration order
+func (r *ClusterRegistry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, 0, len(r.infos))
+	for _, info := range r.infos {
+		names = append(names, info.Name)
+	}
+	return names
+}
+
+// Clusters returns a description of every registered cluster
+func (r *ClusterRegistry) Clusters() []ClusterInfo {
+	if r == nil {
+		return nil
+	}
+	return append([]ClusterInfo(nil), r.infos...)
+}
+
+// StartCaches starts the informer cache of every cluster that has one
+func (r *ClusterRegistry) StartCaches(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	for _, client := range r.clients {
+		client.StartCache(ctx)
+	}
+}
+
+// CacheStatus reports the informer cache state of every cluster that has a cache enabled
+func (r *ClusterRegistry) CacheStatus() map[string]CacheStatus {
+	statuses := make(map[string]CacheStatus)
+	if r == nil {
+		return statuses
+	}
+	for name, client := range r.clients {
+		if status := client.CacheStatus(); status.Enabled {
+			statuses[name] = status
+		}
+	}
+	return statuses
+}
+
+// ClusterForServer maps an ArgoCD destination server URL to a registered cluster name.
+// An empty server or ArgoCD's in-cluster address resolves to the in-cluster client,
+// falling back to the default cluster.
+func (r *ClusterRegistry) ClusterForServer(server string) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+
+	if name, ok := r.servers[normalizeServer(server)]; ok && server != "" {
+		return name, true
+	}
+
+	if server == "" || normalizeServer(server) == normalizeServer(InClusterServer) {
+		if r.inCluster != "" {
+			return r.inCluster, true
+		}
+		return r.defaultName, true
+	}
+
+	return "", false
+}
+
+// normalizeServer canonicalizes an API server URL for comparison
+func normalizeServer(server string) string {
+	server = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(server)), "/")
+	if server == "" {
+		return ""
+	}
+
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return server
+	}
+	host := u.Host
+	if u.Scheme == "https" {
+		host = strings.TrimSuffix(host, ":443")
+	}
+	return u.Scheme + "://" + host + strings.TrimSuffix(u.Path, "/")
+}
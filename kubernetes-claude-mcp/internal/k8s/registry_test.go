@@ -0,0 +1,156 @@
+package k8s
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: staging
+clusters:
+- name: prod
+  cluster:
+    server: https://prod.example.com:443
+- name: staging
+  cluster:
+    server: https://staging.example.com:6443
+contexts:
+- name: prod
+  context:
+    cluster: prod
+    user: admin
+    namespace: apps
+- name: staging
+  context:
+    cluster: staging
+    user: admin
+users:
+- name: admin
+  user:
+    token: test-token
+`
+
+func writeKubeconfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0o600); err != nil {
+		t.Fatalf("Failed to write kubeconfig: %v", err)
+	}
+	return path
+}
+
+func TestClusterRegistryAllContexts(t *testing.T) {
+	registry, err := NewClusterRegistry(config.KubernetesConfig{
+		KubeConfig:  writeKubeconfig(t),
+		AllContexts: true,
+	}, logging.NewLogger())
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	if names := registry.Names(); !reflect.DeepEqual(names, []string{"prod", "staging"}) {
+		t.Errorf("Unexpected cluster names %v", names)
+	}
+	if registry.DefaultName() != "staging" {
+		t.Errorf("Expected the current context to be the default, got %s", registry.DefaultName())
+	}
+
+	prod, err := registry.Get("prod")
+	if err != nil {
+		t.Fatalf("Failed to get prod cluster: %v", err)
+	}
+	if prod.GetDefaultNamespace() != "apps" {
+		t.Errorf("Expected the context namespace to be used, got %s", prod.GetDefaultNamespace())
+	}
+	if _, err := registry.Get("nope"); err == nil {
+		t.Error("Expected an error for an unknown cluster")
+	}
+	if client, err := registry.Get(""); err != nil || client != registry.Default() {
+		t.Error("Expected an empty name to select the default cluster")
+	}
+}
+
+func TestClusterRegistryClusterForServer(t *testing.T) {
+	kubeconfig := writeKubeconfig(t)
+	registry, err := NewClusterRegistry(config.KubernetesConfig{
+		KubeConfig:     kubeconfig,
+		DefaultCluster: "staging",
+		Clusters: []config.ClusterConfig{
+			{Name: "prod", Context: "prod"},
+			{Name: "staging", Context: "staging", Server: "https://argo-proxy.example.com/staging"},
+		},
+	}, logging.NewLogger())
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	tests := []struct {
+		server string
+		want   string
+		found  bool
+	}{
+		{"https://prod.example.com", "prod", true},
+		{"https://PROD.example.com:443/", "prod", true},
+		{"https://argo-proxy.example.com/staging/", "staging", true},
+		{InClusterServer, "staging", true},
+		{"", "staging", true},
+		{"https://other.example.com", "", false},
+	}
+
+	for _, tt := range tests {
+		name, found := registry.ClusterForServer(tt.server)
+		if name != tt.want || found != tt.found {
+			t.Errorf("ClusterForServer(%q) = %q, %v; want %q, %v", tt.server, name, found, tt.want, tt.found)
+		}
+	}
+}
+
+func TestClusterRegistryNil(t *testing.T) {
+	var registry *ClusterRegistry
+	if _, err := registry.Get(""); err == nil {
+		t.Error("Expected an error from a nil registry")
+	}
+	if _, found := registry.ClusterForServer(InClusterServer); found {
+		t.Error("Expected no cluster from a nil registry")
+	}
+}
+
+func TestClusterRegistryForContext(t *testing.T) {
+	registry, err := NewClusterRegistry(config.KubernetesConfig{
+		KubeConfig:  writeKubeconfig(t),
+		Impersonate: true,
+	}, logging.NewLogger())
+	if err != nil {
+		t.Fatalf("Failed to create registry: %v", err)
+	}
+
+	base, err := registry.ForContext(context.Background(), "")
+	if err != nil || base != registry.Default() {
+		t.Fatalf("Expected the server's own client without a caller, got %v, %v", base, err)
+	}
+
+	ctx := WithImpersonation(context.Background(), "jane@example.com", []string{"developers"})
+	client, err := registry.ForContext(ctx, "")
+	if err != nil {
+		t.Fatalf("Failed to get impersonating client: %v", err)
+	}
+	impersonate := client.GetRestConfig().Impersonate
+	if impersonate.UserName != "jane@example.com" || !reflect.DeepEqual(impersonate.Groups, []string{"developers"}) {
+		t.Errorf("Unexpected impersonation config %+v", impersonate)
+	}
+	if registry.Default().GetRestConfig().Impersonate.UserName != "" {
+		t.Error("Expected the shared client's config to be left unchanged")
+	}
+
+	registry.impersonate = false
+	if client, _ := registry.ForContext(ctx, ""); client != registry.Default() {
+		t.Error("Expected no impersonation when it is disabled")
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"strings"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -88,7 +87,7 @@ func (m *ResourceMapper) GetNamespaceTopology(ctx context.Context, namespace str
 
 			// List resources of this type
 			m.logger.Debug("Listing resources", "namespace", namespace, "resource", r.Name)
-			list, err := m.client.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
+			items, err := m.client.listResources(ctx, gvr, namespace, "")
 			if err != nil {
 				m.logger.Warn("Failed to list resources",
 					"namespace", namespace,
@@ -98,13 +97,13 @@ func (m *ResourceMapper) GetNamespaceTopology(ctx context.Context, namespace str
 			}
 
 			// Add to topology
-			if len(list.Items) > 0 {
-				topology.Resources[r.Kind] = make([]string, len(list.Items))
-				topology.Metrics[r.Kind] = map[string]int{"count": len(list.Items)}
+			if len(items) > 0 {
+				topology.Resources[r.Kind] = make([]string, len(items))
+				topology.Metrics[r.Kind] = map[string]int{"count": len(items)}
 				topology.Health[r.Kind] = make(map[string]string)
 
-				for i := range list.Items {
-					item := list.Items[i]
+				for i := range items {
+					item := items[i]
 					topology.Resources[r.Kind][i] = item.GetName()
 
 					// Determine health status
@@ -113,7 +112,7 @@ func (m *ResourceMapper) GetNamespaceTopology(ctx context.Context, namespace str
 				}
 
 				// Find relationships for this resource type
-				relationships := m.findRelationships(ctx, list.Items, namespace)
+				relationships := m.findRelationships(ctx, items, namespace)
 				topology.Relationships = append(topology.Relationships, relationships...)
 			}
 		}
@@ -127,6 +126,36 @@ func (m *ResourceMapper) GetNamespaceTopology(ctx context.Context, namespace str
 	return topology, nil
 }
 
+// relationshipKinds are the resource types findRelationships reads relationships from
+var relationshipKinds = []string{
+	"deployment", "replicaset", "statefulset", "daemonset", "job", "cronjob",
+	"pod", "service", "ingress", "pvc",
+}
+
+// GetRelationships finds the relationships between the resources in a namespace. Only
+// the types that relationships are read from are listed, so it is much cheaper than
+// GetNamespaceTopology. Types that cannot be listed are skipped.
+func (m *ResourceMapper) GetRelationships(ctx context.Context, namespace string) ([]ResourceRelationship, error) {
+	var relationships []ResourceRelationship
+	listed := 0
+	for _, kind := range relationshipKinds {
+		items, err := m.client.listResources(ctx, resourceMappings[kind], namespace, "")
+		if err != nil {
+			m.logger.Debug("Failed to list resources for relationships",
+				"namespace", namespace,
+				"resource", kind,
+				"error", err)
+			continue
+		}
+		listed++
+		relationships = append(relationships, m.findRelationships(ctx, items, namespace)...)
+	}
+	if listed == 0 {
+		return nil, fmt.Errorf("failed to list any resources in namespace %s", namespace)
+	}
+	return relationships, nil
+}
+
 // GetResourceGraph returns a resource graph for visualization
 func (m *ResourceMapper) GetResourceGraph(ctx context.Context, namespace string) (map[string]interface{}, error) {
 	topology, err := m.GetNamespaceTopology(ctx, namespace)
@@ -218,18 +247,16 @@ func (m *ResourceMapper) findRelationships(ctx context.Context, resources []unst
 			selector, found, _ := unstructured.NestedMap(resource.Object, "spec", "selector")
 			if found && len(selector) > 0 {
 				// Find pods matching this selector
-				pods, err := m.client.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
-					LabelSelector: m.labelsToSelector(selector),
-				})
+				pods, err := m.client.listResources(ctx, resourceMappings["pod"], namespace, m.labelsToSelector(selector))
 
 				if err == nil {
-					for _, pod := range pods.Items {
+					for _, pod := range pods {
 						rel := ResourceRelationship{
 							SourceKind:      "Service",
 							SourceName:      resource.GetName(),
 							SourceNamespace: namespace,
 							TargetKind:      "Pod",
-							TargetName:      pod.Name,
+							TargetName:      pod.GetName(),
 							TargetNamespace: namespace,
 							RelationType:    "selects",
 						}
@@ -427,16 +454,12 @@ func (m *ResourceMapper) findRelationships(ctx context.Context, resources []unst
 							continue
 						}
 
-						backend, found, _ := unstructured.NestedMap(path, "backend")
+						// networking.k8s.io/v1 names the service under backend.service, older
+						// API versions as backend.serviceName
+						serviceName, found, _ := unstructured.NestedString(path, "backend", "service", "name")
 						if !found {
-							// Check for newer API version format
-							backend, found, _ = unstructured.NestedMap(path, "backend", "service")
-							if !found {
-								continue
-							}
+							serviceName, found, _ = unstructured.NestedString(path, "backend", "serviceName")
 						}
-
-						serviceName, found, _ := unstructured.NestedString(backend, "name")
 						if found {
 							rel := ResourceRelationship{
 								SourceKind:      "Ingress",
@@ -18,20 +18,23 @@ import (
 
 // resourceMappings maps common resource types to their API versions and kinds
 var resourceMappings = map[string]schema.GroupVersionResource{
-	"pod":         {Group: "", Version: "v1", Resource: "pods"},
-	"deployment":  {Group: "apps", Version: "v1", Resource: "deployments"},
-	"service":     {Group: "", Version: "v1", Resource: "services"},
-	"configmap":   {Group: "", Version: "v1", Resource: "configmaps"},
-	"secret":      {Group: "", Version: "v1", Resource: "secrets"},
-	"statefulset": {Group: "apps", Version: "v1", Resource: "statefulsets"},
-	"daemonset":   {Group: "apps", Version: "v1", Resource: "daemonsets"},
-	"job":         {Group: "batch", Version: "v1", Resource: "jobs"},
-	"cronjob":     {Group: "batch", Version: "v1", Resource: "cronjobs"},
-	"ingress":     {Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
-	"namespace":   {Group: "", Version: "v1", Resource: "namespaces"},
-	"node":        {Group: "", Version: "v1", Resource: "nodes"},
-	"pv":          {Group: "", Version: "v1", Resource: "persistentvolumes"},
-	"pvc":         {Group: "", Version: "v1", Resource: "persistentvolumeclaims"},
+	"pod":                {Group: "", Version: "v1", Resource: "pods"},
+	"deployment":         {Group: "apps", Version: "v1", Resource: "deployments"},
+	"replicaset":         {Group: "apps", Version: "v1", Resource: "replicasets"},
+	"controllerrevision": {Group: "apps", Version: "v1", Resource: "controllerrevisions"},
+	"service":            {Group: "", Version: "v1", Resource: "services"},
+	"configmap":          {Group: "", Version: "v1", Resource: "configmaps"},
+	"secret":             {Group: "", Version: "v1", Resource: "secrets"},
+	"statefulset":        {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	"daemonset":          {Group: "apps", Version: "v1", Resource: "daemonsets"},
+	"job":                {Group: "batch", Version: "v1", Resource: "jobs"},
+	"cronjob":            {Group: "batch", Version: "v1", Resource: "cronjobs"},
+	"ingress":            {Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+	"namespace":          {Group: "", Version: "v1", Resource: "namespaces"},
+	"node":               {Group: "", Version: "v1", Resource: "nodes"},
+	"pv":                 {Group: "", Version: "v1", Resource: "persistentvolumes"},
+	"pvc":                {Group: "", Version: "v1", Resource: "persistentvolumeclaims"},
+	"event":              {Group: "", Version: "v1", Resource: "events"},
 }
 
 // getGVR returns the GroupVersionResource for a given resource type
@@ -83,6 +86,13 @@ func (c *Client) GetResource(ctx context.Context, kind, namespace, name string)
 		return nil, err
 	}
 
+	if obj, ok, cacheErr := c.cache.Get(gvr, namespace, name); ok {
+		if cacheErr != nil {
+			return nil, fmt.Errorf("failed to get %s %s/%s: %w", kind, namespace, name, cacheErr)
+		}
+		return obj, nil
+	}
+
 	var obj *unstructured.Unstructured
 	if namespace != "" {
 		obj, err = c.dynamicClient.Resource(gvr).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
@@ -106,19 +116,13 @@ func (c *Client) ListResources(ctx context.Context, kind, namespace string) ([]u
 		return nil, err
 	}
 
-	var list *unstructured.UnstructuredList
-	if namespace != "" {
-		list, err = c.dynamicClient.Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{})
-	} else {
-		list, err = c.dynamicClient.Resource(gvr).List(ctx, metav1.ListOptions{})
-	}
-
+	items, err := c.listResources(ctx, gvr, namespace, "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list resources: %w", err)
 	}
 
-	c.logger.Debug("Listed resources", "kind", kind, "count", len(list.Items))
-	return list.Items, nil
+	c.logger.Debug("Listed resources", "kind", kind, "count", len(items))
+	return items, nil
 }
 
 // GetPodStatus returns detailed status information for a pod
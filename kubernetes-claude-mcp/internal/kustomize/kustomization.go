@@ -0,0 +1,144 @@
+package kustomize
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// FileNames are the names kustomize accepts for a kustomization file, in the order it
+// looks for them
+var FileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// IsKustomizationFile reports whether a path names a kustomization file
+func IsKustomizationFile(filePath string) bool {
+	base := path.Base(filePath)
+	for _, name := range FileNames {
+		if base == name {
+			return true
+		}
+	}
+	return false
+}
+
+// pathRef is an entry such as a patch or replacement that points at a file
+type pathRef struct {
+	Path string `yaml:"path"`
+}
+
+// generator is a ConfigMap or Secret generator
+type generator struct {
+	Files []string `yaml:"files"`
+	Envs  []string `yaml:"envs"`
+	Env   string   `yaml:"env"`
+}
+
+// Kustomization holds the fields of a kustomization file that reference other files.
+// Everything else is left to kustomize itself.
+type Kustomization struct {
+	Resources             []string    `yaml:"resources"`
+	Bases                 []string    `yaml:"bases"`
+	Components            []string    `yaml:"components"`
+	CRDs                  []string    `yaml:"crds"`
+	PatchesStrategicMerge []string    `yaml:"patchesStrategicMerge"`
+	Patches               []pathRef   `yaml:"patches"`
+	PatchesJSON6902       []pathRef   `yaml:"patchesJson6902"`
+	Replacements          []pathRef   `yaml:"replacements"`
+	ConfigMapGenerator    []generator `yaml:"configMapGenerator"`
+	SecretGenerator       []generator `yaml:"secretGenerator"`
+	Generators            []string    `yaml:"generators"`
+	Transformers          []string    `yaml:"transformers"`
+	Validators            []string    `yaml:"validators"`
+}
+
+// Parse decodes a kustomization file
+func Parse(content string) (*Kustomization, error) {
+	var k Kustomization
+	if err := yaml.Unmarshal([]byte(content), &k); err != nil {
+		return nil, fmt.Errorf("failed to parse kustomization: %w", err)
+	}
+	return &k, nil
+}
+
+// References returns the local files and directories the kustomization uses, relative
+// to its directory. Remote bases and inline patches are left out.
+func (k *Kustomization) References() []string {
+	var refs []string
+	for _, entry := range k.entries() {
+		if isLocalReference(entry) {
+			refs = append(refs, entry)
+		}
+	}
+	return refs
+}
+
+// RemoteReferences returns the bases and files the kustomization loads from other
+// repositories or URLs
+func (k *Kustomization) RemoteReferences() []string {
+	var refs []string
+	for _, entry := range k.entries() {
+		if isRemoteReference(entry) {
+			refs = append(refs, entry)
+		}
+	}
+	return refs
+}
+
+// entries lists every field value that may name a file, directory or URL
+func (k *Kustomization) entries() []string {
+	var entries []string
+	add := func(values ...string) {
+		entries = append(entries, values...)
+	}
+
+	add(k.Resources...)
+	add(k.Bases...)
+	add(k.Components...)
+	add(k.CRDs...)
+	add(k.PatchesStrategicMerge...)
+	add(k.Generators...)
+	add(k.Transformers...)
+	add(k.Validators...)
+	for _, refsList := range [][]pathRef{k.Patches, k.PatchesJSON6902, k.Replacements} {
+		for _, ref := range refsList {
+			add(ref.Path)
+		}
+	}
+	for _, gen := range append(append([]generator{}, k.ConfigMapGenerator...), k.SecretGenerator...) {
+		for _, file := range gen.Files {
+			// Files may be given as key=path
+			if i := strings.Index(file, "="); i >= 0 {
+				file = file[i+1:]
+			}
+			add(file)
+		}
+		add(gen.Envs...)
+		add(gen.Env)
+	}
+	return entries
+}
+
+// isLocalReference reports whether an entry is a path in the same repository rather
+// than a remote base or an inline document
+func isLocalReference(entry string) bool {
+	entry = strings.TrimSpace(entry)
+	if entry == "" || strings.Contains(entry, "\n") || path.IsAbs(entry) {
+		return false
+	}
+	return !isRemoteReference(entry)
+}
+
+// isRemoteReference reports whether an entry points at another repository or a URL
+func isRemoteReference(entry string) bool {
+	if strings.Contains(entry, "\n") {
+		return false
+	}
+	for _, remote := range []string{"://", "git@", "github.com/", "gitlab.com/", "bitbucket.org/", "?ref="} {
+		if strings.Contains(entry, remote) {
+			return true
+		}
+	}
+	return false
+}
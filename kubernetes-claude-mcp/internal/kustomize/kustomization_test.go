@@ -0,0 +1,71 @@
+package kustomize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReferences(t *testing.T) {
+	k, err := Parse(`
+apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+namespace: shop
+resources:
+- ../../base
+- ingress.yaml
+- https://github.com/example/deploy//base?ref=v1
+components:
+- ../../components/monitoring
+patches:
+- path: patches/replicas.yaml
+- patch: |-
+    - op: replace
+      path: /spec/replicas
+      value: 3
+patchesStrategicMerge:
+- |-
+  apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: api
+configMapGenerator:
+- name: api-config
+  files:
+  - config.json
+  - app.properties=config/app.properties
+  envs:
+  - api.env
+images:
+- name: api
+  newTag: 1.5.0
+`)
+	if err != nil {
+		t.Fatalf("Failed to parse kustomization: %v", err)
+	}
+
+	expected := []string{
+		"../../base",
+		"ingress.yaml",
+		"../../components/monitoring",
+		"patches/replicas.yaml",
+		"config.json",
+		"config/app.properties",
+		"api.env",
+	}
+	if refs := k.References(); !reflect.DeepEqual(refs, expected) {
+		t.Errorf("Expected references %v, got %v", expected, refs)
+	}
+}
+
+func TestIsKustomizationFile(t *testing.T) {
+	for path, expected := range map[string]bool{
+		"apps/api/overlays/prod/kustomization.yaml": true,
+		"kustomization.yml":                         true,
+		"apps/api/Kustomization":                    true,
+		"apps/api/deployment.yaml":                  false,
+	} {
+		if IsKustomizationFile(path) != expected {
+			t.Errorf("IsKustomizationFile(%q) = %v, expected %v", path, !expected, expected)
+		}
+	}
+}
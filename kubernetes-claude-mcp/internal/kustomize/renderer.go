@@ -0,0 +1,71 @@
+package kustomize
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// repoRoot is where repository files are laid out in the in-memory file system
+const repoRoot = "/repo"
+
+// Renderer builds kustomizations fetched from a repository in process
+type Renderer struct {
+	logger *logging.Logger
+}
+
+// NewRenderer creates a new kustomize renderer
+func NewRenderer(logger *logging.Logger) *Renderer {
+	if logger == nil {
+		logger = logging.NewLogger().Named("kustomize")
+	}
+
+	return &Renderer{
+		logger: logger,
+	}
+}
+
+// Build lays out a kustomization and the files it references, keyed by repository
+// path, in an in-memory file system and runs kustomize build on dir. Plugins are
+// disabled and kustomizations with remote bases are rejected, so nothing is fetched or
+// executed while building.
+func (r *Renderer) Build(files map[string]string, dir string) (string, error) {
+	fs := filesys.MakeFsInMemory()
+	for repoPath, content := range files {
+		if IsKustomizationFile(repoPath) {
+			k, err := Parse(content)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", repoPath, err)
+			}
+			if remote := k.RemoteReferences(); len(remote) > 0 {
+				return "", fmt.Errorf("%s uses remote bases, which are not supported: %s", repoPath, strings.Join(remote, ", "))
+			}
+		}
+
+		cleaned := path.Clean(repoPath)
+		if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			return "", fmt.Errorf("refusing to load file outside the repository: %s", repoPath)
+		}
+		if err := fs.WriteFile(path.Join(repoRoot, cleaned), []byte(content)); err != nil {
+			return "", fmt.Errorf("failed to load file %s: %w", repoPath, err)
+		}
+	}
+
+	r.logger.Debug("Building kustomization", "dir", dir, "fileCount", len(files))
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	resources, err := kustomizer.Run(fs, path.Join(repoRoot, path.Clean(dir)))
+	if err != nil {
+		return "", fmt.Errorf("failed to build kustomization %s: %w", dir, err)
+	}
+
+	output, err := resources.AsYaml()
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize kustomization %s: %w", dir, err)
+	}
+	return string(output), nil
+}
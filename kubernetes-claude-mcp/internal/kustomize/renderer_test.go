@@ -0,0 +1,56 @@
+package kustomize
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+func TestBuild(t *testing.T) {
+	files := map[string]string{
+		"apps/api/base/kustomization.yaml": "resources:\n- deployment.yaml\n",
+		"apps/api/base/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: api
+        image: registry.example.com/api:1.4.2
+`,
+		"apps/api/overlays/prod/kustomization.yaml": `resources:
+- ../../base
+namespace: shop
+namePrefix: prod-
+images:
+- name: registry.example.com/api
+  newTag: 1.5.0
+replicas:
+- name: api
+  count: 3
+`,
+	}
+
+	output, err := NewRenderer(logging.NewLogger()).Build(files, "apps/api/overlays/prod")
+	if err != nil {
+		t.Fatalf("Failed to build kustomization: %v", err)
+	}
+	for _, expected := range []string{"name: prod-api", "namespace: shop", "image: registry.example.com/api:1.5.0", "replicas: 3"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected the output to contain %q, got:\n%s", expected, output)
+		}
+	}
+}
+
+func TestBuildRejectsRemoteBases(t *testing.T) {
+	files := map[string]string{
+		"kustomization.yaml": "resources:\n- https://github.com/example/deploy//base?ref=v1\n",
+	}
+	if _, err := NewRenderer(logging.NewLogger()).Build(files, "."); err == nil || !strings.Contains(err.Error(), "remote bases") {
+		t.Errorf("Expected a remote base to be rejected, got %v", err)
+	}
+}
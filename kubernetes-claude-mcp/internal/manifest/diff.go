@@ -0,0 +1,331 @@
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// Masker returns a copy of an object with sensitive values masked. Diff detects
+// changes on the original objects but reports values from the masked copies, so a
+// changed Secret shows up as a change without revealing either value.
+type Masker func(obj map[string]interface{}) map[string]interface{}
+
+// podSpecPaths are where workload kinds keep their pod spec
+var podSpecPaths = [][]string{
+	{"spec", "template", "spec"},
+	{"spec", "jobTemplate", "spec", "template", "spec"},
+	{"spec"},
+}
+
+// side is a value and its masked copy at the same position
+type side struct {
+	raw   interface{}
+	shown interface{}
+}
+
+// Diff compares the objects rendered from two versions of a chart or manifest directory
+// and returns the added, removed and modified objects. Objects are matched by group,
+// kind, namespace and name; a nil mask reports values as they are.
+func Diff(before, after []map[string]interface{}, mask Masker) []models.ResourceDiff {
+	if mask == nil {
+		mask = func(obj map[string]interface{}) map[string]interface{} { return obj }
+	}
+
+	beforeByKey := make(map[string]map[string]interface{}, len(before))
+	for _, obj := range before {
+		beforeByKey[Key(obj)] = obj
+	}
+
+	var diffs []models.ResourceDiff
+	seen := make(map[string]bool, len(after))
+	for _, obj := range after {
+		key := Key(obj)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		old, existed := beforeByKey[key]
+		switch {
+		case !existed:
+			diffs = append(diffs, resourceDiff(models.ResourceAdded, nil, obj, nil))
+		case !reflect.DeepEqual(old, obj):
+			var fields []models.FieldChange
+			compareValues("", side{old, mask(old)}, side{obj, mask(obj)}, &fields)
+			diffs = append(diffs, resourceDiff(models.ResourceModified, old, obj, fields))
+		}
+	}
+
+	for _, obj := range before {
+		key := Key(obj)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		diffs = append(diffs, resourceDiff(models.ResourceRemoved, obj, nil, nil))
+	}
+
+	return diffs
+}
+
+// DiffFields returns the fields that differ between two maps, such as the values of
+// two chart releases. Changes are detected on the originals and reported from the
+// masked copies; a nil mask reports values as they are.
+func DiffFields(before, after map[string]interface{}, mask Masker) []models.FieldChange {
+	if mask == nil {
+		mask = func(obj map[string]interface{}) map[string]interface{} { return obj }
+	}
+	if before == nil {
+		before = map[string]interface{}{}
+	}
+	if after == nil {
+		after = map[string]interface{}{}
+	}
+
+	var fields []models.FieldChange
+	compareValues("", side{before, mask(before)}, side{after, mask(after)}, &fields)
+	return fields
+}
+
+func resourceDiff(change string, before, after map[string]interface{}, fields []models.FieldChange) models.ResourceDiff {
+	current := after
+	if current == nil {
+		current = before
+	}
+	apiVersion, kind, namespace, name := Identity(current)
+
+	diff := models.ResourceDiff{
+		APIVersion:   apiVersion,
+		Kind:         kind,
+		Namespace:    namespace,
+		Name:         name,
+		Change:       change,
+		Fields:       fields,
+		ImageChanges: imageChanges(containerImages(before), containerImages(after)),
+	}
+
+	beforeReplicas, afterReplicas := replicas(before), replicas(after)
+	if !reflect.DeepEqual(beforeReplicas, afterReplicas) {
+		diff.ReplicaChange = &models.ReplicaChange{Before: beforeReplicas, After: afterReplicas}
+	}
+	return diff
+}
+
+// compareValues records the fields that differ between two values, descending into
+// maps and lists
+func compareValues(path string, before, after side, changes *[]models.FieldChange) {
+	switch b := before.raw.(type) {
+	case map[string]interface{}:
+		if a, ok := after.raw.(map[string]interface{}); ok {
+			beforeShown, _ := before.shown.(map[string]interface{})
+			afterShown, _ := after.shown.(map[string]interface{})
+			for _, key := range unionKeys(b, a) {
+				compareValues(joinPath(path, key),
+					side{b[key], beforeShown[key]},
+					side{a[key], afterShown[key]},
+					changes)
+			}
+			return
+		}
+	case []interface{}:
+		if a, ok := after.raw.([]interface{}); ok {
+			compareLists(path, b, a, before.shown, after.shown, changes)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(before.raw, after.raw) {
+		*changes = append(*changes, models.FieldChange{Path: path, Before: before.shown, After: after.shown})
+	}
+}
+
+// compareLists matches list items by name when every item has a unique one, as
+// containers, env vars, ports and volumes do, and by position otherwise
+func compareLists(path string, before, after []interface{}, beforeShown, afterShown interface{}, changes *[]models.FieldChange) {
+	bShown, _ := beforeShown.([]interface{})
+	aShown, _ := afterShown.([]interface{})
+
+	beforeNames, beforeNamed := itemNames(before)
+	afterNames, afterNamed := itemNames(after)
+	if beforeNamed && afterNamed {
+		beforeIndex := make(map[string]int, len(beforeNames))
+		for i, name := range beforeNames {
+			beforeIndex[name] = i
+		}
+		afterIndex := make(map[string]int, len(afterNames))
+		for i, name := range afterNames {
+			afterIndex[name] = i
+		}
+
+		names := append([]string{}, beforeNames...)
+		for _, name := range afterNames {
+			if _, ok := beforeIndex[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			var b, a side
+			if i, ok := beforeIndex[name]; ok {
+				b = side{before[i], itemAt(bShown, i)}
+			}
+			if i, ok := afterIndex[name]; ok {
+				a = side{after[i], itemAt(aShown, i)}
+			}
+			compareValues(fmt.Sprintf("%s[%s]", path, name), b, a, changes)
+		}
+		return
+	}
+
+	for i := 0; i < len(before) || i < len(after); i++ {
+		var b, a side
+		if i < len(before) {
+			b = side{before[i], itemAt(bShown, i)}
+		}
+		if i < len(after) {
+			a = side{after[i], itemAt(aShown, i)}
+		}
+		compareValues(fmt.Sprintf("%s[%d]", path, i), b, a, changes)
+	}
+}
+
+// itemNames returns the names of list items, and whether every item is a map with a
+// unique, non-empty name
+func itemNames(items []interface{}) ([]string, bool) {
+	names := make([]string, 0, len(items))
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		name, _ := m["name"].(string)
+		if name == "" || seen[name] {
+			return nil, false
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	return names, len(names) > 0
+}
+
+func itemAt(items []interface{}, i int) interface{} {
+	if i < len(items) {
+		return items[i]
+	}
+	return nil
+}
+
+// containerImages maps container names to images across containers and init containers
+func containerImages(obj map[string]interface{}) map[string]string {
+	images := make(map[string]string)
+	if obj == nil {
+		return images
+	}
+
+	for _, specPath := range podSpecPaths {
+		spec, ok := nestedMap(obj, specPath...)
+		if !ok {
+			continue
+		}
+		found := false
+		for _, field := range []string{"initContainers", "containers"} {
+			containers, _ := spec[field].([]interface{})
+			for _, c := range containers {
+				container, ok := c.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := container["name"].(string)
+				image, _ := container["image"].(string)
+				if name != "" {
+					images[name] = image
+					found = true
+				}
+			}
+		}
+		if found {
+			break
+		}
+	}
+	return images
+}
+
+func imageChanges(before, after map[string]string) []models.ImageChange {
+	names := make(map[string]bool, len(before)+len(after))
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+
+	var changes []models.ImageChange
+	for _, name := range sortedNames(names) {
+		if before[name] != after[name] {
+			changes = append(changes, models.ImageChange{Container: name, Before: before[name], After: after[name]})
+		}
+	}
+	return changes
+}
+
+// replicas returns spec.replicas, or nil when the object or field is absent
+func replicas(obj map[string]interface{}) *int64 {
+	spec, ok := nestedMap(obj, "spec")
+	if !ok {
+		return nil
+	}
+	var count int64
+	switch v := spec["replicas"].(type) {
+	case int64:
+		count = v
+	case int:
+		count = int64(v)
+	case float64:
+		count = int64(v)
+	default:
+		return nil
+	}
+	return &count
+}
+
+func nestedMap(obj map[string]interface{}, fields ...string) (map[string]interface{}, bool) {
+	current := obj
+	for _, field := range fields {
+		next, ok := current[field].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, current != nil
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make(map[string]bool, len(a)+len(b))
+	for key := range a {
+		keys[key] = true
+	}
+	for key := range b {
+		keys[key] = true
+	}
+	return sortedNames(keys)
+}
+
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
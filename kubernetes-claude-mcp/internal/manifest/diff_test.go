@@ -0,0 +1,165 @@
+package manifest
+
+import (
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/redact"
+)
+
+const baseManifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: api
+        image: registry.example.com/api:1.4.2
+        env:
+        - name: LOG_LEVEL
+          value: info
+      - name: proxy
+        image: envoyproxy/envoy:v1.30.0
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db-creds
+  namespace: shop
+data:
+  password: aHVudGVyMg==
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: legacy
+  namespace: shop
+data:
+  mode: old
+`
+
+const headManifests = `
+apiVersion: v1
+kind: List
+items:
+- apiVersion: apps/v1
+  kind: Deployment
+  metadata:
+    name: api
+    namespace: shop
+  spec:
+    replicas: 4
+    template:
+      spec:
+        containers:
+        - name: proxy
+          image: envoyproxy/envoy:v1.30.0
+        - name: api
+          image: registry.example.com/api:1.5.0
+          env:
+          - name: LOG_LEVEL
+            value: debug
+- apiVersion: v1
+  kind: Secret
+  metadata:
+    name: db-creds
+    namespace: shop
+  data:
+    password: Y29ycmVjdC1ob3JzZQ==
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: api
+    namespace: shop
+  spec:
+    ports:
+    - port: 80
+`
+
+func findDiff(diffs []models.ResourceDiff, kind, name string) *models.ResourceDiff {
+	for i := range diffs {
+		if diffs[i].Kind == kind && diffs[i].Name == name {
+			return &diffs[i]
+		}
+	}
+	return nil
+}
+
+func findField(fields []models.FieldChange, path string) *models.FieldChange {
+	for i := range fields {
+		if fields[i].Path == path {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+func TestDiff(t *testing.T) {
+	before, err := Parse(baseManifests)
+	if err != nil {
+		t.Fatalf("Failed to parse base manifests: %v", err)
+	}
+	after, err := Parse(headManifests)
+	if err != nil {
+		t.Fatalf("Failed to parse head manifests: %v", err)
+	}
+
+	mask := func(obj map[string]interface{}) map[string]interface{} {
+		masked, _ := redact.Default().RedactObject(obj)
+		return masked
+	}
+	diffs := Diff(before, after, mask)
+	if len(diffs) != 4 {
+		t.Fatalf("Expected 4 resource diffs, got %d: %+v", len(diffs), diffs)
+	}
+
+	deployment := findDiff(diffs, "Deployment", "api")
+	if deployment == nil || deployment.Change != models.ResourceModified {
+		t.Fatalf("Expected the Deployment to be modified, got %+v", deployment)
+	}
+	if len(deployment.ImageChanges) != 1 || deployment.ImageChanges[0].Container != "api" ||
+		deployment.ImageChanges[0].After != "registry.example.com/api:1.5.0" {
+		t.Errorf("Expected one image change for the api container, got %+v", deployment.ImageChanges)
+	}
+	if rc := deployment.ReplicaChange; rc == nil || *rc.Before != 2 || *rc.After != 4 {
+		t.Errorf("Expected replicas to change from 2 to 4, got %+v", rc)
+	}
+	if f := findField(deployment.Fields, "spec.template.spec.containers[api].env[LOG_LEVEL].value"); f == nil || f.After != "debug" {
+		t.Errorf("Expected the env change to be addressed by name, got %+v", deployment.Fields)
+	}
+	if findField(deployment.Fields, "spec.template.spec.containers[proxy].image") != nil {
+		t.Error("Expected reordering containers not to be reported as a change")
+	}
+
+	secret := findDiff(diffs, "Secret", "db-creds")
+	if secret == nil || secret.Change != models.ResourceModified {
+		t.Fatalf("Expected the Secret to be modified, got %+v", secret)
+	}
+	password := findField(secret.Fields, "data.password")
+	if password == nil || password.Before != redact.Mask || password.After != redact.Mask {
+		t.Errorf("Expected the Secret change to be reported with masked values, got %+v", password)
+	}
+
+	if d := findDiff(diffs, "Service", "api"); d == nil || d.Change != models.ResourceAdded {
+		t.Errorf("Expected the Service to be added, got %+v", d)
+	}
+	if d := findDiff(diffs, "ConfigMap", "legacy"); d == nil || d.Change != models.ResourceRemoved {
+		t.Errorf("Expected the ConfigMap to be removed, got %+v", d)
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	objects, err := Parse(baseManifests)
+	if err != nil {
+		t.Fatalf("Failed to parse manifests: %v", err)
+	}
+	again, _ := Parse(baseManifests)
+	if diffs := Diff(objects, again, nil); len(diffs) != 0 {
+		t.Errorf("Expected no diffs for identical manifests, got %+v", diffs)
+	}
+}
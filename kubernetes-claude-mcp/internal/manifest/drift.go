@@ -0,0 +1,175 @@
+package manifest
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// ignoredDriftPaths are fields the API server and controllers maintain, which never
+// match a target state
+var ignoredDriftPaths = map[string]bool{
+	"status":                     true,
+	"metadata.managedFields":     true,
+	"metadata.resourceVersion":   true,
+	"metadata.uid":               true,
+	"metadata.generation":        true,
+	"metadata.creationTimestamp": true,
+	"metadata.selfLink":          true,
+	"metadata.annotations.kubectl.kubernetes.io/last-applied-configuration": true,
+}
+
+// DiffLive compares the live state of a resource with its target state and returns the
+// fields that drifted. The comparison is driven by the target: fields and list items
+// only present in the live state, which the API server, controllers and admission
+// webhooks add, are ignored, as are status and server-set metadata. Empty and zero
+// target values match absent live fields, and quantities and numbers match in any
+// notation. Changes are reported from the masked copies; a nil mask reports values as
+// they are.
+func DiffLive(target, live map[string]interface{}, mask Masker) []models.DriftField {
+	if mask == nil {
+		mask = func(obj map[string]interface{}) map[string]interface{} { return obj }
+	}
+
+	var fields []models.DriftField
+	compareLive("", side{target, mask(target)}, side{live, mask(live)}, true, &fields)
+	return fields
+}
+
+// compareLive records the fields of a target value that the live value does not match.
+// livePresent is false when the live state has no such field.
+func compareLive(path string, target, live side, livePresent bool, fields *[]models.DriftField) {
+	if ignoredDriftPaths[path] {
+		return
+	}
+	if !livePresent {
+		if !isEmptyValue(target.raw) {
+			*fields = append(*fields, models.DriftField{Path: path, Target: target.shown})
+		}
+		return
+	}
+
+	switch t := target.raw.(type) {
+	case map[string]interface{}:
+		if l, ok := live.raw.(map[string]interface{}); ok {
+			targetShown, _ := target.shown.(map[string]interface{})
+			liveShown, _ := live.shown.(map[string]interface{})
+			for _, key := range unionKeys(t, nil) {
+				value, present := l[key]
+				compareLive(joinPath(path, key), side{t[key], targetShown[key]}, side{value, liveShown[key]}, present, fields)
+			}
+			return
+		}
+	case []interface{}:
+		if l, ok := live.raw.([]interface{}); ok {
+			compareLiveLists(path, t, l, target.shown, live.shown, fields)
+			return
+		}
+	}
+
+	if !equalLiveValues(target.raw, live.raw) {
+		*fields = append(*fields, models.DriftField{Path: path, Target: target.shown, Live: live.shown})
+	}
+}
+
+// compareLiveLists matches target list items to live ones by name when every item has
+// a unique one, and by position otherwise
+func compareLiveLists(path string, target, live []interface{}, targetShown, liveShown interface{}, fields *[]models.DriftField) {
+	tShown, _ := targetShown.([]interface{})
+	lShown, _ := liveShown.([]interface{})
+
+	targetNames, targetNamed := itemNames(target)
+	liveNames, liveNamed := itemNames(live)
+	if targetNamed && (liveNamed || len(live) == 0) {
+		liveIndex := make(map[string]int, len(liveNames))
+		for i, name := range liveNames {
+			liveIndex[name] = i
+		}
+		for i, name := range targetNames {
+			var l side
+			j, present := liveIndex[name]
+			if present {
+				l = side{live[j], itemAt(lShown, j)}
+			}
+			compareLive(fmt.Sprintf("%s[%s]", path, name), side{target[i], itemAt(tShown, i)}, l, present, fields)
+		}
+		return
+	}
+
+	for i := range target {
+		var l side
+		present := i < len(live)
+		if present {
+			l = side{live[i], itemAt(lShown, i)}
+		}
+		compareLive(fmt.Sprintf("%s[%d]", path, i), side{target[i], itemAt(tShown, i)}, l, present, fields)
+	}
+}
+
+// equalLiveValues compares scalar values, treating a null target as unset and
+// comparing numbers and quantities by value
+func equalLiveValues(target, live interface{}) bool {
+	if target == nil || reflect.DeepEqual(target, live) {
+		return true
+	}
+
+	t, tok := scalarString(target)
+	l, lok := scalarString(live)
+	if !tok || !lok {
+		return false
+	}
+	if t == l {
+		return true
+	}
+	tq, tErr := resource.ParseQuantity(t)
+	lq, lErr := resource.ParseQuantity(l)
+	return tErr == nil && lErr == nil && tq.Cmp(lq) == 0
+}
+
+// scalarString formats a string or number for comparison
+func scalarString(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case int:
+		return strconv.Itoa(v), true
+	}
+	return "", false
+}
+
+// isEmptyValue reports whether a value is null, zero or empty, or a map of such values,
+// which the API server drops rather than stores
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case int64:
+		return v == 0
+	case int:
+		return v == 0
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, item := range v {
+			if !isEmptyValue(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+const targetState = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+  labels:
+    app: api
+spec:
+  replicas: 3
+  template:
+    spec:
+      securityContext: {}
+      hostNetwork: false
+      containers:
+        - name: api
+          image: registry.example.com/api:1.4.0
+          ports:
+            - containerPort: 8080
+          resources:
+            limits:
+              cpu: 1000m
+              memory: 1Gi
+          env:
+            - name: LOG_LEVEL
+              value: info
+            - name: FEATURE_X
+              value: "true"
+`
+
+const liveState = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+  labels:
+    app: api
+  resourceVersion: "123"
+  generation: 7
+  managedFields:
+    - manager: kubectl
+spec:
+  replicas: 5
+  progressDeadlineSeconds: 600
+  template:
+    spec:
+      dnsPolicy: ClusterFirst
+      containers:
+        - name: istio-proxy
+          image: istio/proxyv2
+        - name: api
+          image: registry.example.com/api:1.4.0
+          imagePullPolicy: IfNotPresent
+          ports:
+            - containerPort: 8080
+              protocol: TCP
+          resources:
+            limits:
+              cpu: "1"
+              memory: 1Gi
+          env:
+            - name: LOG_LEVEL
+              value: debug
+status:
+  replicas: 5
+`
+
+func TestDiffLive(t *testing.T) {
+	target, err := Parse(targetState)
+	if err != nil {
+		t.Fatalf("Failed to parse target state: %v", err)
+	}
+	live, err := Parse(liveState)
+	if err != nil {
+		t.Fatalf("Failed to parse live state: %v", err)
+	}
+
+	fields := DiffLive(target[0], live[0], nil)
+
+	expected := []models.DriftField{
+		{Path: "spec.replicas", Target: float64(3), Live: float64(5)},
+		{Path: "spec.template.spec.containers[api].env[LOG_LEVEL].value", Target: "info", Live: "debug"},
+		{Path: "spec.template.spec.containers[api].env[FEATURE_X]", Target: map[string]interface{}{"name": "FEATURE_X", "value": "true"}},
+	}
+	if !reflect.DeepEqual(normalizeNumbers(fields), expected) {
+		t.Errorf("Expected drift %+v, got %+v", expected, fields)
+	}
+}
+
+func TestDiffLiveInSync(t *testing.T) {
+	objects, err := Parse(targetState)
+	if err != nil {
+		t.Fatalf("Failed to parse target state: %v", err)
+	}
+	if fields := DiffLive(objects[0], objects[0], nil); len(fields) != 0 {
+		t.Errorf("Expected no drift between identical states, got %+v", fields)
+	}
+}
+
+// normalizeNumbers converts the integer types YAML decodes to float64, as JSON would
+func normalizeNumbers(fields []models.DriftField) []models.DriftField {
+	convert := func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case int:
+			return float64(v)
+		case int64:
+			return float64(v)
+		}
+		return value
+	}
+	for i := range fields {
+		fields[i].Target = convert(fields[i].Target)
+		fields[i].Live = convert(fields[i].Live)
+	}
+	return fields
+}
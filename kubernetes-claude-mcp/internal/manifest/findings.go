@@ -0,0 +1,204 @@
+package manifest
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// hunkHeader matches the line ranges of a unified diff hunk
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// replicaKeys are the keys that set a replica count in manifests and chart values
+var replicaKeys = map[string]bool{
+	"replicas":     true,
+	"replicaCount": true,
+}
+
+// diffLine is a line of a unified diff hunk with its line numbers in the old and new
+// file. Op is '+', '-' or ' '.
+type diffLine struct {
+	op      byte
+	oldLine int
+	newLine int
+	text    string
+}
+
+// Findings scans the YAML files a merge request changes for risky lines: an image
+// moving to the latest tag, resource limits being removed and a replica count set to
+// 0. Manifests and chart values are both scanned, so a finding points at the line an
+// author changed rather than at the rendered object.
+func Findings(changes []models.GitLabDiff) []models.ManifestFinding {
+	var findings []models.ManifestFinding
+	for _, change := range changes {
+		if change.DeletedFile || !isYAMLFile(change.NewPath) {
+			continue
+		}
+		for _, hunk := range parseHunks(change.Diff) {
+			findings = append(findings, hunkFindings(change, hunk)...)
+		}
+	}
+	return findings
+}
+
+// hunkFindings applies the rules to the added and removed lines of one hunk
+func hunkFindings(change models.GitLabDiff, hunk []diffLine) []models.ManifestFinding {
+	// A limits block that is rewritten within the hunk is not removed
+	limitsAdded := false
+	for _, line := range hunk {
+		if key, _ := yamlKeyValue(line.text); line.op == '+' && key == "limits" {
+			limitsAdded = true
+		}
+	}
+
+	var findings []models.ManifestFinding
+	added := func(rule, severity, message string, line diffLine) {
+		findings = append(findings, models.ManifestFinding{
+			Rule:     rule,
+			Severity: severity,
+			Message:  message,
+			NewPath:  change.NewPath,
+			NewLine:  line.newLine,
+			Text:     strings.TrimSpace(line.text),
+		})
+	}
+
+	for _, line := range hunk {
+		key, value := yamlKeyValue(line.text)
+		switch line.op {
+		case '+':
+			switch {
+			case key == "image":
+				if image, implicit, ok := latestImage(value); ok {
+					message := fmt.Sprintf("Image %s uses the latest tag", image)
+					if implicit {
+						message = fmt.Sprintf("Image %s has no tag, so it resolves to latest", image)
+					}
+					added(models.RuleImageLatest, models.FindingWarning,
+						message+": the image deployed can change whenever a pod is rescheduled, and a rollback "+
+							"cannot bring the previous image back", line)
+				}
+			case (key == "tag" || key == "newTag") && value == "latest":
+				added(models.RuleImageLatest, models.FindingWarning,
+					"Image tag is set to latest: the image deployed can change whenever a pod is rescheduled, "+
+						"and a rollback cannot bring the previous image back", line)
+			case replicaKeys[key] && value == "0":
+				added(models.RuleReplicasToZero, models.FindingCritical,
+					fmt.Sprintf("%s is set to 0, which scales the workload down and stops it serving", key), line)
+			case key == "limits" && (value == "{}" || value == "null" || value == "~"):
+				added(models.RuleLimitsRemoved, models.FindingWarning,
+					"Resource limits are cleared, so the containers can use all of a node's CPU and memory", line)
+			}
+		case '-':
+			if key == "limits" && value == "" && !limitsAdded {
+				findings = append(findings, models.ManifestFinding{
+					Rule:     models.RuleLimitsRemoved,
+					Severity: models.FindingWarning,
+					Message:  "Resource limits are removed, so the containers can use all of a node's CPU and memory",
+					OldPath:  change.OldPath,
+					NewPath:  change.NewPath,
+					OldLine:  line.oldLine,
+					Text:     strings.TrimSpace(line.text),
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// parseHunks splits a unified diff into hunks, numbering each line in the old and
+// new file
+func parseHunks(diff string) [][]diffLine {
+	var hunks [][]diffLine
+	var current []diffLine
+	oldLine, newLine := 0, 0
+
+	for _, text := range strings.Split(diff, "\n") {
+		if match := hunkHeader.FindStringSubmatch(text); match != nil {
+			if len(current) > 0 {
+				hunks = append(hunks, current)
+			}
+			current = nil
+			oldLine, _ = strconv.Atoi(match[1])
+			newLine, _ = strconv.Atoi(match[2])
+			continue
+		}
+		if text == "" || (oldLine == 0 && newLine == 0) {
+			continue
+		}
+
+		switch text[0] {
+		case '+':
+			current = append(current, diffLine{op: '+', newLine: newLine, text: text[1:]})
+			newLine++
+		case '-':
+			current = append(current, diffLine{op: '-', oldLine: oldLine, text: text[1:]})
+			oldLine++
+		case ' ':
+			current = append(current, diffLine{op: ' ', oldLine: oldLine, newLine: newLine, text: text[1:]})
+			oldLine++
+			newLine++
+		}
+	}
+	if len(current) > 0 {
+		hunks = append(hunks, current)
+	}
+	return hunks
+}
+
+// yamlKeyValue reads the key and scalar value of a YAML mapping line, including one
+// that starts a list item. Quotes and trailing comments are stripped; the value is
+// empty when the key opens a nested block.
+func yamlKeyValue(line string) (key, value string) {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSpace(strings.TrimPrefix(line, "- "))
+	if strings.HasPrefix(line, "#") {
+		return "", ""
+	}
+
+	key, value, ok := strings.Cut(line, ":")
+	if !ok || strings.ContainsAny(key, " \"'{") {
+		return "", ""
+	}
+	value = strings.TrimSpace(value)
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		value = value[1 : len(value)-1]
+	}
+	return key, value
+}
+
+// latestImage reports whether an image reference resolves to the latest tag, either
+// explicitly or because it has no tag or digest. Templated references are skipped.
+func latestImage(image string) (ref string, implicit, ok bool) {
+	if image == "" || strings.Contains(image, "{{") || strings.Contains(image, "${") || strings.Contains(image, "@") {
+		return "", false, false
+	}
+
+	name := image[strings.LastIndex(image, "/")+1:]
+	_, tag, tagged := strings.Cut(name, ":")
+	switch {
+	case !tagged:
+		return image, true, true
+	case tag == "latest":
+		return image, false, true
+	default:
+		return "", false, false
+	}
+}
+
+// isYAMLFile reports whether a path is a YAML file
+func isYAMLFile(file string) bool {
+	switch strings.ToLower(path.Ext(file)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,86 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+func TestFindings(t *testing.T) {
+	changes := []models.GitLabDiff{
+		{
+			OldPath: "deploy/web.yaml",
+			NewPath: "deploy/web.yaml",
+			Diff: "@@ -3,14 +3,12 @@ metadata:\n" +
+				" spec:\n" +
+				"-  replicas: 3\n" +
+				"+  replicas: 0\n" +
+				"   template:\n" +
+				"     spec:\n" +
+				"       containers:\n" +
+				"       - name: web\n" +
+				"-        image: registry.example.com/web:1.4.2\n" +
+				"+        image: \"registry.example.com/web:latest\" # testing\n" +
+				"         resources:\n" +
+				"-          limits:\n" +
+				"-            cpu: 500m\n" +
+				"-            memory: 256Mi\n" +
+				"           requests:\n" +
+				"             cpu: 100m\n" +
+				"@@ -30,2 +28,3 @@ spec:\n" +
+				"       - name: sidecar\n" +
+				"+        image: busybox\n" +
+				"         args: [\"sleep\"]\n",
+		},
+		{
+			// Limits rewritten in place are not removed, and templated images are skipped
+			OldPath: "charts/api/templates/deployment.yaml",
+			NewPath: "charts/api/templates/deployment.yaml",
+			Diff: "@@ -20,4 +20,4 @@\n" +
+				"           limits:\n" +
+				"-            cpu: 500m\n" +
+				"+            cpu: 1\n" +
+				"-          image: \"{{ .Values.image.repository }}:{{ .Values.image.tag }}\"\n" +
+				"+          image: \"{{ .Values.image.repository }}:{{ .Values.image.tag | default \"latest\" }}\"\n",
+		},
+		{
+			OldPath: "charts/api/values.yaml",
+			NewPath: "charts/api/values.yaml",
+			Diff: "@@ -1,5 +1,5 @@\n" +
+				" image:\n" +
+				"   repository: api\n" +
+				"-  tag: 2.0.1\n" +
+				"+  tag: latest\n" +
+				"-replicaCount: 2\n" +
+				"+replicaCount: 1\n",
+		},
+		{
+			OldPath: "README.md",
+			NewPath: "README.md",
+			Diff:    "@@ -1 +1 @@\n-replicas: 3\n+replicas: 0\n",
+		},
+	}
+
+	type location struct {
+		rule    string
+		path    string
+		oldLine int
+		newLine int
+	}
+	var got []location
+	for _, finding := range Findings(changes) {
+		got = append(got, location{finding.Rule, finding.NewPath, finding.OldLine, finding.NewLine})
+	}
+
+	want := []location{
+		{models.RuleReplicasToZero, "deploy/web.yaml", 0, 4},
+		{models.RuleImageLatest, "deploy/web.yaml", 0, 9},
+		{models.RuleLimitsRemoved, "deploy/web.yaml", 11, 0},
+		{models.RuleImageLatest, "deploy/web.yaml", 0, 29},
+		{models.RuleImageLatest, "charts/api/values.yaml", 0, 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Findings() =\n%v\nwant\n%v", got, want)
+	}
+}
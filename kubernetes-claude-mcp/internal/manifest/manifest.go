@@ -0,0 +1,70 @@
+package manifest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Parse decodes a stream of YAML or JSON documents into Kubernetes objects. Empty
+// documents are skipped, List objects are flattened into their items, and documents
+// without a kind are ignored.
+func Parse(content string) ([]map[string]interface{}, error) {
+	decoder := utilyaml.NewYAMLOrJSONDecoder(strings.NewReader(content), 4096)
+
+	var objects []map[string]interface{}
+	for {
+		var obj map[string]interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return objects, fmt.Errorf("failed to decode manifest: %w", err)
+		}
+		if obj == nil {
+			continue
+		}
+
+		kind, _ := obj["kind"].(string)
+		if strings.HasSuffix(kind, "List") {
+			if items, ok := obj["items"].([]interface{}); ok {
+				for _, item := range items {
+					if itemObj, ok := item.(map[string]interface{}); ok {
+						objects = append(objects, itemObj)
+					}
+				}
+				continue
+			}
+		}
+		if kind == "" {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// Identity returns an object's API version, kind, namespace and name
+func Identity(obj map[string]interface{}) (apiVersion, kind, namespace, name string) {
+	apiVersion, _ = obj["apiVersion"].(string)
+	kind, _ = obj["kind"].(string)
+	if metadata, ok := obj["metadata"].(map[string]interface{}); ok {
+		namespace, _ = metadata["namespace"].(string)
+		name, _ = metadata["name"].(string)
+	}
+	return apiVersion, kind, namespace, name
+}
+
+// Key identifies an object across versions of a manifest. The API version is reduced
+// to its group so an object moving from one version to another is still matched.
+func Key(obj map[string]interface{}) string {
+	apiVersion, kind, namespace, name := Identity(obj)
+	group := ""
+	if i := strings.LastIndex(apiVersion, "/"); i >= 0 {
+		group = apiVersion[:i]
+	}
+	return strings.Join([]string{group, kind, namespace, name}, "/")
+}
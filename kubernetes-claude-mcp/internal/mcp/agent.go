@@ -0,0 +1,198 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/claude"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/utils"
+)
+
+const (
+	// defaultAgentMaxIterations bounds the number of model round trips per request
+	defaultAgentMaxIterations = 8
+
+	// defaultAgentMaxTotalTokens bounds input plus output tokens across all round trips
+	defaultAgentMaxTotalTokens = 200000
+
+	// maxToolResultSize bounds the size of a single tool result sent back to the model
+	maxToolResultSize = 20000
+)
+
+// agentSystemSuffix is appended to the system prompt when tools are available
+const agentSystemSuffix = `
+
+You can call tools to fetch additional data from Kubernetes, ArgoCD and GitLab when the
+provided context is not enough to answer. Prefer targeted calls (a single resource, its
+events or pod logs) over broad listings, and stop calling tools once you can answer.`
+
+// budgetExhaustedNote is sent to the model when it must answer without further tool calls
+const budgetExhaustedNote = "The tool call budget for this request is exhausted. " +
+	"Answer now using the information gathered so far."
+
+// AgentToolCall records a tool invocation made during an agent run
+type AgentToolCall struct {
+	Name     string        `json:"name"`
+	IsError  bool          `json:"isError"`
+	Duration time.Duration `json:"duration"`
+}
+
+// AgentResult is the outcome of an agent run
+type AgentResult struct {
+	Text         string          `json:"text"`
+	Iterations   int             `json:"iterations"`
+	ToolCalls    []AgentToolCall `json:"toolCalls"`
+	InputTokens  int             `json:"inputTokens"`
+	OutputTokens int             `json:"outputTokens"`
+}
+
+// Agent runs a Claude tool-use loop over the read-only tools registered on an MCP server
+type Agent struct {
+	claudeClient   *claude.Client
+	server         *Server
+	maxIterations  int
+	maxTotalTokens int
+	logger         *logging.Logger
+}
+
+// NewAgent creates a new agent. Zero limits fall back to the defaults.
+func NewAgent(claudeClient *claude.Client, server *Server, maxIterations, maxTotalTokens int, logger *logging.Logger) *Agent {
+	if logger == nil {
+		logger = logging.NewLogger().Named("agent")
+	}
+	if maxIterations <= 0 {
+		maxIterations = defaultAgentMaxIterations
+	}
+	if maxTotalTokens <= 0 {
+		maxTotalTokens = defaultAgentMaxTotalTokens
+	}
+
+	return &Agent{
+		claudeClient:   claudeClient,
+		server:         server,
+		maxIterations:  maxIterations,
+		maxTotalTokens: maxTotalTokens,
+		logger:         logger,
+	}
+}
+
+// toolDefinitions converts the server's read-only tools into Claude tool definitions
+func (a *Agent) toolDefinitions() ([]claude.ToolDefinition, map[string]*Tool) {
+	var definitions []claude.ToolDefinition
+	available := make(map[string]*Tool)
+
+	for _, tool := range a.server.Tools() {
+		if !tool.IsReadOnly() {
+			continue
+		}
+		definitions = append(definitions, claude.ToolDefinition{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: tool.InputSchema,
+		})
+		available[tool.Name] = tool
+	}
+
+	return definitions, available
+}
+
+// Run sends the prompts to Claude and executes requested tools until the model
+// finishes or the iteration or token budget is spent
+func (a *Agent) Run(ctx context.Context, systemPrompt, userPrompt string) (*AgentResult, error) {
+	startTime := time.Now()
+	definitions, available := a.toolDefinitions()
+	systemPrompt += agentSystemSuffix
+
+	messages := []claude.ConversationMessage{
+		{
+			Role:    "user",
+			Content: []claude.ContentBlock{{Type: claude.ContentTypeText, Text: userPrompt}},
+		},
+	}
+
+	result := &AgentResult{ToolCalls: []AgentToolCall{}}
+	var toolChoice *claude.ToolChoice
+
+	for {
+		result.Iterations++
+
+		resp, err := a.claudeClient.CompleteWithTools(ctx, systemPrompt, messages, definitions, toolChoice)
+		if err != nil {
+			return nil, fmt.Errorf("claude tool completion failed: %w", err)
+		}
+
+		result.InputTokens += resp.Usage.InputTokens
+		result.OutputTokens += resp.Usage.OutputTokens
+
+		toolUses := resp.ToolUses()
+		if resp.StopReason != claude.StopReasonToolUse || len(toolUses) == 0 || toolChoice != nil {
+			result.Text = resp.Text()
+			break
+		}
+
+		messages = append(messages, claude.ConversationMessage{Role: "assistant", Content: resp.Content})
+
+		// Execute every requested tool; results go back in a single user message
+		results := make([]claude.ContentBlock, 0, len(toolUses)+1)
+		for _, use := range toolUses {
+			results = append(results, a.executeTool(ctx, available, use, result))
+		}
+
+		// Out of budget: ask for a final answer without further tool calls
+		if result.Iterations+1 >= a.maxIterations || result.InputTokens+result.OutputTokens >= a.maxTotalTokens {
+			a.logger.Info("Agent budget exhausted, requesting final answer",
+				"iterations", result.Iterations,
+				"totalTokens", result.InputTokens+result.OutputTokens)
+			results = append(results, claude.ContentBlock{Type: claude.ContentTypeText, Text: budgetExhaustedNote})
+			toolChoice = &claude.ToolChoice{Type: "none"}
+		}
+
+		messages = append(messages, claude.ConversationMessage{Role: "user", Content: results})
+	}
+
+	a.logger.Info("Agent run completed",
+		"iterations", result.Iterations,
+		"toolCalls", len(result.ToolCalls),
+		"inputTokens", result.InputTokens,
+		"outputTokens", result.OutputTokens,
+		"duration", time.Since(startTime))
+
+	return result, nil
+}
+
+// executeTool runs a single tool_use block and converts the outcome into a tool_result block
+func (a *Agent) executeTool(
+	ctx context.Context,
+	available map[string]*Tool,
+	use claude.ContentBlock,
+	result *AgentResult,
+) claude.ContentBlock {
+	startTime := time.Now()
+	block := claude.ContentBlock{Type: claude.ContentTypeToolResult, ToolUseID: use.ID}
+
+	tool, ok := available[use.Name]
+	if !ok {
+		block.Content = fmt.Sprintf("unknown tool: %s", use.Name)
+		block.IsError = true
+		result.ToolCalls = append(result.ToolCalls, AgentToolCall{Name: use.Name, IsError: true})
+		return block
+	}
+
+	toolResult := a.server.CallTool(ctx, tool, use.Input)
+	var text string
+	for _, content := range toolResult.Content {
+		text += content.Text
+	}
+
+	block.Content = utils.TruncateContent(text, maxToolResultSize)
+	block.IsError = toolResult.IsError
+	result.ToolCalls = append(result.ToolCalls, AgentToolCall{
+		Name:     use.Name,
+		IsError:  toolResult.IsError,
+		Duration: time.Since(startTime),
+	})
+
+	return block
+}
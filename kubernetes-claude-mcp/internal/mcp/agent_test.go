@@ -0,0 +1,190 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/claude"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// fakeClaude serves scripted /v1/messages responses and records the requests it received
+func fakeClaude(t *testing.T, responses []claude.ToolCompletionResponse) (*httptest.Server, *[]claude.ToolCompletionRequest) {
+	t.Helper()
+	var requests []claude.ToolCompletionRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req claude.ToolCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+		}
+		requests = append(requests, req)
+
+		resp := responses[len(responses)-1]
+		if len(requests) <= len(responses) {
+			resp = responses[len(requests)-1]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &requests
+}
+
+func toolUseResponse(id, name, input string) claude.ToolCompletionResponse {
+	return claude.ToolCompletionResponse{
+		StopReason: claude.StopReasonToolUse,
+		Content: []claude.ContentBlock{
+			{Type: claude.ContentTypeToolUse, ID: id, Name: name, Input: json.RawMessage(input)},
+		},
+		Usage: claude.Usage{InputTokens: 100, OutputTokens: 10},
+	}
+}
+
+func newTestAgent(baseURL string, maxIterations, maxTotalTokens int) (*Agent, *int) {
+	s := newTestServer()
+	calls := 0
+	s.RegisterTool(&Tool{
+		Name:        "echo",
+		Description: "Echo the input",
+		InputSchema: objectSchema(map[string]interface{}{"value": stringProperty("Value to echo")}),
+		Annotations: readOnly,
+		Handler: func(_ context.Context, arguments json.RawMessage) (interface{}, error) {
+			calls++
+			return string(arguments), nil
+		},
+	})
+	s.RegisterTool(&Tool{
+		Name:        "mutate",
+		Description: "A tool with side effects",
+		InputSchema: objectSchema(map[string]interface{}{}),
+		Handler: func(context.Context, json.RawMessage) (interface{}, error) {
+			return "mutated", nil
+		},
+	})
+
+	client := claude.NewClient(claude.ClaudeConfig{
+		APIKey:    "test-key",
+		BaseURL:   baseURL,
+		ModelID:   "test-model",
+		MaxTokens: 1024,
+	}, logging.NewLogger())
+
+	return NewAgent(client, s, maxIterations, maxTotalTokens, logging.NewLogger()), &calls
+}
+
+func TestAgentRunExecutesTools(t *testing.T) {
+	server, requests := fakeClaude(t, []claude.ToolCompletionResponse{
+		toolUseResponse("call-1", "echo", `{"value":"hello"}`),
+		{
+			StopReason: "end_turn",
+			Content:    []claude.ContentBlock{{Type: claude.ContentTypeText, Text: "done"}},
+			Usage:      claude.Usage{InputTokens: 200, OutputTokens: 20},
+		},
+	})
+
+	agent, calls := newTestAgent(server.URL, 5, 0)
+	result, err := agent.Run(context.Background(), "system", "question")
+	if err != nil {
+		t.Fatalf("Agent run failed: %v", err)
+	}
+
+	if result.Text != "done" {
+		t.Errorf("Expected final text 'done', got %q", result.Text)
+	}
+	if result.Iterations != 2 || *calls != 1 {
+		t.Errorf("Expected 2 iterations and 1 tool call, got %d and %d", result.Iterations, *calls)
+	}
+	if result.InputTokens != 300 || result.OutputTokens != 30 {
+		t.Errorf("Unexpected token usage: %d in, %d out", result.InputTokens, result.OutputTokens)
+	}
+
+	// Only read-only tools are offered to the model
+	first := (*requests)[0]
+	for _, tool := range first.Tools {
+		if tool.Name == "mutate" {
+			t.Error("Tool with side effects should not be offered to the model")
+		}
+	}
+
+	// The tool result is sent back against the tool_use ID
+	second := (*requests)[1]
+	last := second.Messages[len(second.Messages)-1]
+	if last.Role != "user" || last.Content[0].Type != claude.ContentTypeToolResult || last.Content[0].ToolUseID != "call-1" {
+		t.Errorf("Expected a tool_result for call-1, got %+v", last)
+	}
+	if last.Content[0].Content != `{"value":"hello"}` {
+		t.Errorf("Unexpected tool result content %q", last.Content[0].Content)
+	}
+}
+
+func TestAgentRunStopsAtIterationLimit(t *testing.T) {
+	// The model keeps asking for tools; the agent must force a final answer
+	server, requests := fakeClaude(t, []claude.ToolCompletionResponse{
+		toolUseResponse("call-1", "echo", `{}`),
+		toolUseResponse("call-2", "echo", `{}`),
+		toolUseResponse("call-3", "echo", `{}`),
+	})
+
+	agent, calls := newTestAgent(server.URL, 3, 0)
+	result, err := agent.Run(context.Background(), "system", "question")
+	if err != nil {
+		t.Fatalf("Agent run failed: %v", err)
+	}
+
+	if result.Iterations != 3 || len(*requests) != 3 {
+		t.Errorf("Expected 3 iterations, got %d (%d requests)", result.Iterations, len(*requests))
+	}
+	if *calls != 2 {
+		t.Errorf("Expected 2 tool calls, got %d", *calls)
+	}
+	final := (*requests)[2]
+	if final.ToolChoice == nil || final.ToolChoice.Type != "none" {
+		t.Errorf("Expected the final request to disable tools, got %+v", final.ToolChoice)
+	}
+}
+
+func TestAgentRunStopsAtTokenLimit(t *testing.T) {
+	server, requests := fakeClaude(t, []claude.ToolCompletionResponse{
+		toolUseResponse("call-1", "echo", `{}`),
+		toolUseResponse("call-2", "echo", `{}`),
+	})
+
+	agent, _ := newTestAgent(server.URL, 10, 50)
+	if _, err := agent.Run(context.Background(), "system", "question"); err != nil {
+		t.Fatalf("Agent run failed: %v", err)
+	}
+
+	if len(*requests) != 2 {
+		t.Errorf("Expected the token budget to end the run after 2 requests, got %d", len(*requests))
+	}
+}
+
+func TestAgentAllowed(t *testing.T) {
+	full := &auth.Identity{Name: "admin", Role: &auth.Role{
+		Name: "admin", Namespaces: []string{"*"}, Kinds: []string{"*"}, Actions: []string{"*"},
+	}}
+	limited := &auth.Identity{Name: "team", Role: &auth.Role{
+		Name: "team", Namespaces: []string{"team-*"}, Kinds: []string{"*"}, Actions: []string{"*"},
+	}}
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want bool
+	}{
+		{"server initiated", context.Background(), true},
+		{"full read access", auth.WithIdentity(context.Background(), full), true},
+		{"limited role", auth.WithIdentity(context.Background(), limited), false},
+	}
+	for _, tt := range tests {
+		if got := agentAllowed(tt.ctx); got != tt.want {
+			t.Errorf("agentAllowed(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -2,11 +2,13 @@ package mcp
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/utils"
 )
@@ -200,7 +202,10 @@ func (cm *ContextManager) FormatResourceContext(rc *models.ResourceContext) (str
 		formattedContext += fmt.Sprintf("Sync Status: %s\n", rc.ArgoSyncStatus)
 		formattedContext += fmt.Sprintf("Health Status: %s\n", rc.ArgoHealthStatus)
 
-		if rc.ArgoApplication.Spec.Source.RepoURL != "" {
+		if len(rc.ArgoApplication.Spec.Sources) > 0 {
+			formattedContext += "Sources:\n"
+			formattedContext += formatArgoSources(rc.ArgoApplication.Spec.Sources)
+		} else if rc.ArgoApplication.Spec.Source.RepoURL != "" {
 			formattedContext += fmt.Sprintf("Source: %s\n", rc.ArgoApplication.Spec.Source.RepoURL)
 			formattedContext += fmt.Sprintf("Path: %s\n", rc.ArgoApplication.Spec.Source.Path)
 			formattedContext += fmt.Sprintf("Target Revision: %s\n", rc.ArgoApplication.Spec.Source.TargetRevision)
@@ -208,6 +213,29 @@ func (cm *ContextManager) FormatResourceContext(rc *models.ResourceContext) (str
 
 		formattedContext += "\n"
 
+		// Add the ApplicationSet the application was generated from
+		if origin := rc.ArgoApplicationSet; origin != nil {
+			formattedContext += "### ApplicationSet\n"
+			formattedContext += fmt.Sprintf("Name: %s\n", origin.Name)
+			if origin.Generator != "" {
+				formattedContext += fmt.Sprintf("Generator: %s\n", origin.Generator)
+			}
+			if len(origin.Generators) > 1 || origin.Generator == "" {
+				formattedContext += fmt.Sprintf("Generators: %s\n", strings.Join(origin.Generators, "; "))
+			}
+			formattedContext += fmt.Sprintf("Template Name: %s\n", origin.TemplateName)
+			if len(origin.TemplateSources) > 0 {
+				formattedContext += "Template Sources:\n"
+				formattedContext += formatArgoSources(origin.TemplateSources)
+			}
+			formattedContext += "\n"
+		}
+
+		// Add what drifted from Git in an OutOfSync application
+		if len(rc.ArgoDrift) > 0 {
+			formattedContext += formatArgoDrift(rc.ArgoDrift)
+		}
+
 		// Add recent sync history
 		if len(rc.ArgoSyncHistory) > 0 {
 			formattedContext += "### Recent Sync History\n"
@@ -222,9 +250,50 @@ func (cm *ContextManager) FormatResourceContext(rc *models.ResourceContext) (str
 		}
 	}
 
-	// Format GitLab information if available
+	// Format the Flux object if Flux applied the resource
+	if rc.FluxObject != nil {
+		formattedContext += formatFluxObject(rc.FluxObject)
+	}
+
+	// Format the Helm release if the resource was installed by Helm
+	if rc.HelmRelease != nil {
+		formattedContext += "## Helm Release\n"
+		formattedContext += fmt.Sprintf("Name: %s/%s\n", rc.HelmRelease.Namespace, rc.HelmRelease.Name)
+		formattedContext += fmt.Sprintf("Chart: %s %s\n", rc.HelmRelease.Chart, rc.HelmRelease.ChartVersion)
+		if rc.HelmRelease.AppVersion != "" {
+			formattedContext += fmt.Sprintf("App Version: %s\n", rc.HelmRelease.AppVersion)
+		}
+		formattedContext += fmt.Sprintf("Revision: %d (%s)\n\n", rc.HelmRelease.Revision, rc.HelmRelease.Status)
+
+		if len(rc.HelmRelease.History) > 1 {
+			formattedContext += "### Revision History\n"
+			for i, revision := range rc.HelmRelease.History {
+				if i == 5 {
+					break
+				}
+				formattedContext += fmt.Sprintf("%d. [%s] Revision %d: %s %s, Status: %s",
+					i+1,
+					revision.Updated.Format(time.RFC3339),
+					revision.Revision,
+					revision.Chart,
+					revision.ChartVersion,
+					revision.Status)
+				if revision.Description != "" {
+					formattedContext += fmt.Sprintf(" (%s)", revision.Description)
+				}
+				formattedContext += "\n"
+			}
+			formattedContext += "\n"
+		}
+	}
+
+	// Format the source repository if available
 	if rc.GitLabProject != nil {
-		formattedContext += "## GitLab Project\n"
+		if rc.GitLabProject.Provider == scm.ProviderGitHub {
+			formattedContext += "## GitHub Repository\n"
+		} else {
+			formattedContext += "## GitLab Project\n"
+		}
 		formattedContext += fmt.Sprintf("Name: %s\n", rc.GitLabProject.PathWithNamespace)
 		formattedContext += fmt.Sprintf("URL: %s\n\n", rc.GitLabProject.WebURL)
 
@@ -446,3 +515,375 @@ func (cm *ContextManager) CombineContexts(ctx context.Context, resourceContexts
 		"contextSize", len(combinedContext))
 	return combinedContext, nil
 }
+
+// maxCommitDiffSize caps the size of each file diff included in a commit context
+const maxCommitDiffSize = 4000
+
+// FormatCommitImpact formats a commit's changes and the applications, environments and
+// resources it affects, followed by the traced context of those resources
+func (cm *ContextManager) FormatCommitImpact(
+	ctx context.Context,
+	impact *models.CommitImpact,
+	resourceContexts []models.ResourceContext,
+) (string, error) {
+	cm.logger.Debug("Formatting commit impact",
+		"commitSHA", impact.CommitSHA,
+		"applicationCount", len(impact.Applications))
+
+	var formattedContext string
+
+	formattedContext += fmt.Sprintf("# Commit %s in project %s\n", impact.CommitSHA, impact.ProjectID)
+	if impact.Commit != nil {
+		formattedContext += fmt.Sprintf("Author: %s\n", impact.Commit.AuthorName)
+		formattedContext += fmt.Sprintf("Message: %s\n", strings.TrimSpace(impact.Commit.Message))
+	}
+	formattedContext += "\n"
+
+	formattedContext += fmt.Sprintf("## Changed Files (%d)\n", len(impact.ChangedFiles))
+	for _, diff := range impact.Diffs {
+		path := diff.NewPath
+		switch {
+		case diff.NewFile:
+			path += " (new)"
+		case diff.DeletedFile:
+			path = diff.OldPath + " (deleted)"
+		case diff.RenamedFile:
+			path = fmt.Sprintf("%s (renamed from %s)", diff.NewPath, diff.OldPath)
+		}
+		formattedContext += fmt.Sprintf("### %s\n```diff\n%s\n```\n", path, utils.TruncateContent(diff.Diff, maxCommitDiffSize))
+	}
+	formattedContext += "\n"
+
+	if len(impact.HelmResources) > 0 {
+		formattedContext += "## Resources Rendered by Changed Helm Charts\n"
+		for _, resource := range impact.HelmResources {
+			formattedContext += fmt.Sprintf("- %s\n", resource)
+		}
+		formattedContext += "\n"
+	}
+
+	formattedContext += fmt.Sprintf("## Affected ArgoCD Applications (%d)\n", len(impact.Applications))
+	if len(impact.Applications) == 0 {
+		formattedContext += "No ArgoCD application deploys the changed files.\n"
+	}
+	for _, app := range impact.Applications {
+		formattedContext += fmt.Sprintf("- %s: environment %s, namespace %s, cluster %s, sync %s, health %s, %d resources (matched by %s)\n",
+			app.Name, app.Environment, app.Namespace, app.Cluster, app.SyncStatus, app.HealthStatus, app.ResourceCount, app.Reason)
+	}
+	if len(impact.Environments) > 0 {
+		formattedContext += fmt.Sprintf("Environments: %s\n", strings.Join(impact.Environments, ", "))
+	}
+	formattedContext += "\n"
+
+	resources, err := cm.CombineContexts(ctx, resourceContexts)
+	if err != nil {
+		return "", err
+	}
+	formattedContext += resources
+
+	if len(formattedContext) > cm.maxContextSize {
+		formattedContext = utils.TruncateContextSmartly(formattedContext, cm.maxContextSize)
+	}
+	return formattedContext, nil
+}
+
+// maxDiffFields caps how many changed fields are listed for each resource in a manifest diff
+const maxDiffFields = 25
+
+// FormatManifestDiffs formats the rendered before/after diffs of a merge request
+func (cm *ContextManager) FormatManifestDiffs(diffs []models.ManifestDiff) string {
+	if len(diffs) == 0 {
+		return ""
+	}
+
+	var formattedContext string
+	formattedContext += "# Rendered Manifest Changes\n"
+	for _, diff := range diffs {
+		formattedContext += fmt.Sprintf("## %s %s (%s..%s)\n", diff.Type, diff.Path, shortSHA(diff.BaseSHA), shortSHA(diff.HeadSHA))
+		for _, renderErr := range diff.Errors {
+			formattedContext += fmt.Sprintf("Error: %s\n", renderErr)
+		}
+
+		for _, resource := range diff.Resources {
+			name := resource.Kind + "/" + resource.Name
+			if resource.Namespace != "" {
+				name = resource.Namespace + "/" + name
+			}
+			formattedContext += fmt.Sprintf("### %s (%s)\n", name, resource.Change)
+
+			for _, image := range resource.ImageChanges {
+				formattedContext += fmt.Sprintf("- Image %s: %s -> %s\n", image.Container, orUnset(image.Before), orUnset(image.After))
+			}
+			if rc := resource.ReplicaChange; rc != nil {
+				formattedContext += fmt.Sprintf("- Replicas: %s -> %s\n", formatReplicas(rc.Before), formatReplicas(rc.After))
+			}
+			for i, field := range resource.Fields {
+				if i == maxDiffFields {
+					formattedContext += fmt.Sprintf("- ... and %d more fields\n", len(resource.Fields)-maxDiffFields)
+					break
+				}
+				formattedContext += fmt.Sprintf("- %s: %s -> %s\n", field.Path, formatFieldValue(field.Before), formatFieldValue(field.After))
+			}
+		}
+		formattedContext += "\n"
+	}
+	return formattedContext
+}
+
+// FormatManifestFindings formats the risky lines found in a merge request's changes
+func (cm *ContextManager) FormatManifestFindings(findings []models.ManifestFinding) string {
+	if len(findings) == 0 {
+		return ""
+	}
+
+	formattedContext := "# Risky Changed Lines\n"
+	for _, finding := range findings {
+		location := fmt.Sprintf("%s:%d", finding.NewPath, finding.NewLine)
+		if finding.NewLine == 0 {
+			location = fmt.Sprintf("%s:%d (removed)", finding.OldPath, finding.OldLine)
+		}
+		formattedContext += fmt.Sprintf("- [%s] %s `%s`: %s\n", finding.Severity, location, finding.Text, finding.Message)
+	}
+	return formattedContext + "\n"
+}
+
+// FormatPolicyResult formats the policy verdict on a merge request's rendered manifests
+func (cm *ContextManager) FormatPolicyResult(result *models.PolicyResult) string {
+	if result == nil || (len(result.Violations) == 0 && len(result.Errors) == 0) {
+		return ""
+	}
+
+	formattedContext := fmt.Sprintf("# Policy Verdict: %s\n", result.Verdict)
+	for _, v := range result.Violations {
+		name := v.Kind + "/" + v.Name
+		if v.Namespace != "" {
+			name = v.Namespace + "/" + name
+		}
+		formattedContext += fmt.Sprintf("- [%s] %s %s (from %s): %s\n", v.Severity, v.Rule, name, v.Source, v.Message)
+	}
+	for _, err := range result.Errors {
+		formattedContext += fmt.Sprintf("- Not checked: %s\n", err)
+	}
+	return formattedContext + "\n"
+}
+
+// FormatBlastRadius formats the ranked live resources a merge request's changes reach
+func (cm *ContextManager) FormatBlastRadius(impacted []models.ImpactedResource) string {
+	if len(impacted) == 0 {
+		return ""
+	}
+
+	formattedContext := "# Blast Radius\n"
+	for _, resource := range impacted {
+		name := resource.Kind + "/" + resource.Name
+		if resource.Namespace != "" {
+			name = resource.Namespace + "/" + name
+		}
+		if resource.Cluster != "" {
+			name = resource.Cluster + ":" + name
+		}
+		formattedContext += fmt.Sprintf("%d. %s (depth %d, reached by %d changes): %s\n",
+			resource.Rank, name, resource.Depth, resource.Changes, strings.Join(resource.Path, "; "))
+	}
+	return formattedContext + "\n"
+}
+
+// FormatTimeline formats a timeline oldest first, one line per entry, followed by the
+// sources that could not be read
+func (cm *ContextManager) FormatTimeline(timeline *models.Timeline) string {
+	var subject string
+	switch {
+	case timeline.Application != "":
+		subject = fmt.Sprintf("ArgoCD application %s", timeline.Application)
+	case timeline.Kind != "" && timeline.Name != "":
+		subject = fmt.Sprintf("%s %s/%s", timeline.Kind, timeline.Namespace, timeline.Name)
+	default:
+		subject = fmt.Sprintf("Namespace %s", timeline.Namespace)
+	}
+	if timeline.Cluster != "" {
+		subject += fmt.Sprintf(" on cluster %s", timeline.Cluster)
+	}
+
+	formattedContext := "# Timeline\n"
+	formattedContext += fmt.Sprintf("%s, from %s to %s\n\n", subject,
+		timeline.Since.Format(time.RFC3339), timeline.Until.Format(time.RFC3339))
+
+	if len(timeline.Entries) == 0 {
+		formattedContext += "Nothing happened in this window.\n"
+	}
+	for _, entry := range timeline.Entries {
+		line := fmt.Sprintf("- %s [%s] %s %s: %s",
+			entry.Time.Format(time.RFC3339), entry.Type, entry.Source, entry.Subject, entry.Summary)
+		if entry.Status != "" {
+			line += fmt.Sprintf(" (%s)", entry.Status)
+		}
+		if entry.URL != "" {
+			line += " " + entry.URL
+		}
+		formattedContext += line + "\n"
+	}
+
+	if len(timeline.Errors) > 0 {
+		formattedContext += "\n## Sources Not Read\n"
+		for _, err := range timeline.Errors {
+			formattedContext += fmt.Sprintf("- %s\n", err)
+		}
+	}
+	return formattedContext + "\n"
+}
+
+// FormatApplicationSetChanges formats the applications ApplicationSets would add or
+// remove once a merge request is merged
+func (cm *ContextManager) FormatApplicationSetChanges(changes []models.ApplicationSetChange) string {
+	if len(changes) == 0 {
+		return ""
+	}
+
+	formattedContext := "# ApplicationSet Changes\n"
+	for _, change := range changes {
+		formattedContext += fmt.Sprintf("- ApplicationSet %s would have an application %s for %s (%s generator)\n",
+			change.ApplicationSet, change.Change, change.Path, change.Generator)
+	}
+	return formattedContext + "\n"
+}
+
+// formatArgoDrift lists the resources whose live state differs from Git, with the
+// fields that differ
+func formatArgoDrift(drifts []models.ResourceDrift) string {
+	formatted := "### Live State Drift from Git\n"
+	for _, drift := range drifts {
+		name := drift.Kind + "/" + drift.Name
+		if drift.Namespace != "" {
+			name = drift.Namespace + "/" + name
+		}
+		formatted += fmt.Sprintf("- %s (%s)\n", name, drift.Status)
+
+		for i, field := range drift.Fields {
+			if i == maxDiffFields {
+				formatted += fmt.Sprintf("  - ... and %d more fields\n", len(drift.Fields)-maxDiffFields)
+				break
+			}
+			formatted += fmt.Sprintf("  - %s: Git %s, live %s\n", field.Path, formatFieldValue(field.Target), formatFieldValue(field.Live))
+		}
+	}
+	return formatted + "\n"
+}
+
+// formatArgoSources lists the sources of a multi-source application or template
+func formatArgoSources(sources []models.ArgoApplicationSource) string {
+	formatted := ""
+	for _, source := range sources {
+		formatted += fmt.Sprintf("- %s", source.RepoURL)
+		if source.Chart != "" {
+			formatted += fmt.Sprintf(" chart %s", source.Chart)
+		}
+		if source.Path != "" {
+			formatted += fmt.Sprintf(" path %s", source.Path)
+		}
+		if source.TargetRevision != "" {
+			formatted += fmt.Sprintf(" at %s", source.TargetRevision)
+		}
+		if source.Ref != "" {
+			formatted += fmt.Sprintf(" (ref %s)", source.Ref)
+		}
+		if source.Helm != nil && len(source.Helm.ValueFiles) > 0 {
+			formatted += fmt.Sprintf(", value files %s", strings.Join(source.Helm.ValueFiles, ", "))
+		}
+		formatted += "\n"
+	}
+	return formatted
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+func orUnset(s string) string {
+	if s == "" {
+		return "<unset>"
+	}
+	return s
+}
+
+func formatReplicas(replicas *int64) string {
+	if replicas == nil {
+		return "<unset>"
+	}
+	return fmt.Sprintf("%d", *replicas)
+}
+
+// formatFieldValue renders a field value compactly, truncating large values
+func formatFieldValue(value interface{}) string {
+	if value == nil {
+		return "<unset>"
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return utils.TruncateContent(string(data), 200)
+}
+
+// formatFluxObject formats the Flux Kustomization or HelmRelease that applied a
+// resource, its chart and its source, with the conditions that need attention
+func formatFluxObject(obj *models.FluxObject) string {
+	formatted := fmt.Sprintf("## Flux %s\n", obj.Kind)
+	formatted += fmt.Sprintf("Name: %s/%s\n", obj.Namespace, obj.Name)
+	formatted += fmt.Sprintf("Ready: %s\n", obj.Ready)
+	if obj.Suspended {
+		formatted += "Suspended: true\n"
+	}
+	if obj.Path != "" {
+		formatted += fmt.Sprintf("Path: %s\n", obj.Path)
+	}
+	if obj.LastAppliedRevision != "" {
+		formatted += fmt.Sprintf("Last Applied Revision: %s\n", obj.LastAppliedRevision)
+	}
+	if obj.LastAttemptedRevision != "" && obj.LastAttemptedRevision != obj.LastAppliedRevision {
+		formatted += fmt.Sprintf("Last Attempted Revision: %s\n", obj.LastAttemptedRevision)
+	}
+	formatted += formatFluxConditions(obj.Conditions)
+
+	for _, source := range []*models.FluxSource{obj.Chart, obj.Source} {
+		if source == nil {
+			continue
+		}
+		formatted += fmt.Sprintf("### %s %s/%s\n", source.Kind, source.Namespace, source.Name)
+		formatted += fmt.Sprintf("Ready: %s\n", source.Ready)
+		if source.Suspended {
+			formatted += "Suspended: true\n"
+		}
+		if source.URL != "" {
+			formatted += fmt.Sprintf("URL: %s\n", source.URL)
+		}
+		if source.Ref != "" {
+			formatted += fmt.Sprintf("Ref: %s\n", source.Ref)
+		}
+		if source.Chart != "" {
+			formatted += fmt.Sprintf("Chart: %s %s\n", source.Chart, source.Version)
+		}
+		if source.Revision != "" {
+			formatted += fmt.Sprintf("Revision: %s\n", source.Revision)
+		}
+		formatted += formatFluxConditions(source.Conditions)
+	}
+
+	return formatted + "\n"
+}
+
+// formatFluxConditions lists the conditions that need attention: Stalled and
+// Reconciling when True, and any other condition that is not True
+func formatFluxConditions(conditions []models.FluxCondition) string {
+	formatted := ""
+	for _, condition := range conditions {
+		abnormal := condition.Type == "Stalled" || condition.Type == "Reconciling"
+		if (condition.Status == "True") != abnormal {
+			continue
+		}
+		formatted += fmt.Sprintf("- %s=%s (%s): %s\n", condition.Type, condition.Status, condition.Reason, condition.Message)
+	}
+	return formatted
+}
@@ -0,0 +1,223 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+func TestFormatCommitImpact(t *testing.T) {
+	cm := NewContextManager(100000, logging.NewLogger())
+	impact := &models.CommitImpact{
+		ProjectID:    "platform/deploy",
+		CommitSHA:    "abc123",
+		Commit:       &models.GitLabCommit{AuthorName: "Sam", Message: "Bump api replicas\n"},
+		ChangedFiles: []string{"apps/api/values.yaml", "apps/old.yaml"},
+		Diffs: []models.GitLabDiff{
+			{OldPath: "apps/api/values.yaml", NewPath: "apps/api/values.yaml", Diff: "-replicas: 2\n+replicas: 4"},
+			{OldPath: "apps/old.yaml", NewPath: "apps/old.yaml", DeletedFile: true},
+		},
+		Applications: []models.AffectedApplication{{
+			Name: "api-prod", Cluster: "prod", Namespace: "api", Environment: "production",
+			SyncStatus: "Synced", HealthStatus: "Healthy", Reason: "path", ResourceCount: 1,
+		}},
+		Environments: []string{"production"},
+		Resources:    []models.AffectedResource{{Cluster: "prod", Application: "api-prod", Kind: "Deployment", Namespace: "api", Name: "api"}},
+	}
+	resources := []models.ResourceContext{{Cluster: "prod", Kind: "Deployment", Name: "api", Namespace: "api", APIVersion: "apps/v1"}}
+
+	formatted, err := cm.FormatCommitImpact(context.Background(), impact, resources)
+	if err != nil {
+		t.Fatalf("Failed to format commit impact: %v", err)
+	}
+
+	for _, expected := range []string{
+		"# Commit abc123 in project platform/deploy",
+		"Message: Bump api replicas\n",
+		"+replicas: 4",
+		"apps/old.yaml (deleted)",
+		"- api-prod: environment production, namespace api, cluster prod",
+		"Environments: production",
+		"# Kubernetes Resource: Deployment/api",
+	} {
+		if !strings.Contains(formatted, expected) {
+			t.Errorf("Expected the context to contain %q, got:\n%s", expected, formatted)
+		}
+	}
+}
+
+func TestFormatManifestDiffs(t *testing.T) {
+	cm := NewContextManager(100000, logging.NewLogger())
+	two, four := int64(2), int64(4)
+	diffs := []models.ManifestDiff{{
+		Path:    "charts/api",
+		Type:    models.ManifestSourceHelm,
+		BaseSHA: "1111111111",
+		HeadSHA: "2222222222",
+		Resources: []models.ResourceDiff{{
+			Kind: "Deployment", Namespace: "shop", Name: "api", Change: models.ResourceModified,
+			ImageChanges:  []models.ImageChange{{Container: "api", Before: "api:1.4", After: "api:1.5"}},
+			ReplicaChange: &models.ReplicaChange{Before: &two, After: &four},
+			Fields:        []models.FieldChange{{Path: "spec.template.spec.containers[api].env[LOG_LEVEL].value", Before: "info", After: "debug"}},
+		}},
+	}}
+
+	formatted := cm.FormatManifestDiffs(diffs)
+	for _, expected := range []string{
+		"## helm charts/api (11111111..22222222)",
+		"### shop/Deployment/api (modified)",
+		"- Image api: api:1.4 -> api:1.5",
+		"- Replicas: 2 -> 4",
+		`- spec.template.spec.containers[api].env[LOG_LEVEL].value: "info" -> "debug"`,
+	} {
+		if !strings.Contains(formatted, expected) {
+			t.Errorf("Expected the context to contain %q, got:\n%s", expected, formatted)
+		}
+	}
+}
+
+func TestFormatResourceContextHelmRelease(t *testing.T) {
+	cm := NewContextManager(100000, logging.NewLogger())
+	latest := models.HelmRelease{
+		Name: "api", Namespace: "shop", Revision: 3, Status: "failed",
+		Chart: "api", ChartVersion: "1.4.0", AppVersion: "2.1.0", Description: "Upgrade failed",
+	}
+	previous := models.HelmRelease{Name: "api", Namespace: "shop", Revision: 2, Status: "deployed", Chart: "api", ChartVersion: "1.3.0"}
+	rc := &models.ResourceContext{
+		Kind: "Deployment", Name: "api", Namespace: "shop", APIVersion: "apps/v1",
+		HelmRelease: &models.HelmReleaseSummary{HelmRelease: latest, History: []models.HelmRelease{latest, previous}},
+	}
+
+	formatted, err := cm.FormatResourceContext(rc)
+	if err != nil {
+		t.Fatalf("Failed to format resource context: %v", err)
+	}
+
+	for _, expected := range []string{
+		"## Helm Release",
+		"Name: shop/api",
+		"Chart: api 1.4.0",
+		"Revision: 3 (failed)",
+		"Revision 3: api 1.4.0, Status: failed (Upgrade failed)",
+		"Revision 2: api 1.3.0, Status: deployed",
+	} {
+		if !strings.Contains(formatted, expected) {
+			t.Errorf("Expected the context to contain %q, got:\n%s", expected, formatted)
+		}
+	}
+}
+
+func TestFormatResourceContextFlux(t *testing.T) {
+	cm := NewContextManager(100000, logging.NewLogger())
+	rc := &models.ResourceContext{
+		Kind: "Deployment", Name: "api", Namespace: "shop", APIVersion: "apps/v1",
+		FluxObject: &models.FluxObject{
+			Kind: "Kustomization", Name: "apps", Namespace: "flux-system", Path: "./apps/prod",
+			Ready: "False", LastAppliedRevision: "main@sha1:aaa", LastAttemptedRevision: "main@sha1:bbb",
+			Conditions: []models.FluxCondition{
+				{Type: "Ready", Status: "False", Reason: "HealthCheckFailed", Message: "timeout waiting for api"},
+				{Type: "Healthy", Status: "True", Reason: "Succeeded"},
+			},
+			Source: &models.FluxSource{
+				Kind: "GitRepository", Name: "infra", Namespace: "flux-system", Ready: "True",
+				URL: "https://gitlab.com/acme/infra", Ref: "branch:main", Revision: "main@sha1:bbb",
+			},
+		},
+	}
+
+	formatted, err := cm.FormatResourceContext(rc)
+	if err != nil {
+		t.Fatalf("Failed to format resource context: %v", err)
+	}
+
+	for _, expected := range []string{
+		"## Flux Kustomization",
+		"Name: flux-system/apps",
+		"Ready: False",
+		"Path: ./apps/prod",
+		"Last Applied Revision: main@sha1:aaa",
+		"Last Attempted Revision: main@sha1:bbb",
+		"- Ready=False (HealthCheckFailed): timeout waiting for api",
+		"### GitRepository flux-system/infra",
+		"URL: https://gitlab.com/acme/infra",
+		"Ref: branch:main",
+	} {
+		if !strings.Contains(formatted, expected) {
+			t.Errorf("Expected the context to contain %q, got:\n%s", expected, formatted)
+		}
+	}
+	if strings.Contains(formatted, "Healthy=True") {
+		t.Errorf("Expected healthy conditions to be left out, got:\n%s", formatted)
+	}
+}
+
+func TestFormatResourceContextApplicationSet(t *testing.T) {
+	cm := NewContextManager(100000, logging.NewLogger())
+	app := &models.ArgoApplication{Name: "payments"}
+	app.Spec.Sources = []models.ArgoApplicationSource{
+		{RepoURL: "https://charts.example.com", Chart: "api", TargetRevision: "1.2.0",
+			Helm: &models.ArgoHelmSource{ValueFiles: []string{"$values/envs/prod/api.yaml"}}},
+		{RepoURL: "https://gitlab.com/acme/config.git", Ref: "values"},
+	}
+	rc := &models.ResourceContext{
+		Kind: "Deployment", Name: "api", Namespace: "payments", APIVersion: "apps/v1",
+		ArgoApplication: app,
+		ArgoApplicationSet: &models.ArgoApplicationSetOrigin{
+			Name:         "platform",
+			Generators:   []string{"git directories apps/* in https://gitlab.com/acme/config.git"},
+			Generator:    "git directories apps/* in https://gitlab.com/acme/config.git",
+			TemplateName: "{{path.basename}}",
+		},
+	}
+
+	formatted, err := cm.FormatResourceContext(rc)
+	if err != nil {
+		t.Fatalf("Failed to format resource context: %v", err)
+	}
+
+	for _, expected := range []string{
+		"- https://charts.example.com chart api at 1.2.0, value files $values/envs/prod/api.yaml",
+		"- https://gitlab.com/acme/config.git (ref values)",
+		"### ApplicationSet",
+		"Name: platform",
+		"Generator: git directories apps/* in https://gitlab.com/acme/config.git",
+		"Template Name: {{path.basename}}",
+	} {
+		if !strings.Contains(formatted, expected) {
+			t.Errorf("Expected the context to contain %q, got:\n%s", expected, formatted)
+		}
+	}
+}
+
+func TestFormatResourceContextArgoDrift(t *testing.T) {
+	cm := NewContextManager(100000, logging.NewLogger())
+	rc := &models.ResourceContext{
+		Kind: "Deployment", Name: "api", Namespace: "shop", APIVersion: "apps/v1",
+		ArgoApplication: &models.ArgoApplication{Name: "shop"},
+		ArgoSyncStatus:  "OutOfSync",
+		ArgoDrift: []models.ResourceDrift{
+			{Kind: "Deployment", Namespace: "shop", Name: "api", Status: models.DriftModified,
+				Fields: []models.DriftField{{Path: "spec.replicas", Target: float64(3), Live: float64(5)}}},
+			{Kind: "ConfigMap", Namespace: "shop", Name: "api-flags", Status: models.DriftMissing},
+		},
+	}
+
+	formatted, err := cm.FormatResourceContext(rc)
+	if err != nil {
+		t.Fatalf("Failed to format resource context: %v", err)
+	}
+
+	for _, expected := range []string{
+		"### Live State Drift from Git",
+		"- shop/Deployment/api (modified)",
+		"  - spec.replicas: Git 3, live 5",
+		"- shop/ConfigMap/api-flags (missing)",
+	} {
+		if !strings.Contains(formatted, expected) {
+			t.Errorf("Expected the context to contain %q, got:\n%s", expected, formatted)
+		}
+	}
+}
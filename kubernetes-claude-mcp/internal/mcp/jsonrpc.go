@@ -0,0 +1,91 @@
+package mcp
+
+import (
+	"encoding/json"
+)
+
+// JSON-RPC 2.0 error codes used by the MCP server
+const (
+	ErrCodeParse          = -32700
+	ErrCodeInvalidRequest = -32600
+	ErrCodeMethodNotFound = -32601
+	ErrCodeInvalidParams  = -32602
+	ErrCodeInternal       = -32603
+)
+
+// jsonRPCVersion is the only JSON-RPC version accepted by the server
+const jsonRPCVersion = "2.0"
+
+// RPCRequest represents a JSON-RPC 2.0 request or notification
+type RPCRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// IsNotification reports whether the request carries no ID and therefore expects no
+// response. An explicit "id": null is kept as the raw value null, so it still gets one.
+func (r *RPCRequest) IsNotification() bool {
+	return len(r.ID) == 0
+}
+
+// RPCResponse represents a JSON-RPC 2.0 response
+type RPCResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// MarshalJSON always writes a result member on success, as null when the method
+// returns nothing, so that every response carries either a result or an error
+func (r RPCResponse) MarshalJSON() ([]byte, error) {
+	if r.Error != nil {
+		return json.Marshal(struct {
+			JSONRPC string          `json:"jsonrpc"`
+			ID      json.RawMessage `json:"id"`
+			Error   *RPCError       `json:"error"`
+		}{r.JSONRPC, normalizeID(r.ID), r.Error})
+	}
+	return json.Marshal(struct {
+		JSONRPC string          `json:"jsonrpc"`
+		ID      json.RawMessage `json:"id"`
+		Result  interface{}     `json:"result"`
+	}{r.JSONRPC, normalizeID(r.ID), r.Result})
+}
+
+// RPCError represents a JSON-RPC 2.0 error object
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Error implements the error interface
+func (e *RPCError) Error() string {
+	return e.Message
+}
+
+// newRPCError creates a JSON-RPC error with the given code and message
+func newRPCError(code int, message string) *RPCError {
+	return &RPCError{Code: code, Message: message}
+}
+
+// newResultResponse builds a successful response for a request ID
+func newResultResponse(id json.RawMessage, result interface{}) *RPCResponse {
+	return &RPCResponse{JSONRPC: jsonRPCVersion, ID: normalizeID(id), Result: result}
+}
+
+// newErrorResponse builds an error response for a request ID
+func newErrorResponse(id json.RawMessage, rpcErr *RPCError) *RPCResponse {
+	return &RPCResponse{JSONRPC: jsonRPCVersion, ID: normalizeID(id), Error: rpcErr}
+}
+
+// normalizeID makes sure a missing ID is serialized as null
+func normalizeID(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
@@ -21,13 +21,19 @@ type NamespaceAnalysisResult struct {
 	Analysis              string                     `json:"analysis"`
 }
 
-// AnalyzeNamespace analyzes all resources in a namespace using Claude
-func (h *ProtocolHandler) AnalyzeNamespace(ctx context.Context, namespace string) (*models.NamespaceAnalysisResult, error) {
+// AnalyzeNamespace analyzes all resources in a namespace using Claude. An empty cluster
+// selects the default cluster.
+func (h *ProtocolHandler) AnalyzeNamespace(ctx context.Context, cluster, namespace string) (*models.NamespaceAnalysisResult, error) {
 	startTime := time.Now()
-	h.logger.Info("Analyzing namespace", "namespace", namespace)
+	h.logger.Info("Analyzing namespace", "cluster", cluster, "namespace", namespace)
+
+	k8sClient, err := h.clusters.ForContext(ctx, cluster)
+	if err != nil {
+		return nil, err
+	}
 
 	// Get namespace topology
-	topology, err := h.k8sClient.GetNamespaceTopology(ctx, namespace)
+	topology, err := k8sClient.GetNamespaceTopology(ctx, namespace)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get namespace topology: %w", err)
 	}
@@ -70,7 +76,7 @@ func (h *ProtocolHandler) AnalyzeNamespace(ctx context.Context, namespace string
 	}
 
 	// Get events for the namespace
-	events, err := h.k8sClient.GetNamespaceEvents(ctx, namespace)
+	events, err := k8sClient.GetNamespaceEvents(ctx, namespace)
 	if err != nil {
 		h.logger.Warn("Failed to get namespace events", "error", err)
 	}
@@ -124,10 +130,11 @@ func (h *ProtocolHandler) AnalyzeNamespace(ctx context.Context, namespace string
 		"systemPromptLength", len(systemPrompt),
 		"analysisPromptLength", len(analysisPrompt))
 
-	analysis, err := h.claudeProtocol.GetCompletion(ctx, systemPrompt, analysisPrompt)
+	analysis, redactions, err := h.complete(ctx, systemPrompt, analysisPrompt, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get completion for namespace analysis: %w", err)
 	}
+	result.Redactions = redactions
 
 	// Extract recommendations from analysis
 	lines := strings.Split(analysis, "\n")
@@ -0,0 +1,121 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// PromptArgument describes an argument accepted by a prompt template
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+}
+
+// Prompt is a reusable prompt template exposed to MCP clients
+type Prompt struct {
+	Name        string                              `json:"name"`
+	Description string                              `json:"description,omitempty"`
+	Arguments   []PromptArgument                    `json:"arguments,omitempty"`
+	Render      func(args map[string]string) string `json:"-"`
+}
+
+// PromptMessage is a single message produced by prompts/get
+type PromptMessage struct {
+	Role    string  `json:"role"`
+	Content Content `json:"content"`
+}
+
+// registerBuiltinPrompts registers prompt templates for the common workflows
+func (s *Server) registerBuiltinPrompts() {
+	s.RegisterPrompt(&Prompt{
+		Name:        "troubleshoot_resource",
+		Description: "Investigate why a Kubernetes resource is unhealthy",
+		Arguments: []PromptArgument{
+			{Name: "namespace", Description: "Namespace of the resource", Required: true},
+			{Name: "kind", Description: "Resource kind", Required: true},
+			{Name: "name", Description: "Resource name", Required: true},
+		},
+		Render: func(args map[string]string) string {
+			return fmt.Sprintf("Troubleshoot the %s %s in namespace %s. "+
+				"Use the troubleshoot_resource tool to detect issues, trace_resource_deployment to find "+
+				"recent GitOps changes, and get_pod_logs for failing pods. Explain the most likely root cause "+
+				"and give concrete remediation steps.",
+				args["kind"], args["name"], args["namespace"])
+		},
+	})
+
+	s.RegisterPrompt(&Prompt{
+		Name:        "review_merge_request",
+		Description: "Review a GitLab merge request for its impact on the cluster",
+		Arguments: []PromptArgument{
+			{Name: "projectId", Description: "GitLab project ID or path, or github.com/owner/repo", Required: true},
+			{Name: "mergeRequestIid", Description: "Merge request IID", Required: true},
+		},
+		Render: func(args map[string]string) string {
+			return fmt.Sprintf("Review merge request !%s in GitLab project %s. "+
+				"Use the analyze_merge_request tool to find the affected ArgoCD applications and Kubernetes "+
+				"resources, then summarize the deployment risk, the resources that will change, and anything "+
+				"reviewers should check before merging.",
+				args["mergeRequestIid"], args["projectId"])
+		},
+	})
+
+	s.RegisterPrompt(&Prompt{
+		Name:        "analyze_namespace",
+		Description: "Assess the overall health of a namespace",
+		Arguments: []PromptArgument{
+			{Name: "namespace", Description: "Namespace to analyze", Required: true},
+		},
+		Render: func(args map[string]string) string {
+			return fmt.Sprintf("Analyze the health of namespace %s. "+
+				"Use get_namespace_topology to map its resources and relationships, read "+
+				"k8s:///namespaces/%s/events for recent warnings, and report issues, misconfigurations "+
+				"and prioritized recommendations.",
+				args["namespace"], args["namespace"])
+		},
+	})
+}
+
+// handlePromptsList returns the registered prompts in registration order
+func (s *Server) handlePromptsList() (interface{}, *RPCError) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prompts := make([]*Prompt, 0, len(s.promptOrder))
+	for _, name := range s.promptOrder {
+		prompts = append(prompts, s.prompts[name])
+	}
+	return map[string]interface{}{"prompts": prompts}, nil
+}
+
+// handlePromptsGet renders a prompt with the supplied arguments
+func (s *Server) handlePromptsGet(params json.RawMessage) (interface{}, *RPCError) {
+	var getParams struct {
+		Name      string            `json:"name"`
+		Arguments map[string]string `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &getParams); err != nil {
+		return nil, newRPCError(ErrCodeInvalidParams, "invalid prompts/get params: "+err.Error())
+	}
+
+	s.mu.RLock()
+	prompt, ok := s.prompts[getParams.Name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, newRPCError(ErrCodeInvalidParams, fmt.Sprintf("unknown prompt: %s", getParams.Name))
+	}
+
+	for _, arg := range prompt.Arguments {
+		if arg.Required && getParams.Arguments[arg.Name] == "" {
+			return nil, newRPCError(ErrCodeInvalidParams, fmt.Sprintf("missing required argument: %s", arg.Name))
+		}
+	}
+
+	return map[string]interface{}{
+		"description": prompt.Description,
+		"messages": []PromptMessage{
+			{Role: "user", Content: Content{Type: "text", Text: prompt.Render(getParams.Arguments)}},
+		},
+	}, nil
+}
@@ -6,10 +6,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/auth"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/claude"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/correlator"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/policy"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/redact"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
 	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/utils"
 
@@ -21,9 +24,11 @@ type ProtocolHandler struct {
 	claudeClient     *claude.Client
 	claudeProtocol   *claude.ProtocolHandler
 	gitOpsCorrelator *correlator.GitOpsCorrelator
-	k8sClient        *k8s.Client
+	clusters         *k8s.ClusterRegistry
 	contextManager   *ContextManager
 	promptGenerator  *PromptGenerator
+	agent            *Agent
+	redactor         *redact.Redactor
 	logger           *logging.Logger
 }
 
@@ -31,7 +36,7 @@ type ProtocolHandler struct {
 func NewProtocolHandler(
 	claudeClient *claude.Client,
 	gitOpsCorrelator *correlator.GitOpsCorrelator,
-	k8sClient *k8s.Client,
+	clusters *k8s.ClusterRegistry,
 	logger *logging.Logger,
 ) *ProtocolHandler {
 	if logger == nil {
@@ -42,19 +47,48 @@ func NewProtocolHandler(
 		claudeClient:     claudeClient,
 		claudeProtocol:   claude.NewProtocolHandler(claudeClient),
 		gitOpsCorrelator: gitOpsCorrelator,
-		k8sClient:        k8sClient,
+		clusters:         clusters,
 		contextManager:   NewContextManager(100000, logger.Named("context")),
 		promptGenerator:  NewPromptGenerator(logger.Named("prompt")),
+		redactor:         redact.Default(),
 		logger:           logger,
 	}
 }
 
+// defaultCommitQuery is asked about a commit when the request has no query
+const defaultCommitQuery = "Assess the rollout risk of this commit. For each affected application and " +
+	"environment, explain what will change when ArgoCD syncs it, what could break, how to verify the " +
+	"rollout and how to roll back. Rate the overall risk as low, medium or high."
+
+// defaultTimelineQuery is asked about a timeline when the request has no query
+const defaultTimelineQuery = "Using this timeline, identify the change most likely to have triggered " +
+	"the warnings and failures it shows. Name the commit, sync, release or deployment, explain how the " +
+	"events that follow it point to it, and say how confident you are. Mention any other candidates."
+
 // ProcessRequest processes an MCP request
 func (h *ProtocolHandler) ProcessRequest(ctx context.Context, request *models.MCPRequest) (*models.MCPResponse, error) {
+	return h.ProcessRequestStream(ctx, request, nil)
+}
+
+// ProcessRequestStream processes an MCP request, passing analysis text to onDelta as
+// Claude generates it. A nil onDelta waits for the complete analysis.
+func (h *ProtocolHandler) ProcessRequestStream(
+	ctx context.Context,
+	request *models.MCPRequest,
+	onDelta claude.StreamHandler,
+) (*models.MCPResponse, error) {
 	startTime := time.Now()
 	h.logger.Info("Processing MCP request", "action", request.Action)
 
 	var resourceContext string
+	var redactions []models.Redaction
+	var commitImpact *models.CommitImpact
+	var manifestDiffs []models.ManifestDiff
+	var manifestFindings []models.ManifestFinding
+	var policyResult *models.PolicyResult
+	var blastRadius []models.ImpactedResource
+	var appSetChanges []models.ApplicationSetChange
+	var timeline *models.Timeline
 	var err error
 
 	// Handle different types of queries
@@ -67,6 +101,7 @@ func (h *ProtocolHandler) ProcessRequest(ctx context.Context, request *models.MC
 			// Trace deployment for a specific resource
 			resourceInfo, traceErr := h.gitOpsCorrelator.TraceResourceDeployment(
 				ctx,
+				request.Cluster,
 				request.Namespace,
 				request.Resource,
 				request.Name,
@@ -76,12 +111,15 @@ func (h *ProtocolHandler) ProcessRequest(ctx context.Context, request *models.MC
 			}
 
 			// For non-namespace resources, enhance with the actual resource data
-			if !strings.EqualFold(request.Resource, "namespace") {
+			if k8sClient, clusterErr := h.clusters.ForContext(ctx, request.Cluster); clusterErr == nil && !strings.EqualFold(request.Resource, "namespace") {
 				// Get the full resource details
-				resource, getErr := h.k8sClient.GetResource(ctx, request.Resource, request.Namespace, request.Name)
+				resource, getErr := k8sClient.GetResource(ctx, request.Resource, request.Namespace, request.Name)
 				if getErr == nil && resource != nil {
-					// Add the full resource details to the context
-					resourceData, jsonErr := utils.ToJSON(resource.Object)
+					// Add the full resource details to the context, with Secret data
+					// and other sensitive values masked
+					redacted, resourceRedactions := h.redactor.RedactObject(resource.Object)
+					redactions = append(redactions, prefixRedactions("resource", resourceRedactions)...)
+					resourceData, jsonErr := utils.ToJSON(redacted)
 					if jsonErr == nil {
 						resourceInfo.ResourceData = resourceData
 
@@ -182,6 +220,72 @@ func (h *ProtocolHandler) ProcessRequest(ctx context.Context, request *models.MC
 			return nil, fmt.Errorf("failed to combine resource contexts: %w", err)
 		}
 
+		// Render the touched charts and manifests at the MR's base and head
+		diffs, diffErr := h.gitOpsCorrelator.DiffMergeRequestManifests(ctx, request.ProjectID, request.MergeRequestIID)
+		if diffErr != nil {
+			h.logger.Warn("Failed to diff merge request manifests", "error", diffErr)
+		} else {
+			manifestDiffs = diffs
+			policyResult = policy.Result(diffs)
+			resourceContext = h.contextManager.FormatPolicyResult(policyResult) +
+				h.contextManager.FormatManifestDiffs(diffs) + resourceContext
+
+			// Follow the changed objects through the live resource graph
+			blastRadius = h.gitOpsCorrelator.MergeRequestBlastRadius(ctx, diffs, resources)
+			resourceContext = h.contextManager.FormatBlastRadius(blastRadius) + resourceContext
+		}
+
+		// Find the applications ApplicationSet git generators would add or remove
+		changes, changesErr := h.gitOpsCorrelator.ApplicationSetChanges(ctx, request.ProjectID, request.MergeRequestIID)
+		if changesErr != nil {
+			h.logger.Warn("Failed to find ApplicationSet changes", "error", changesErr)
+		} else {
+			appSetChanges = changes
+			resourceContext = h.contextManager.FormatApplicationSetChanges(changes) + resourceContext
+		}
+
+		// Flag risky lines, such as images moving to latest, so they can be pinned to the diff
+		findings, findingsErr := h.gitOpsCorrelator.MergeRequestFindings(ctx, request.ProjectID, request.MergeRequestIID)
+		if findingsErr != nil {
+			h.logger.Warn("Failed to scan merge request for risky changes", "error", findingsErr)
+		} else {
+			manifestFindings = findings
+			resourceContext = h.contextManager.FormatManifestFindings(findings) + resourceContext
+		}
+
+	case "queryCommit":
+		// Find the applications, environments and live resources the commit affects
+		impact, resources, analyzeErr := h.gitOpsCorrelator.AnalyzeCommit(
+			ctx,
+			request.ProjectID,
+			request.CommitSHA,
+		)
+		if analyzeErr != nil {
+			return nil, fmt.Errorf("failed to analyze commit: %w", analyzeErr)
+		}
+		commitImpact = impact
+
+		resourceContext, err = h.contextManager.FormatCommitImpact(ctx, impact, resources)
+		if err != nil {
+			return nil, fmt.Errorf("failed to format commit impact: %w", err)
+		}
+
+		if strings.TrimSpace(request.Query) == "" {
+			request.Query = defaultCommitQuery
+		}
+
+	case "queryTimeline":
+		// Merge what happened to the resource, application or namespace into one list
+		timeline, err = h.BuildTimeline(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		resourceContext = h.contextManager.FormatTimeline(timeline)
+
+		if strings.TrimSpace(request.Query) == "" {
+			request.Query = defaultTimelineQuery
+		}
+
 	default:
 		return nil, fmt.Errorf("unsupported action: %s", request.Action)
 	}
@@ -196,16 +300,24 @@ func (h *ProtocolHandler) ProcessRequest(ctx context.Context, request *models.MC
 		"systemPromptLength", len(systemPrompt),
 		"userPromptLength", len(userPrompt))
 
-	analysis, err := h.claudeProtocol.GetCompletion(ctx, systemPrompt, userPrompt)
+	analysis, promptRedactions, err := h.complete(ctx, systemPrompt, userPrompt, onDelta)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get completion from Claude: %w", err)
 	}
 
 	// Build response
 	response := &models.MCPResponse{
-		Success:  true,
-		Analysis: analysis,
-		Message:  fmt.Sprintf("Successfully processed %s request in %v", request.Action, time.Since(startTime)),
+		Success:               true,
+		Analysis:              analysis,
+		Message:               fmt.Sprintf("Successfully processed %s request in %v", request.Action, time.Since(startTime)),
+		CommitImpact:          commitImpact,
+		ManifestDiffs:         manifestDiffs,
+		ManifestFindings:      manifestFindings,
+		Policy:                policyResult,
+		BlastRadius:           blastRadius,
+		ApplicationSetChanges: appSetChanges,
+		Timeline:              timeline,
+		Redactions:            append(redactions, promptRedactions...),
 	}
 
 	h.logger.Info("MCP request processed successfully",
@@ -218,6 +330,17 @@ func (h *ProtocolHandler) ProcessRequest(ctx context.Context, request *models.MC
 
 // ProcessTroubleshootRequest processes a troubleshooting request with detected issues
 func (h *ProtocolHandler) ProcessTroubleshootRequest(ctx context.Context, request *models.MCPRequest, troubleshootResult *models.TroubleshootResult) (*models.MCPResponse, error) {
+	return h.ProcessTroubleshootRequestStream(ctx, request, troubleshootResult, nil)
+}
+
+// ProcessTroubleshootRequestStream processes a troubleshooting request, passing analysis
+// text to onDelta as Claude generates it
+func (h *ProtocolHandler) ProcessTroubleshootRequestStream(
+	ctx context.Context,
+	request *models.MCPRequest,
+	troubleshootResult *models.TroubleshootResult,
+	onDelta claude.StreamHandler,
+) (*models.MCPResponse, error) {
 	startTime := time.Now()
 	h.logger.Debug("Processing troubleshoot request")
 
@@ -236,9 +359,15 @@ func (h *ProtocolHandler) ProcessTroubleshootRequest(ctx context.Context, reques
 		recommendationsText += fmt.Sprintf("%d. %s\n", i+1, rec)
 	}
 
+	// Name the cluster so kubectl suggestions target the right context
+	var clusterText string
+	if request.Cluster != "" {
+		clusterText = fmt.Sprintf(" on cluster '%s'", request.Cluster)
+	}
+
 	// Create a prompt for Claude with the troubleshooting results
 	userPrompt := fmt.Sprintf(
-		"I'm troubleshooting a Kubernetes %s named '%s' in namespace '%s'.\n\n"+
+		"I'm troubleshooting a Kubernetes %s named '%s' in namespace '%s'%s.\n\n"+
 			"The following issues were detected:\n%s\n"+
 			"General recommendations:\n%s\n\n"+
 			"Based on these detected issues, please provide specific kubectl commands "+
@@ -246,6 +375,7 @@ func (h *ProtocolHandler) ProcessTroubleshootRequest(ctx context.Context, reques
 		request.Resource,
 		request.Name,
 		request.Namespace,
+		clusterText,
 		issuesText,
 		recommendationsText,
 		request.Query)
@@ -258,16 +388,17 @@ func (h *ProtocolHandler) ProcessTroubleshootRequest(ctx context.Context, reques
 		"systemPromptLength", len(systemPrompt),
 		"userPromptLength", len(userPrompt))
 
-	analysis, err := h.claudeProtocol.GetCompletion(ctx, systemPrompt, userPrompt)
+	analysis, redactions, err := h.complete(ctx, systemPrompt, userPrompt, onDelta)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get completion for troubleshoot request: %w", err)
 	}
 
 	// Create response
 	response := &models.MCPResponse{
-		Success:  true,
-		Analysis: analysis,
-		Message:  fmt.Sprintf("Successfully processed troubleshoot request in %v", time.Since(startTime)),
+		Success:    true,
+		Analysis:   analysis,
+		Message:    fmt.Sprintf("Successfully processed troubleshoot request in %v", time.Since(startTime)),
+		Redactions: redactions,
 	}
 
 	h.logger.Info("Troubleshoot request processed successfully",
@@ -277,6 +408,49 @@ func (h *ProtocolHandler) ProcessTroubleshootRequest(ctx context.Context, reques
 	return response, nil
 }
 
+// CheckMergeRequestPolicy checks the manifests a merge request renders against the
+// policy rules and returns the verdict, without asking Claude
+func (h *ProtocolHandler) CheckMergeRequestPolicy(ctx context.Context, projectID string, mergeRequestIID int) (*models.PolicyResult, error) {
+	return h.gitOpsCorrelator.CheckMergeRequestPolicy(ctx, projectID, mergeRequestIID)
+}
+
+// BuildTimeline builds the timeline of the resource, ArgoCD application or namespace a
+// request names over its window, without asking Claude
+func (h *ProtocolHandler) BuildTimeline(ctx context.Context, request *models.MCPRequest) (*models.Timeline, error) {
+	since, until, err := correlator.TimelineWindow(request.Since, request.Until, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	scope := models.TimelineScope{
+		Cluster:     request.Cluster,
+		Namespace:   request.Namespace,
+		Kind:        request.Resource,
+		Name:        request.Name,
+		Application: request.Application,
+	}
+	timeline, err := h.gitOpsCorrelator.BuildTimeline(ctx, scope, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build timeline: %w", err)
+	}
+	return timeline, nil
+}
+
+// DetectChanges reports what changed in a namespace, or across a cluster when
+// namespace is empty, between since and until, most suspicious first, without asking
+// Claude. When kindAllowed is set, only resources of the kinds it allows are reported.
+func (h *ProtocolHandler) DetectChanges(
+	ctx context.Context,
+	cluster, namespace string,
+	since, until time.Time,
+	kindAllowed func(kind string) bool,
+) (*models.ChangeReport, error) {
+	report, err := h.gitOpsCorrelator.DetectChanges(ctx, cluster, namespace, since, until, kindAllowed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect changes: %w", err)
+	}
+	return report, nil
+}
+
 // WithCustomPrompt sets a custom base prompt template
 func (h *ProtocolHandler) WithCustomPrompt(template string) *ProtocolHandler {
 	h.promptGenerator.WithBasePrompt(template)
@@ -288,3 +462,65 @@ func (h *ProtocolHandler) WithMaxContextSize(size int) *ProtocolHandler {
 	h.contextManager = NewContextManager(size, h.logger.Named("context"))
 	return h
 }
+
+// WithAgent enables the tool-use loop so Claude can fetch more cluster data on demand
+func (h *ProtocolHandler) WithAgent(agent *Agent) *ProtocolHandler {
+	h.agent = agent
+	return h
+}
+
+// WithRedactor replaces the default redactor applied to context before it reaches Claude
+func (h *ProtocolHandler) WithRedactor(redactor *redact.Redactor) *ProtocolHandler {
+	h.redactor = redactor
+	return h
+}
+
+// complete gets an analysis from Claude, through the agent loop when one is configured and
+// the caller may use it. When onDelta is set the analysis is streamed; agent runs deliver
+// their final answer in one delta.
+// The user prompt is redacted first and the redactions are returned for the response audit.
+func (h *ProtocolHandler) complete(ctx context.Context, systemPrompt, userPrompt string, onDelta claude.StreamHandler) (string, []models.Redaction, error) {
+	userPrompt, redactions := h.redactor.RedactText("prompt", userPrompt)
+	if len(redactions) > 0 {
+		h.logger.Info("Redacted sensitive values from prompt", "count", len(redactions))
+	}
+
+	var analysis string
+	var err error
+	if h.agent == nil || !agentAllowed(ctx) {
+		if onDelta != nil {
+			analysis, err = h.claudeProtocol.GetCompletionStream(ctx, systemPrompt, userPrompt, onDelta)
+		} else {
+			analysis, err = h.claudeProtocol.GetCompletion(ctx, systemPrompt, userPrompt)
+		}
+		return analysis, redactions, err
+	}
+
+	result, err := h.agent.Run(ctx, systemPrompt, utils.TruncateContextSmartly(userPrompt, h.contextManager.maxContextSize))
+	if err != nil {
+		return "", nil, err
+	}
+	if onDelta != nil {
+		if err := onDelta(result.Text); err != nil {
+			return "", nil, err
+		}
+	}
+	return result.Text, redactions, nil
+}
+
+// agentAllowed reports whether the agent may run for the caller. Its tools take any
+// namespace and kind without checking the caller's role, so callers whose role is
+// limited get an analysis of the context they were authorized for alone. Requests
+// without an identity are made by the server itself, such as webhook reviews.
+func agentAllowed(ctx context.Context) bool {
+	identity := auth.IdentityFromContext(ctx)
+	return identity == nil || identity.FullReadAccess()
+}
+
+// prefixRedactions places audit paths under a prefix naming where the value came from
+func prefixRedactions(prefix string, redactions []models.Redaction) []models.Redaction {
+	for i := range redactions {
+		redactions[i].Path = prefix + "." + redactions[i].Path
+	}
+	return redactions
+}
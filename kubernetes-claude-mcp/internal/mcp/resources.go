@@ -0,0 +1,229 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Resource describes a concrete resource exposed to MCP clients
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceTemplate describes a parameterized family of resources
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the body of a resource returned by resources/read
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+const (
+	k8sScheme     = "k8s:///"
+	k8sPrefix     = "k8s://"
+	argoCDScheme  = "argocd:///"
+	jsonMediaType = "application/json"
+)
+
+// resourceTemplates lists the URI templates understood by resources/read
+var resourceTemplates = []ResourceTemplate{
+	{
+		URITemplate: "k8s:///namespaces/{namespace}/topology",
+		Name:        "Namespace topology",
+		Description: "Resources, health and relationships in a namespace",
+		MimeType:    jsonMediaType,
+	},
+	{
+		URITemplate: "k8s:///namespaces/{namespace}/events",
+		Name:        "Namespace events",
+		Description: "Kubernetes events in a namespace",
+		MimeType:    jsonMediaType,
+	},
+	{
+		URITemplate: "k8s:///namespaces/{namespace}/{kind}/{name}",
+		Name:        "Kubernetes resource",
+		Description: "A single namespaced Kubernetes resource",
+		MimeType:    jsonMediaType,
+	},
+	{
+		URITemplate: "k8s://{cluster}/namespaces/{namespace}/{kind}/{name}",
+		Name:        "Kubernetes resource in a named cluster",
+		Description: "A single namespaced Kubernetes resource in a cluster other than the default",
+		MimeType:    jsonMediaType,
+	},
+	{
+		URITemplate: "argocd:///applications/{name}",
+		Name:        "ArgoCD application",
+		Description: "An ArgoCD application and its status",
+		MimeType:    jsonMediaType,
+	},
+	{
+		URITemplate: "argocd:///applicationsets/{name}",
+		Name:        "ArgoCD ApplicationSet",
+		Description: "An ArgoCD ApplicationSet with its generators, template and generated applications",
+		MimeType:    jsonMediaType,
+	},
+}
+
+// handleResourcesList returns the concrete resources available on the server
+func (s *Server) handleResourcesList(ctx context.Context) (interface{}, *RPCError) {
+	resources := []Resource{}
+
+	if k8sClient, err := s.clusters.ForContext(ctx, ""); err == nil {
+		resources = append(resources, Resource{
+			URI:         k8sScheme + "namespaces",
+			Name:        "Namespaces",
+			Description: "All namespaces in the default cluster",
+			MimeType:    jsonMediaType,
+		})
+
+		for _, cluster := range s.clusters.Names() {
+			if cluster == s.clusters.DefaultName() {
+				continue
+			}
+			resources = append(resources, Resource{
+				URI:         fmt.Sprintf("%s%s/namespaces", k8sPrefix, cluster),
+				Name:        fmt.Sprintf("Namespaces in %s", cluster),
+				Description: fmt.Sprintf("All namespaces in cluster %s", cluster),
+				MimeType:    jsonMediaType,
+			})
+		}
+
+		namespaces, err := k8sClient.GetNamespaces(ctx)
+		if err != nil {
+			s.logger.Warn("Failed to list namespaces for MCP resources", "error", err)
+		}
+		for _, ns := range namespaces {
+			resources = append(resources, Resource{
+				URI:         fmt.Sprintf("%snamespaces/%s/topology", k8sScheme, ns),
+				Name:        fmt.Sprintf("Topology of %s", ns),
+				Description: fmt.Sprintf("Resources, health and relationships in namespace %s", ns),
+				MimeType:    jsonMediaType,
+			})
+		}
+	}
+
+	if s.argoClient != nil {
+		resources = append(resources, Resource{
+			URI:         argoCDScheme + "applications",
+			Name:        "ArgoCD applications",
+			Description: "All applications managed by ArgoCD",
+			MimeType:    jsonMediaType,
+		}, Resource{
+			URI:         argoCDScheme + "applicationsets",
+			Name:        "ArgoCD ApplicationSets",
+			Description: "All ApplicationSets that generate ArgoCD applications",
+			MimeType:    jsonMediaType,
+		})
+	}
+
+	return map[string]interface{}{"resources": resources}, nil
+}
+
+// handleResourceTemplatesList returns the URI templates understood by resources/read
+func (s *Server) handleResourceTemplatesList() (interface{}, *RPCError) {
+	return map[string]interface{}{"resourceTemplates": resourceTemplates}, nil
+}
+
+// handleResourcesRead reads a resource by URI
+func (s *Server) handleResourcesRead(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+	var readParams struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(params, &readParams); err != nil || readParams.URI == "" {
+		return nil, newRPCError(ErrCodeInvalidParams, "resources/read requires a uri")
+	}
+
+	data, rpcErr := s.readResource(ctx, readParams.URI)
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+
+	text, redactions, err := s.redactOutput(data)
+	if err != nil {
+		return nil, newRPCError(ErrCodeInternal, err.Error())
+	}
+
+	result := map[string]interface{}{
+		"contents": []ResourceContents{{URI: readParams.URI, MimeType: jsonMediaType, Text: text}},
+	}
+	if meta := resultMeta(redactions); meta != nil {
+		result["_meta"] = meta
+	}
+	return result, nil
+}
+
+// readResource resolves a resource URI to the underlying data
+func (s *Server) readResource(ctx context.Context, uri string) (interface{}, *RPCError) {
+	var (
+		data interface{}
+		err  error
+	)
+
+	switch {
+	case strings.HasPrefix(uri, k8sPrefix):
+		if s.clusters == nil {
+			return nil, newRPCError(ErrCodeInternal, "kubernetes client is not configured")
+		}
+		// The URI authority names the cluster; k8s:/// addresses the default cluster
+		cluster, path, _ := strings.Cut(strings.TrimPrefix(uri, k8sPrefix), "/")
+		k8sClient, clusterErr := s.clusters.ForContext(ctx, cluster)
+		if clusterErr != nil {
+			return nil, newRPCError(ErrCodeInvalidParams, clusterErr.Error())
+		}
+		parts := strings.Split(strings.Trim(path, "/"), "/")
+
+		switch {
+		case len(parts) == 1 && parts[0] == "namespaces":
+			data, err = k8sClient.GetNamespaces(ctx)
+		case len(parts) == 3 && parts[0] == "namespaces" && parts[2] == "topology":
+			data, err = k8sClient.GetNamespaceTopology(ctx, parts[1])
+		case len(parts) == 3 && parts[0] == "namespaces" && parts[2] == "events":
+			data, err = k8sClient.GetNamespaceEvents(ctx, parts[1])
+		case len(parts) == 4 && parts[0] == "namespaces":
+			data, err = k8sClient.GetResource(ctx, parts[2], parts[1], parts[3])
+		default:
+			return nil, newRPCError(ErrCodeInvalidParams, fmt.Sprintf("unknown resource: %s", uri))
+		}
+
+	case strings.HasPrefix(uri, argoCDScheme):
+		if s.argoClient == nil {
+			return nil, newRPCError(ErrCodeInternal, "ArgoCD client is not configured")
+		}
+		parts := strings.Split(strings.Trim(strings.TrimPrefix(uri, argoCDScheme), "/"), "/")
+
+		switch {
+		case len(parts) == 1 && parts[0] == "applications":
+			data, err = s.argoClient.ListApplications(ctx)
+		case len(parts) == 2 && parts[0] == "applications":
+			data, err = s.argoClient.GetApplication(ctx, parts[1])
+		case len(parts) == 1 && parts[0] == "applicationsets":
+			data, err = s.argoClient.ListApplicationSets(ctx)
+		case len(parts) == 2 && parts[0] == "applicationsets":
+			data, err = s.argoClient.GetApplicationSet(ctx, parts[1])
+		default:
+			return nil, newRPCError(ErrCodeInvalidParams, fmt.Sprintf("unknown resource: %s", uri))
+		}
+
+	default:
+		return nil, newRPCError(ErrCodeInvalidParams, fmt.Sprintf("unsupported resource URI: %s", uri))
+	}
+
+	if err != nil {
+		s.logger.Warn("Failed to read MCP resource", "uri", uri, "error", err)
+		return nil, newRPCError(ErrCodeInternal, fmt.Sprintf("failed to read %s: %v", uri, err))
+	}
+	return data, nil
+}
@@ -0,0 +1,271 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/argocd"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/correlator"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/k8s"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/redact"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/scm"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// ServerName is the name the server reports to MCP clients
+const ServerName = "kubernetes-claude-mcp"
+
+// ServerVersion is the version the server reports to MCP clients
+var ServerVersion = "0.1.0"
+
+// supportedProtocolVersions lists the MCP protocol revisions the server understands, newest first
+var supportedProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// serverInstructions is returned to clients during initialization
+const serverInstructions = `This server exposes Kubernetes, ArgoCD and GitLab context as MCP tools and resources.
+Use trace_resource_deployment to connect a live resource to its ArgoCD application and GitLab project,
+troubleshoot_resource to detect common problems, analyze_merge_request to see which resources a
+merge request would affect, check_merge_request_policy for a pass, warn or fail verdict on its manifests,
+get_timeline to see what happened around an incident, and detect_changes to rank what changed before it. Project IDs default to GitLab; prefix a repository with its host, as in
+github.com/owner/repo, to address GitHub. Resources are addressed with k8s:/// and argocd:/// URIs. When several clusters are configured,
+use list_clusters to find their names and pass cluster to the Kubernetes tools; k8s://{cluster}/
+URIs address a named cluster.`
+
+// Server implements the Model Context Protocol over JSON-RPC 2.0.
+// Transports (stdio, streamable HTTP) hand raw messages to HandleMessage.
+type Server struct {
+	gitOpsCorrelator       *correlator.GitOpsCorrelator
+	troubleshootCorrelator *correlator.TroubleshootCorrelator
+	clusters               *k8s.ClusterRegistry
+	argoClient             *argocd.Client
+	repos                  *scm.Registry
+	tools                  map[string]*Tool
+	toolOrder              []string
+	prompts                map[string]*Prompt
+	promptOrder            []string
+	redactor               *redact.Redactor
+	mu                     sync.RWMutex
+	logger                 *logging.Logger
+}
+
+// NewServer creates a new MCP server and registers the built-in tools, resources and prompts
+func NewServer(
+	gitOpsCorrelator *correlator.GitOpsCorrelator,
+	troubleshootCorrelator *correlator.TroubleshootCorrelator,
+	clusters *k8s.ClusterRegistry,
+	argoClient *argocd.Client,
+	repos *scm.Registry,
+	logger *logging.Logger,
+) *Server {
+	if logger == nil {
+		logger = logging.NewLogger().Named("mcp-server")
+	}
+
+	s := &Server{
+		gitOpsCorrelator:       gitOpsCorrelator,
+		troubleshootCorrelator: troubleshootCorrelator,
+		clusters:               clusters,
+		argoClient:             argoClient,
+		repos:                  repos,
+		tools:                  make(map[string]*Tool),
+		prompts:                make(map[string]*Prompt),
+		redactor:               redact.Default(),
+		logger:                 logger,
+	}
+
+	s.registerBuiltinTools()
+	s.registerBuiltinPrompts()
+
+	return s
+}
+
+// WithRedactor replaces the default redactor applied to tool and resource results
+func (s *Server) WithRedactor(redactor *redact.Redactor) *Server {
+	s.redactor = redactor
+	return s
+}
+
+// RegisterTool adds a tool to the server, replacing any tool with the same name
+func (s *Server) RegisterTool(tool *Tool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.tools[tool.Name]; !exists {
+		s.toolOrder = append(s.toolOrder, tool.Name)
+	}
+	s.tools[tool.Name] = tool
+}
+
+// Tools returns the registered tools in registration order
+func (s *Server) Tools() []*Tool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tools := make([]*Tool, 0, len(s.toolOrder))
+	for _, name := range s.toolOrder {
+		tools = append(tools, s.tools[name])
+	}
+	return tools
+}
+
+// RegisterPrompt adds a prompt template to the server
+func (s *Server) RegisterPrompt(prompt *Prompt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.prompts[prompt.Name]; !exists {
+		s.promptOrder = append(s.promptOrder, prompt.Name)
+	}
+	s.prompts[prompt.Name] = prompt
+}
+
+// HandleMessage processes a raw JSON-RPC message (single or batch) and returns the
+// encoded response. A nil response means the message only contained notifications.
+func (s *Server) HandleMessage(ctx context.Context, message []byte) []byte {
+	trimmed := bytes.TrimSpace(message)
+	if len(trimmed) == 0 {
+		return s.encode(newErrorResponse(nil, newRPCError(ErrCodeInvalidRequest, "empty message")))
+	}
+
+	// Batch requests
+	if trimmed[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return s.encode(newErrorResponse(nil, newRPCError(ErrCodeParse, "parse error: "+err.Error())))
+		}
+		if len(batch) == 0 {
+			return s.encode(newErrorResponse(nil, newRPCError(ErrCodeInvalidRequest, "empty batch")))
+		}
+
+		var responses []*RPCResponse
+		for _, item := range batch {
+			if resp := s.handleSingle(ctx, item); resp != nil {
+				responses = append(responses, resp)
+			}
+		}
+		if len(responses) == 0 {
+			return nil
+		}
+		return s.encode(responses)
+	}
+
+	resp := s.handleSingle(ctx, trimmed)
+	if resp == nil {
+		return nil
+	}
+	return s.encode(resp)
+}
+
+// handleSingle processes one JSON-RPC request object
+func (s *Server) handleSingle(ctx context.Context, raw json.RawMessage) *RPCResponse {
+	var req RPCRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return newErrorResponse(nil, newRPCError(ErrCodeParse, "parse error: "+err.Error()))
+	}
+
+	if req.JSONRPC != jsonRPCVersion || req.Method == "" {
+		return newErrorResponse(req.ID, newRPCError(ErrCodeInvalidRequest, "invalid JSON-RPC 2.0 request"))
+	}
+
+	result, rpcErr := s.dispatch(ctx, &req)
+
+	// Notifications never get a response, even on error
+	if req.IsNotification() {
+		if rpcErr != nil {
+			s.logger.Debug("Notification handling failed", "method", req.Method, "error", rpcErr.Message)
+		}
+		return nil
+	}
+
+	if rpcErr != nil {
+		return newErrorResponse(req.ID, rpcErr)
+	}
+	return newResultResponse(req.ID, result)
+}
+
+// dispatch routes a request to the matching MCP method handler
+func (s *Server) dispatch(ctx context.Context, req *RPCRequest) (interface{}, *RPCError) {
+	s.logger.Debug("Handling MCP method", "method", req.Method)
+
+	switch req.Method {
+	case "initialize":
+		return s.handleInitialize(req.Params)
+	case "notifications/initialized", "notifications/cancelled":
+		return nil, nil
+	case "ping":
+		return map[string]interface{}{}, nil
+	case "tools/list":
+		return s.handleToolsList()
+	case "tools/call":
+		return s.handleToolsCall(ctx, req.Params)
+	case "resources/list":
+		return s.handleResourcesList(ctx)
+	case "resources/templates/list":
+		return s.handleResourceTemplatesList()
+	case "resources/read":
+		return s.handleResourcesRead(ctx, req.Params)
+	case "prompts/list":
+		return s.handlePromptsList()
+	case "prompts/get":
+		return s.handlePromptsGet(req.Params)
+	default:
+		return nil, newRPCError(ErrCodeMethodNotFound, fmt.Sprintf("method not found: %s", req.Method))
+	}
+}
+
+// handleInitialize negotiates the protocol version and advertises server capabilities
+func (s *Server) handleInitialize(params json.RawMessage) (interface{}, *RPCError) {
+	var initParams struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ClientInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"clientInfo"`
+	}
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &initParams); err != nil {
+			return nil, newRPCError(ErrCodeInvalidParams, "invalid initialize params: "+err.Error())
+		}
+	}
+
+	protocolVersion := supportedProtocolVersions[0]
+	for _, version := range supportedProtocolVersions {
+		if version == initParams.ProtocolVersion {
+			protocolVersion = version
+			break
+		}
+	}
+
+	s.logger.Info("MCP client initialized",
+		"client", initParams.ClientInfo.Name,
+		"clientVersion", initParams.ClientInfo.Version,
+		"protocolVersion", protocolVersion)
+
+	return map[string]interface{}{
+		"protocolVersion": protocolVersion,
+		"capabilities": map[string]interface{}{
+			"tools":     map[string]interface{}{"listChanged": false},
+			"resources": map[string]interface{}{"subscribe": false, "listChanged": false},
+			"prompts":   map[string]interface{}{"listChanged": false},
+		},
+		"serverInfo": map[string]interface{}{
+			"name":    ServerName,
+			"version": ServerVersion,
+		},
+		"instructions": serverInstructions,
+	}, nil
+}
+
+// encode marshals a response payload, falling back to an internal error response
+func (s *Server) encode(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		s.logger.Error("Failed to encode JSON-RPC response", "error", err)
+		fallback, _ := json.Marshal(newErrorResponse(nil, newRPCError(ErrCodeInternal, "failed to encode response")))
+		return fallback
+	}
+	return data
+}
@@ -0,0 +1,315 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+func newTestServer() *Server {
+	return NewServer(nil, nil, nil, nil, nil, logging.NewLogger())
+}
+
+func decodeResponse(t *testing.T, data []byte) RPCResponse {
+	t.Helper()
+	var resp RPCResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("Failed to decode response %q: %v", data, err)
+	}
+	return resp
+}
+
+func TestHandleMessageInitialize(t *testing.T) {
+	s := newTestServer()
+
+	data := s.HandleMessage(context.Background(),
+		[]byte(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","clientInfo":{"name":"test"}}}`))
+	resp := decodeResponse(t, data)
+
+	if resp.Error != nil {
+		t.Fatalf("Unexpected error: %v", resp.Error)
+	}
+	if string(resp.ID) != "1" {
+		t.Errorf("Expected id 1, got %s", resp.ID)
+	}
+
+	result := resp.Result.(map[string]interface{})
+	if result["protocolVersion"] != "2024-11-05" {
+		t.Errorf("Expected negotiated version 2024-11-05, got %v", result["protocolVersion"])
+	}
+	serverInfo := result["serverInfo"].(map[string]interface{})
+	if serverInfo["name"] != ServerName {
+		t.Errorf("Expected server name %s, got %v", ServerName, serverInfo["name"])
+	}
+}
+
+func TestHandleMessageToolsList(t *testing.T) {
+	s := newTestServer()
+
+	data := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","id":"a","method":"tools/list"}`))
+	resp := decodeResponse(t, data)
+
+	tools := resp.Result.(map[string]interface{})["tools"].([]interface{})
+	expected := []string{
+		"trace_resource_deployment",
+		"troubleshoot_resource",
+		"analyze_merge_request",
+		"check_merge_request_policy",
+		"get_timeline",
+		"detect_changes",
+		"get_namespace_topology",
+		"list_resources",
+		"get_pod_logs",
+		"get_resource",
+		"get_resource_events",
+		"get_argocd_application",
+		"get_gitlab_file",
+		"list_clusters",
+	}
+	if len(tools) != len(expected) {
+		t.Fatalf("Expected %d tools, got %d", len(expected), len(tools))
+	}
+	for i, name := range expected {
+		tool := tools[i].(map[string]interface{})
+		if tool["name"] != name {
+			t.Errorf("Expected tool %d to be %s, got %v", i, name, tool["name"])
+		}
+		if _, ok := tool["inputSchema"]; !ok {
+			t.Errorf("Tool %s has no inputSchema", name)
+		}
+	}
+}
+
+func TestHandleMessageToolsCallReportsToolErrors(t *testing.T) {
+	s := newTestServer()
+
+	data := s.HandleMessage(context.Background(),
+		[]byte(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_namespace_topology","arguments":{}}}`))
+	resp := decodeResponse(t, data)
+
+	if resp.Error != nil {
+		t.Fatalf("Tool failures should not be protocol errors: %v", resp.Error)
+	}
+	result := resp.Result.(map[string]interface{})
+	if result["isError"] != true {
+		t.Errorf("Expected isError to be true, got %v", result["isError"])
+	}
+}
+
+func TestHandleMessageToolsCallRedactsOutput(t *testing.T) {
+	s := newTestServer()
+	s.RegisterTool(&Tool{
+		Name:        "get_secret",
+		InputSchema: objectSchema(map[string]interface{}{}),
+		Handler: func(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+			return map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]interface{}{"name": "db-creds"},
+				"data":       map[string]interface{}{"password": "aHVudGVyMg=="},
+			}, nil
+		},
+	})
+
+	data := s.HandleMessage(context.Background(),
+		[]byte(`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_secret"}}`))
+	resp := decodeResponse(t, data)
+
+	result := resp.Result.(map[string]interface{})
+	text := result["content"].([]interface{})[0].(map[string]interface{})["text"].(string)
+	if strings.Contains(text, "aHVudGVyMg==") || !strings.Contains(text, "db-creds") {
+		t.Errorf("Expected the Secret data to be masked and its name kept, got %s", text)
+	}
+	meta, ok := result["_meta"].(map[string]interface{})
+	if !ok || len(meta["redactions"].([]interface{})) != 1 {
+		t.Errorf("Expected one redaction in the result metadata, got %v", result["_meta"])
+	}
+}
+
+func TestHandleMessageErrors(t *testing.T) {
+	s := newTestServer()
+
+	tests := []struct {
+		name    string
+		message string
+		code    int
+	}{
+		{"parse error", `{"jsonrpc":`, ErrCodeParse},
+		{"wrong version", `{"jsonrpc":"1.0","id":1,"method":"ping"}`, ErrCodeInvalidRequest},
+		{"unknown method", `{"jsonrpc":"2.0","id":1,"method":"nope"}`, ErrCodeMethodNotFound},
+		{"unknown tool", `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"nope"}}`, ErrCodeInvalidParams},
+		{"missing prompt argument", `{"jsonrpc":"2.0","id":1,"method":"prompts/get","params":{"name":"analyze_namespace"}}`, ErrCodeInvalidParams},
+		{"unknown resource", `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"file:///etc/passwd"}}`, ErrCodeInvalidParams},
+		{"unconfigured cluster", `{"jsonrpc":"2.0","id":1,"method":"resources/read","params":{"uri":"k8s://prod/namespaces"}}`, ErrCodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := decodeResponse(t, s.HandleMessage(context.Background(), []byte(tt.message)))
+			if resp.Error == nil {
+				t.Fatalf("Expected error code %d, got result %v", tt.code, resp.Result)
+			}
+			if resp.Error.Code != tt.code {
+				t.Errorf("Expected error code %d, got %d", tt.code, resp.Error.Code)
+			}
+		})
+	}
+}
+
+func TestHandleMessageNotificationsAndBatches(t *testing.T) {
+	s := newTestServer()
+
+	if data := s.HandleMessage(context.Background(), []byte(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)); data != nil {
+		t.Errorf("Expected no response to a notification, got %s", data)
+	}
+
+	data := s.HandleMessage(context.Background(), []byte(`[
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":1,"method":"ping"},
+		{"jsonrpc":"2.0","id":2,"method":"prompts/list"}
+	]`))
+	var responses []RPCResponse
+	if err := json.Unmarshal(data, &responses); err != nil {
+		t.Fatalf("Failed to decode batch response: %v", err)
+	}
+	if len(responses) != 2 {
+		t.Errorf("Expected 2 responses, got %d", len(responses))
+	}
+}
+
+func TestServeStdio(t *testing.T) {
+	s := newTestServer()
+
+	in := strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}` + "\n" +
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n")
+	var out bytes.Buffer
+
+	if err := s.ServeStdio(context.Background(), in, &out); err != nil {
+		t.Fatalf("ServeStdio failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 response line, got %d: %q", len(lines), out.String())
+	}
+	if resp := decodeResponse(t, []byte(lines[0])); resp.Error != nil || string(resp.ID) != "1" {
+		t.Errorf("Unexpected ping response: %s", lines[0])
+	}
+}
+
+func TestHTTPHandlerSessions(t *testing.T) {
+	handler := NewHTTPHandler(newTestServer())
+
+	// Initialize assigns a session
+	req := httptest.NewRequest(http.MethodPost, "/mcp",
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`))
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", rec.Code)
+	}
+	sessionID := rec.Header().Get(SessionHeader)
+	if sessionID == "" {
+		t.Fatal("Expected a session ID on initialize")
+	}
+
+	// Notifications are accepted without a body
+	req = httptest.NewRequest(http.MethodPost, "/mcp",
+		strings.NewReader(`{"jsonrpc":"2.0","method":"notifications/initialized"}`))
+	req.Header.Set(SessionHeader, sessionID)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("Expected 202 for a notification, got %d", rec.Code)
+	}
+
+	// SSE-only clients get an event stream
+	req = httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"ping"}`))
+	req.Header.Set(SessionHeader, sessionID)
+	req.Header.Set("Accept", "text/event-stream")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if !strings.HasPrefix(rec.Body.String(), "event: message\ndata: ") {
+		t.Errorf("Expected an SSE message, got %q", rec.Body.String())
+	}
+
+	// Deleting ends the session
+	req = httptest.NewRequest(http.MethodDelete, "/mcp", nil)
+	req.Header.Set(SessionHeader, sessionID)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("Expected 204 on delete, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":3,"method":"ping"}`))
+	req.Header.Set(SessionHeader, sessionID)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a terminated session, got %d", rec.Code)
+	}
+}
+
+func TestHTTPHandlerSessionExpiry(t *testing.T) {
+	handler := NewHTTPHandler(newTestServer())
+	handler.sessionTTL = time.Minute
+	handler.sessions["stale"] = time.Now().Add(-2 * time.Minute)
+	handler.sessions["live"] = time.Now()
+
+	// An expired session is answered with 404 so the client initializes again
+	req := httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+	req.Header.Set(SessionHeader, "stale")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an expired session, got %d", rec.Code)
+	}
+
+	// Initializing sweeps the expired sessions
+	handler.sessions["stale"] = time.Now().Add(-2 * time.Minute)
+	req = httptest.NewRequest(http.MethodPost, "/mcp",
+		strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"initialize","params":{}}`))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if _, ok := handler.sessions["stale"]; ok {
+		t.Error("Expected the expired session to be swept")
+	}
+	if _, ok := handler.sessions["live"]; !ok {
+		t.Error("Expected the live session to be kept")
+	}
+	if len(handler.sessions) != 2 {
+		t.Errorf("Expected 2 sessions, got %d", len(handler.sessions))
+	}
+}
+
+func TestHandleMessageRequestIDs(t *testing.T) {
+	s := newTestServer()
+
+	tests := []struct {
+		name    string
+		message string
+		want    string
+	}{
+		{"null id", `{"jsonrpc":"2.0","id":null,"method":"ping"}`, `{"jsonrpc":"2.0","id":null,"result":{}}`},
+		{"notification method with an id", `{"jsonrpc":"2.0","id":3,"method":"notifications/initialized"}`, `{"jsonrpc":"2.0","id":3,"result":null}`},
+		{"string id", `{"jsonrpc":"2.0","id":"a","method":"nope"}`, `{"jsonrpc":"2.0","id":"a","error":{"code":-32601,"message":"method not found: nope"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := s.HandleMessage(context.Background(), []byte(tt.message))
+			if got := string(bytes.TrimSpace(data)); got != tt.want {
+				t.Errorf("Expected response %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,668 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/correlator"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/utils"
+)
+
+// ToolHandler executes a tool with its raw JSON arguments
+type ToolHandler func(ctx context.Context, arguments json.RawMessage) (interface{}, error)
+
+// Tool describes an operation exposed to MCP clients
+type Tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+	Annotations *ToolAnnotations       `json:"annotations,omitempty"`
+	Handler     ToolHandler            `json:"-"`
+}
+
+// ToolAnnotations carries behavioral hints about a tool
+type ToolAnnotations struct {
+	Title          string `json:"title,omitempty"`
+	ReadOnlyHint   bool   `json:"readOnlyHint"`
+	OpenWorldHint  bool   `json:"openWorldHint"`
+	IdempotentHint bool   `json:"idempotentHint"`
+}
+
+// IsReadOnly reports whether the tool is annotated as read-only
+func (t *Tool) IsReadOnly() bool {
+	return t.Annotations != nil && t.Annotations.ReadOnlyHint
+}
+
+// Content is a single content block in a tool result or prompt message
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// ToolResult is the result of a tools/call request
+type ToolResult struct {
+	Content []Content   `json:"content"`
+	IsError bool        `json:"isError"`
+	Meta    *ResultMeta `json:"_meta,omitempty"`
+}
+
+// ResultMeta carries the audit of values redacted from a tool or resource result
+type ResultMeta struct {
+	Redactions []models.Redaction `json:"redactions"`
+}
+
+// resultMeta returns the metadata for a result, or nil when nothing was redacted
+func resultMeta(redactions []models.Redaction) *ResultMeta {
+	if len(redactions) == 0 {
+		return nil
+	}
+	return &ResultMeta{Redactions: redactions}
+}
+
+// readOnly is the annotation shared by all built-in tools
+var readOnly = &ToolAnnotations{ReadOnlyHint: true, OpenWorldHint: true, IdempotentHint: true}
+
+// objectSchema builds a JSON schema for an object with the given properties
+func objectSchema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// stringProperty builds a JSON schema string property
+func stringProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "string", "description": description}
+}
+
+// integerProperty builds a JSON schema integer property
+func integerProperty(description string) map[string]interface{} {
+	return map[string]interface{}{"type": "integer", "description": description}
+}
+
+// clusterProperty is the optional cluster argument of the Kubernetes tools
+var clusterProperty = stringProperty("Name of the cluster (see list_clusters; empty for the default cluster)")
+
+// resourceRefArgs are the arguments shared by tools that target a single resource
+type resourceRefArgs struct {
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+}
+
+// resourceRefSchema is the input schema for resourceRefArgs
+var resourceRefSchema = objectSchema(map[string]interface{}{
+	"cluster":   clusterProperty,
+	"namespace": stringProperty("Namespace of the resource (empty for cluster-scoped resources)"),
+	"kind":      stringProperty("Resource kind, e.g. deployment, pod, service"),
+	"name":      stringProperty("Name of the resource"),
+}, "kind", "name")
+
+// registerBuiltinTools registers the tools backed by the existing correlators and clients
+func (s *Server) registerBuiltinTools() {
+	s.RegisterTool(&Tool{
+		Name: "trace_resource_deployment",
+		Description: "Trace how a Kubernetes resource was deployed: its ArgoCD application and sync history " +
+			"or Flux Kustomization or HelmRelease and source, its GitLab project, recent commits, pipelines and events.",
+		InputSchema: resourceRefSchema,
+		Annotations: readOnly,
+		Handler:     s.toolTraceResourceDeployment,
+	})
+
+	s.RegisterTool(&Tool{
+		Name:        "troubleshoot_resource",
+		Description: "Detect common problems with a Kubernetes resource and return issues with recommendations.",
+		InputSchema: resourceRefSchema,
+		Annotations: readOnly,
+		Handler:     s.toolTroubleshootResource,
+	})
+
+	s.RegisterTool(&Tool{
+		Name:        "analyze_merge_request",
+		Description: "Identify the ArgoCD applications and Kubernetes resources affected by a GitLab merge request.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"projectId":       stringProperty("GitLab project ID or path, or a repository qualified with its host such as github.com/owner/repo"),
+			"mergeRequestIid": integerProperty("Merge request IID within the project"),
+		}, "projectId", "mergeRequestIid"),
+		Annotations: readOnly,
+		Handler:     s.toolAnalyzeMergeRequest,
+	})
+
+	s.RegisterTool(&Tool{
+		Name: "check_merge_request_policy",
+		Description: "Check the manifests a GitLab merge request renders against the policy rules, such as " +
+			"missing resource limits or privileged containers, and return a pass, warn or fail verdict.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"projectId":       stringProperty("GitLab project ID or path, or a repository qualified with its host such as github.com/owner/repo"),
+			"mergeRequestIid": integerProperty("Merge request IID within the project"),
+		}, "projectId", "mergeRequestIid"),
+		Annotations: readOnly,
+		Handler:     s.toolCheckMergeRequestPolicy,
+	})
+
+	s.RegisterTool(&Tool{
+		Name: "get_timeline",
+		Description: "Merge the Kubernetes events, ArgoCD syncs, Helm releases, commits, pipelines and " +
+			"deployments behind a resource, an ArgoCD application or a namespace into one timeline, oldest " +
+			"first, to find the change that triggered an incident.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"cluster":     clusterProperty,
+			"namespace":   stringProperty("Namespace of the resource, or the namespace to cover"),
+			"kind":        stringProperty("Resource kind, e.g. deployment; set with name to cover one resource"),
+			"name":        stringProperty("Resource name"),
+			"application": stringProperty("ArgoCD application to cover instead of a resource or namespace"),
+			"since":       stringProperty("Start of the window, as an RFC 3339 time or a duration such as 6h (default 24h)"),
+			"until":       stringProperty("End of the window as an RFC 3339 time (default now)"),
+		}),
+		Annotations: readOnly,
+		Handler:     s.toolGetTimeline,
+	})
+
+	s.RegisterTool(&Tool{
+		Name: "detect_changes",
+		Description: "Report what changed in a namespace, or across the cluster when no namespace is given: " +
+			"Deployment rollouts and pod template revisions, ConfigMap and Secret updates, ArgoCD syncs, merged " +
+			"merge requests and deployments. Each change lists the resources it touched and is ranked by how " +
+			"likely it is to have caused the Warning events.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"cluster":   clusterProperty,
+			"namespace": stringProperty("Namespace to cover; omit to cover the whole cluster"),
+			"since":     stringProperty("Start of the window, as an RFC 3339 time or a duration such as 6h (default 24h)"),
+			"until":     stringProperty("End of the window as an RFC 3339 time (default now)"),
+		}),
+		Annotations: readOnly,
+		Handler:     s.toolDetectChanges,
+	})
+
+	s.RegisterTool(&Tool{
+		Name:        "get_namespace_topology",
+		Description: "Map the resources in a namespace, their health and the relationships between them.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"cluster":   clusterProperty,
+			"namespace": stringProperty("Namespace to map"),
+		}, "namespace"),
+		Annotations: readOnly,
+		Handler:     s.toolGetNamespaceTopology,
+	})
+
+	s.RegisterTool(&Tool{
+		Name:        "list_resources",
+		Description: "List Kubernetes resources of a kind, optionally limited to a namespace.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"cluster":   clusterProperty,
+			"kind":      stringProperty("Resource kind, e.g. deployment, pod, configmap"),
+			"namespace": stringProperty("Namespace to list (empty for all namespaces)"),
+		}, "kind"),
+		Annotations: readOnly,
+		Handler:     s.toolListResources,
+	})
+
+	s.RegisterTool(&Tool{
+		Name:        "get_pod_logs",
+		Description: "Fetch the most recent log lines of a pod container.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"cluster":   clusterProperty,
+			"namespace": stringProperty("Namespace of the pod"),
+			"name":      stringProperty("Name of the pod"),
+			"container": stringProperty("Container name (optional for single-container pods)"),
+			"tailLines": integerProperty("Number of lines from the end of the log (default 100)"),
+		}, "namespace", "name"),
+		Annotations: readOnly,
+		Handler:     s.toolGetPodLogs,
+	})
+
+	s.RegisterTool(&Tool{
+		Name:        "get_resource",
+		Description: "Fetch the full manifest and status of a single Kubernetes resource.",
+		InputSchema: resourceRefSchema,
+		Annotations: readOnly,
+		Handler:     s.toolGetResource,
+	})
+
+	s.RegisterTool(&Tool{
+		Name:        "get_resource_events",
+		Description: "Fetch the Kubernetes events recorded for a resource.",
+		InputSchema: resourceRefSchema,
+		Annotations: readOnly,
+		Handler:     s.toolGetResourceEvents,
+	})
+
+	s.RegisterTool(&Tool{
+		Name:        "get_argocd_application",
+		Description: "Fetch an ArgoCD application with its sync and health status and recent sync history.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"name": stringProperty("Name of the ArgoCD application"),
+		}, "name"),
+		Annotations: readOnly,
+		Handler:     s.toolGetArgoCDApplication,
+	})
+
+	s.RegisterTool(&Tool{
+		Name:        "get_gitlab_file",
+		Description: "Read a file from a GitLab or GitHub repository at a branch, tag or commit.",
+		InputSchema: objectSchema(map[string]interface{}{
+			"projectId": stringProperty("GitLab project ID or path, or a repository qualified with its host such as github.com/owner/repo"),
+			"path":      stringProperty("Path of the file within the repository"),
+			"ref":       stringProperty("Branch, tag or commit SHA (default main)"),
+		}, "projectId", "path"),
+		Annotations: readOnly,
+		Handler:     s.toolGetGitLabFile,
+	})
+
+	s.RegisterTool(&Tool{
+		Name:        "list_clusters",
+		Description: "List the Kubernetes clusters this server can query and which one is the default.",
+		InputSchema: objectSchema(map[string]interface{}{}),
+		Annotations: readOnly,
+		Handler:     s.toolListClusters,
+	})
+}
+
+// handleToolsList returns the registered tools
+func (s *Server) handleToolsList() (interface{}, *RPCError) {
+	return map[string]interface{}{"tools": s.Tools()}, nil
+}
+
+// handleToolsCall executes a tool. Tool failures are reported in the result with isError set,
+// protocol failures (unknown tool, malformed params) as JSON-RPC errors.
+func (s *Server) handleToolsCall(ctx context.Context, params json.RawMessage) (interface{}, *RPCError) {
+	var callParams struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &callParams); err != nil {
+		return nil, newRPCError(ErrCodeInvalidParams, "invalid tools/call params: "+err.Error())
+	}
+
+	s.mu.RLock()
+	tool, ok := s.tools[callParams.Name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, newRPCError(ErrCodeInvalidParams, fmt.Sprintf("unknown tool: %s", callParams.Name))
+	}
+
+	return s.CallTool(ctx, tool, callParams.Arguments), nil
+}
+
+// CallTool runs a tool and converts its output into a ToolResult
+func (s *Server) CallTool(ctx context.Context, tool *Tool, arguments json.RawMessage) *ToolResult {
+	if len(arguments) == 0 || string(arguments) == "null" {
+		arguments = json.RawMessage("{}")
+	}
+
+	s.logger.Info("Calling MCP tool", "tool", tool.Name)
+
+	output, err := tool.Handler(ctx, arguments)
+	if err != nil {
+		s.logger.Warn("MCP tool failed", "tool", tool.Name, "error", err)
+		return &ToolResult{
+			Content: []Content{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}
+	}
+
+	text, redactions, err := s.redactOutput(output)
+	if err != nil {
+		return &ToolResult{
+			Content: []Content{{Type: "text", Text: err.Error()}},
+			IsError: true,
+		}
+	}
+	if len(redactions) > 0 {
+		s.logger.Info("Redacted sensitive values from tool output", "tool", tool.Name, "count", len(redactions))
+	}
+
+	return &ToolResult{Content: []Content{{Type: "text", Text: text}}, Meta: resultMeta(redactions)}
+}
+
+// redactOutput masks sensitive values in a tool or resource result and serializes it
+// for the client. Nothing leaves the server unredacted, including what the agent
+// passes back to Claude.
+func (s *Server) redactOutput(output interface{}) (string, []models.Redaction, error) {
+	if text, ok := output.(string); ok {
+		redacted, redactions := s.redactor.RedactText("output", text)
+		return redacted, redactions, nil
+	}
+
+	redacted, redactions := s.redactor.RedactValue("", output)
+	text, err := utils.ToJSON(redacted)
+	if err != nil {
+		return "", nil, err
+	}
+	return text, redactions, nil
+}
+
+// decodeArguments unmarshals tool arguments into the target struct
+func decodeArguments(arguments json.RawMessage, target interface{}) error {
+	if err := json.Unmarshal(arguments, target); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// toolTraceResourceDeployment implements trace_resource_deployment
+func (s *Server) toolTraceResourceDeployment(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args resourceRefArgs
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Kind == "" || args.Name == "" {
+		return nil, fmt.Errorf("kind and name are required")
+	}
+	if s.gitOpsCorrelator == nil {
+		return nil, fmt.Errorf("GitOps correlator is not configured")
+	}
+
+	return s.gitOpsCorrelator.TraceResourceDeployment(ctx, args.Cluster, args.Namespace, args.Kind, args.Name)
+}
+
+// toolTroubleshootResource implements troubleshoot_resource
+func (s *Server) toolTroubleshootResource(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args resourceRefArgs
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Kind == "" || args.Name == "" {
+		return nil, fmt.Errorf("kind and name are required")
+	}
+	if s.troubleshootCorrelator == nil {
+		return nil, fmt.Errorf("troubleshoot correlator is not configured")
+	}
+
+	return s.troubleshootCorrelator.TroubleshootResource(ctx, args.Cluster, args.Namespace, args.Kind, args.Name)
+}
+
+// toolAnalyzeMergeRequest implements analyze_merge_request
+func (s *Server) toolAnalyzeMergeRequest(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		ProjectID       string `json:"projectId"`
+		MergeRequestIID int    `json:"mergeRequestIid"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.ProjectID == "" || args.MergeRequestIID <= 0 {
+		return nil, fmt.Errorf("projectId and mergeRequestIid are required")
+	}
+	if s.gitOpsCorrelator == nil {
+		return nil, fmt.Errorf("GitOps correlator is not configured")
+	}
+
+	return s.gitOpsCorrelator.AnalyzeMergeRequest(ctx, args.ProjectID, args.MergeRequestIID)
+}
+
+// toolCheckMergeRequestPolicy implements check_merge_request_policy
+func (s *Server) toolCheckMergeRequestPolicy(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		ProjectID       string `json:"projectId"`
+		MergeRequestIID int    `json:"mergeRequestIid"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.ProjectID == "" || args.MergeRequestIID <= 0 {
+		return nil, fmt.Errorf("projectId and mergeRequestIid are required")
+	}
+	if s.gitOpsCorrelator == nil {
+		return nil, fmt.Errorf("GitOps correlator is not configured")
+	}
+
+	return s.gitOpsCorrelator.CheckMergeRequestPolicy(ctx, args.ProjectID, args.MergeRequestIID)
+}
+
+// toolGetTimeline implements get_timeline
+func (s *Server) toolGetTimeline(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		models.TimelineScope
+		Since string `json:"since"`
+		Until string `json:"until"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Application == "" && args.Namespace == "" {
+		return nil, fmt.Errorf("namespace or application is required")
+	}
+	if s.gitOpsCorrelator == nil {
+		return nil, fmt.Errorf("GitOps correlator is not configured")
+	}
+	since, until, err := correlator.TimelineWindow(args.Since, args.Until, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.gitOpsCorrelator.BuildTimeline(ctx, args.TimelineScope, since, until)
+}
+
+// toolDetectChanges implements detect_changes
+func (s *Server) toolDetectChanges(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Cluster   string `json:"cluster"`
+		Namespace string `json:"namespace"`
+		Since     string `json:"since"`
+		Until     string `json:"until"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if s.gitOpsCorrelator == nil {
+		return nil, fmt.Errorf("GitOps correlator is not configured")
+	}
+	since, until, err := correlator.TimelineWindow(args.Since, args.Until, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.gitOpsCorrelator.DetectChanges(ctx, args.Cluster, args.Namespace, since, until, nil)
+}
+
+// toolGetNamespaceTopology implements get_namespace_topology
+func (s *Server) toolGetNamespaceTopology(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Cluster   string `json:"cluster"`
+		Namespace string `json:"namespace"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Namespace == "" {
+		return nil, fmt.Errorf("namespace is required")
+	}
+	k8sClient, err := s.clusters.ForContext(ctx, args.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return k8sClient.GetNamespaceTopology(ctx, args.Namespace)
+}
+
+// toolListResources implements list_resources. Only a summary of each object is returned
+// to keep the result small enough for a model context window.
+func (s *Server) toolListResources(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Cluster   string `json:"cluster"`
+		Kind      string `json:"kind"`
+		Namespace string `json:"namespace"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Kind == "" {
+		return nil, fmt.Errorf("kind is required")
+	}
+	k8sClient, err := s.clusters.ForContext(ctx, args.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := k8sClient.ListResources(ctx, args.Kind, args.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]map[string]interface{}, 0, len(items))
+	for i := range items {
+		item := &items[i]
+		summary := map[string]interface{}{
+			"kind":              item.GetKind(),
+			"name":              item.GetName(),
+			"creationTimestamp": item.GetCreationTimestamp().Time,
+		}
+		if item.GetNamespace() != "" {
+			summary["namespace"] = item.GetNamespace()
+		}
+		if labels := item.GetLabels(); len(labels) > 0 {
+			summary["labels"] = labels
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return map[string]interface{}{
+		"kind":      args.Kind,
+		"namespace": args.Namespace,
+		"count":     len(summaries),
+		"items":     summaries,
+	}, nil
+}
+
+// toolGetPodLogs implements get_pod_logs
+func (s *Server) toolGetPodLogs(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Cluster   string `json:"cluster"`
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+		Container string `json:"container"`
+		TailLines int64  `json:"tailLines"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Namespace == "" || args.Name == "" {
+		return nil, fmt.Errorf("namespace and name are required")
+	}
+	k8sClient, err := s.clusters.ForContext(ctx, args.Cluster)
+	if err != nil {
+		return nil, err
+	}
+	if args.TailLines <= 0 {
+		args.TailLines = 100
+	}
+
+	logs, err := k8sClient.GetPodLogs(ctx, args.Namespace, args.Name, args.Container, args.TailLines)
+	if err != nil {
+		return nil, err
+	}
+	if logs == "" {
+		return "(no log output)", nil
+	}
+	return logs, nil
+}
+
+// toolGetResource implements get_resource
+func (s *Server) toolGetResource(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args resourceRefArgs
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Kind == "" || args.Name == "" {
+		return nil, fmt.Errorf("kind and name are required")
+	}
+	k8sClient, err := s.clusters.ForContext(ctx, args.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := k8sClient.GetResource(ctx, args.Kind, args.Namespace, args.Name)
+	if err != nil {
+		return nil, err
+	}
+	return resource.Object, nil
+}
+
+// toolGetResourceEvents implements get_resource_events
+func (s *Server) toolGetResourceEvents(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args resourceRefArgs
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Kind == "" || args.Name == "" {
+		return nil, fmt.Errorf("kind and name are required")
+	}
+	k8sClient, err := s.clusters.ForContext(ctx, args.Cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	return k8sClient.GetResourceEvents(ctx, args.Namespace, args.Kind, args.Name)
+}
+
+// toolGetArgoCDApplication implements get_argocd_application
+func (s *Server) toolGetArgoCDApplication(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		Name string `json:"name"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if s.argoClient == nil {
+		return nil, fmt.Errorf("ArgoCD client is not configured")
+	}
+
+	app, err := s.argoClient.GetApplication(ctx, args.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := s.argoClient.GetApplicationHistory(ctx, args.Name)
+	if err != nil {
+		s.logger.Debug("Failed to get ArgoCD application history", "application", args.Name, "error", err)
+	}
+
+	return map[string]interface{}{
+		"application": app,
+		"history":     history,
+	}, nil
+}
+
+// toolGetGitLabFile implements get_gitlab_file
+func (s *Server) toolGetGitLabFile(ctx context.Context, arguments json.RawMessage) (interface{}, error) {
+	var args struct {
+		ProjectID string `json:"projectId"`
+		Path      string `json:"path"`
+		Ref       string `json:"ref"`
+	}
+	if err := decodeArguments(arguments, &args); err != nil {
+		return nil, err
+	}
+	if args.ProjectID == "" || args.Path == "" {
+		return nil, fmt.Errorf("projectId and path are required")
+	}
+	if s.repos == nil {
+		return nil, fmt.Errorf("no SCM provider is configured")
+	}
+	if args.Ref == "" {
+		args.Ref = "main"
+	}
+
+	return s.repos.GetFileContent(ctx, args.ProjectID, args.Path, args.Ref)
+}
+
+// toolListClusters implements list_clusters
+func (s *Server) toolListClusters(_ context.Context, _ json.RawMessage) (interface{}, error) {
+	if s.clusters == nil {
+		return nil, fmt.Errorf("kubernetes client is not configured")
+	}
+
+	return map[string]interface{}{"clusters": s.clusters.Clusters()}, nil
+}
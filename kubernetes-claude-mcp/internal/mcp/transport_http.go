@@ -0,0 +1,264 @@
+package mcp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SessionHeader carries the MCP session ID assigned on initialize
+	SessionHeader = "Mcp-Session-Id"
+
+	// maxHTTPMessageSize bounds the body of a single POST
+	maxHTTPMessageSize = 10 * 1024 * 1024
+
+	// sseKeepAliveInterval is how often idle SSE streams receive a comment line
+	sseKeepAliveInterval = 30 * time.Second
+
+	// defaultSessionTTL is how long a session lives without activity
+	defaultSessionTTL = 30 * time.Minute
+)
+
+// HTTPHandler serves MCP using the streamable HTTP transport: clients POST JSON-RPC
+// messages and receive either a JSON body or a single-event SSE stream, may GET an SSE
+// stream for server messages and DELETE their session when done. Sessions idle for
+// longer than the session TTL expire and are answered with 404, as the transport
+// specifies, so the client initializes again.
+type HTTPHandler struct {
+	server     *Server
+	sessions   map[string]time.Time
+	sessionTTL time.Duration
+	mu         sync.Mutex
+}
+
+// NewHTTPHandler creates a streamable HTTP transport for the server
+func NewHTTPHandler(server *Server) *HTTPHandler {
+	return &HTTPHandler{
+		server:     server,
+		sessions:   make(map[string]time.Time),
+		sessionTTL: defaultSessionTTL,
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		h.handlePost(w, r)
+	case http.MethodGet:
+		h.handleGet(w, r)
+	case http.MethodDelete:
+		h.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost processes JSON-RPC messages sent by the client
+func (h *HTTPHandler) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxHTTPMessageSize))
+	if err != nil {
+		writeRPCError(w, http.StatusBadRequest, newRPCError(ErrCodeParse, "failed to read request body"))
+		return
+	}
+
+	initializing := isInitializeMessage(body)
+	sessionID := r.Header.Get(SessionHeader)
+
+	switch {
+	case initializing:
+		sessionID, err = h.newSession()
+		if err != nil {
+			writeRPCError(w, http.StatusInternalServerError, newRPCError(ErrCodeInternal, "failed to create session"))
+			return
+		}
+	case sessionID != "" && !h.touchSession(sessionID):
+		http.Error(w, "unknown MCP session", http.StatusNotFound)
+		return
+	}
+
+	resp := h.server.HandleMessage(r.Context(), body)
+
+	if sessionID != "" {
+		w.Header().Set(SessionHeader, sessionID)
+	}
+
+	// Notifications and responses only
+	if resp == nil {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	if prefersEventStream(r) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", resp)
+		_ = http.NewResponseController(w).Flush()
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(resp)
+}
+
+// handleGet opens an SSE stream for server-initiated messages. The server does not
+// currently send any, so the stream only carries keep-alives until the client disconnects.
+func (h *HTTPHandler) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "GET requires Accept: text/event-stream", http.StatusMethodNotAllowed)
+		return
+	}
+
+	sessionID := r.Header.Get(SessionHeader)
+	if sessionID != "" && !h.touchSession(sessionID) {
+		http.Error(w, "unknown MCP session", http.StatusNotFound)
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	// Long-lived stream: lift the server write timeout for this connection
+	_ = controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(sseKeepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			// An open stream keeps its session alive
+			if sessionID != "" {
+				h.touchSession(sessionID)
+			}
+			if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			if err := controller.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// handleDelete terminates a session
+func (h *HTTPHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.Header.Get(SessionHeader)
+	if sessionID == "" {
+		http.Error(w, "missing "+SessionHeader+" header", http.StatusBadRequest)
+		return
+	}
+
+	h.mu.Lock()
+	_, ok := h.sessions[sessionID]
+	delete(h.sessions, sessionID)
+	h.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "unknown MCP session", http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// newSession allocates a random session ID
+func (h *HTTPHandler) newSession() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate session ID: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+
+	now := time.Now()
+	h.mu.Lock()
+	h.sweepSessions(now)
+	h.sessions[id] = now
+	h.mu.Unlock()
+
+	return id, nil
+}
+
+// touchSession records activity on a session and reports whether it exists and has
+// not expired
+func (h *HTTPHandler) touchSession(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	lastSeen, ok := h.sessions[id]
+	if !ok {
+		return false
+	}
+	if now.Sub(lastSeen) > h.sessionTTL {
+		delete(h.sessions, id)
+		return false
+	}
+	h.sessions[id] = now
+	return true
+}
+
+// sweepSessions removes the sessions idle for longer than the TTL. The caller holds
+// the lock; sweeping on every new session bounds the map by the sessions active
+// within one TTL.
+func (h *HTTPHandler) sweepSessions(now time.Time) {
+	for id, lastSeen := range h.sessions {
+		if now.Sub(lastSeen) > h.sessionTTL {
+			delete(h.sessions, id)
+		}
+	}
+}
+
+// isInitializeMessage reports whether a message (or any batch entry) is an initialize request
+func isInitializeMessage(body []byte) bool {
+	var probe struct {
+		Method string `json:"method"`
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(trimmed, &batch); err != nil {
+			return false
+		}
+		for _, item := range batch {
+			if json.Unmarshal(item, &probe) == nil && probe.Method == "initialize" {
+				return true
+			}
+		}
+		return false
+	}
+
+	return json.Unmarshal(trimmed, &probe) == nil && probe.Method == "initialize"
+}
+
+// prefersEventStream reports whether the client only accepts an SSE response
+func prefersEventStream(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "text/event-stream") && !strings.Contains(accept, "application/json")
+}
+
+// writeRPCError writes a JSON-RPC error response with the given HTTP status
+func writeRPCError(w http.ResponseWriter, status int, rpcErr *RPCError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(newErrorResponse(nil, rpcErr))
+}
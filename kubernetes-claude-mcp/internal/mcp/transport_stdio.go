@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// maxStdioMessageSize bounds a single newline-delimited JSON-RPC message
+const maxStdioMessageSize = 10 * 1024 * 1024
+
+// ServeStdio serves MCP over newline-delimited JSON-RPC messages read from in and written to out.
+// Requests are handled concurrently so a slow tool call does not block pings or other requests.
+// It returns when in reaches EOF or ctx is cancelled.
+func (s *Server) ServeStdio(ctx context.Context, in io.Reader, out io.Writer) error {
+	s.logger.Info("Serving MCP over stdio")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		writeMu sync.Mutex
+		wg      sync.WaitGroup
+	)
+	writer := bufio.NewWriter(out)
+
+	write := func(data []byte) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+
+		if _, err := writer.Write(append(data, '\n')); err != nil {
+			s.logger.Error("Failed to write MCP response", "error", err)
+			return
+		}
+		if err := writer.Flush(); err != nil {
+			s.logger.Error("Failed to flush MCP response", "error", err)
+		}
+	}
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), maxStdioMessageSize)
+
+	lines := make(chan []byte)
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(lines)
+		for scanner.Scan() {
+			line := append([]byte(nil), scanner.Bytes()...)
+			select {
+			case lines <- line:
+			case <-ctx.Done():
+				return
+			}
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		case line, ok := <-lines:
+			if !ok {
+				wg.Wait()
+				if err := <-scanErr; err != nil && !errors.Is(err, io.EOF) {
+					return fmt.Errorf("failed to read MCP message: %w", err)
+				}
+				s.logger.Info("MCP stdio input closed")
+				return nil
+			}
+			if len(line) == 0 {
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if resp := s.HandleMessage(ctx, line); resp != nil {
+					write(resp)
+				}
+			}()
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
+	"sort"
 	"time"
 )
 
@@ -8,26 +11,26 @@ import (
 type ArgoApplication struct {
 	// These fields might need adjustment based on the actual API response
 	Metadata struct {
-		Name      string            `json:"name"`
-		Namespace string            `json:"namespace"`
-		Labels    map[string]string `json:"labels,omitempty"`
+		Name            string               `json:"name"`
+		Namespace       string               `json:"namespace"`
+		Labels          map[string]string    `json:"labels,omitempty"`
+		OwnerReferences []ArgoOwnerReference `json:"ownerReferences,omitempty"`
 	} `json:"metadata"`
 	Spec struct {
-		Source struct {
-			RepoURL        string `json:"repoURL"`
-			Path           string `json:"path,omitempty"`
-			TargetRevision string `json:"targetRevision,omitempty"`
-			Chart          string `json:"chart,omitempty"`
-		} `json:"source"`
-		Destination struct {
-			Server    string `json:"server"`
-			Namespace string `json:"namespace"`
-		} `json:"destination"`
+		// Source is set for single-source applications and Sources for multi-source
+		// ones; use AllSources to read either
+		Source      ArgoApplicationSource   `json:"source,omitzero"`
+		Sources     []ArgoApplicationSource `json:"sources,omitempty"`
+		Destination ArgoDestination         `json:"destination"`
 	} `json:"spec"`
 	Status struct {
 		Sync struct {
 			Status   string `json:"status"`
 			Revision string `json:"revision,omitempty"`
+
+			// Revisions holds the synced revision of each source of a multi-source
+			// application, in the order of spec.sources
+			Revisions []string `json:"revisions,omitempty"`
 		} `json:"sync"`
 		Health struct {
 			Status string `json:"status"`
@@ -37,6 +40,213 @@ type ArgoApplication struct {
 	Name string `json:"name"`
 }
 
+// ArgoApplicationSource is a repository an application deploys from. A source with a
+// Ref and no Path only provides files, such as Helm value files, to the other sources,
+// which refer to them as "$<ref>/<path>".
+type ArgoApplicationSource struct {
+	RepoURL        string          `json:"repoURL"`
+	Path           string          `json:"path,omitempty"`
+	TargetRevision string          `json:"targetRevision,omitempty"`
+	Chart          string          `json:"chart,omitempty"`
+	Ref            string          `json:"ref,omitempty"`
+	Helm           *ArgoHelmSource `json:"helm,omitempty"`
+}
+
+// ArgoHelmSource holds the Helm settings of an application source
+type ArgoHelmSource struct {
+	ReleaseName string   `json:"releaseName,omitempty"`
+	ValueFiles  []string `json:"valueFiles,omitempty"`
+}
+
+// IsRefOnly reports whether the source only provides files to other sources
+func (s *ArgoApplicationSource) IsRefOnly() bool {
+	return s.Ref != "" && s.Path == "" && s.Chart == ""
+}
+
+// ArgoDestination is the cluster and namespace an application deploys to
+type ArgoDestination struct {
+	Server    string `json:"server"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace"`
+}
+
+// ArgoOwnerReference is an owner reference in an ArgoCD object's metadata
+type ArgoOwnerReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+// AllSources returns the sources of a multi-source application, or the single source
+// of any other application
+func (a *ArgoApplication) AllSources() []ArgoApplicationSource {
+	if len(a.Spec.Sources) > 0 {
+		return a.Spec.Sources
+	}
+	if a.Spec.Source.RepoURL == "" {
+		return nil
+	}
+	return []ArgoApplicationSource{a.Spec.Source}
+}
+
+// PrimarySource returns the source an application deploys from, skipping sources that
+// only provide files to the others, with the revision it is synced at
+func (a *ArgoApplication) PrimarySource() (ArgoApplicationSource, string) {
+	if len(a.Spec.Sources) == 0 {
+		return a.Spec.Source, a.Status.Sync.Revision
+	}
+
+	primary := 0
+	for i := range a.Spec.Sources {
+		if !a.Spec.Sources[i].IsRefOnly() {
+			primary = i
+			break
+		}
+	}
+	revision := ""
+	if primary < len(a.Status.Sync.Revisions) {
+		revision = a.Status.Sync.Revisions[primary]
+	}
+	return a.Spec.Sources[primary], revision
+}
+
+// ApplicationSetName returns the name of the ApplicationSet that generated the
+// application, or "" when it was not generated
+func (a *ArgoApplication) ApplicationSetName() string {
+	for _, owner := range a.Metadata.OwnerReferences {
+		if owner.Kind == "ApplicationSet" {
+			return owner.Name
+		}
+	}
+	return ""
+}
+
+// ArgoApplicationSet represents an ArgoCD ApplicationSet, which generates applications
+// from a template with the parameters its generators produce
+type ArgoApplicationSet struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		GoTemplate bool                    `json:"goTemplate,omitempty"`
+		Generators []ArgoGenerator         `json:"generators"`
+		Template   ArgoApplicationTemplate `json:"template"`
+	} `json:"spec"`
+	Status struct {
+		Conditions []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason,omitempty"`
+			Message string `json:"message,omitempty"`
+		} `json:"conditions,omitempty"`
+
+		// Resources lists the applications the ApplicationSet generated
+		Resources []ArgoResourceStatus `json:"resources,omitempty"`
+	} `json:"status"`
+}
+
+// ArgoApplicationTemplate is the application template of an ApplicationSet. Its fields
+// may hold generator parameters such as "{{path.basename}}".
+type ArgoApplicationTemplate struct {
+	Metadata struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels,omitempty"`
+	} `json:"metadata"`
+	Spec struct {
+		Project     string                  `json:"project,omitempty"`
+		Source      ArgoApplicationSource   `json:"source,omitzero"`
+		Sources     []ArgoApplicationSource `json:"sources,omitempty"`
+		Destination ArgoDestination         `json:"destination"`
+	} `json:"spec"`
+}
+
+// ArgoGenerator is an ApplicationSet generator, keyed by its type such as "list",
+// "clusters", "git", "matrix" or "merge", with an optional "selector"
+type ArgoGenerator map[string]json.RawMessage
+
+// Type returns the generator's type
+func (g ArgoGenerator) Type() string {
+	types := make([]string, 0, len(g))
+	for key := range g {
+		if key != "selector" {
+			types = append(types, key)
+		}
+	}
+	sort.Strings(types)
+	if len(types) == 0 {
+		return ""
+	}
+	return types[0]
+}
+
+// Git returns the settings of a git generator
+func (g ArgoGenerator) Git() (*ArgoGitGenerator, bool) {
+	raw, ok := g["git"]
+	if !ok {
+		return nil, false
+	}
+	var git ArgoGitGenerator
+	if err := json.Unmarshal(raw, &git); err != nil {
+		return nil, false
+	}
+	return &git, true
+}
+
+// Children returns the generators nested in a matrix or merge generator
+func (g ArgoGenerator) Children() []ArgoGenerator {
+	for _, key := range []string{"matrix", "merge"} {
+		raw, ok := g[key]
+		if !ok {
+			continue
+		}
+		var nested struct {
+			Generators []ArgoGenerator `json:"generators"`
+		}
+		if err := json.Unmarshal(raw, &nested); err == nil {
+			return nested.Generators
+		}
+	}
+	return nil
+}
+
+// ArgoGitGenerator generates an application for each directory or file in a Git
+// repository that matches its path patterns
+type ArgoGitGenerator struct {
+	RepoURL     string `json:"repoURL"`
+	Revision    string `json:"revision,omitempty"`
+	Directories []struct {
+		Path    string `json:"path"`
+		Exclude bool   `json:"exclude,omitempty"`
+	} `json:"directories,omitempty"`
+	Files []struct {
+		Path string `json:"path"`
+	} `json:"files,omitempty"`
+}
+
+// ArgoApplicationSetOrigin is the ApplicationSet that generated an application, with
+// its generators and template. Generator describes the generator that produced the
+// application when it can be told apart from the others.
+type ArgoApplicationSetOrigin struct {
+	Name            string                  `json:"name"`
+	Namespace       string                  `json:"namespace,omitempty"`
+	Generators      []string                `json:"generators"`
+	Generator       string                  `json:"generator,omitempty"`
+	TemplateName    string                  `json:"templateName"`
+	TemplateSources []ArgoApplicationSource `json:"templateSources,omitempty"`
+}
+
+// ApplicationSetChange is an application that an ApplicationSet's git generator would
+// add or remove once a merge request is merged. Path is the directory or file the
+// application is generated from, and Change is "added" or "removed".
+type ApplicationSetChange struct {
+	ApplicationSet string `json:"applicationSet"`
+	Generator      string `json:"generator"`
+	Path           string `json:"path"`
+	Change         string `json:"change"`
+}
+
 // ArgoResourceStatus represents the status of a resource managed by ArgoCD
 type ArgoResourceStatus struct {
 	Group     string `json:"group"`
@@ -51,6 +261,79 @@ type ArgoResourceStatus struct {
 	} `json:"health"`
 }
 
+// ArgoManagedResource is a resource an application manages, as the managed-resources
+// endpoint returns it, with its target and live states as JSON documents.
+// NormalizedLiveState is the live state with ArgoCD's normalizations and the
+// application's ignoreDifferences applied.
+type ArgoManagedResource struct {
+	Group               string `json:"group,omitempty"`
+	Kind                string `json:"kind"`
+	Namespace           string `json:"namespace,omitempty"`
+	Name                string `json:"name"`
+	TargetState         string `json:"targetState,omitempty"`
+	LiveState           string `json:"liveState,omitempty"`
+	NormalizedLiveState string `json:"normalizedLiveState,omitempty"`
+	Hook                bool   `json:"hook,omitempty"`
+}
+
+// States decodes the target state and the live state, preferring the normalized live
+// state. A state is nil when the resource does not exist on that side.
+func (r *ArgoManagedResource) States() (map[string]interface{}, map[string]interface{}, error) {
+	liveState := r.NormalizedLiveState
+	if liveState == "" || liveState == "null" {
+		liveState = r.LiveState
+	}
+
+	target, err := decodeState(r.TargetState)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode target state: %w", err)
+	}
+	live, err := decodeState(liveState)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode live state: %w", err)
+	}
+	return target, live, nil
+}
+
+func decodeState(state string) (map[string]interface{}, error) {
+	if state == "" || state == "null" {
+		return nil, nil
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(state), &obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// Kinds of drift between a resource's live and target states
+const (
+	DriftModified   = "modified"
+	DriftMissing    = "missing"
+	DriftExtraneous = "extraneous"
+)
+
+// ResourceDrift is how the live state of a resource ArgoCD manages differs from the
+// target state rendered from Git. Status is "modified" when fields differ, "missing"
+// when the resource does not exist in the cluster, and "extraneous" when it is no
+// longer in Git and would be pruned.
+type ResourceDrift struct {
+	Group     string       `json:"group,omitempty"`
+	Kind      string       `json:"kind"`
+	Namespace string       `json:"namespace,omitempty"`
+	Name      string       `json:"name"`
+	Status    string       `json:"status"`
+	Fields    []DriftField `json:"fields,omitempty"`
+}
+
+// DriftField is a field whose live value differs from its target value, addressed like
+// a FieldChange path. Live is unset when the field is missing from the live state.
+type DriftField struct {
+	Path   string      `json:"path"`
+	Target interface{} `json:"target,omitempty"`
+	Live   interface{} `json:"live,omitempty"`
+}
+
 // ArgoApplicationHistory represents a sync entry in an application's history
 type ArgoApplicationHistory struct {
 	ID         int64     `json:"id"`
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Change types
+const (
+	ChangeRollout      = "rollout"
+	ChangeConfig       = "config"
+	ChangeSync         = "sync"
+	ChangeMergeRequest = "mergeRequest"
+	ChangeDeployment   = "deployment"
+)
+
+// ChangeReport lists what changed in a namespace, or across a cluster when Namespace
+// is empty, between Since and Until, most suspicious first. WarningsStarted is when
+// the earliest Warning event that first occurred within the window did; Warnings also
+// counts those that started earlier and recurred within it.
+type ChangeReport struct {
+	Cluster         string           `json:"cluster"`
+	Namespace       string           `json:"namespace,omitempty"`
+	Since           time.Time        `json:"since"`
+	Until           time.Time        `json:"until"`
+	WarningsStarted *time.Time       `json:"warningsStarted,omitempty"`
+	Warnings        int              `json:"warnings"`
+	Changes         []DetectedChange `json:"changes"`
+	Errors          []string         `json:"errors,omitempty"`
+}
+
+// DetectedChange is one change to the cluster or to what deploys to it. Resources
+// lists the live resources it touched as namespace/Kind/name, the ones it changed
+// directly first and then those they affect. Suspicion scores how likely the change
+// is to have caused the warnings, and Reasons explain the score.
+type DetectedChange struct {
+	Rank      int       `json:"rank"`
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`
+	Source    string    `json:"source"`
+	Subject   string    `json:"subject"`
+	Summary   string    `json:"summary"`
+	Revision  string    `json:"revision,omitempty"`
+	URL       string    `json:"url,omitempty"`
+	Resources []string  `json:"resources,omitempty"`
+	Suspicion int       `json:"suspicion"`
+	Reasons   []string  `json:"reasons,omitempty"`
+}
@@ -3,6 +3,7 @@ package models
 // ResourceContext combines information about a Kubernetes resource with GitOps context
 type ResourceContext struct {
 	// Basic resource information
+	Cluster      string                 `json:"cluster,omitempty"`
 	Kind         string                 `json:"kind"`
 	Name         string                 `json:"name"`
 	Namespace    string                 `json:"namespace"`
@@ -16,6 +17,19 @@ type ResourceContext struct {
 	ArgoHealthStatus string                   `json:"argoHealthStatus,omitempty"`
 	ArgoSyncHistory  []ArgoApplicationHistory `json:"argoSyncHistory,omitempty"`
 
+	// How the live state of the application's resources drifted from Git, when the
+	// application is OutOfSync
+	ArgoDrift []ResourceDrift `json:"argoDrift,omitempty"`
+
+	// The ApplicationSet that generated the ArgoCD application, if any
+	ArgoApplicationSet *ArgoApplicationSetOrigin `json:"argoApplicationSet,omitempty"`
+
+	// Flux Kustomization or HelmRelease that applied the resource, when Flux manages it
+	FluxObject *FluxObject `json:"fluxObject,omitempty"`
+
+	// Helm release that installed the resource, when it has Helm ownership metadata
+	HelmRelease *HelmReleaseSummary `json:"helmRelease,omitempty"`
+
 	// Related GitLab information
 	GitLabProject  *GitLabProject    `json:"gitlabProject,omitempty"`
 	LastPipeline   *GitLabPipeline   `json:"lastPipeline,omitempty"`
@@ -47,6 +61,7 @@ type TroubleshootResult struct {
 // MCPRequest represents a request to the MCP server
 type MCPRequest struct {
 	Action          string                 `json:"action"`
+	Cluster         string                 `json:"cluster,omitempty"`
 	Resource        string                 `json:"resource,omitempty"`
 	Namespace       string                 `json:"namespace,omitempty"`
 	Name            string                 `json:"name,omitempty"`
@@ -54,6 +69,9 @@ type MCPRequest struct {
 	CommitSHA       string                 `json:"commitSha,omitempty"`
 	ProjectID       string                 `json:"projectId,omitempty"`
 	MergeRequestIID int                    `json:"mergeRequestIid,omitempty"`
+	Application     string                 `json:"application,omitempty"`
+	Since           string                 `json:"since,omitempty"`
+	Until           string                 `json:"until,omitempty"`
 	ResourceSpecs   map[string]interface{} `json:"resourceSpecs,omitempty"`
 	Context         string                 `json:"context,omitempty"`
 }
@@ -78,16 +96,77 @@ type NamespaceAnalysisResult struct {
 	Issues                []Issue                   `json:"issues"`
 	Recommendations       []string                  `json:"recommendations"`
 	Analysis              string                    `json:"analysis"`
+	Redactions            []Redaction               `json:"redactions,omitempty"`
 }
 
 // MCPResponse represents a response from the MCP server
 type MCPResponse struct {
-	Success            bool                     `json:"success"`
-	Message            string                   `json:"message,omitempty"`
-	Analysis           string                   `json:"analysis,omitempty"`
-	Context            ResourceContext          `json:"context,omitempty"`
-	Actions            []string                 `json:"actions,omitempty"`
-	ErrorDetails       string                   `json:"errorDetails,omitempty"`
-	TroubleshootResult *TroubleshootResult      `json:"troubleshootResult,omitempty"`
-	NamespaceAnalysis  *NamespaceAnalysisResult `json:"namespaceAnalysis,omitempty"`
+	Success               bool                     `json:"success"`
+	Message               string                   `json:"message,omitempty"`
+	Analysis              string                   `json:"analysis,omitempty"`
+	Context               ResourceContext          `json:"context,omitempty"`
+	Actions               []string                 `json:"actions,omitempty"`
+	ErrorDetails          string                   `json:"errorDetails,omitempty"`
+	TroubleshootResult    *TroubleshootResult      `json:"troubleshootResult,omitempty"`
+	NamespaceAnalysis     *NamespaceAnalysisResult `json:"namespaceAnalysis,omitempty"`
+	CommitImpact          *CommitImpact            `json:"commitImpact,omitempty"`
+	ManifestDiffs         []ManifestDiff           `json:"manifestDiffs,omitempty"`
+	ManifestFindings      []ManifestFinding        `json:"manifestFindings,omitempty"`
+	Policy                *PolicyResult            `json:"policy,omitempty"`
+	BlastRadius           []ImpactedResource       `json:"blastRadius,omitempty"`
+	ApplicationSetChanges []ApplicationSetChange   `json:"applicationSetChanges,omitempty"`
+	Timeline              *Timeline                `json:"timeline,omitempty"`
+	Redactions            []Redaction              `json:"redactions,omitempty"`
+}
+
+// CommitImpact lists the ArgoCD applications, environments and live resources a
+// commit affects once it is synced
+type CommitImpact struct {
+	ProjectID     string                `json:"projectId"`
+	CommitSHA     string                `json:"commitSha"`
+	Commit        *GitLabCommit         `json:"commit,omitempty"`
+	ChangedFiles  []string              `json:"changedFiles"`
+	HelmResources []string              `json:"helmResources,omitempty"`
+	Applications  []AffectedApplication `json:"applications"`
+	Environments  []string              `json:"environments"`
+	Resources     []AffectedResource    `json:"resources"`
+
+	// Diffs are passed to Claude but left out of API responses
+	Diffs []GitLabDiff `json:"-"`
+}
+
+// AffectedApplication is an ArgoCD application whose source a commit changes. Reason
+// is "path" when a changed file is under one of the application's source paths or is
+// one of its Helm value files, "helm" when a changed chart renders resources the
+// application manages, and "kustomize" when the application's kustomization includes a
+// changed base, component or patch. SourcePath and TargetRevision are those of the
+// primary source of a multi-source application.
+type AffectedApplication struct {
+	Name           string `json:"name"`
+	Cluster        string `json:"cluster,omitempty"`
+	Namespace      string `json:"namespace"`
+	Environment    string `json:"environment"`
+	SourcePath     string `json:"sourcePath,omitempty"`
+	TargetRevision string `json:"targetRevision,omitempty"`
+	SyncStatus     string `json:"syncStatus"`
+	HealthStatus   string `json:"healthStatus"`
+	Reason         string `json:"reason"`
+	ResourceCount  int    `json:"resourceCount"`
+}
+
+// AffectedResource is a live resource managed by an affected application
+type AffectedResource struct {
+	Cluster     string `json:"cluster,omitempty"`
+	Application string `json:"application"`
+	Kind        string `json:"kind"`
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name"`
+	Health      string `json:"health,omitempty"`
+}
+
+// Redaction records a value that was masked before reaching Claude or the caller.
+// Path locates the value and Reason names the rule that matched it.
+type Redaction struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
 }
@@ -0,0 +1,53 @@
+package models
+
+// FluxObject is the Flux Kustomization or HelmRelease that applies a resource, along
+// with the source it reconciles from. Ready is the status of the Ready condition:
+// "True", "False" or "Unknown".
+type FluxObject struct {
+	Kind                  string            `json:"kind"`
+	Name                  string            `json:"name"`
+	Namespace             string            `json:"namespace"`
+	Labels                map[string]string `json:"labels,omitempty"`
+	Path                  string            `json:"path,omitempty"`
+	TargetNamespace       string            `json:"targetNamespace,omitempty"`
+	Interval              string            `json:"interval,omitempty"`
+	Suspended             bool              `json:"suspended,omitempty"`
+	Ready                 string            `json:"ready"`
+	LastAppliedRevision   string            `json:"lastAppliedRevision,omitempty"`
+	LastAttemptedRevision string            `json:"lastAttemptedRevision,omitempty"`
+	Conditions            []FluxCondition   `json:"conditions,omitempty"`
+
+	// Chart is the HelmChart a HelmRelease installs
+	Chart *FluxSource `json:"chart,omitempty"`
+
+	// Source is the GitRepository, OCIRepository, Bucket or HelmRepository the object
+	// or its chart is built from
+	Source *FluxSource `json:"source,omitempty"`
+}
+
+// FluxSource is a Flux source object. Revision is the revision of its latest artifact,
+// such as "main@sha1:<commit>" for a GitRepository; Commit is the commit SHA taken
+// from it.
+type FluxSource struct {
+	Kind       string          `json:"kind"`
+	Name       string          `json:"name"`
+	Namespace  string          `json:"namespace"`
+	URL        string          `json:"url,omitempty"`
+	Ref        string          `json:"ref,omitempty"`
+	Chart      string          `json:"chart,omitempty"`
+	Version    string          `json:"version,omitempty"`
+	Suspended  bool            `json:"suspended,omitempty"`
+	Ready      string          `json:"ready"`
+	Revision   string          `json:"revision,omitempty"`
+	Commit     string          `json:"commit,omitempty"`
+	Conditions []FluxCondition `json:"conditions,omitempty"`
+}
+
+// FluxCondition is a status condition of a Flux object
+type FluxCondition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
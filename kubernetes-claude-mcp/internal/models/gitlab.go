@@ -1,7 +1,9 @@
 package models
 
-// GitLabProject represents a GitLab project
+// GitLabProject represents a GitLab project, or a repository on another SCM provider
+// named by Provider
 type GitLabProject struct {
+	Provider          string `json:"provider,omitempty"`
 	ID                int    `json:"id"`
 	Name              string `json:"name"`
 	Path              string `json:"path"`
@@ -37,6 +39,17 @@ type GitLabJob struct {
 	} `json:"pipeline"`
 }
 
+// GitLabCommitStatus is the state of an external check reported on a commit, shown
+// alongside the commit's pipeline jobs
+type GitLabCommitStatus struct {
+	ID          int    `json:"id,omitempty"`
+	SHA         string `json:"sha,omitempty"`
+	Status      string `json:"status"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	TargetURL   string `json:"target_url,omitempty"`
+}
+
 // GitLabCommit represents a Git commit in GitLab
 type GitLabCommit struct {
 	ID             string      `json:"id"`
@@ -52,6 +65,15 @@ type GitLabCommit struct {
 	WebURL         string      `json:"web_url"`
 }
 
+// GitLabTreeEntry is a file or directory in a repository tree
+type GitLabTreeEntry struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+}
+
 // GitLabDiff represents a file diff in a commit
 type GitLabDiff struct {
 	OldPath     string `json:"old_path"`
@@ -102,47 +124,37 @@ type GitLabRelease struct {
 	} `json:"assets"`
 }
 
+// GitLabUser is the author, assignee or merger of a merge request
+type GitLabUser struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
 // GitLabMergeRequest represents a merge request in GitLab
 type GitLabMergeRequest struct {
-	ID          int    `json:"id"`
-	IID         int    `json:"iid"`
-	ProjectID   int    `json:"project_id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	State       string `json:"state"`
-	MergedBy    *struct {
-		ID       int    `json:"id"`
-		Username string `json:"username"`
-		Name     string `json:"name"`
-	} `json:"merged_by,omitempty"`
-	MergedAt     interface{} `json:"merged_at"`
-	CreatedAt    interface{} `json:"created_at"`
-	UpdatedAt    interface{} `json:"updated_at"`
-	TargetBranch string      `json:"target_branch"`
-	SourceBranch string      `json:"source_branch"`
-	Author       struct {
-		ID       int    `json:"id"`
-		Username string `json:"username"`
-		Name     string `json:"name"`
-	} `json:"author"`
-	Assignees []struct {
-		ID       int    `json:"id"`
-		Username string `json:"username"`
-		Name     string `json:"name"`
-	} `json:"assignees"`
-	SourceProjectID int    `json:"source_project_id"`
-	TargetProjectID int    `json:"target_project_id"`
-	WebURL          string `json:"web_url"`
-	MergeStatus     string `json:"merge_status"`
-	Changes         []struct {
-		OldPath     string `json:"old_path"`
-		NewPath     string `json:"new_path"`
-		Diff        string `json:"diff"`
-		NewFile     bool   `json:"new_file"`
-		RenamedFile bool   `json:"renamed_file"`
-		DeletedFile bool   `json:"deleted_file"`
-	} `json:"changes,omitempty"`
-	DiffRefs struct {
+	ID              int          `json:"id"`
+	IID             int          `json:"iid"`
+	ProjectID       int          `json:"project_id"`
+	Title           string       `json:"title"`
+	Description     string       `json:"description"`
+	State           string       `json:"state"`
+	MergedBy        *GitLabUser  `json:"merged_by,omitempty"`
+	MergedAt        interface{}  `json:"merged_at"`
+	MergeCommitSHA  string       `json:"merge_commit_sha,omitempty"`
+	SquashCommitSHA string       `json:"squash_commit_sha,omitempty"`
+	CreatedAt       interface{}  `json:"created_at"`
+	UpdatedAt       interface{}  `json:"updated_at"`
+	TargetBranch    string       `json:"target_branch"`
+	SourceBranch    string       `json:"source_branch"`
+	Author          GitLabUser   `json:"author"`
+	Assignees       []GitLabUser `json:"assignees"`
+	SourceProjectID int          `json:"source_project_id"`
+	TargetProjectID int          `json:"target_project_id"`
+	WebURL          string       `json:"web_url"`
+	MergeStatus     string       `json:"merge_status"`
+	Changes         []GitLabDiff `json:"changes,omitempty"`
+	DiffRefs        struct {
 		BaseSHA  string `json:"base_sha"`
 		HeadSHA  string `json:"head_sha"`
 		StartSHA string `json:"start_sha"`
@@ -154,6 +166,7 @@ type GitLabMergeRequest struct {
 		CommitMessages     []string `json:"commit_messages,omitempty"`
 		AffectedFiles      []string `json:"affected_files,omitempty"`
 		HelmChartAffected  bool     `json:"helm_chart_affected,omitempty"`
+		KustomizeAffected  bool     `json:"kustomize_affected,omitempty"`
 		KubernetesManifest bool     `json:"kubernetes_manifests_affected,omitempty"`
 	} `json:"merge_request_context,omitempty"`
 }
@@ -172,6 +185,30 @@ type GitLabMergeRequestComment struct {
 		Username string `json:"username"`
 		Name     string `json:"name"`
 	} `json:"author"`
+	Position   *GitLabDiffPosition `json:"position,omitempty"`
+	Resolvable bool                `json:"resolvable,omitempty"`
+	Resolved   bool                `json:"resolved,omitempty"`
+}
+
+// GitLabDiffPosition pins a merge request discussion to a line of its diff. The SHAs
+// are the merge request's diff refs; set NewLine for an added line, OldLine for a
+// removed one and both for an unchanged one.
+type GitLabDiffPosition struct {
+	PositionType string `json:"position_type"`
+	BaseSHA      string `json:"base_sha"`
+	StartSHA     string `json:"start_sha"`
+	HeadSHA      string `json:"head_sha"`
+	OldPath      string `json:"old_path,omitempty"`
+	NewPath      string `json:"new_path,omitempty"`
+	OldLine      int    `json:"old_line,omitempty"`
+	NewLine      int    `json:"new_line,omitempty"`
+}
+
+// GitLabDiscussion is a thread of comments on a merge request
+type GitLabDiscussion struct {
+	ID             string                      `json:"id"`
+	IndividualNote bool                        `json:"individual_note"`
+	Notes          []GitLabMergeRequestComment `json:"notes"`
 }
 
 // GitLabMergeRequestApproval represents approval information for a merge request
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// HelmRelease is one revision of a Helm release, read from its release Secret
+type HelmRelease struct {
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace"`
+	Revision     int       `json:"revision"`
+	Status       string    `json:"status"`
+	Chart        string    `json:"chart"`
+	ChartVersion string    `json:"chartVersion"`
+	AppVersion   string    `json:"appVersion,omitempty"`
+	Description  string    `json:"description,omitempty"`
+	Updated      time.Time `json:"updated"`
+}
+
+// HelmReleaseSummary is the latest revision of a release along with its revision
+// history, newest first
+type HelmReleaseSummary struct {
+	HelmRelease
+	History []HelmRelease `json:"history"`
+}
+
+// HelmReleaseDetail is a release revision with its values and rendered manifest.
+// Values are the chart's defaults merged with UserValues, the values supplied when
+// the revision was installed or upgraded.
+type HelmReleaseDetail struct {
+	HelmRelease
+	Values     map[string]interface{}   `json:"values"`
+	UserValues map[string]interface{}   `json:"userValues"`
+	Manifest   []map[string]interface{} `json:"manifest"`
+}
+
+// HelmReleaseDiff compares the values and rendered objects of two revisions of a release
+type HelmReleaseDiff struct {
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace"`
+	From      HelmRelease    `json:"from"`
+	To        HelmRelease    `json:"to"`
+	Values    []FieldChange  `json:"values"`
+	Resources []ResourceDiff `json:"resources"`
+}
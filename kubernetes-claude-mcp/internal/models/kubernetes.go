@@ -70,6 +70,22 @@ type K8sPodStatus struct {
 	} `json:"containerStatuses"`
 }
 
+// ImpactedResource is a live resource that a change reaches through the relationships
+// between resources, such as an Ingress routing to a changed Service or a Pod mounting
+// a changed ConfigMap. Path lists the relationships from the nearest changed resource
+// to this one, Depth is their number, and Changes counts the changed resources that
+// reach it. Rank orders a list of impacted resources, starting at 1.
+type ImpactedResource struct {
+	Rank      int      `json:"rank"`
+	Cluster   string   `json:"cluster,omitempty"`
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name"`
+	Depth     int      `json:"depth"`
+	Changes   int      `json:"changes"`
+	Path      []string `json:"path"`
+}
+
 // ExtractResourceMeta extracts common metadata from an unstructured resource
 func ExtractResourceMeta(obj unstructured.Unstructured) K8sResourceMeta {
 	meta := K8sResourceMeta{
@@ -0,0 +1,90 @@
+package models
+
+// Kinds of source a manifest diff is rendered from
+const (
+	ManifestSourceHelm      = "helm"
+	ManifestSourceKustomize = "kustomize"
+	ManifestSourceManifests = "manifests"
+)
+
+// Resource changes in a manifest diff
+const (
+	ResourceAdded    = "added"
+	ResourceRemoved  = "removed"
+	ResourceModified = "modified"
+)
+
+// ManifestDiff is the change to the objects rendered from one chart or manifest
+// directory between two commits. Violations are the policy rules broken by the
+// objects the change adds, modifies or removes.
+type ManifestDiff struct {
+	Path       string            `json:"path"`
+	Type       string            `json:"type"`
+	BaseSHA    string            `json:"baseSha"`
+	HeadSHA    string            `json:"headSha"`
+	Resources  []ResourceDiff    `json:"resources"`
+	Violations []PolicyViolation `json:"violations,omitempty"`
+	Errors     []string          `json:"errors,omitempty"`
+}
+
+// ResourceDiff is the change to one Kubernetes object. Fields lists the changed
+// fields of a modified object; ImageChanges and ReplicaChange summarize the changes
+// that matter most for a rollout.
+type ResourceDiff struct {
+	APIVersion    string         `json:"apiVersion"`
+	Kind          string         `json:"kind"`
+	Namespace     string         `json:"namespace,omitempty"`
+	Name          string         `json:"name"`
+	Change        string         `json:"change"`
+	Fields        []FieldChange  `json:"fields,omitempty"`
+	ImageChanges  []ImageChange  `json:"imageChanges,omitempty"`
+	ReplicaChange *ReplicaChange `json:"replicaChange,omitempty"`
+}
+
+// FieldChange is a changed field, addressed by a dotted path. List items with a name
+// are addressed by it, as in spec.template.spec.containers[api].image.
+type FieldChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// ImageChange is a container whose image was added, removed or changed
+type ImageChange struct {
+	Container string `json:"container"`
+	Before    string `json:"before,omitempty"`
+	After     string `json:"after,omitempty"`
+}
+
+// ReplicaChange is a change to spec.replicas; nil means the field is unset
+type ReplicaChange struct {
+	Before *int64 `json:"before,omitempty"`
+	After  *int64 `json:"after,omitempty"`
+}
+
+// Severities of a manifest finding
+const (
+	FindingWarning  = "warning"
+	FindingCritical = "critical"
+)
+
+// Rules a manifest finding can come from
+const (
+	RuleImageLatest    = "image-latest"
+	RuleLimitsRemoved  = "limits-removed"
+	RuleReplicasToZero = "replicas-zero"
+)
+
+// ManifestFinding is a risky change pinned to a line of a merge request's diff. An
+// added line is located by NewPath and NewLine, a removed line by OldPath and OldLine,
+// as GitLab diff positions expect. Text is the line's content.
+type ManifestFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	OldPath  string `json:"oldPath,omitempty"`
+	NewPath  string `json:"newPath"`
+	OldLine  int    `json:"oldLine,omitempty"`
+	NewLine  int    `json:"newLine,omitempty"`
+	Text     string `json:"text"`
+}
@@ -0,0 +1,31 @@
+package models
+
+// Verdicts of a policy check. A warn verdict does not block a merge request.
+const (
+	PolicyPass = "pass"
+	PolicyWarn = "warn"
+	PolicyFail = "fail"
+)
+
+// PolicyResult is the outcome of checking the manifests a merge request renders
+// against the policy rules. Errors lists the charts and directories that could not
+// be rendered, and so were not checked.
+type PolicyResult struct {
+	Verdict    string            `json:"verdict"`
+	Violations []PolicyViolation `json:"violations"`
+	Errors     []string          `json:"errors,omitempty"`
+}
+
+// PolicyViolation is a rendered object breaking a policy rule. Source is the chart or
+// manifest directory the object is rendered from and Field the offending field, in the
+// notation of FieldChange paths. Severity is PolicyWarn or PolicyFail.
+type PolicyViolation struct {
+	Rule      string `json:"rule"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+	Source    string `json:"source"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Field     string `json:"field,omitempty"`
+}
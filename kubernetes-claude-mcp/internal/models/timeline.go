@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Timeline entry types
+const (
+	TimelineEvent       = "event"
+	TimelineSync        = "sync"
+	TimelineHelmRelease = "helmRelease"
+	TimelineCommit      = "commit"
+	TimelinePipeline    = "pipeline"
+	TimelineDeployment  = "deployment"
+)
+
+// TimelineScope selects what a timeline covers: a resource when Kind and Name are
+// set, an ArgoCD application when Application is set, and otherwise a namespace. An
+// empty Cluster selects the default cluster, or the one an application deploys to.
+type TimelineScope struct {
+	Cluster     string `json:"cluster,omitempty"`
+	Namespace   string `json:"namespace,omitempty"`
+	Kind        string `json:"kind,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Application string `json:"application,omitempty"`
+}
+
+// Timeline merges the Kubernetes events, GitOps syncs, Helm releases, commits,
+// pipelines and deployments behind a scope into one list, oldest first
+type Timeline struct {
+	TimelineScope
+	Since   time.Time       `json:"since"`
+	Until   time.Time       `json:"until"`
+	Entries []TimelineEntry `json:"entries"`
+	Errors  []string        `json:"errors,omitempty"`
+}
+
+// TimelineEntry is one thing that happened. Source is the system it was read from,
+// such as Kubernetes, ArgoCD or GitLab, and Subject the object it happened to, such
+// as a Pod, an application or a project. URL links to it in that system, when it
+// has a web page.
+type TimelineEntry struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Source   string    `json:"source"`
+	Subject  string    `json:"subject"`
+	Summary  string    `json:"summary"`
+	Status   string    `json:"status,omitempty"`
+	Revision string    `json:"revision,omitempty"`
+	URL      string    `json:"url,omitempty"`
+}
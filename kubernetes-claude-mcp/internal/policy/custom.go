@@ -0,0 +1,214 @@
+package policy
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+)
+
+// segment is one step of a field path: a map key, every list item ([*]), or a list
+// item by name or a map key that contains dots ([x])
+type segment struct {
+	key      string
+	all      bool
+	brackets bool
+}
+
+// field is a field a path leads to. Missing fields are reported with the path they
+// would have.
+type field struct {
+	path  string
+	value interface{}
+	found bool
+}
+
+// newCustomRule builds a rule from its configuration
+func newCustomRule(cfg config.PolicyRule) (rule, error) {
+	segments, err := parsePath(cfg.Path)
+	if err != nil {
+		return rule{}, err
+	}
+
+	var pattern *regexp.Regexp
+	if cfg.Condition == config.PolicyConditionMatches {
+		if pattern, err = regexp.Compile(cfg.Value); err != nil {
+			return rule{}, fmt.Errorf("failed to compile pattern %q: %w", cfg.Value, err)
+		}
+	}
+
+	severity := cfg.Severity
+	if severity == "" {
+		severity = models.PolicyFail
+	}
+
+	check := func(obj map[string]interface{}) []violation {
+		if !matchesKind(cfg.Kinds, obj) {
+			return nil
+		}
+
+		var violations []violation
+		for _, f := range resolve(obj, segments) {
+			text := fmt.Sprint(f.value)
+			var message string
+			switch cfg.Condition {
+			case config.PolicyConditionExists:
+				if !f.found {
+					message = fmt.Sprintf("%s is required", f.path)
+				}
+			case config.PolicyConditionAbsent:
+				if f.found {
+					message = fmt.Sprintf("%s must not be set", f.path)
+				}
+			case config.PolicyConditionEquals:
+				if !f.found || text != cfg.Value {
+					message = fmt.Sprintf("%s must be %q", f.path, cfg.Value)
+				}
+			case config.PolicyConditionMatches:
+				if !f.found || !pattern.MatchString(text) {
+					message = fmt.Sprintf("%s must match %q", f.path, cfg.Value)
+				}
+			}
+			if message == "" {
+				continue
+			}
+			if cfg.Message != "" {
+				message = cfg.Message
+			}
+			violations = append(violations, violation{field: f.path, message: message})
+		}
+		return violations
+	}
+
+	return rule{name: cfg.Name, severity: severity, check: check}, nil
+}
+
+func matchesKind(kinds []string, obj map[string]interface{}) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	kind, _ := obj["kind"].(string)
+	for _, k := range kinds {
+		if strings.EqualFold(k, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePath splits a dotted field path into segments
+func parsePath(path string) ([]segment, error) {
+	var segments []segment
+	for rest := path; rest != ""; {
+		if rest[0] == '[' {
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket in field path %q", path)
+			}
+			key := rest[1:end]
+			if key == "" {
+				return nil, fmt.Errorf("empty brackets in field path %q", path)
+			}
+			segments = append(segments, segment{key: key, all: key == "*", brackets: true})
+			rest = strings.TrimPrefix(rest[end+1:], ".")
+			continue
+		}
+
+		end := strings.IndexAny(rest, ".[")
+		if end < 0 {
+			end = len(rest)
+		}
+		if end == 0 {
+			return nil, fmt.Errorf("empty field in field path %q", path)
+		}
+		segments = append(segments, segment{key: rest[:end]})
+		rest = rest[end:]
+		if strings.HasPrefix(rest, ".") {
+			rest = rest[1:]
+			if rest == "" {
+				return nil, fmt.Errorf("field path %q ends with a dot", path)
+			}
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("empty field path")
+	}
+	return segments, nil
+}
+
+// resolve returns the fields a path leads to in an object, one for each list item a
+// [*] matches
+func resolve(obj map[string]interface{}, segments []segment) []field {
+	var fields []field
+	walk(obj, segments, "", &fields)
+	return fields
+}
+
+func walk(value interface{}, segments []segment, path string, fields *[]field) {
+	if len(segments) == 0 {
+		*fields = append(*fields, field{path: path, value: value, found: true})
+		return
+	}
+
+	seg := segments[0]
+	if seg.all {
+		items, _ := value.([]interface{})
+		for i, item := range items {
+			walk(item, segments[1:], path+"["+itemName(item, i)+"]", fields)
+		}
+		return
+	}
+
+	var next interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		next = v[seg.key]
+	case []interface{}:
+		if seg.brackets {
+			for i, item := range v {
+				if itemName(item, i) == seg.key {
+					next = item
+					break
+				}
+			}
+		}
+	}
+	path = joinSegment(path, seg)
+
+	if next == nil {
+		// A missing field has no list items for a later [*] to match
+		for _, rest := range segments[1:] {
+			if rest.all {
+				return
+			}
+			path = joinSegment(path, rest)
+		}
+		*fields = append(*fields, field{path: path})
+		return
+	}
+	walk(next, segments[1:], path, fields)
+}
+
+// itemName names a list item by its name field, or by its position when it has none
+func itemName(item interface{}, i int) string {
+	if m, ok := item.(map[string]interface{}); ok {
+		if name, ok := m["name"].(string); ok && name != "" {
+			return name
+		}
+	}
+	return strconv.Itoa(i)
+}
+
+func joinSegment(path string, seg segment) string {
+	switch {
+	case seg.brackets:
+		return path + "[" + seg.key + "]"
+	case path == "":
+		return seg.key
+	default:
+		return path + "." + seg.key
+	}
+}
@@ -0,0 +1,199 @@
+package policy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/manifest"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+)
+
+// violation is a rule broken by one field of an object
+type violation struct {
+	field   string
+	message string
+}
+
+// rule checks one object. Rules with removed set are applied to the objects a merge
+// request removes rather than to the ones it adds or modifies.
+type rule struct {
+	name     string
+	severity string
+	removed  bool
+	check    func(obj map[string]interface{}) []violation
+}
+
+// Engine checks rendered objects against the built-in rules, less any that are
+// disabled, and the custom rules from the configuration. A nil Engine checks nothing.
+type Engine struct {
+	rules []rule
+}
+
+// NewEngine builds a policy engine from the policy configuration
+func NewEngine(cfg config.PolicyConfig) (*Engine, error) {
+	disabled := make(map[string]bool, len(cfg.Disabled))
+	for _, name := range cfg.Disabled {
+		if !isBuiltin(name) {
+			return nil, fmt.Errorf("cannot disable unknown policy rule %q", name)
+		}
+		disabled[name] = true
+	}
+	for name := range cfg.Severities {
+		if !isBuiltin(name) {
+			return nil, fmt.Errorf("cannot set the severity of unknown policy rule %q", name)
+		}
+	}
+
+	e := &Engine{}
+	for _, builtin := range builtinRules {
+		if disabled[builtin.name] {
+			continue
+		}
+		if severity, ok := cfg.Severities[builtin.name]; ok {
+			builtin.severity = severity
+		}
+		e.rules = append(e.rules, builtin)
+	}
+
+	for _, ruleCfg := range cfg.Rules {
+		if isBuiltin(ruleCfg.Name) {
+			return nil, fmt.Errorf("custom policy rule %q has the name of a built-in rule", ruleCfg.Name)
+		}
+		custom, err := newCustomRule(ruleCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build policy rule %s: %w", ruleCfg.Name, err)
+		}
+		e.rules = append(e.rules, custom)
+	}
+	return e, nil
+}
+
+// Default returns an engine with the built-in rules
+func Default() *Engine {
+	e, _ := NewEngine(config.PolicyConfig{})
+	return e
+}
+
+// Check applies the rules to the objects rendered from one chart or manifest
+// directory. Only the objects a merge request adds or modifies are checked, so
+// violations it does not touch do not hold it up; the objects it removes are checked
+// by the rules that guard against removals.
+func (e *Engine) Check(source string, before, after []map[string]interface{}) []models.PolicyViolation {
+	if e == nil {
+		return nil
+	}
+
+	base := make(map[string]map[string]interface{}, len(before))
+	for _, obj := range before {
+		base[manifest.Key(obj)] = obj
+	}
+	head := make(map[string]bool, len(after))
+
+	var violations []models.PolicyViolation
+	for _, obj := range after {
+		key := manifest.Key(obj)
+		head[key] = true
+		if previous, ok := base[key]; ok && reflect.DeepEqual(previous, obj) {
+			continue
+		}
+		violations = append(violations, e.checkObject(source, obj, false)...)
+	}
+	for _, obj := range before {
+		if !head[manifest.Key(obj)] {
+			violations = append(violations, e.checkObject(source, obj, true)...)
+		}
+	}
+	return violations
+}
+
+func (e *Engine) checkObject(source string, obj map[string]interface{}, removed bool) []models.PolicyViolation {
+	_, kind, namespace, name := manifest.Identity(obj)
+
+	var violations []models.PolicyViolation
+	for _, r := range e.rules {
+		if r.removed != removed {
+			continue
+		}
+		for _, v := range r.check(obj) {
+			violations = append(violations, models.PolicyViolation{
+				Rule:      r.name,
+				Severity:  r.severity,
+				Message:   v.message,
+				Source:    source,
+				Kind:      kind,
+				Namespace: namespace,
+				Name:      name,
+				Field:     v.field,
+			})
+		}
+	}
+	return violations
+}
+
+// Result gathers the violations of a merge request's manifest diffs into a verdict.
+// Any failing violation fails the merge request. Warnings, and charts or directories
+// that could not be rendered and so went unchecked, give a warn verdict.
+func Result(diffs []models.ManifestDiff) *models.PolicyResult {
+	result := &models.PolicyResult{
+		Verdict:    models.PolicyPass,
+		Violations: []models.PolicyViolation{},
+	}
+	for _, diff := range diffs {
+		result.Violations = append(result.Violations, diff.Violations...)
+		for _, err := range diff.Errors {
+			result.Errors = append(result.Errors, diff.Path+": "+err)
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		result.Verdict = models.PolicyWarn
+	}
+	for _, v := range result.Violations {
+		switch v.Severity {
+		case models.PolicyFail:
+			result.Verdict = models.PolicyFail
+		case models.PolicyWarn:
+			if result.Verdict == models.PolicyPass {
+				result.Verdict = models.PolicyWarn
+			}
+		}
+	}
+	return result
+}
+
+// Summary counts a result's violations and unrendered sources in one line, such as a
+// commit status description
+func Summary(result *models.PolicyResult) string {
+	failures, warnings := 0, 0
+	for _, v := range result.Violations {
+		if v.Severity == models.PolicyFail {
+			failures++
+		} else {
+			warnings++
+		}
+	}
+
+	var parts []string
+	if failures > 0 {
+		parts = append(parts, plural(failures, "failing check"))
+	}
+	if warnings > 0 {
+		parts = append(parts, plural(warnings, "warning"))
+	}
+	if n := len(result.Errors); n > 0 {
+		parts = append(parts, plural(n, "source")+" not rendered")
+	}
+	if len(parts) == 0 {
+		return "All policy checks passed"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("1 %s", noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,199 @@
+package policy
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/manifest"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+)
+
+const baseManifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: legacy
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+      - name: legacy
+        image: legacy:latest
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: registry.example.com/web:1.4.2
+        resources:
+          requests: {cpu: 100m}
+          limits: {cpu: 500m}
+        readinessProbe: {httpGet: {path: /healthz, port: 8080}}
+        livenessProbe: {httpGet: {path: /healthz, port: 8080}}
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: web
+  namespace: prod
+spec:
+  minAvailable: 2
+`
+
+const headManifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: legacy
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+      - name: legacy
+        image: legacy:latest
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: prod
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: web
+        image: registry.example.com/web:main
+        securityContext: {privileged: true}
+        resources:
+          requests: {cpu: 100m}
+          limits: {cpu: 500m}
+        readinessProbe: {httpGet: {path: /healthz, port: 8080}}
+        livenessProbe: {httpGet: {path: /healthz, port: 8080}}
+      volumes:
+      - name: docker
+        hostPath: {path: /var/run/docker.sock}
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: report
+  namespace: prod
+  labels:
+    app.kubernetes.io/name: report
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: report
+            image: report
+`
+
+func parse(t *testing.T, content string) []map[string]interface{} {
+	t.Helper()
+	objects, err := manifest.Parse(content)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return objects
+}
+
+func TestCheck(t *testing.T) {
+	engine, err := NewEngine(config.PolicyConfig{
+		Disabled:   []string{RuleProbesMissing},
+		Severities: map[string]string{RuleMutableImageTag: models.PolicyFail},
+		Rules: []config.PolicyRule{
+			{
+				Name:      "team-label",
+				Kinds:     []string{"deployment", "CronJob"},
+				Path:      "metadata.labels[app.kubernetes.io/name]",
+				Condition: config.PolicyConditionExists,
+				Severity:  models.PolicyWarn,
+			},
+			{
+				Name:      "non-root",
+				Path:      "spec.template.spec.containers[*].securityContext.runAsNonRoot",
+				Condition: config.PolicyConditionEquals,
+				Value:     "true",
+				Message:   "Containers must run as non-root",
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEngine: %v", err)
+	}
+
+	type violation struct {
+		rule, severity, name, field string
+	}
+	var got []violation
+	for _, v := range engine.Check("deploy", parse(t, baseManifests), parse(t, headManifests)) {
+		if v.Source != "deploy" {
+			t.Errorf("violation %s has source %q, want deploy", v.Rule, v.Source)
+		}
+		got = append(got, violation{v.Rule, v.Severity, v.Name, v.Field})
+	}
+
+	// The unchanged legacy Deployment is not checked, and the CronJob's containers are
+	// not under spec.template so the non-root rule has nothing to match
+	want := []violation{
+		{RulePrivilegedContainer, models.PolicyFail, "web", "spec.template.spec.containers[web].securityContext.privileged"},
+		{RuleMutableImageTag, models.PolicyFail, "web", "spec.template.spec.containers[web].image"},
+		{RuleHostPath, models.PolicyFail, "web", "spec.template.spec.volumes[docker].hostPath"},
+		{"team-label", models.PolicyWarn, "web", "metadata.labels[app.kubernetes.io/name]"},
+		{"non-root", models.PolicyFail, "web", "spec.template.spec.containers[web].securityContext.runAsNonRoot"},
+		{RuleResourcesMissing, models.PolicyWarn, "report", "spec.jobTemplate.spec.template.spec.containers[report].resources"},
+		{RuleMutableImageTag, models.PolicyFail, "report", "spec.jobTemplate.spec.template.spec.containers[report].image"},
+		{RulePDBRemoved, models.PolicyWarn, "web", ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestNewEngineRejectsUnknownRules(t *testing.T) {
+	configs := []config.PolicyConfig{
+		{Disabled: []string{"no-such-rule"}},
+		{Severities: map[string]string{"no-such-rule": models.PolicyFail}},
+		{Rules: []config.PolicyRule{{Name: RuleHostPath, Path: "spec", Condition: config.PolicyConditionExists}}},
+		{Rules: []config.PolicyRule{{Name: "bad-path", Path: "spec..template", Condition: config.PolicyConditionExists}}},
+	}
+	for _, cfg := range configs {
+		if _, err := NewEngine(cfg); err == nil {
+			t.Errorf("NewEngine(%+v) succeeded, want an error", cfg)
+		}
+	}
+}
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		name  string
+		diffs []models.ManifestDiff
+		want  string
+	}{
+		{"clean", []models.ManifestDiff{{Path: "deploy"}}, models.PolicyPass},
+		{"warning", []models.ManifestDiff{{Violations: []models.PolicyViolation{{Severity: models.PolicyWarn}}}}, models.PolicyWarn},
+		{"unrendered", []models.ManifestDiff{{Path: "chart", Errors: []string{"failed to render"}}}, models.PolicyWarn},
+		{"failure", []models.ManifestDiff{
+			{Violations: []models.PolicyViolation{{Severity: models.PolicyFail}}},
+			{Violations: []models.PolicyViolation{{Severity: models.PolicyWarn}}},
+		}, models.PolicyFail},
+	}
+	for _, tt := range tests {
+		if got := Result(tt.diffs).Verdict; got != tt.want {
+			t.Errorf("%s: verdict = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
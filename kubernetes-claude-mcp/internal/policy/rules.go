@@ -0,0 +1,257 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// Built-in rules
+const (
+	RuleResourcesMissing    = "resources-missing"
+	RulePrivilegedContainer = "privileged-container"
+	RuleMutableImageTag     = "mutable-image-tag"
+	RuleProbesMissing       = "probes-missing"
+	RuleHostPath            = "host-path"
+	RulePDBRemoved          = "pdb-removed"
+)
+
+// builtinRules are applied unless disabled. Only privileged containers and hostPath
+// volumes, which give a pod control of its node, fail a merge request by default.
+var builtinRules = []rule{
+	{name: RuleResourcesMissing, severity: models.PolicyWarn, check: checkResources},
+	{name: RulePrivilegedContainer, severity: models.PolicyFail, check: checkPrivileged},
+	{name: RuleMutableImageTag, severity: models.PolicyWarn, check: checkImageTags},
+	{name: RuleProbesMissing, severity: models.PolicyWarn, check: checkProbes},
+	{name: RuleHostPath, severity: models.PolicyFail, check: checkHostPath},
+	{name: RulePDBRemoved, severity: models.PolicyWarn, removed: true, check: checkPDBRemoved},
+}
+
+func isBuiltin(name string) bool {
+	for _, r := range builtinRules {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// podSpecPaths maps workload kinds to where they keep their pod spec
+var podSpecPaths = map[string][]string{
+	"Pod":                   {"spec"},
+	"Deployment":            {"spec", "template", "spec"},
+	"StatefulSet":           {"spec", "template", "spec"},
+	"DaemonSet":             {"spec", "template", "spec"},
+	"ReplicaSet":            {"spec", "template", "spec"},
+	"ReplicationController": {"spec", "template", "spec"},
+	"Job":                   {"spec", "template", "spec"},
+	"CronJob":               {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// mutableTags are tags that registries conventionally move to new images
+var mutableTags = map[string]bool{
+	"latest":  true,
+	"main":    true,
+	"master":  true,
+	"develop": true,
+	"dev":     true,
+	"edge":    true,
+	"nightly": true,
+	"stable":  true,
+}
+
+// container is a container of a pod spec with the path of its fields
+type container struct {
+	name  string
+	path  string
+	init  bool
+	value map[string]interface{}
+}
+
+// podSpec returns a workload's pod spec and its path
+func podSpec(obj map[string]interface{}) (map[string]interface{}, string, bool) {
+	kind, _ := obj["kind"].(string)
+	fields, ok := podSpecPaths[kind]
+	if !ok {
+		return nil, "", false
+	}
+
+	current := obj
+	for _, field := range fields {
+		next, ok := current[field].(map[string]interface{})
+		if !ok {
+			return nil, "", false
+		}
+		current = next
+	}
+	return current, strings.Join(fields, "."), true
+}
+
+// containers returns the init containers and containers of a workload
+func containers(obj map[string]interface{}) []container {
+	spec, specPath, ok := podSpec(obj)
+	if !ok {
+		return nil
+	}
+
+	var found []container
+	for _, field := range []string{"initContainers", "containers"} {
+		items, _ := spec[field].([]interface{})
+		for i, item := range items {
+			value, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := value["name"].(string)
+			if name == "" {
+				name = fmt.Sprint(i)
+			}
+			found = append(found, container{
+				name:  name,
+				path:  fmt.Sprintf("%s.%s[%s]", specPath, field, name),
+				init:  field == "initContainers",
+				value: value,
+			})
+		}
+	}
+	return found
+}
+
+// checkResources flags containers without resource requests or limits
+func checkResources(obj map[string]interface{}) []violation {
+	var violations []violation
+	for _, c := range containers(obj) {
+		resources, _ := c.value["resources"].(map[string]interface{})
+		requests, _ := resources["requests"].(map[string]interface{})
+		limits, _ := resources["limits"].(map[string]interface{})
+
+		var missing string
+		switch {
+		case len(requests) == 0 && len(limits) == 0:
+			missing = "requests or limits"
+		case len(requests) == 0:
+			missing = "requests"
+		case len(limits) == 0:
+			missing = "limits"
+		default:
+			continue
+		}
+		violations = append(violations, violation{
+			field:   c.path + ".resources",
+			message: fmt.Sprintf("Container %s sets no resource %s", c.name, missing),
+		})
+	}
+	return violations
+}
+
+// checkPrivileged flags privileged containers
+func checkPrivileged(obj map[string]interface{}) []violation {
+	var violations []violation
+	for _, c := range containers(obj) {
+		securityContext, _ := c.value["securityContext"].(map[string]interface{})
+		if privileged, _ := securityContext["privileged"].(bool); privileged {
+			violations = append(violations, violation{
+				field:   c.path + ".securityContext.privileged",
+				message: fmt.Sprintf("Container %s runs privileged, with full access to its node", c.name),
+			})
+		}
+	}
+	return violations
+}
+
+// checkImageTags flags images without a tag or with a tag that is moved to new
+// images, since the image deployed then changes whenever a pod is rescheduled
+func checkImageTags(obj map[string]interface{}) []violation {
+	var violations []violation
+	for _, c := range containers(obj) {
+		image, _ := c.value["image"].(string)
+		if image == "" || strings.Contains(image, "@") {
+			continue
+		}
+
+		name := image[strings.LastIndex(image, "/")+1:]
+		_, tag, tagged := strings.Cut(name, ":")
+		var message string
+		switch {
+		case !tagged:
+			message = fmt.Sprintf("Container %s uses image %s without a tag, so it resolves to latest", c.name, image)
+		case mutableTags[strings.ToLower(tag)]:
+			message = fmt.Sprintf("Container %s uses image %s, whose tag is moved to new images", c.name, image)
+		default:
+			continue
+		}
+		violations = append(violations, violation{field: c.path + ".image", message: message})
+	}
+	return violations
+}
+
+// checkProbes flags long-running containers without readiness or liveness probes.
+// Jobs and init containers run to completion and are not probed.
+func checkProbes(obj map[string]interface{}) []violation {
+	if kind, _ := obj["kind"].(string); kind == "Job" || kind == "CronJob" {
+		return nil
+	}
+
+	var violations []violation
+	for _, c := range containers(obj) {
+		if c.init {
+			continue
+		}
+		_, readiness := c.value["readinessProbe"].(map[string]interface{})
+		_, liveness := c.value["livenessProbe"].(map[string]interface{})
+
+		var missing string
+		switch {
+		case !readiness && !liveness:
+			missing = "readiness or liveness probe"
+		case !readiness:
+			missing = "readiness probe"
+		case !liveness:
+			missing = "liveness probe"
+		default:
+			continue
+		}
+		violations = append(violations, violation{
+			field:   c.path,
+			message: fmt.Sprintf("Container %s has no %s", c.name, missing),
+		})
+	}
+	return violations
+}
+
+// checkHostPath flags hostPath volumes
+func checkHostPath(obj map[string]interface{}) []violation {
+	spec, specPath, ok := podSpec(obj)
+	if !ok {
+		return nil
+	}
+
+	var violations []violation
+	volumes, _ := spec["volumes"].([]interface{})
+	for _, item := range volumes {
+		volume, _ := item.(map[string]interface{})
+		hostPath, ok := volume["hostPath"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := volume["name"].(string)
+		path, _ := hostPath["path"].(string)
+		violations = append(violations, violation{
+			field:   fmt.Sprintf("%s.volumes[%s].hostPath", specPath, name),
+			message: fmt.Sprintf("Volume %s mounts %s from the node's filesystem", name, path),
+		})
+	}
+	return violations
+}
+
+// checkPDBRemoved flags a removed PodDisruptionBudget
+func checkPDBRemoved(obj map[string]interface{}) []violation {
+	if kind, _ := obj["kind"].(string); kind != "PodDisruptionBudget" {
+		return nil
+	}
+	return []violation{{
+		message: "PodDisruptionBudget is removed, so node drains and other voluntary disruptions " +
+			"can take down every replica at once",
+	}}
+}
@@ -0,0 +1,363 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/config"
+)
+
+// Mask replaces every redacted value
+const Mask = "[REDACTED]"
+
+// Reasons recorded in the redaction audit
+const (
+	ReasonSecretData   = "secret-data"
+	ReasonSensitiveKey = "sensitive-key"
+	ReasonHighEntropy  = "high-entropy"
+)
+
+// Defaults for high-entropy detection. Long random tokens in base64 or similar
+// alphabets score above 4.5 bits per character; words, paths and hex digests score
+// below.
+const (
+	defaultEntropyThreshold = 4.5
+	defaultMinEntropyLength = 24
+)
+
+// shortTokenEntropyRatio scales the threshold for short tokens. A token of n characters
+// carries at most log2(n) bits per character, and a random one repeats enough
+// characters to fall about 10% short of that, so a 32-character base62 token typically
+// scores 4.4 to 4.6. Tokens are held to the lower of the threshold and this fraction of
+// their maximum; random hex still falls below it.
+const shortTokenEntropyRatio = 0.85
+
+// lastAppliedAnnotation holds a full copy of the object, including a Secret's data
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// defaultSensitiveKeys are matched against map keys and env var names after
+// lower-casing and removing separators
+var defaultSensitiveKeys = []string{
+	"password", "passwd", "passphrase", "secret", "token", "apikey", "accesskey",
+	"privatekey", "credential", "authorization", "connectionstring", "dsn",
+}
+
+// referenceSuffixes mark keys that name a secret rather than hold one, such as
+// secretName, tokenPath or passwordSecretRef
+var referenceSuffixes = []string{"name", "ref", "path", "file"}
+
+type pattern struct {
+	name        string
+	re          *regexp.Regexp
+	replacement string
+}
+
+// builtinPatterns match well-known credential formats inside free text
+var builtinPatterns = []pattern{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), Mask},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), Mask},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`), Mask},
+	{"bearer-token", regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9\-._~+/]{16,}=*`), "${1}" + Mask},
+	{"url-credentials", regexp.MustCompile(`(?i)\b([a-z][a-z0-9+.-]*://[^/\s:@]+:)[^@\s/]+@`), "${1}" + Mask + "@"},
+	{"scm-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|glpat-[A-Za-z0-9_-]{20,})\b`), Mask},
+	{"key-value", regexp.MustCompile(`(?i)\b((?:password|passwd|secret|token|api[_-]?key)["']?\s*[:=]\s*["']?)[^\s"',;\[][^\s"',;]*`), "${1}" + Mask},
+}
+
+// entropyCandidate finds tokens long enough to be checked for randomness
+var entropyCandidate = regexp.MustCompile(`[A-Za-z0-9+/_\-]{16,}={0,2}`)
+
+// Redactor masks Secret data, values under sensitive keys, credential patterns and
+// high-entropy strings. A nil or disabled Redactor returns its input unchanged.
+type Redactor struct {
+	disabled         bool
+	sensitiveKeys    []string
+	patterns         []pattern
+	entropyThreshold float64
+	minEntropyLength int
+}
+
+// NewRedactor builds a redactor from the redaction configuration
+func NewRedactor(cfg config.RedactionConfig) (*Redactor, error) {
+	r := &Redactor{
+		disabled:         cfg.Disabled,
+		sensitiveKeys:    append([]string{}, defaultSensitiveKeys...),
+		patterns:         append([]pattern{}, builtinPatterns...),
+		entropyThreshold: cfg.EntropyThreshold,
+		minEntropyLength: cfg.MinEntropyLength,
+	}
+	for _, key := range cfg.SensitiveKeys {
+		r.sensitiveKeys = append(r.sensitiveKeys, normalizeKey(key))
+	}
+	for i, expr := range cfg.Patterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile redaction pattern %q: %w", expr, err)
+		}
+		r.patterns = append(r.patterns, pattern{name: "custom-" + strconv.Itoa(i+1), re: re, replacement: Mask})
+	}
+	if r.entropyThreshold == 0 {
+		r.entropyThreshold = defaultEntropyThreshold
+	}
+	if r.minEntropyLength <= 0 {
+		r.minEntropyLength = defaultMinEntropyLength
+	}
+	return r, nil
+}
+
+// Default returns a redactor with the built-in rules
+func Default() *Redactor {
+	r, _ := NewRedactor(config.RedactionConfig{})
+	return r
+}
+
+// Enabled reports whether the redactor masks anything
+func (r *Redactor) Enabled() bool {
+	return r != nil && !r.disabled
+}
+
+// RedactObject returns a redacted copy of a Kubernetes object, leaving the original
+// untouched, along with an audit of the masked paths
+func (r *Redactor) RedactObject(obj map[string]interface{}) (map[string]interface{}, []models.Redaction) {
+	if !r.Enabled() || obj == nil {
+		return obj, nil
+	}
+	a := &audit{}
+	redacted, _ := r.walk("", obj, false, a).(map[string]interface{})
+	return redacted, a.entries
+}
+
+// RedactValue redacts any JSON-serializable value, such as a tool result or a list of
+// objects. Values that do not serialize are returned unchanged.
+func (r *Redactor) RedactValue(path string, value interface{}) (interface{}, []models.Redaction) {
+	if !r.Enabled() || value == nil {
+		return value, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value, nil
+	}
+	// Decode numbers as json.Number so large integers survive the round trip
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return value, nil
+	}
+	a := &audit{}
+	return r.walk(path, generic, false, a), a.entries
+}
+
+// RedactText masks credential patterns and high-entropy strings in free text, such
+// as a prompt, logs or a diff. Path labels the text in the audit.
+func (r *Redactor) RedactText(path, text string) (string, []models.Redaction) {
+	if !r.Enabled() || text == "" {
+		return text, nil
+	}
+	a := &audit{}
+	return r.redactString(path, text, a), a.entries
+}
+
+// walk returns a redacted copy of a decoded JSON value. inSecret is set below a
+// Secret's data and stringData, where every value is masked.
+func (r *Redactor) walk(path string, value interface{}, inSecret bool, a *audit) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return r.walkMap(path, v, inSecret, a)
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = r.walk(path+"["+strconv.Itoa(i)+"]", item, inSecret, a)
+		}
+		return out
+	case string:
+		if inSecret {
+			return a.mask(path, ReasonSecretData, v)
+		}
+		return r.redactString(path, v, a)
+	default:
+		if inSecret && v != nil {
+			a.add(path, ReasonSecretData)
+			return Mask
+		}
+		return v
+	}
+}
+
+func (r *Redactor) walkMap(path string, m map[string]interface{}, inSecret bool, a *audit) map[string]interface{} {
+	isSecret := !inSecret && m["kind"] == "Secret" && m["apiVersion"] == "v1"
+
+	// Name/value pairs such as env vars and Helm parameters are judged by the name
+	name, _ := m["name"].(string)
+	sensitivePair := !inSecret && r.sensitiveKey(name)
+
+	out := make(map[string]interface{}, len(m))
+	for _, key := range sortedKeys(m) {
+		child := m[key]
+		childPath := joinPath(path, key)
+
+		switch {
+		case isSecret && (key == "data" || key == "stringData"):
+			out[key] = r.walk(childPath, child, true, a)
+		case isSecret && key == "metadata":
+			out[key] = r.redactSecretMetadata(childPath, child, a)
+		case sensitivePair && key == "value":
+			if s, ok := child.(string); ok {
+				out[key] = a.mask(childPath, ReasonSensitiveKey, s)
+				continue
+			}
+			out[key] = r.walk(childPath, child, inSecret, a)
+		default:
+			if s, ok := child.(string); ok && !inSecret && r.sensitiveKey(key) {
+				out[key] = a.mask(childPath, ReasonSensitiveKey, s)
+				continue
+			}
+			out[key] = r.walk(childPath, child, inSecret, a)
+		}
+	}
+	return out
+}
+
+// redactSecretMetadata masks the last-applied annotation of a Secret, which repeats
+// its data, and redacts the rest of the metadata as usual
+func (r *Redactor) redactSecretMetadata(path string, metadata interface{}, a *audit) interface{} {
+	redacted := r.walk(path, metadata, false, a)
+	meta, ok := redacted.(map[string]interface{})
+	if !ok {
+		return redacted
+	}
+	annotations, ok := meta["annotations"].(map[string]interface{})
+	if !ok {
+		return redacted
+	}
+	if s, ok := annotations[lastAppliedAnnotation].(string); ok {
+		annotations[lastAppliedAnnotation] = a.mask(joinPath(path, "annotations."+lastAppliedAnnotation), ReasonSecretData, s)
+	}
+	return redacted
+}
+
+// redactString applies the credential patterns and then the entropy check
+func (r *Redactor) redactString(path, s string, a *audit) string {
+	for _, p := range r.patterns {
+		if p.re.MatchString(s) {
+			s = p.re.ReplaceAllString(s, p.replacement)
+			a.add(path, p.name)
+		}
+	}
+
+	if r.entropyThreshold < 0 {
+		return s
+	}
+	found := false
+	s = entropyCandidate.ReplaceAllStringFunc(s, func(token string) string {
+		if len(token) < r.minEntropyLength || !looksRandom(token, r.entropyThreshold) {
+			return token
+		}
+		found = true
+		return Mask
+	})
+	if found {
+		a.add(path, ReasonHighEntropy)
+	}
+	return s
+}
+
+// sensitiveKey reports whether a key or env var name suggests its value is a secret
+func (r *Redactor) sensitiveKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	normalized := normalizeKey(key)
+	for _, suffix := range referenceSuffixes {
+		if strings.HasSuffix(normalized, suffix) {
+			return false
+		}
+	}
+	for _, sensitive := range r.sensitiveKeys {
+		if sensitive != "" && strings.Contains(normalized, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// looksRandom reports whether a token mixes letters and digits and has a Shannon
+// entropy, in bits per character, of at least the threshold scaled to its length
+func looksRandom(token string, threshold float64) bool {
+	hasLetter, hasDigit := false, false
+	counts := make(map[rune]int)
+	for _, c := range token {
+		counts[c]++
+		switch {
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			hasLetter = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return false
+	}
+
+	entropy := 0.0
+	length := float64(len(token))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= math.Min(threshold, shortTokenEntropyRatio*math.Log2(length))
+}
+
+func normalizeKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "", ".", "", " ", "").Replace(strings.ToLower(key))
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// sortedKeys keeps the audit in a stable order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// audit collects redactions, skipping repeats of the same path and reason
+type audit struct {
+	entries []models.Redaction
+	seen    map[models.Redaction]bool
+}
+
+func (a *audit) add(path, reason string) {
+	entry := models.Redaction{Path: path, Reason: reason}
+	if a.seen == nil {
+		a.seen = make(map[models.Redaction]bool)
+	}
+	if a.seen[entry] {
+		return
+	}
+	a.seen[entry] = true
+	a.entries = append(a.entries, entry)
+}
+
+// mask records a redaction and returns the mask, leaving empty values as they are
+func (a *audit) mask(path, reason, value string) string {
+	if value == "" {
+		return value
+	}
+	a.add(path, reason)
+	return Mask
+}
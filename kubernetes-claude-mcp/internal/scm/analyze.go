@@ -0,0 +1,85 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/kustomize"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// AnalyzeMergeRequest fetches a merge request with its changes and commit messages and
+// records whether it touches Helm charts, kustomizations or Kubernetes manifests
+func AnalyzeMergeRequest(ctx context.Context, provider Provider, projectID string, mergeRequestIID int, logger *logging.Logger) (*models.GitLabMergeRequest, error) {
+	if logger == nil {
+		logger = logging.NewLogger().Named("scm")
+	}
+	logger.Debug("Analyzing merge request", "provider", provider.Name(), "projectID", projectID, "mergeRequestIID", mergeRequestIID)
+
+	// Get basic merge request data
+	mr, err := provider.GetMergeRequest(ctx, projectID, mergeRequestIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request: %w", err)
+	}
+
+	// Get changes
+	mrChanges, err := provider.GetMergeRequestChanges(ctx, projectID, mergeRequestIID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merge request changes: %w", err)
+	}
+
+	// Copy changes to the original merge request
+	mr.Changes = mrChanges.Changes
+
+	// Initialize context analysis
+	mr.MergeRequestContext.AffectedFiles = make([]string, 0)
+	mr.MergeRequestContext.HelmChartAffected = false
+	mr.MergeRequestContext.KustomizeAffected = false
+	mr.MergeRequestContext.KubernetesManifest = false
+
+	// Analyze changes
+	for _, change := range mr.Changes {
+		mr.MergeRequestContext.AffectedFiles = append(mr.MergeRequestContext.AffectedFiles, change.NewPath)
+
+		// Check for Helm charts
+		if strings.Contains(change.NewPath, "Chart.yaml") ||
+			strings.Contains(change.NewPath, "values.yaml") ||
+			(strings.Contains(change.NewPath, "templates/") && strings.HasSuffix(change.NewPath, ".yaml")) {
+			mr.MergeRequestContext.HelmChartAffected = true
+		}
+
+		// Check for kustomizations
+		if kustomize.IsKustomizationFile(change.NewPath) || kustomize.IsKustomizationFile(change.OldPath) {
+			mr.MergeRequestContext.KustomizeAffected = true
+		}
+
+		// Check for Kubernetes manifests
+		if strings.HasSuffix(change.NewPath, ".yaml") || strings.HasSuffix(change.NewPath, ".yml") {
+			// Look for Kubernetes kind in the file content
+			if strings.Contains(change.Diff, "kind:") &&
+				(strings.Contains(change.Diff, "Deployment") ||
+					strings.Contains(change.Diff, "Service") ||
+					strings.Contains(change.Diff, "ConfigMap") ||
+					strings.Contains(change.Diff, "Secret") ||
+					strings.Contains(change.Diff, "Pod")) {
+				mr.MergeRequestContext.KubernetesManifest = true
+			}
+		}
+	}
+
+	// Get commits
+	commits, err := provider.GetMergeRequestCommits(ctx, projectID, mergeRequestIID)
+	if err != nil {
+		logger.Warn("Failed to get merge request commits", "error", err)
+	} else {
+		// Extract commit messages
+		mr.MergeRequestContext.CommitMessages = make([]string, 0)
+		for _, commit := range commits {
+			mr.MergeRequestContext.CommitMessages = append(mr.MergeRequestContext.CommitMessages, commit.Title)
+		}
+	}
+
+	return mr, nil
+}
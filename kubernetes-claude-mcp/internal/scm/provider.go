@@ -0,0 +1,63 @@
+package scm
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+)
+
+// Provider names
+const (
+	ProviderGitLab = "gitlab"
+	ProviderGitHub = "github"
+)
+
+// Provider is a source code host. Project IDs are whatever the host accepts, such as a
+// GitLab numeric ID or path with namespace, or a GitHub owner/repo. Results use the
+// GitLab-shaped models the correlators already consume; other hosts map onto them, so
+// a GitHub pull request is a merge request and a workflow run is a pipeline.
+type Provider interface {
+	// Name returns the provider name, such as "gitlab" or "github"
+	Name() string
+
+	// CheckConnectivity tests the connection to the host's API
+	CheckConnectivity(ctx context.Context) error
+
+	// Projects
+	ListProjects(ctx context.Context) ([]models.GitLabProject, error)
+	GetProject(ctx context.Context, projectID string) (*models.GitLabProject, error)
+	GetProjectByPath(ctx context.Context, path string) (*models.GitLabProject, error)
+
+	// Commits, diffs and repository content
+	GetCommit(ctx context.Context, projectID, sha string) (*models.GitLabCommit, error)
+	GetCommitDiff(ctx context.Context, projectID, sha string) ([]models.GitLabDiff, error)
+	FindRecentChanges(ctx context.Context, projectID string, since time.Time) ([]models.GitLabCommit, error)
+	GetFileContent(ctx context.Context, projectID, filePath, ref string) (string, error)
+	ListRepositoryTree(ctx context.Context, projectID, treePath, ref string, recursive bool) ([]models.GitLabTreeEntry, error)
+
+	// Merge requests (pull requests) and their comments
+	GetMergeRequest(ctx context.Context, projectID string, mergeRequestIID int) (*models.GitLabMergeRequest, error)
+	GetMergeRequestChanges(ctx context.Context, projectID string, mergeRequestIID int) (*models.GitLabMergeRequest, error)
+	ListMergedMergeRequests(ctx context.Context, projectID string, updatedAfter time.Time) ([]models.GitLabMergeRequest, error)
+	GetMergeRequestCommits(ctx context.Context, projectID string, mergeRequestIID int) ([]models.GitLabCommit, error)
+	GetMergeRequestComments(ctx context.Context, projectID string, mergeRequestIID int) ([]models.GitLabMergeRequestComment, error)
+	CreateMergeRequestComment(ctx context.Context, projectID string, mergeRequestIID int, body string) (*models.GitLabMergeRequestComment, error)
+
+	// Pipelines (checks) and deployments
+	ListPipelines(ctx context.Context, projectID string) ([]models.GitLabPipeline, error)
+	FindRecentDeployments(ctx context.Context, projectID, environment string) ([]models.GitLabDeployment, error)
+}
+
+// notFound is implemented by provider API errors
+type notFound interface {
+	NotFound() bool
+}
+
+// IsNotFound reports whether an error from any provider is a 404, such as a file or
+// path that does not exist at a ref
+func IsNotFound(err error) bool {
+	var nf notFound
+	return errors.As(err, &nf) && nf.NotFound()
+}
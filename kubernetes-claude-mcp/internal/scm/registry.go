@@ -0,0 +1,239 @@
+package scm
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// Registry chooses the provider for a repository by host. Repositories on hosts
+// without a registered provider, and project IDs without a host, go to the default
+// provider.
+type Registry struct {
+	defaultHost string
+	providers   map[string]Provider
+	logger      *logging.Logger
+}
+
+// NewRegistry creates a registry whose default provider serves defaultHost
+func NewRegistry(defaultHost string, defaultProvider Provider, logger *logging.Logger) *Registry {
+	if logger == nil {
+		logger = logging.NewLogger().Named("scm")
+	}
+
+	r := &Registry{
+		defaultHost: normalizeHost(defaultHost),
+		providers:   make(map[string]Provider),
+		logger:      logger,
+	}
+	r.providers[r.defaultHost] = defaultProvider
+	return r
+}
+
+// Register adds the provider for repositories on a host
+func (r *Registry) Register(host string, provider Provider) *Registry {
+	host = normalizeHost(host)
+	r.providers[host] = provider
+	r.logger.Debug("Registered SCM provider", "host", host, "provider", provider.Name())
+	return r
+}
+
+// Default returns the default provider
+func (r *Registry) Default() Provider {
+	return r.providers[r.defaultHost]
+}
+
+// Hosts returns the registered hosts in sorted order
+func (r *Registry) Hosts() []string {
+	hosts := make([]string, 0, len(r.providers))
+	for host := range r.providers {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// ForProject resolves a project ID, which may be qualified with the host as in
+// "github.com/owner/repo", to its provider, host and the ID the provider understands
+func (r *Registry) ForProject(projectID string) (Provider, string, string) {
+	if i := strings.Index(projectID, "/"); i > 0 {
+		host := normalizeHost(projectID[:i])
+		if provider, ok := r.providers[host]; ok {
+			return provider, host, projectID[i+1:]
+		}
+	}
+	return r.Default(), r.defaultHost, projectID
+}
+
+// ForRepoURL resolves a repository URL, such as an ArgoCD application's source, to its
+// provider, host and project path. It reports false when the URL cannot be parsed.
+func (r *Registry) ForRepoURL(repoURL string) (Provider, string, string, bool) {
+	host, projectPath, ok := ParseRepoURL(repoURL)
+	if !ok {
+		return nil, "", "", false
+	}
+	if provider, found := r.providers[host]; found {
+		return provider, host, projectPath, true
+	}
+	return r.Default(), r.defaultHost, projectPath, true
+}
+
+// SameRepository reports whether a repository URL points at a project. The hosts
+// must resolve to the same provider, so a GitHub repository never matches a GitLab
+// project with the same path.
+func (r *Registry) SameRepository(repoURL, host, projectPath string) bool {
+	_, repoHost, repoPath, ok := r.ForRepoURL(repoURL)
+	return ok && repoHost == normalizeHost(host) && strings.EqualFold(repoPath, projectPath)
+}
+
+// ParseRepoURL splits a Git repository URL into its host and project path. HTTP(S),
+// ssh:// and scp-style git@host:path URLs are accepted; ports and a .git suffix are
+// dropped.
+func ParseRepoURL(repoURL string) (string, string, bool) {
+	repoURL = strings.TrimSpace(repoURL)
+
+	var host, projectPath string
+	switch {
+	case strings.Contains(repoURL, "://"):
+		u, err := url.Parse(repoURL)
+		if err != nil {
+			return "", "", false
+		}
+		host, projectPath = u.Hostname(), u.Path
+	case strings.Contains(repoURL, "@") && strings.Contains(repoURL, ":"):
+		// scp-style: git@gitlab.com:namespace/project.git
+		hostPart, pathPart, _ := strings.Cut(repoURL, ":")
+		host = hostPart[strings.LastIndex(hostPart, "@")+1:]
+		projectPath = pathPart
+	default:
+		return "", "", false
+	}
+
+	projectPath = strings.TrimSuffix(strings.Trim(projectPath, "/"), ".git")
+	if host == "" || projectPath == "" {
+		return "", "", false
+	}
+	return normalizeHost(host), projectPath, true
+}
+
+// HostFromURL returns the host of an API or web URL, mapping api.github.com to
+// github.com so it matches repository URLs
+func HostFromURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("URL %q has no host", rawURL)
+	}
+	host := normalizeHost(u.Hostname())
+	if host == "api.github.com" {
+		host = "github.com"
+	}
+	return host, nil
+}
+
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// The methods below route a call to the provider for its project ID, so the registry
+// can stand in wherever a single provider was used. Project IDs qualified with a host
+// are passed to that host's provider without the host.
+
+// GetProject returns a project from its provider
+func (r *Registry) GetProject(ctx context.Context, projectID string) (*models.GitLabProject, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.GetProject(ctx, id)
+}
+
+// GetProjectByPath returns a project by its path from its provider
+func (r *Registry) GetProjectByPath(ctx context.Context, projectPath string) (*models.GitLabProject, error) {
+	provider, _, id := r.ForProject(projectPath)
+	return provider.GetProjectByPath(ctx, id)
+}
+
+// GetCommit returns a commit from its project's provider
+func (r *Registry) GetCommit(ctx context.Context, projectID, sha string) (*models.GitLabCommit, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.GetCommit(ctx, id, sha)
+}
+
+// GetCommitDiff returns the changes in a commit from its project's provider
+func (r *Registry) GetCommitDiff(ctx context.Context, projectID, sha string) ([]models.GitLabDiff, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.GetCommitDiff(ctx, id, sha)
+}
+
+// FindRecentChanges finds recent commits from the project's provider
+func (r *Registry) FindRecentChanges(ctx context.Context, projectID string, since time.Time) ([]models.GitLabCommit, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.FindRecentChanges(ctx, id, since)
+}
+
+// GetFileContent returns a file from the project's provider
+func (r *Registry) GetFileContent(ctx context.Context, projectID, filePath, ref string) (string, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.GetFileContent(ctx, id, filePath, ref)
+}
+
+// ListRepositoryTree lists a repository tree from the project's provider
+func (r *Registry) ListRepositoryTree(ctx context.Context, projectID, treePath, ref string, recursive bool) ([]models.GitLabTreeEntry, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.ListRepositoryTree(ctx, id, treePath, ref, recursive)
+}
+
+// GetMergeRequestChanges returns a merge request with its changes from the project's
+// provider
+func (r *Registry) GetMergeRequestChanges(ctx context.Context, projectID string, mergeRequestIID int) (*models.GitLabMergeRequest, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.GetMergeRequestChanges(ctx, id, mergeRequestIID)
+}
+
+// GetMergeRequestCommits returns the commits of a merge request from the project's
+// provider
+func (r *Registry) GetMergeRequestCommits(ctx context.Context, projectID string, mergeRequestIID int) ([]models.GitLabCommit, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.GetMergeRequestCommits(ctx, id, mergeRequestIID)
+}
+
+// ListMergedMergeRequests lists recently merged merge requests from the project's
+// provider
+func (r *Registry) ListMergedMergeRequests(ctx context.Context, projectID string, updatedAfter time.Time) ([]models.GitLabMergeRequest, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.ListMergedMergeRequests(ctx, id, updatedAfter)
+}
+
+// ListPipelines lists pipelines from the project's provider
+func (r *Registry) ListPipelines(ctx context.Context, projectID string) ([]models.GitLabPipeline, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.ListPipelines(ctx, id)
+}
+
+// FindRecentDeployments finds recent deployments from the project's provider
+func (r *Registry) FindRecentDeployments(ctx context.Context, projectID, environment string) ([]models.GitLabDeployment, error) {
+	provider, _, id := r.ForProject(projectID)
+	return provider.FindRecentDeployments(ctx, id, environment)
+}
+
+// AnalyzeMergeRequest analyzes a merge request with the project's provider
+func (r *Registry) AnalyzeMergeRequest(ctx context.Context, projectID string, mergeRequestIID int) (*models.GitLabMergeRequest, error) {
+	provider, _, id := r.ForProject(projectID)
+	return AnalyzeMergeRequest(ctx, provider, id, mergeRequestIID, r.logger)
+}
+
+// QualifiedID returns the project ID that routes back to a project's provider: the
+// path with namespace, prefixed with the host unless the host is the default one
+func (r *Registry) QualifiedID(host string, project *models.GitLabProject) string {
+	host = normalizeHost(host)
+	if host == r.defaultHost {
+		return project.PathWithNamespace
+	}
+	return host + "/" + project.PathWithNamespace
+}
@@ -0,0 +1,137 @@
+package scm
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/internal/models"
+	"github.com/Blankcut/kubernetes-mcp-server/kubernetes-claude-mcp/pkg/logging"
+)
+
+// stubProvider is a provider that reports its name and echoes file requests
+type stubProvider struct {
+	Provider
+	name string
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) GetFileContent(_ context.Context, projectID, filePath, _ string) (string, error) {
+	return p.name + ":" + projectID + ":" + filePath, nil
+}
+
+func newTestRegistry() *Registry {
+	return NewRegistry("gitlab.example.com", &stubProvider{name: ProviderGitLab}, logging.NewLogger()).
+		Register("github.com", &stubProvider{name: ProviderGitHub})
+}
+
+func TestParseRepoURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		host string
+		path string
+		ok   bool
+	}{
+		{"https://gitlab.example.com/group/sub/project.git", "gitlab.example.com", "group/sub/project", true},
+		{"https://github.com/owner/repo", "github.com", "owner/repo", true},
+		{"ssh://git@GitHub.com:22/owner/repo.git", "github.com", "owner/repo", true},
+		{"git@github.com:owner/repo.git", "github.com", "owner/repo", true},
+		{"https://charts.example.com/", "", "", false},
+		{"owner/repo", "", "", false},
+	}
+
+	for _, tt := range tests {
+		host, path, ok := ParseRepoURL(tt.url)
+		if host != tt.host || path != tt.path || ok != tt.ok {
+			t.Errorf("ParseRepoURL(%q) = %q, %q, %v, want %q, %q, %v", tt.url, host, path, ok, tt.host, tt.path, tt.ok)
+		}
+	}
+}
+
+func TestHostFromURL(t *testing.T) {
+	for rawURL, want := range map[string]string{
+		"https://api.github.com":                "github.com",
+		"https://github.example.com/api/v3":     "github.example.com",
+		"https://GitLab.example.com/api/v4/":    "gitlab.example.com",
+		"http://gitlab.internal:8080/gitlab/v4": "gitlab.internal",
+	} {
+		host, err := HostFromURL(rawURL)
+		if err != nil || host != want {
+			t.Errorf("HostFromURL(%q) = %q, %v, want %q", rawURL, host, err, want)
+		}
+	}
+
+	if _, err := HostFromURL("not a url"); err == nil {
+		t.Error("expected an error for a URL without a host")
+	}
+}
+
+func TestRegistryForProject(t *testing.T) {
+	repos := newTestRegistry()
+
+	tests := []struct {
+		projectID string
+		provider  string
+		host      string
+		id        string
+	}{
+		{"group/project", ProviderGitLab, "gitlab.example.com", "group/project"},
+		{"42", ProviderGitLab, "gitlab.example.com", "42"},
+		{"github.com/owner/repo", ProviderGitHub, "github.com", "owner/repo"},
+		{"gitlab.example.com/group/project", ProviderGitLab, "gitlab.example.com", "group/project"},
+	}
+
+	for _, tt := range tests {
+		provider, host, id := repos.ForProject(tt.projectID)
+		if provider.Name() != tt.provider || host != tt.host || id != tt.id {
+			t.Errorf("ForProject(%q) = %s, %q, %q, want %s, %q, %q",
+				tt.projectID, provider.Name(), host, id, tt.provider, tt.host, tt.id)
+		}
+	}
+
+	content, err := repos.GetFileContent(context.Background(), "github.com/owner/repo", "values.yaml", "main")
+	if err != nil || content != "github:owner/repo:values.yaml" {
+		t.Errorf("GetFileContent routed to %q, %v", content, err)
+	}
+}
+
+func TestRegistryForRepoURL(t *testing.T) {
+	repos := newTestRegistry()
+
+	provider, host, path, ok := repos.ForRepoURL("git@github.com:owner/repo.git")
+	if !ok || provider.Name() != ProviderGitHub || host != "github.com" || path != "owner/repo" {
+		t.Errorf("ForRepoURL(github) = %v, %q, %q, %v", provider, host, path, ok)
+	}
+
+	// Unknown hosts fall back to the default provider
+	provider, host, _, ok = repos.ForRepoURL("https://git.other.example/group/project.git")
+	if !ok || provider.Name() != ProviderGitLab || host != "gitlab.example.com" {
+		t.Errorf("ForRepoURL(unknown) = %v, %q, %v", provider, host, ok)
+	}
+}
+
+func TestRegistrySameRepository(t *testing.T) {
+	repos := newTestRegistry()
+
+	if !repos.SameRepository("https://github.com/Owner/Repo.git", "github.com", "owner/repo") {
+		t.Error("expected the GitHub repository to match")
+	}
+	if repos.SameRepository("https://github.com/owner/repo.git", "gitlab.example.com", "owner/repo") {
+		t.Error("a GitHub repository must not match a GitLab project with the same path")
+	}
+	if !repos.SameRepository("git@gitlab.example.com:group/project.git", "gitlab.example.com", "group/project") {
+		t.Error("expected the GitLab project to match")
+	}
+}
+
+func TestRegistryQualifiedID(t *testing.T) {
+	repos := newTestRegistry()
+	project := &models.GitLabProject{PathWithNamespace: "owner/repo"}
+
+	if id := repos.QualifiedID("github.com", project); id != "github.com/owner/repo" {
+		t.Errorf("QualifiedID(github) = %q", id)
+	}
+	if id := repos.QualifiedID("gitlab.example.com", project); id != "owner/repo" {
+		t.Errorf("QualifiedID(default) = %q", id)
+	}
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"regexp"
 
 	"gopkg.in/yaml.v2"
 )
@@ -13,34 +15,129 @@ type Config struct {
 	Kubernetes KubernetesConfig `yaml:"kubernetes"`
 	ArgoCD     ArgoCDConfig     `yaml:"argocd"`
 	GitLab     GitLabConfig     `yaml:"gitlab"`
+	GitHub     GitHubConfig     `yaml:"github"`
 	Claude     ClaudeConfig     `yaml:"claude"`
+	Redaction  RedactionConfig  `yaml:"redaction"`
+	Helm       HelmConfig       `yaml:"helm"`
+	Policy     PolicyConfig     `yaml:"policy"`
 }
 
+// Supported server transports
+const (
+	TransportHTTP  = "http"
+	TransportStdio = "stdio"
+)
+
 // ServerConfig holds the HTTP server configuration
 type ServerConfig struct {
-	Address      string `yaml:"address"`
-	ReadTimeout  int    `yaml:"readTimeout"`
-	WriteTimeout int    `yaml:"writeTimeout"`
-	Auth         struct {
-		APIKey string `yaml:"apiKey"`
-	} `yaml:"auth"`
+	Transport    string     `yaml:"transport"`
+	Address      string     `yaml:"address"`
+	ReadTimeout  int        `yaml:"readTimeout"`
+	WriteTimeout int        `yaml:"writeTimeout"`
+	Auth         AuthConfig `yaml:"auth"`
+}
+
+// AuthConfig holds API authentication and authorization settings. APIKey is a single
+// key with full access; Keys assigns each key a role defined in Roles.
+type AuthConfig struct {
+	APIKey string         `yaml:"apiKey"`
+	Keys   []APIKeyConfig `yaml:"keys"`
+	Roles  []RoleConfig   `yaml:"roles"`
+	OIDC   OIDCConfig     `yaml:"oidc"`
+}
+
+// OIDCConfig enables JWT bearer tokens issued by an OIDC provider. Callers get the
+// role of the first GroupRoles entry matching one of their groups, or DefaultRole.
+type OIDCConfig struct {
+	IssuerURL        string            `yaml:"issuerURL"`
+	Audience         string            `yaml:"audience"`
+	JWKSURL          string            `yaml:"jwksURL"`
+	UsernameClaim    string            `yaml:"usernameClaim"`
+	GroupsClaim      string            `yaml:"groupsClaim"`
+	GroupRoles       []GroupRoleConfig `yaml:"groupRoles"`
+	DefaultRole      string            `yaml:"defaultRole"`
+	JWKSCacheSeconds int               `yaml:"jwksCacheSeconds"`
+	ClockSkewSeconds int               `yaml:"clockSkewSeconds"`
+}
+
+// GroupRoleConfig grants a role to members of an OIDC group
+type GroupRoleConfig struct {
+	Group string `yaml:"group"`
+	Role  string `yaml:"role"`
+}
+
+// APIKeyConfig identifies an API caller by key and grants it a role. KubernetesUser
+// and KubernetesGroups are impersonated when Kubernetes impersonation is enabled.
+type APIKeyConfig struct {
+	Name             string   `yaml:"name"`
+	Key              string   `yaml:"key"`
+	Role             string   `yaml:"role"`
+	KubernetesUser   string   `yaml:"kubernetesUser"`
+	KubernetesGroups []string `yaml:"kubernetesGroups"`
+}
+
+// RoleConfig limits what a caller may do. Namespaces accept glob patterns; empty
+// Namespaces allows every namespace and empty Kinds allows every kind except Secrets.
+// Actions are read, query, sync and comment, or "*" for all.
+type RoleConfig struct {
+	Name       string   `yaml:"name"`
+	Namespaces []string `yaml:"namespaces"`
+	Kinds      []string `yaml:"kinds"`
+	Actions    []string `yaml:"actions"`
 }
 
 // KubernetesConfig holds configuration for Kubernetes client
 type KubernetesConfig struct {
+	KubeConfig       string          `yaml:"kubeconfig"`
+	InCluster        bool            `yaml:"inCluster"`
+	DefaultContext   string          `yaml:"defaultContext"`
+	DefaultNamespace string          `yaml:"defaultNamespace"`
+	AllContexts      bool            `yaml:"allContexts"`
+	DefaultCluster   string          `yaml:"defaultCluster"`
+	Clusters         []ClusterConfig `yaml:"clusters"`
+	Cache            CacheConfig     `yaml:"cache"`
+	Impersonate      bool            `yaml:"impersonate"`
+}
+
+// CacheConfig controls the informer cache that serves Kubernetes reads from memory.
+// Empty Resources caches a default set of workload types; empty Namespaces watches
+// the whole cluster.
+type CacheConfig struct {
+	Enabled       bool     `yaml:"enabled"`
+	Resources     []string `yaml:"resources"`
+	Namespaces    []string `yaml:"namespaces"`
+	ResyncSeconds int      `yaml:"resyncSeconds"`
+}
+
+// ClusterConfig describes one named cluster in a multi-cluster setup.
+// KubeConfig and DefaultNamespace fall back to the top-level Kubernetes settings.
+type ClusterConfig struct {
+	Name             string `yaml:"name"`
 	KubeConfig       string `yaml:"kubeconfig"`
+	Context          string `yaml:"context"`
 	InCluster        bool   `yaml:"inCluster"`
-	DefaultContext   string `yaml:"defaultContext"`
+	Server           string `yaml:"server"`
 	DefaultNamespace string `yaml:"defaultNamespace"`
 }
 
 // ArgoCDConfig holds configuration for the ArgoCD client
 type ArgoCDConfig struct {
-	URL       string `yaml:"url"`
-	AuthToken string `yaml:"authToken"`
-	Username  string `yaml:"username"`
-	Password  string `yaml:"password"`
-	Insecure  bool   `yaml:"insecure"`
+	URL       string          `yaml:"url"`
+	AuthToken string          `yaml:"authToken"`
+	Username  string          `yaml:"username"`
+	Password  string          `yaml:"password"`
+	Insecure  bool            `yaml:"insecure"`
+	Index     ArgoIndexConfig `yaml:"index"`
+}
+
+// ArgoIndexConfig controls the in-memory index from Kubernetes resources to the ArgoCD
+// applications that manage them. The index is rebuilt from application resource trees
+// every RefreshSeconds, fetching trees only for applications that changed or whose tree
+// is older than TreeTTLSeconds.
+type ArgoIndexConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	RefreshSeconds int  `yaml:"refreshSeconds"`
+	TreeTTLSeconds int  `yaml:"treeTTLSeconds"`
 }
 
 // GitLabConfig holds configuration for the GitLab client
@@ -48,17 +145,111 @@ type GitLabConfig struct {
 	URL        string `yaml:"url"`
 	AuthToken  string `yaml:"authToken"`
 	APIVersion string `yaml:"apiVersion"`
+
+	// WebhookSecret is the token GitLab sends in X-Gitlab-Token with merge request
+	// webhooks. The webhook endpoint is disabled when it is empty.
+	WebhookSecret string `yaml:"webhookSecret"`
+}
+
+// DefaultGitHubURL is the REST API URL of github.com
+const DefaultGitHubURL = "https://api.github.com"
+
+// GitHubConfig holds configuration for the GitHub client. URL is the REST API URL:
+// https://api.github.com, or https://HOST/api/v3 for GitHub Enterprise Server. The
+// client is enabled when either field is set.
+type GitHubConfig struct {
+	URL       string `yaml:"url"`
+	AuthToken string `yaml:"authToken"`
+}
+
+// Enabled reports whether GitHub repositories should be served by the GitHub client
+func (c GitHubConfig) Enabled() bool {
+	return c.URL != "" || c.AuthToken != ""
+}
+
+// APIURL returns the configured REST API URL, or github.com's
+func (c GitHubConfig) APIURL() string {
+	if c.URL == "" {
+		return DefaultGitHubURL
+	}
+	return c.URL
 }
 
 // ClaudeConfig holds configuration for the Claude API client
 type ClaudeConfig struct {
-	APIKey      string  `yaml:"apiKey"`
-	BaseURL     string  `yaml:"baseURL"`
-	ModelID     string  `yaml:"modelID"`
-	MaxTokens   int     `yaml:"maxTokens"`
-	Temperature float64 `yaml:"temperature"`
+	APIKey      string      `yaml:"apiKey"`
+	BaseURL     string      `yaml:"baseURL"`
+	ModelID     string      `yaml:"modelID"`
+	MaxTokens   int         `yaml:"maxTokens"`
+	Temperature float64     `yaml:"temperature"`
+	Agent       AgentConfig `yaml:"agent"`
+}
+
+// AgentConfig holds limits for the server-side tool-use loop
+type AgentConfig struct {
+	Enabled        bool `yaml:"enabled"`
+	MaxIterations  int  `yaml:"maxIterations"`
+	MaxTotalTokens int  `yaml:"maxTotalTokens"`
+}
+
+// RedactionConfig controls the masking of Secrets and sensitive values before they
+// are sent to Claude or returned by the API. Redaction is on unless Disabled is set.
+// SensitiveKeys and Patterns extend the built-in lists. An EntropyThreshold of zero
+// uses the default and a negative one turns off high-entropy detection.
+type RedactionConfig struct {
+	Disabled         bool     `yaml:"disabled"`
+	SensitiveKeys    []string `yaml:"sensitiveKeys"`
+	Patterns         []string `yaml:"patterns"`
+	EntropyThreshold float64  `yaml:"entropyThreshold"`
+	MinEntropyLength int      `yaml:"minEntropyLength"`
+}
+
+// PolicyConfig tunes the policy checks run on the manifests a merge request renders.
+// Disabled turns off built-in rules by name and Severities overrides a built-in
+// rule's severity with warn or fail. Rules adds custom rules.
+type PolicyConfig struct {
+	Disabled   []string          `yaml:"disabled"`
+	Severities map[string]string `yaml:"severities"`
+	Rules      []PolicyRule      `yaml:"rules"`
+}
+
+// Conditions a custom policy rule can require of a field
+const (
+	PolicyConditionExists  = "exists"
+	PolicyConditionAbsent  = "absent"
+	PolicyConditionEquals  = "equals"
+	PolicyConditionMatches = "matches"
+)
+
+// PolicyRule is a custom policy rule. Objects of the listed kinds, or of every kind
+// when Kinds is empty, must meet Condition at Path. Path is a dotted field path where
+// [*] stands for every list item and [x] for the list item named x or the map key x,
+// as in spec.template.spec.containers[*].securityContext.runAsNonRoot or
+// metadata.labels[app.kubernetes.io/name]. Equals and matches compare the field with
+// Value, a regular expression for matches. Severity defaults to fail.
+type PolicyRule struct {
+	Name      string   `yaml:"name"`
+	Kinds     []string `yaml:"kinds"`
+	Path      string   `yaml:"path"`
+	Condition string   `yaml:"condition"`
+	Value     string   `yaml:"value"`
+	Severity  string   `yaml:"severity"`
+	Message   string   `yaml:"message"`
+}
+
+// HelmConfig holds chart rendering settings. KubeVersion and APIVersions set the
+// capabilities chart templates see; without them helm's built-in defaults apply.
+// BuildDependencies fetches the dependencies of charts that do not vendor them, as helm
+// dependency build does, which needs access to the dependencies' repositories.
+type HelmConfig struct {
+	KubeVersion       string   `yaml:"kubeVersion"`
+	APIVersions       []string `yaml:"apiVersions"`
+	BuildDependencies bool     `yaml:"buildDependencies"`
 }
 
+// kubeVersionPattern matches versions such as 1.30 or v1.30.2
+var kubeVersionPattern = regexp.MustCompile(`^v?\d+\.\d+(\.\d+)?$`)
+
 // Load reads configuration from a file and environment variables
 func Load(path string) (*Config, error) {
 	config := &Config{}
@@ -83,6 +274,11 @@ func Load(path string) (*Config, error) {
 		config.Kubernetes.KubeConfig = kubeconfig
 	}
 
+	// Server transport
+	if transport := os.Getenv("MCP_TRANSPORT"); transport != "" {
+		config.Server.Transport = transport
+	}
+
 	// API Key settings (for server authentication)
 	if apiKey := os.Getenv("API_KEY"); apiKey != "" {
 		config.Server.Auth.APIKey = apiKey
@@ -114,6 +310,17 @@ func Load(path string) (*Config, error) {
 	if gitlabToken := os.Getenv("GITLAB_AUTH_TOKEN"); gitlabToken != "" {
 		config.GitLab.AuthToken = gitlabToken
 	}
+	if webhookSecret := os.Getenv("GITLAB_WEBHOOK_SECRET"); webhookSecret != "" {
+		config.GitLab.WebhookSecret = webhookSecret
+	}
+
+	// GitHub settings
+	if githubURL := os.Getenv("GITHUB_URL"); githubURL != "" {
+		config.GitHub.URL = githubURL
+	}
+	if githubToken := os.Getenv("GITHUB_TOKEN"); githubToken != "" {
+		config.GitHub.AuthToken = githubToken
+	}
 
 	return config, nil
 }
@@ -121,7 +328,13 @@ func Load(path string) (*Config, error) {
 // Validate checks if the configuration is valid
 func (c *Config) Validate() error {
 	// Check server configuration
-	if c.Server.Address == "" {
+	switch c.Server.Transport {
+	case "", TransportHTTP, TransportStdio:
+	default:
+		return fmt.Errorf("unsupported server transport: %s", c.Server.Transport)
+	}
+
+	if c.Server.Address == "" && c.Server.Transport != TransportStdio {
 		return fmt.Errorf("server address is required")
 	}
 
@@ -134,33 +347,47 @@ func (c *Config) Validate() error {
 	}
 
 	// Check Claude configuration
-	if c.Claude.APIKey == "" {
-		return fmt.Errorf("claude API key is required")
+	if c.ClaudeRequired() {
+		if err := c.validateClaude(); err != nil {
+			return err
+		}
 	}
 
-	if c.Claude.ModelID == "" {
-		return fmt.Errorf("claude model ID is required")
+	// Check Kubernetes configuration
+	if c.Kubernetes.InCluster && c.Kubernetes.KubeConfig != "" {
+		return fmt.Errorf("cannot specify both inCluster=true and kubeconfig path")
 	}
 
-	if c.Claude.BaseURL == "" {
-		return fmt.Errorf("claude base URL is required")
+	if err := c.validateClusters(); err != nil {
+		return err
 	}
 
-	if c.Claude.MaxTokens <= 0 {
-		return fmt.Errorf("claude max tokens must be positive")
+	if err := c.validateAuth(); err != nil {
+		return err
 	}
 
-	if c.Claude.MaxTokens > 8192 {
-		return fmt.Errorf("claude max tokens cannot exceed 8192")
+	if c.Kubernetes.Cache.ResyncSeconds < 0 {
+		return fmt.Errorf("kubernetes cache resync interval must be non-negative")
 	}
 
-	if c.Claude.Temperature < 0.0 || c.Claude.Temperature > 1.0 {
-		return fmt.Errorf("claude temperature must be between 0.0 and 1.0")
+	if c.ArgoCD.Index.RefreshSeconds < 0 {
+		return fmt.Errorf("argocd index refresh interval must be non-negative")
 	}
 
-	// Check Kubernetes configuration
-	if c.Kubernetes.InCluster && c.Kubernetes.KubeConfig != "" {
-		return fmt.Errorf("cannot specify both inCluster=true and kubeconfig path")
+	if c.ArgoCD.Index.TreeTTLSeconds < 0 {
+		return fmt.Errorf("argocd index tree TTL must be non-negative")
+	}
+
+	if err := c.validateRedaction(); err != nil {
+		return err
+	}
+
+	if c.Helm.KubeVersion != "" && !kubeVersionPattern.MatchString(c.Helm.KubeVersion) {
+		return fmt.Errorf("invalid helm kubeVersion %q", c.Helm.KubeVersion)
+	}
+
+	if err := c.validatePolicy(); err != nil {
+		return err
 	}
 
 	// Validate ArgoCD configuration if URL is provided
@@ -175,5 +402,207 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("GitLab auth token is required when GitLab URL is provided")
 	}
 
+	// Validate the GitHub API URL if one is provided
+	if c.GitHub.URL != "" {
+		if u, err := url.Parse(c.GitHub.URL); err != nil || u.Host == "" {
+			return fmt.Errorf("invalid GitHub URL %q", c.GitHub.URL)
+		}
+	}
+
+	return nil
+}
+
+// validateClusters checks the named cluster list
+func (c *Config) validateClusters() error {
+	if len(c.Kubernetes.Clusters) > 0 && c.Kubernetes.AllContexts {
+		return fmt.Errorf("cannot specify both kubernetes clusters and allContexts")
+	}
+
+	names := make(map[string]bool, len(c.Kubernetes.Clusters))
+	for _, cluster := range c.Kubernetes.Clusters {
+		if cluster.Name == "" {
+			return fmt.Errorf("kubernetes cluster name is required")
+		}
+		if names[cluster.Name] {
+			return fmt.Errorf("duplicate kubernetes cluster name: %s", cluster.Name)
+		}
+		names[cluster.Name] = true
+
+		if cluster.InCluster && (cluster.KubeConfig != "" || cluster.Context != "") {
+			return fmt.Errorf("cluster %s cannot specify both inCluster=true and a kubeconfig or context", cluster.Name)
+		}
+	}
+
+	if c.Kubernetes.DefaultCluster != "" && len(c.Kubernetes.Clusters) > 0 && !names[c.Kubernetes.DefaultCluster] {
+		return fmt.Errorf("default cluster %s is not in the cluster list", c.Kubernetes.DefaultCluster)
+	}
+
+	return nil
+}
+
+// validateAuth checks the API keys and roles
+func (c *Config) validateAuth() error {
+	roles := map[string]bool{"admin": true}
+	for _, role := range c.Server.Auth.Roles {
+		if role.Name == "" {
+			return fmt.Errorf("auth role name is required")
+		}
+		if roles[role.Name] {
+			return fmt.Errorf("duplicate or reserved auth role name: %s", role.Name)
+		}
+		roles[role.Name] = true
+
+		if len(role.Actions) == 0 {
+			return fmt.Errorf("auth role %s must allow at least one action", role.Name)
+		}
+		for _, action := range role.Actions {
+			switch action {
+			case "read", "query", "sync", "comment", "*":
+			default:
+				return fmt.Errorf("auth role %s has unknown action: %s", role.Name, action)
+			}
+		}
+	}
+
+	keys := make(map[string]bool, len(c.Server.Auth.Keys))
+	for _, key := range c.Server.Auth.Keys {
+		if key.Name == "" {
+			return fmt.Errorf("auth key name is required")
+		}
+		if key.Key == "" {
+			return fmt.Errorf("auth key %s has no key value", key.Name)
+		}
+		if keys[key.Key] || key.Key == c.Server.Auth.APIKey {
+			return fmt.Errorf("auth key %s reuses another key's value", key.Name)
+		}
+		keys[key.Key] = true
+
+		if !roles[key.Role] {
+			return fmt.Errorf("auth key %s refers to unknown role: %s", key.Name, key.Role)
+		}
+	}
+
+	return c.validateOIDC(roles)
+}
+
+// validateOIDC checks the OIDC issuer settings and group-to-role mapping
+func (c *Config) validateOIDC(roles map[string]bool) error {
+	oidc := c.Server.Auth.OIDC
+	if oidc.IssuerURL == "" {
+		return nil
+	}
+
+	if oidc.Audience == "" {
+		return fmt.Errorf("OIDC audience is required when an issuer is configured")
+	}
+	if oidc.JWKSCacheSeconds < 0 || oidc.ClockSkewSeconds < 0 {
+		return fmt.Errorf("OIDC cache and clock skew settings must be non-negative")
+	}
+	if len(oidc.GroupRoles) == 0 && oidc.DefaultRole == "" {
+		return fmt.Errorf("OIDC requires groupRoles or a defaultRole")
+	}
+	for _, mapping := range oidc.GroupRoles {
+		if mapping.Group == "" {
+			return fmt.Errorf("OIDC group role mapping requires a group")
+		}
+		if !roles[mapping.Role] {
+			return fmt.Errorf("OIDC group %s refers to unknown role: %s", mapping.Group, mapping.Role)
+		}
+	}
+	if oidc.DefaultRole != "" && !roles[oidc.DefaultRole] {
+		return fmt.Errorf("OIDC default role is unknown: %s", oidc.DefaultRole)
+	}
+
+	return nil
+}
+
+// validateRedaction checks that the configured redaction patterns compile
+func (c *Config) validateRedaction() error {
+	for _, pattern := range c.Redaction.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+	}
+	if c.Redaction.MinEntropyLength < 0 {
+		return fmt.Errorf("redaction minimum entropy length must be non-negative")
+	}
+	return nil
+}
+
+// validatePolicy checks the custom policy rules and severity overrides
+func (c *Config) validatePolicy() error {
+	for rule, severity := range c.Policy.Severities {
+		if severity != "warn" && severity != "fail" {
+			return fmt.Errorf("policy rule %s has invalid severity %q (must be warn or fail)", rule, severity)
+		}
+	}
+
+	names := make(map[string]bool, len(c.Policy.Rules))
+	for i, rule := range c.Policy.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("policy rule %d must have a name", i+1)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate policy rule name: %s", rule.Name)
+		}
+		names[rule.Name] = true
+
+		if rule.Path == "" {
+			return fmt.Errorf("policy rule %s must have a path", rule.Name)
+		}
+		switch rule.Condition {
+		case PolicyConditionExists, PolicyConditionAbsent, PolicyConditionEquals:
+		case PolicyConditionMatches:
+			if _, err := regexp.Compile(rule.Value); err != nil {
+				return fmt.Errorf("policy rule %s has an invalid pattern: %w", rule.Name, err)
+			}
+		default:
+			return fmt.Errorf("policy rule %s has invalid condition %q (must be %s, %s, %s or %s)", rule.Name, rule.Condition,
+				PolicyConditionExists, PolicyConditionAbsent, PolicyConditionEquals, PolicyConditionMatches)
+		}
+		if rule.Severity != "" && rule.Severity != "warn" && rule.Severity != "fail" {
+			return fmt.Errorf("policy rule %s has invalid severity %q (must be warn or fail)", rule.Name, rule.Severity)
+		}
+	}
+	return nil
+}
+
+// ClaudeRequired reports whether the server needs a working Claude configuration.
+// In stdio mode the calling agent does the reasoning, so Claude is optional unless
+// an API key has been supplied.
+func (c *Config) ClaudeRequired() bool {
+	return c.Server.Transport != TransportStdio || c.Claude.APIKey != ""
+}
+
+// validateClaude checks the Claude API configuration
+func (c *Config) validateClaude() error {
+	if c.Claude.APIKey == "" {
+		return fmt.Errorf("claude API key is required")
+	}
+
+	if c.Claude.ModelID == "" {
+		return fmt.Errorf("claude model ID is required")
+	}
+
+	if c.Claude.BaseURL == "" {
+		return fmt.Errorf("claude base URL is required")
+	}
+
+	if c.Claude.MaxTokens <= 0 {
+		return fmt.Errorf("claude max tokens must be positive")
+	}
+
+	if c.Claude.MaxTokens > 8192 {
+		return fmt.Errorf("claude max tokens cannot exceed 8192")
+	}
+
+	if c.Claude.Temperature < 0.0 || c.Claude.Temperature > 1.0 {
+		return fmt.Errorf("claude temperature must be between 0.0 and 1.0")
+	}
+
+	if c.Claude.Agent.MaxIterations < 0 || c.Claude.Agent.MaxTotalTokens < 0 {
+		return fmt.Errorf("claude agent limits must be non-negative")
+	}
+
 	return nil
 }
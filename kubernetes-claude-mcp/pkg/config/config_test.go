@@ -137,3 +137,162 @@ claude:
 		t.Errorf("Expected API key to be overridden to 'env-api-key-12345', got '%s'", cfg.Server.Auth.APIKey)
 	}
 }
+
+func TestValidateStdioTransport(t *testing.T) {
+	cfg := &Config{
+		Server: ServerConfig{Transport: TransportStdio},
+	}
+
+	// Neither an address nor Claude settings are needed when the client does the reasoning
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected stdio config without Claude to be valid, got: %v", err)
+	}
+	if cfg.ClaudeRequired() {
+		t.Error("Expected Claude to be optional in stdio mode")
+	}
+
+	// A Claude key opts back into full Claude validation
+	cfg.Claude.APIKey = "test-claude-key"
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected incomplete Claude config to be rejected once an API key is set")
+	}
+
+	// HTTP mode still requires an address and Claude
+	cfg = &Config{Server: ServerConfig{Transport: TransportHTTP, Address: ":8080"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected HTTP config without Claude to be rejected")
+	}
+
+	cfg = &Config{Server: ServerConfig{Transport: "grpc"}}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Expected unknown transport to be rejected")
+	}
+}
+
+func TestValidateClusters(t *testing.T) {
+	base := func() *Config {
+		return &Config{Server: ServerConfig{Transport: TransportStdio}}
+	}
+
+	cfg := base()
+	cfg.Kubernetes.Clusters = []ClusterConfig{{Name: "prod", Context: "prod"}, {Name: "staging", InCluster: true}}
+	cfg.Kubernetes.DefaultCluster = "staging"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected cluster list to be valid, got: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		clusters []ClusterConfig
+		modify   func(*Config)
+	}{
+		{"missing name", []ClusterConfig{{Context: "prod"}}, nil},
+		{"duplicate name", []ClusterConfig{{Name: "prod"}, {Name: "prod"}}, nil},
+		{"in-cluster with context", []ClusterConfig{{Name: "prod", InCluster: true, Context: "prod"}}, nil},
+		{"unknown default", []ClusterConfig{{Name: "prod"}}, func(c *Config) { c.Kubernetes.DefaultCluster = "dev" }},
+		{"list and all contexts", []ClusterConfig{{Name: "prod"}}, func(c *Config) { c.Kubernetes.AllContexts = true }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			cfg.Kubernetes.Clusters = tt.clusters
+			if tt.modify != nil {
+				tt.modify(cfg)
+			}
+			if err := cfg.Validate(); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestValidateAuth(t *testing.T) {
+	base := func() *Config {
+		return &Config{Server: ServerConfig{Transport: TransportStdio, Auth: AuthConfig{
+			APIKey: "admin-key",
+			Roles:  []RoleConfig{{Name: "viewer", Namespaces: []string{"apps-*"}, Actions: []string{"read"}}},
+			Keys:   []APIKeyConfig{{Name: "oncall", Key: "oncall-key", Role: "viewer"}},
+		}}}
+	}
+
+	if err := base().Validate(); err != nil {
+		t.Errorf("Expected auth config to be valid, got: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*AuthConfig)
+	}{
+		{"unknown role", func(a *AuthConfig) { a.Keys[0].Role = "editor" }},
+		{"reserved role name", func(a *AuthConfig) { a.Roles[0].Name = "admin"; a.Keys[0].Role = "admin" }},
+		{"unknown action", func(a *AuthConfig) { a.Roles[0].Actions = []string{"delete"} }},
+		{"no actions", func(a *AuthConfig) { a.Roles[0].Actions = nil }},
+		{"empty key", func(a *AuthConfig) { a.Keys[0].Key = "" }},
+		{"reused key", func(a *AuthConfig) { a.Keys[0].Key = "admin-key" }},
+		{"OIDC without audience", func(a *AuthConfig) {
+			a.OIDC = OIDCConfig{IssuerURL: "https://sso.example.com", DefaultRole: "viewer"}
+		}},
+		{"OIDC without roles", func(a *AuthConfig) {
+			a.OIDC = OIDCConfig{IssuerURL: "https://sso.example.com", Audience: "k8s-mcp"}
+		}},
+		{"OIDC unknown group role", func(a *AuthConfig) {
+			a.OIDC = OIDCConfig{IssuerURL: "https://sso.example.com", Audience: "k8s-mcp",
+				GroupRoles: []GroupRoleConfig{{Group: "sre", Role: "operator"}}}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.modify(&cfg.Server.Auth)
+			if err := cfg.Validate(); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	base := func() *Config {
+		return &Config{
+			Server: ServerConfig{Transport: TransportStdio},
+			Policy: PolicyConfig{
+				Severities: map[string]string{"probes-missing": "fail"},
+				Rules: []PolicyRule{{
+					Name:      "team-label",
+					Path:      "metadata.labels.team",
+					Condition: PolicyConditionMatches,
+					Value:     "^[a-z-]+$",
+				}},
+			},
+		}
+	}
+
+	if err := base().Validate(); err != nil {
+		t.Errorf("Expected policy config to be valid, got: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*PolicyConfig)
+	}{
+		{"invalid severity override", func(p *PolicyConfig) { p.Severities["probes-missing"] = "error" }},
+		{"unnamed rule", func(p *PolicyConfig) { p.Rules[0].Name = "" }},
+		{"duplicate rule", func(p *PolicyConfig) { p.Rules = append(p.Rules, p.Rules[0]) }},
+		{"no path", func(p *PolicyConfig) { p.Rules[0].Path = "" }},
+		{"unknown condition", func(p *PolicyConfig) { p.Rules[0].Condition = "contains" }},
+		{"invalid pattern", func(p *PolicyConfig) { p.Rules[0].Value = "([a-z" }},
+		{"invalid severity", func(p *PolicyConfig) { p.Rules[0].Severity = "critical" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base()
+			tt.modify(&cfg.Policy)
+			if err := cfg.Validate(); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
@@ -43,9 +43,16 @@ func NewLogger() *Logger {
 		}
 	}
 
+	// Determine output from environment variable. stdout is reserved for the
+	// protocol stream when the server runs as a stdio MCP subprocess.
+	output := os.Stdout
+	if os.Getenv("LOG_OUTPUT") == "stderr" {
+		output = os.Stderr
+	}
+
 	core := zapcore.NewCore(
 		zapcore.NewJSONEncoder(encoderConfig),
-		zapcore.NewMultiWriteSyncer(zapcore.AddSync(os.Stdout)),
+		zapcore.NewMultiWriteSyncer(zapcore.AddSync(output)),
 		zap.NewAtomicLevelAt(logLevel),
 	)
 